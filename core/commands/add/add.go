@@ -1,170 +1,217 @@
 package add
 
 import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
 	"github.com/macinnir/dvc/core/lib"
+	"github.com/macinnir/dvc/core/lib/cache"
+	"github.com/macinnir/dvc/core/lib/gen"
+	"github.com/macinnir/dvc/core/lib/gen/dal"
+	"github.com/macinnir/dvc/core/lib/gen/model"
+	"github.com/macinnir/dvc/core/lib/schema"
+	libsql "github.com/macinnir/dvc/core/lib/sql"
 	"go.uber.org/zap"
 )
 
 const CommandName = "add"
 
-// Cmd adds an object to the database
+// Cmd adds a column to a table in the local schema and writes a migration for it
+// dvc add [table] [column] [definition]
 func Cmd(log *zap.Logger, config *lib.Config, args []string) error {
-	return nil
-}
-
-// 	database := lib.LoadSchema(config)
-
-// 	reader := bufio.NewReader(os.Stdin)
-
-// 	sqlParts := []string{}
-// 	sql := ""
-
-// 	tableName := ""
-
-// 	if len(args) > 0 {
-// 		tableName = args[0]
-// 	} else {
-// 		tableName = lib.ReadCliInput(reader, "Table Name:")
-// 	}
-
-// 	// Creating a new table
-// 	if _, ok := database.Tables[tableName]; !ok {
-
-// 		log.Infof("Create table `%s`", tableName)
-
-// 		start := fmt.Sprintf("CREATE TABLE `%s` (", tableName)
-// 		sql += start
-// 		sqlParts = append(sqlParts, start)
-// 		cols := []string{
-// 			fmt.Sprintf("%sID INT UNSIGNED NOT NULL PRIMARY KEY AUTO_INCREMENT", tableName),
-// 			fmt.Sprintf("IsDeleted TINYINT UNSIGNED NOT NULL DEFAULT 0"),
-// 			fmt.Sprintf("DateCreated BIGINT UNSIGNED NOT NULL DEFAULT 0"),
-// 			fmt.Sprintf("LastUpdated BIGINT UNSIGNED NOT NULL DEFAULT 0"),
-// 		}
-
-// 		for k := range cols {
-// 			fmt.Printf("`%s`.Column(%d): %s\n", tableName, k+1, cols[k])
-// 		}
-
-// 		includeColumns := lib.ReadCliInput(reader, fmt.Sprintf("Include the above columns (Y/n)?"))
-
-// 		n := 1
-// 		if includeColumns == "Y" {
-// 			n = len(cols) + 1
-// 		} else {
-// 			cols = []string{}
-// 		}
-
-// 		for {
 
-// 			col := lib.ReadCliInput(reader, fmt.Sprintf("`%s`.Column(%d):", tableName, n))
-// 			if len(col) == 0 {
-// 				break
-// 			}
-
-// 			// Remove trailing space
-// 			col = strings.Trim(col, " ")
-
-// 			// Remove trailing comma if exists
-// 			if col[len(col)-1:] == "," {
-// 				col = col[0 : len(col)-1]
-// 			}
-
-// 			colParts := strings.Split(col, " ")
-
-// 			// Validate the sql part
-// 			if len(colParts) < 2 {
-// 				log.Error("Invalid sql part. Try again...")
-// 				continue
-// 			}
-
-// 			// Validate the datatype
-// 			dataType := strings.ToLower(colParts[1])
-
-// 			if strings.Contains(dataType, "(") {
-// 				dataType = strings.Split(dataType, "(")[0]
-// 			}
-
-// 			if lib.IsValidSQLType(dataType) == false {
-// 				log.Errorf("Invalid SQL type: %s. Try gain...\n", dataType)
-// 				continue
-// 			}
-
-// 			n++
-
-// 			for k := range colParts {
-
-// 				if k == 0 {
-// 					continue
-// 				}
+	var e error
+	var positional = []string{}
+	var schemaName = ""
+	var regenerate = false
+
+	for len(args) > 0 {
+
+		switch args[0] {
+		case "-g", "--gen":
+			regenerate = true
+			args = args[1:]
+			continue
+		case "-s", "--schema":
+			if len(args) < 2 {
+				return fmt.Errorf("Missing value for argument `%s`", args[0])
+			}
+			schemaName = args[1]
+			args = args[2:]
+			continue
+		}
+
+		if len(args[0]) > 1 && args[0][0] == '-' {
+			return fmt.Errorf("Unknown argument `%s`", args[0])
+		}
+
+		positional = append(positional, args[0])
+		args = args[1:]
+	}
+
+	if len(positional) < 3 {
+		fmt.Println("Usage: dvc add [table] [column] \"[definition]\"")
+		return errors.New("A table, column and column definition are required")
+	}
+
+	var tableName, columnName = positional[0], positional[1]
+	var definition = strings.Join(positional[2:], " ")
+
+	var column *schema.Column
+	if column, e = schema.ParseColumnDefinition(columnName, definition); e != nil {
+		return e
+	}
+
+	if len(schemaName) == 0 {
+		if schemaName, e = findSchema(tableName); e != nil {
+			return e
+		}
+	}
+
+	var filePath = schema.SchemaFilePath(schemaName)
+
+	var schemaList *schema.SchemaList
+	if schemaList, e = schema.LoadSchemaFile(filePath); e != nil {
+		return e
+	}
+
+	var table *schema.Table
+	for k := range schemaList.Schemas {
+		if schemaList.Schemas[k].Name == schemaName {
+			table = schemaList.Schemas[k].Tables[tableName]
+			break
+		}
+	}
+
+	if table == nil {
+		return fmt.Errorf("Table `%s` not found in schema `%s`", tableName, schemaName)
+	}
+
+	if _, ok := table.Columns[columnName]; ok {
+		return fmt.Errorf("Column `%s`.`%s` already exists", tableName, columnName)
+	}
+
+	var migrationSQL string
+	if migrationSQL, e = AddColumnSQL(table, column); e != nil {
+		return e
+	}
+
+	// The migration is written first, so that a schema file is never changed without one
+	var migrationPath string
+	if migrationPath, e = lib.WriteMigration(fmt.Sprintf("add_%s_%s", tableName, columnName), migrationSQL, time.Now()); e != nil {
+		return e
+	}
+
+	table.Columns[columnName] = column
+
+	if e = schema.SaveSchemaFile(filePath, schemaList); e != nil {
+		os.Remove(migrationPath)
+		return e
+	}
+
+	fmt.Printf("Added `%s`.`%s` %s to %s\n", tableName, columnName, column.Type, filePath)
+	fmt.Printf("Wrote migration %s\n", migrationPath)
+
+	if !regenerate {
+		return nil
+	}
+
+	return regenerateTable(config, table)
+}
 
-// 				colParts[k] = strings.ToUpper(colParts[k])
-// 			}
+// AddColumnSQL returns the statements that add `column` (and its index, if any) to `table`
+func AddColumnSQL(table *schema.Table, column *schema.Column) (string, error) {
 
-// 			col = "`" + colParts[0] + "` " + strings.Join(colParts[1:], " ")
-// 			sqlParts = append(sqlParts, col)
-// 			cols = append(cols, col)
-// 		}
+	var e error
+	var q = libsql.Query{}
+	var statements = []string{}
+	var statement string
 
-// 		sql += strings.Join(cols, ", ") + ")"
+	if statement, e = q.AlterTableCreateColumn(table, column); e != nil {
+		return "", e
+	}
 
-// 		sqlParts = append(sqlParts, ")")
+	statements = append(statements, statement)
 
-// 	} else {
+	switch column.ColumnKey {
+	case "UNI":
+		statement, e = q.AddUniqueIndex(table, column)
+		statements = append(statements, statement)
+	case "MUL":
+		statement, e = q.AddIndex(table, column)
+		statements = append(statements, statement)
+	}
 
-// 		col := lib.ReadCliInput(reader, fmt.Sprintf("`%s`.Column:", tableName))
-// 		if len(col) == 0 {
-// 			log.Error("Column cannot be empty")
-// 			return
-// 		}
+	return strings.Join(statements, "\n"), e
+}
 
-// 		colParts := strings.Split(col, " ")
+// findSchema returns the name of the only local schema that contains `tableName`
+func findSchema(tableName string) (string, error) {
+
+	var e error
+	var schemaList *schema.SchemaList
+	if schemaList, e = schema.LoadLocalSchemas(); e != nil {
+		return "", e
+	}
+
+	var names = []string{}
+	for k := range schemaList.Schemas {
+		if _, ok := schemaList.Schemas[k].Tables[tableName]; ok {
+			names = append(names, schemaList.Schemas[k].Name)
+		}
+	}
+
+	switch len(names) {
+	case 0:
+		return "", fmt.Errorf("Table `%s` not found in the local schemas", tableName)
+	case 1:
+		return names[0], nil
+	}
+
+	return "", fmt.Errorf("Table `%s` exists in schemas %s; use --schema", tableName, strings.Join(names, ", "))
+}
 
-// 		if _, ok := database.Tables[tableName].Columns[colParts[0]]; ok {
-// 			fmt.Printf("Column `%s`.`%s` already exists", tableName, colParts[0])
-// 			return
-// 		}
+// regenerateTable regenerates the model and DAL for a single table and updates the tables cache
+func regenerateTable(config *lib.Config, table *schema.Table) error {
 
-// 		for k := range colParts {
+	var e error
+	var tables = []*schema.Table{table}
 
-// 			if k == 0 {
-// 				continue
-// 			}
+	// Relations of the table can point to tables of any schema
+	var schemaList *schema.SchemaList
+	if schemaList, e = schema.LoadLocalSchemas(); e != nil {
+		return e
+	}
 
-// 			colParts[k] = strings.ToUpper(colParts[k])
-// 		}
+	if e = model.GenModels(tables, schemaList, config); e != nil {
+		return e
+	}
 
-// 		col = "`" + colParts[0] + "` " + strings.Join(colParts[1:], " ")
-// 		sql = fmt.Sprintf("ALTER TABLE `%s` ADD COLUMN %s", tableName, col)
-// 		sqlParts = append(sqlParts, sql)
-// 	}
+	if e = dal.GenDALs(tables, config); e != nil {
+		return e
+	}
 
-// 	fmt.Print("\n------------------------------------------------------\n")
-// 	fmt.Print("--------------------- REVIEW -------------------------\n")
-// 	fmt.Print("------------------------------------------------------\n")
+	if e = gen.GenInterfaces(lib.DalsGenDir, lib.DALDefinitionsGenDir); e != nil {
+		return e
+	}
 
-// 	fmt.Println(sql)
-// 	// for k := range sqlParts {
-// 	// 	// Not first or last line
-// 	// 	if k != 0 && k != len(sqlParts)-1 {
-// 	// 		fmt.Print("\t")
-// 	// 	}
-// 	// 	fmt.Printf("%s", sqlParts[k])
-// 	// 	// Not first or last line
-// 	// 	if k != 0 && k != len(sqlParts)-1 {
-// 	// 		fmt.Print(",")
-// 	// 	}
+	var tableCache *cache.TablesCache
+	if tableCache, e = cache.LoadTableCache(); e != nil {
+		return e
+	}
 
-// 	// 	fmt.Print("\n")
-// 	// }
+	if tableCache.Models[table.Key()], e = cache.HashTable(table); e != nil {
+		return e
+	}
 
-// 	fmt.Print("\n------------------------------------------------------\n")
+	if e = cache.SaveTableCache(tableCache); e != nil {
+		return e
+	}
 
-// 	if lib.ReadCliInput(reader, "Are you sure want to execute the above SQL (Y/n)?") == "Y" {
-// 		connector, _ := lib.(config.DatabaseType, c.Config)
-// 		x := lib.NewExecutor(c.Config, connector)
-// 		x.RunSQL(sql)
+	fmt.Printf("Regenerated the %s model and DAL\n", table.Name)
 
-// 		c.Refresh([]string{})
-// 	}
-// }
+	return nil
+}
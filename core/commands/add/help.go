@@ -4,9 +4,18 @@ import "fmt"
 
 func Help() {
 	fmt.Println(`
-	add  [table]
-		
-		If the table name already exists, add a column to the existing table.
-		Add an object to the database and then (by calling the import command) the local schema.
+	add [table] [column] [definition] [[-s|--schema] [schema]] [[-g|--gen]]
+
+		Adds a column to a table in the local schema file and writes the ALTER TABLE statement to a new
+		migration in .dvc/migrations. Columns are nullable unless NOT NULL is given.
+
+			dvc add User Nickname "varchar(100) NULL"
+			dvc add User LoginCount "int unsigned NOT NULL DEFAULT 0" --gen
+
+		Definitions support a type with its length, precision or enum values, UNSIGNED, NULL, NOT NULL,
+		DEFAULT, AUTO_INCREMENT, UNIQUE, INDEX, CHARACTER SET and COLLATE.
+
+			-s, --schema 	The schema the table belongs to (only needed if more than one schema has the table)
+			-g, --gen 		Regenerate the table's model and DAL
 	`)
 }
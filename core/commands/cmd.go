@@ -22,7 +22,11 @@ import (
 	"github.com/macinnir/dvc/core/commands/refresh"
 	"github.com/macinnir/dvc/core/commands/rm"
 	"github.com/macinnir/dvc/core/commands/schemas"
+	"github.com/macinnir/dvc/core/commands/seed"
 	"github.com/macinnir/dvc/core/commands/selectcmd"
+	"github.com/macinnir/dvc/core/commands/sqlcmd"
+	"github.com/macinnir/dvc/core/commands/stats"
+	"github.com/macinnir/dvc/core/commands/subset"
 	"github.com/macinnir/dvc/core/commands/test"
 	"github.com/macinnir/dvc/core/commands/transfer"
 	"github.com/macinnir/dvc/core/commands/version"
@@ -59,10 +63,14 @@ func makeCommands() {
 		refresh.CommandName:     refresh.Cmd,
 		rm.CommandName:          rm.Cmd,
 		selectcmd.CommandName:   selectcmd.Cmd,
+		sqlcmd.CommandName:      sqlcmd.Cmd,
+		stats.CommandName:       stats.Cmd,
+		subset.CommandName:      subset.Cmd,
 		test.CommandName:        test.Cmd,
 		cli.CommandName:         cli.Cmd,
 		transfer.CommandName:    transfer.Cmd,
 		schemas.CommandName:     schemas.Cmd,
+		seed.CommandName:        seed.Cmd,
 		connections.CommandName: connections.Cmd,
 		version.CommandName:     version.Cmd,
 	}
@@ -84,9 +92,13 @@ func makeHelp() {
 		refresh.CommandName:     refresh.Help,
 		rm.CommandName:          rm.Help,
 		selectcmd.CommandName:   selectcmd.Help,
+		sqlcmd.CommandName:      sqlcmd.Help,
+		stats.CommandName:       stats.Help,
+		subset.CommandName:      subset.Help,
 		test.CommandName:        test.Help,
 		transfer.CommandName:    transfer.Help,
 		schemas.CommandName:     schemas.Help,
+		seed.CommandName:        seed.Help,
 		connections.CommandName: connections.Help,
 	}
 }
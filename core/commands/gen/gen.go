@@ -3,6 +3,8 @@ package gen
 import (
 	"errors"
 	"fmt"
+	"os"
+	"path"
 
 	"github.com/macinnir/dvc/core/lib"
 	"github.com/macinnir/dvc/core/lib/cache"
@@ -89,7 +91,9 @@ func Cmd(log *zap.Logger, config *lib.Config, args []string) error {
 		}
 
 		if len(changedTables) > 0 {
-			model.GenModels(changedTables, config)
+			if e = model.GenModels(changedTables, schemaList, config); e != nil {
+				return e
+			}
 		}
 
 		if len(changedTables) > 0 {
@@ -105,18 +109,7 @@ func Cmd(log *zap.Logger, config *lib.Config, args []string) error {
 
 		if len(changedTables) > 0 {
 
-			var cacheConfigs = config.Cache
-
-			if cacheConfigs == nil {
-				cacheConfigs = map[string]*lib.CacheConfig{}
-			}
-
-			var coreCacheConfig = map[string]*lib.CacheConfig{}
-			if coreCacheConfig, e = lib.LoadCoreCacheFile(); e == nil {
-				for tableName := range coreCacheConfig {
-					cacheConfigs[tableName] = coreCacheConfig[tableName]
-				}
-			}
+			var cacheConfigs = loadCacheConfigs(config)
 
 			gen.GenCaches(changedTables, config.BasePackage, cacheConfigs)
 			gen.GenerateCacheBootstrapFile(config.BasePackage, cacheConfigs)
@@ -129,7 +122,9 @@ func Cmd(log *zap.Logger, config *lib.Config, args []string) error {
 
 		// gen.GenAppBootstrapFile(config.BasePackage)
 		if len(changedTables) > 0 {
-			gen.GenInterfaces(lib.DalsGenDir, lib.DALDefinitionsGenDir)
+			if e = gen.GenInterfaces(lib.DalsGenDir, lib.DALDefinitionsGenDir); e != nil {
+				return e
+			}
 		}
 
 		gen.GenInterfaces(lib.CoreServicesDir, lib.ServiceDefinitionsGenDir)
@@ -204,6 +199,51 @@ func Cmd(log *zap.Logger, config *lib.Config, args []string) error {
 		gen.GenInterfaces(lib.CoreServicesDir, lib.ServiceDefinitionsGenDir)
 		gen.GenInterfaces(lib.AppServicesDir, lib.ServiceDefinitionsGenDir)
 
+	case "mocks":
+
+		var e error
+		var schemaList *schema.SchemaList
+
+		if schemaList, e = schema.LoadLocalSchemas(); e != nil {
+			return e
+		}
+
+		var tables = []*schema.Table{}
+		for k := range schemaList.Schemas {
+			for l := range schemaList.Schemas[k].Tables {
+				tables = append(tables, schemaList.Schemas[k].Tables[l])
+			}
+		}
+
+		for _, dir := range []string{
+			lib.DALDefinitionsGenDir,
+			lib.CacheInterfaceGenDir,
+			lib.RepoInterfaceGenDir,
+			lib.ServiceDefinitionsGenDir,
+		} {
+			if _, e = os.Stat(dir); os.IsNotExist(e) {
+				continue
+			}
+
+			if e = gen.GenMocks(config.BasePackage, dir, lib.MocksGenDir); e != nil {
+				return e
+			}
+		}
+
+		var cacheConfigs = loadCacheConfigs(config)
+
+		if e = dal.GenDALFakes(tables, config, path.Join(lib.MocksGenDir, "dalmocks")); e != nil {
+			return e
+		}
+
+		if e = gen.GenCacheFakes(config.BasePackage, tables, cacheConfigs, path.Join(lib.MocksGenDir, "cachesmocks")); e != nil {
+			return e
+		}
+
+		if e = gen.GenRepoFakes(config.BasePackage, tables, cacheConfigs, path.Join(lib.MocksGenDir, "reposmocks")); e != nil {
+			return e
+		}
+
 	// case "routes":
 	// 	cf := fetcher.NewControllerFetcher()
 	// 	controllers, dirs, e := cf.FetchAll()
@@ -278,6 +318,24 @@ func Cmd(log *zap.Logger, config *lib.Config, args []string) error {
 
 }
 
+// loadCacheConfigs returns the cache config of the app merged with the core cache config
+func loadCacheConfigs(config *lib.Config) map[string]*lib.CacheConfig {
+
+	var cacheConfigs = config.Cache
+
+	if cacheConfigs == nil {
+		cacheConfigs = map[string]*lib.CacheConfig{}
+	}
+
+	if coreCacheConfig, e := lib.LoadCoreCacheFile(); e == nil {
+		for tableName := range coreCacheConfig {
+			cacheConfigs[tableName] = coreCacheConfig[tableName]
+		}
+	}
+
+	return cacheConfigs
+}
+
 // 	var e error
 
 // 	// fmt.Printf("Args: %v", args)
@@ -4,6 +4,17 @@ import "fmt"
 
 func Help() {
 	fmt.Println(`
-	insert Builds a SQL insert query based upon field by field input and executes it
+	insert [table] [[-j|--json] [json]] [[-c|--connection] [connection]] [--dry-run]
+
+		Inserts rows into [table] from a JSON object or an array of objects. Every value is validated against
+		the local schema (type, range, length, enum values and nullability) and required columns must be
+		present before anything is sent to the database. Values are bound as parameters.
+
+			dvc insert User --json '{"Email": "a@example.com", "AccountID": 1}'
+			dvc insert User --json @users.json
+
+			-j, --json 			The rows to insert, or @[file] to read them from a file
+			-c, --connection 	The connection to insert into (defaults to the first connection with the table)
+			--dry-run 			Print the query and its parameters without running it
 	`)
 }
@@ -1,92 +1,268 @@
 package insert
 
 import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"sort"
+	"strings"
+
+	"github.com/macinnir/dvc/core/connectors"
 	"github.com/macinnir/dvc/core/lib"
+	"github.com/macinnir/dvc/core/lib/executor"
+	"github.com/macinnir/dvc/core/lib/schema"
+	"github.com/macinnir/dvc/core/lib/subset"
 	"go.uber.org/zap"
 )
 
 const CommandName = "insert"
 
-// Insert inserts data into the database
+// Cmd inserts rows into a table from JSON
+// dvc insert [table] --json '{...}'
 func Cmd(logger *zap.Logger, config *lib.Config, args []string) error {
 
+	var e error
+	var tableName = ""
+	var connectionName = ""
+	var jsonInput = ""
+	var dryRun = false
+
+	for len(args) > 0 {
+
+		if args[0] == "--dry-run" {
+			dryRun = true
+			args = args[1:]
+			continue
+		}
+
+		if !strings.HasPrefix(args[0], "-") {
+			if len(tableName) > 0 {
+				return fmt.Errorf("Unexpected argument `%s`", args[0])
+			}
+			tableName = args[0]
+			args = args[1:]
+			continue
+		}
+
+		if len(args) < 2 {
+			return fmt.Errorf("Missing value for argument `%s`", args[0])
+		}
+
+		switch args[0] {
+		case "-c", "--connection":
+			connectionName = args[1]
+		case "-j", "--json":
+			jsonInput = args[1]
+		default:
+			return fmt.Errorf("Unknown argument `%s`", args[0])
+		}
+
+		args = args[2:]
+	}
+
+	if len(tableName) == 0 || len(jsonInput) == 0 {
+		fmt.Println("Usage: dvc insert [table] --json '{...}'")
+		return errors.New("A table name and --json are required")
+	}
+
+	// --json @file.json reads the rows from a file
+	if strings.HasPrefix(jsonInput, "@") {
+		var fileBytes []byte
+		if fileBytes, e = ioutil.ReadFile(jsonInput[1:]); e != nil {
+			return e
+		}
+		jsonInput = string(fileBytes)
+	}
+
+	var schemaList *schema.SchemaList
+	if schemaList, e = schema.LoadLocalSchemas(); e != nil {
+		return e
+	}
+
+	var dbConfig *lib.ConfigDatabase
+	var table *schema.Table
+	if dbConfig, table, e = resolveTable(config, schemaList, connectionName, tableName); e != nil {
+		return e
+	}
+
+	var rows []map[string]interface{}
+	if rows, e = DecodeRows(jsonInput); e != nil {
+		return e
+	}
+
+	var insertTable *schema.Table
+	if insertTable, rows, e = ValidateRows(table, rows); e != nil {
+		return e
+	}
+
+	var query, queryArgs = subset.InsertSQLWithArgs(insertTable, rows)
+
+	if dryRun {
+		fmt.Println(query)
+		fmt.Println(queryArgs...)
+		return nil
+	}
+
+	var connector connectors.IConnector
+	if connector, e = connectors.DBConnectorFactory(dbConfig); e != nil {
+		return fmt.Errorf("Error creating connector for connection %s: %w", dbConfig.Key, e)
+	}
+
+	var server = executor.NewExecutor(dbConfig, connector).Connect()
+	defer server.Connection.Close()
+
+	logger.Debug("Insert", zap.String("connection", dbConfig.Key), zap.String("query", query), zap.Any("args", queryArgs))
+
+	var result, execErr = server.Connection.Exec(query, queryArgs...)
+	if execErr != nil {
+		return fmt.Errorf("Error inserting into `%s`: %w", tableName, execErr)
+	}
+
+	var affected, _ = result.RowsAffected()
+	var lastID, _ = result.LastInsertId()
+
+	if lastID > 0 && len(rows) == 1 {
+		fmt.Printf("Inserted 1 row into %s (ID %d)\n", tableName, lastID)
+		return nil
+	}
+
+	fmt.Printf("Inserted %d rows into %s\n", affected, tableName)
+
 	return nil
+}
 
-	// database := c.loadDatabase()
+// DecodeRows decodes a JSON object or array of objects. Numbers are kept as json.Number.
+func DecodeRows(input string) ([]map[string]interface{}, error) {
 
-	// reader := bufio.NewReader(os.Stdin)
+	var decoder = json.NewDecoder(bytes.NewBufferString(input))
+	decoder.UseNumber()
 
-	// tableName := ""
+	var decoded interface{}
+	if e := decoder.Decode(&decoded); e != nil {
+		return nil, fmt.Errorf("Invalid JSON: %w", e)
+	}
 
-	// if len(args) > 0 {
-	// 	tableName = args[0]
-	// } else {
-	// 	tableName = lib.ReadCliInput(reader, "Table:")
-	// }
+	switch v := decoded.(type) {
+	case map[string]interface{}:
+		return []map[string]interface{}{v}, nil
+	case []interface{}:
+		var rows = make([]map[string]interface{}, len(v))
+		for k := range v {
+			var row, ok = v[k].(map[string]interface{})
+			if !ok {
+				return nil, fmt.Errorf("Row %d is not a JSON object", k+1)
+			}
+			rows[k] = row
+		}
+		if len(rows) == 0 {
+			return nil, errors.New("No rows to insert")
+		}
+		return rows, nil
+	}
+
+	return nil, errors.New("Expected a JSON object or an array of objects")
+}
 
-	// if len(tableName) == 0 {
-	// 	fmt.Println("No table specified")
-	// 	return
-	// }
+// ValidateRows checks every value against the table's column types and nullability and that every required column
+// is present. It returns a table of only the columns being inserted and the rows with their values converted.
+func ValidateRows(table *schema.Table, rows []map[string]interface{}) (*schema.Table, []map[string]interface{}, error) {
 
-	// if _, ok := database.Tables[tableName]; !ok {
-	// 	fmt.Printf("Table `%s` not found.\n", tableName)
-	// 	return
-	// }
+	var insertTable = &schema.Table{Name: table.Name, Columns: map[string]*schema.Column{}}
+	var errs = []string{}
 
-	// table := database.Tables[tableName]
+	for _, row := range rows {
+		for name := range row {
+			var column, ok = table.Columns[name]
+			if !ok {
+				return nil, nil, fmt.Errorf("Unknown column `%s`.`%s`", table.Name, name)
+			}
+			insertTable.Columns[name] = column
+		}
+	}
 
-	// columns := table.ToSortedColumns()
+	var result = make([]map[string]interface{}, len(rows))
 
-	// sql := fmt.Sprintf("INSERT INTO `%s` (\n", tableName)
+	for k, row := range rows {
 
-	// columnNames := []string{}
-	// values := []string{}
+		result[k] = make(map[string]interface{}, len(insertTable.Columns))
 
-	// for k := range columns {
+		for _, column := range table.ToSortedColumns() {
 
-	// 	if columns[k].ColumnKey == "PRI" {
-	// 		continue
-	// 	}
+			var value, ok = row[column.Name]
 
-	// 	if columns[k].Name == "IsDeleted" {
-	// 		continue
-	// 	}
+			if !ok {
+				if schema.IsRequired(column) {
+					errs = append(errs, rowError(len(rows), k, fmt.Sprintf("`%s` is required", column.Name)))
+				} else if _, inserting := insertTable.Columns[column.Name]; inserting {
+					// Another row set this column, so this row gets the column's default
+					result[k][column.Name] = defaultValue(column)
+				}
+				continue
+			}
+
+			var e error
+			if result[k][column.Name], e = schema.ValidateValue(column, value); e != nil {
+				errs = append(errs, rowError(len(rows), k, e.Error()))
+			}
+		}
+	}
+
+	if len(errs) > 0 {
+		sort.Strings(errs)
+		return nil, nil, errors.New(strings.Join(errs, "\n"))
+	}
+
+	return insertTable, result, nil
+}
+
+// defaultValue is the value MySQL would use for a column left out of an insert
+func defaultValue(column *schema.Column) interface{} {
+	if len(column.Default) == 0 && (column.IsNullable || strings.Contains(strings.ToLower(column.Extra), "auto_increment")) {
+		return nil
+	}
+	return column.Default
+}
+
+func rowError(rowCount, row int, message string) string {
+	if rowCount == 1 {
+		return message
+	}
+	return fmt.Sprintf("Row %d: %s", row+1, message)
+}
 
-	// 	columnNames = append(columnNames, fmt.Sprintf("`%s`", columns[k].Name))
+// resolveTable finds the connection and local table. Without a connection name the first connection whose schema
+// contains the table is used.
+func resolveTable(config *lib.Config, schemaList *schema.SchemaList, connectionName, tableName string) (*lib.ConfigDatabase, *schema.Table, error) {
 
-	// 	value := "?"
-	// 	if columns[k].Name == "DateCreated" {
-	// 		value = fmt.Sprintf("%d", time.Now().UnixNano()/1000000)
-	// 	} else {
-	// 		value = lib.ReadCliInput(reader, columns[k].Name+" ("+columns[k].DataType+"):")
-	// 	}
+	for k := range config.Databases {
 
-	// 	if lib.IsString(columns[k]) {
-	// 		value = "'" + value + "'"
-	// 	} else {
-	// 		if len(value) == 0 {
-	// 			value = "0"
-	// 		}
-	// 	}
+		var dbConfig = config.Databases[k]
 
-	// 	values = append(values, value)
-	// }
+		if len(connectionName) > 0 && dbConfig.Key != connectionName {
+			continue
+		}
 
-	// sql += "\t" + strings.Join(columnNames, ",\n\t")
-	// sql += "\n) VALUES (\n"
-	// sql += "\t" + strings.Join(values, ",\n\t")
-	// sql += "\n)\n"
+		var schemaName = lib.ExtractRootNameFromKey(dbConfig.Key)
 
-	// fmt.Println(sql)
+		for l := range schemaList.Schemas {
+			if schemaList.Schemas[l].Name != schemaName {
+				continue
+			}
+			if table, ok := schemaList.Schemas[l].Tables[tableName]; ok {
+				return dbConfig, table, nil
+			}
+		}
 
-	// doInsertYN := lib.ReadCliInput(reader, "Run above SQL (Y/n")
+		if len(connectionName) > 0 {
+			return nil, nil, fmt.Errorf("Table `%s` not found in schema `%s`", tableName, schemaName)
+		}
+	}
 
-	// if doInsertYN != "Y" {
-	// 	return
-	// }
+	if len(connectionName) > 0 {
+		return nil, nil, errors.New("Unknown connection key: " + connectionName)
+	}
 
-	// connector, _ := connectorFactory(c.Config.DatabaseType, c.Config)
-	// x := lib.NewExecutor(c.Config, connector)
-	// x.RunSQL(sql)
+	return nil, nil, fmt.Errorf("Table `%s` not found in any configured connection", tableName)
 }
@@ -0,0 +1,63 @@
+package insert
+
+import (
+	"testing"
+
+	"github.com/macinnir/dvc/core/lib"
+	"github.com/macinnir/dvc/core/lib/schema"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestValidateRows(t *testing.T) {
+
+	var table = &schema.Table{
+		Name: "User",
+		Columns: map[string]*schema.Column{
+			"UserID":    {Name: "UserID", DataType: "bigint", IsUnsigned: true, ColumnKey: "PRI", Extra: "auto_increment"},
+			"AccountID": {Name: "AccountID", DataType: "bigint", IsUnsigned: true},
+			"Email":     {Name: "Email", DataType: "varchar", MaxLength: 100},
+			"Nickname":  {Name: "Nickname", DataType: "varchar", MaxLength: 10, IsNullable: true},
+		},
+	}
+
+	var rows, e = DecodeRows(`{"AccountID": 1, "Email": "a@example.com"}`)
+	require.Nil(t, e)
+
+	var insertTable *schema.Table
+	insertTable, rows, e = ValidateRows(table, rows)
+	require.Nil(t, e)
+	assert.Len(t, insertTable.Columns, 2)
+	assert.Equal(t, uint64(1), rows[0]["AccountID"])
+
+	rows, _ = DecodeRows(`[{"Email": "a@example.com"}, {"AccountID": -1, "Nickname": null}]`)
+	_, _, e = ValidateRows(table, rows)
+	require.NotNil(t, e)
+	assert.Equal(t, "Row 1: `AccountID` is required\nRow 2: `AccountID` expects an unsigned integer, got `-1`", e.Error())
+
+	// Columns left out of some rows get their default
+	rows, _ = DecodeRows(`[{"AccountID": 1, "Email": "a@example.com"}, {"AccountID": 2, "Nickname": "b"}]`)
+	_, rows, e = ValidateRows(table, rows)
+	require.Nil(t, e)
+	assert.Nil(t, rows[0]["Nickname"])
+	assert.Equal(t, "", rows[1]["Email"])
+
+	rows, _ = DecodeRows(`{"Foo": 1}`)
+	_, _, e = ValidateRows(table, rows)
+	assert.NotNil(t, e)
+
+	_, e = DecodeRows(`[1]`)
+	assert.NotNil(t, e)
+	_, e = DecodeRows(`[]`)
+	assert.NotNil(t, e)
+}
+
+func TestCmd_InvalidArguments(t *testing.T) {
+
+	var logger = zap.NewNop()
+	var config = &lib.Config{}
+
+	assert.EqualError(t, Cmd(logger, config, []string{""}), "A table name and --json are required")
+	assert.EqualError(t, Cmd(logger, config, []string{"User", "--json"}), "Missing value for argument `--json`")
+}
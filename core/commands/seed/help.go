@@ -0,0 +1,27 @@
+package seed
+
+import "fmt"
+
+func Help() {
+	fmt.Println(`
+	seed [table] [[-n|--count] [count]] [[-c|--connection] [connection]]
+
+		Inserts generated rows into a table. Values are based on each column's data type, length, nullability
+		and unsigned flag, with heuristics for common names (e.g. Email, Phone, DateCreated). Unique columns
+		never repeat a value, and columns that reference a parent table (foreign keys or the onetomany,
+		manytoone and onetoone config maps) use existing parent keys. Empty parent tables are seeded first.
+
+		Rules in .dvc/seed.json override the defaults per column:
+
+			{ "User": { "Role": { "values": ["admin", "member"] }, "Age": { "min": 18, "max": 90 } } }
+
+		Rule fields: value, values, format (a Sprintf pattern given the row number), generator, min, max, nullRate
+		Generators: email, phone, firstName, lastName, name, url, word, sentence, paragraph, hex, bool, zero,
+		timestamp, date, datetime
+
+			-n, --count 		Number of rows to insert (default 100)
+			-c, --connection 	The connection to seed (defaults to the first connection)
+			--config 			Seed config path (default .dvc/seed.json)
+			--random-seed 		Seed for the random generator, for repeatable data
+	`)
+}
@@ -0,0 +1,135 @@
+package seed
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/macinnir/dvc/core/connectors"
+	"github.com/macinnir/dvc/core/lib"
+	"github.com/macinnir/dvc/core/lib/executor"
+	"github.com/macinnir/dvc/core/lib/schema"
+	libseed "github.com/macinnir/dvc/core/lib/seed"
+	"github.com/macinnir/dvc/core/lib/subset"
+	"go.uber.org/zap"
+)
+
+const CommandName = "seed"
+
+// Cmd inserts generated rows into a table
+// dvc seed [table] -n [count]
+func Cmd(logger *zap.Logger, config *lib.Config, args []string) error {
+
+	var e error
+	var tableName = ""
+	var count = 100
+	var connectionName = ""
+	var configPath = lib.SeedConfigFilePath
+	var randomSeed = time.Now().UnixNano()
+
+	for len(args) > 0 {
+
+		if !strings.HasPrefix(args[0], "-") {
+			if len(tableName) > 0 {
+				return fmt.Errorf("Unexpected argument `%s`", args[0])
+			}
+			tableName = args[0]
+			args = args[1:]
+			continue
+		}
+
+		if len(args) < 2 {
+			return fmt.Errorf("Missing value for argument `%s`", args[0])
+		}
+
+		switch args[0] {
+		case "-n", "--count":
+			if count, e = strconv.Atoi(args[1]); e != nil || count <= 0 {
+				return fmt.Errorf("Invalid count `%s`", args[1])
+			}
+		case "-c", "--connection":
+			connectionName = args[1]
+		case "--config":
+			configPath = args[1]
+		case "--random-seed":
+			if randomSeed, e = strconv.ParseInt(args[1], 10, 64); e != nil {
+				return fmt.Errorf("Invalid random seed `%s`", args[1])
+			}
+		default:
+			return fmt.Errorf("Unknown argument `%s`", args[0])
+		}
+
+		args = args[2:]
+	}
+
+	if len(tableName) == 0 {
+		fmt.Println("Usage: dvc seed [table] -n [count]")
+		return errors.New("A table name is required")
+	}
+
+	configs := map[string]*lib.ConfigDatabase{}
+	for k := range config.Databases {
+		configs[config.Databases[k].Key] = config.Databases[k]
+	}
+
+	if len(connectionName) == 0 {
+		if len(config.Databases) == 0 {
+			return errors.New("No connections configured")
+		}
+		connectionName = config.Databases[0].Key
+	}
+
+	var dbConfig, ok = configs[connectionName]
+	if !ok {
+		return errors.New("Unknown connection key: " + connectionName)
+	}
+
+	var seedConfig libseed.Config
+	if seedConfig, e = libseed.LoadConfig(configPath); e != nil {
+		return e
+	}
+
+	var connector connectors.IConnector
+	if connector, e = connectors.DBConnectorFactory(dbConfig); e != nil {
+		return fmt.Errorf("Error creating connector for connection %s: %w", connectionName, e)
+	}
+
+	var server = executor.NewExecutor(dbConfig, connector).Connect()
+	defer server.Connection.Close()
+
+	var database *schema.Database
+	if database, e = connector.FetchDatabase(connectionName, server, dbConfig.Name); e != nil {
+		return fmt.Errorf("Error fetching schema for connection %s: %w", connectionName, e)
+	}
+
+	var foreignKeys, declared []*subset.Relationship
+	if foreignKeys, e = subset.FetchForeignKeys(server.Connection, dbConfig.Name); e != nil {
+		return e
+	}
+
+	if declared, e = subset.ParseRelationships(dbConfig); e != nil {
+		return e
+	}
+
+	logger.Info("Seeding", zap.String("connection", connectionName), zap.String("table", tableName), zap.Int("rows", count), zap.Int64("randomSeed", randomSeed))
+
+	var seeder = libseed.NewSeeder(
+		server.Connection,
+		database.ToSchema(connectionName),
+		subset.MergeRelationships(foreignKeys, declared),
+		seedConfig,
+		libseed.NewGenerator(randomSeed),
+		logger,
+	)
+
+	var start = time.Now()
+	if e = seeder.Seed(tableName, count); e != nil {
+		return e
+	}
+
+	fmt.Printf("Seeded %d rows into %s in %f seconds\n", count, tableName, time.Since(start).Seconds())
+
+	return nil
+}
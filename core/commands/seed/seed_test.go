@@ -0,0 +1,18 @@
+package seed
+
+import (
+	"testing"
+
+	"github.com/macinnir/dvc/core/lib"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+func TestCmd_InvalidArguments(t *testing.T) {
+
+	var logger = zap.NewNop()
+	var config = &lib.Config{}
+
+	assert.EqualError(t, Cmd(logger, config, []string{""}), "A table name is required")
+	assert.EqualError(t, Cmd(logger, config, []string{"User", "-n", "0"}), "Invalid count `0`")
+}
@@ -4,8 +4,29 @@ import "fmt"
 
 func Help() {
 	fmt.Println(`
-	select [tableName] 
-	
-		Selects rows from the table [tableName]
+	select [table] [[-w|--where] [condition]] [--columns [columns]] [--order [columns]] [[-l|--limit] [n]]
+
+		Selects rows from the table [table]. Column names, condition values and joins are validated against the
+		local schema before the query is sent. Condition values are bound as parameters.
+
+		Conditions are written as Column Operator Value and are combined with AND:
+
+			--where "AccountID = 1" --where "Email LIKE %@example.com" --where "Role IN (admin,member)"
+
+		Operators: =, !=, <>, <, <=, >, >=, LIKE, NOT LIKE, IN, NOT IN, IS NULL, IS NOT NULL
+
+			-c, --connection 	The connection to query (defaults to the first connection with the table)
+			--columns 			Comma separated columns to select, e.g. UserID,Account.Name or Account.*
+			-w, --where 		A condition, can be repeated
+			--order 			Comma separated order columns, prefix with - or suffix with DESC to sort descending
+			-j, --join 			Inner join a table related to a table in the query, can be repeated
+			--left-join 		Left join a table related to a table in the query, can be repeated
+			-l, --limit 		Maximum number of rows (default 100, 0 for no limit)
+			--offset 			Number of rows to skip
+			-f, --format 		Output format: table (default), json, csv or xlsx
+			-o, --output 		Write the output to a file (xlsx defaults to [table].xlsx)
+
+		Joins use the onetomany, manytoone and onetoone maps on the connection config, then columns named
+		after a table's primary key (e.g. User.AccountID => Account.AccountID).
 	`)
 }
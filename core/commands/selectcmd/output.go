@@ -0,0 +1,162 @@
+package selectcmd
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+
+	"github.com/macinnir/dvc/core/lib"
+	"github.com/macinnir/dvc/core/lib/schema"
+	"github.com/macinnir/dvc/core/lib/utils/excel"
+	"github.com/tealeg/xlsx"
+)
+
+const (
+	FormatTable = "table"
+	FormatJSON  = "json"
+	FormatCSV   = "csv"
+	FormatXLSX  = "xlsx"
+)
+
+// IsValidFormat returns true if `format` is a supported output format
+func IsValidFormat(format string) bool {
+	switch format {
+	case FormatTable, FormatJSON, FormatCSV, FormatXLSX:
+		return true
+	}
+	return false
+}
+
+// cellString renders a scanned value for text output
+func cellString(value interface{}) string {
+	if value == nil {
+		return "NULL"
+	}
+	return fmt.Sprint(value)
+}
+
+// WriteTable writes the rows as a CLI table
+func WriteTable(w io.Writer, headers []string, rows []map[string]interface{}) error {
+
+	var t = lib.NewCLITable(headers)
+
+	for _, row := range rows {
+		t.Row()
+		for _, header := range headers {
+			t.Col(cellString(row[header]))
+		}
+	}
+
+	var _, e = fmt.Fprintln(w, t.String())
+	return e
+}
+
+// WriteJSON writes the rows as a JSON array of objects
+func WriteJSON(w io.Writer, columns []*SelectColumn, headers []string, rows []map[string]interface{}) error {
+
+	var result = make([]map[string]interface{}, len(rows))
+
+	for k, row := range rows {
+		result[k] = make(map[string]interface{}, len(headers))
+		for l, header := range headers {
+			result[k][header] = typedValue(columns[l].Column, row[header])
+		}
+	}
+
+	var e error
+	var b []byte
+	if b, e = json.MarshalIndent(result, "", "  "); e != nil {
+		return e
+	}
+
+	_, e = fmt.Fprintln(w, string(b))
+	return e
+}
+
+// typedValue converts numeric strings returned by the driver back to numbers for JSON output
+func typedValue(column *schema.Column, value interface{}) interface{} {
+
+	var str, ok = value.(string)
+	if !ok {
+		return value
+	}
+
+	if schema.IsInteger(column) {
+		if n, e := strconv.ParseInt(str, 10, 64); e == nil {
+			return n
+		}
+	}
+
+	if column.DataType == "decimal" || column.DataType == "float" || column.DataType == "double" {
+		return json.Number(str)
+	}
+
+	return str
+}
+
+// WriteCSV writes the rows as CSV with a header row. NULL values are written as empty fields.
+func WriteCSV(w io.Writer, headers []string, rows []map[string]interface{}) error {
+
+	var writer = csv.NewWriter(w)
+
+	if e := writer.Write(headers); e != nil {
+		return e
+	}
+
+	for _, row := range rows {
+		var record = make([]string, len(headers))
+		for k, header := range headers {
+			if row[header] != nil {
+				record[k] = fmt.Sprint(row[header])
+			}
+		}
+		if e := writer.Write(record); e != nil {
+			return e
+		}
+	}
+
+	writer.Flush()
+	return writer.Error()
+}
+
+// WriteXLSX writes the rows to an Excel file at `filePath`
+func WriteXLSX(filePath string, sheetName string, columns []*SelectColumn, headers []string, rows []map[string]interface{}) error {
+
+	var e error
+	var file = xlsx.NewFile()
+	var sheet *xlsx.Sheet
+
+	if sheet, e = file.AddSheet(sheetName); e != nil {
+		return e
+	}
+
+	excel.BuildExcelHeaders(sheet, headers)
+
+	for _, row := range rows {
+
+		var r = sheet.AddRow()
+
+		for k, header := range headers {
+
+			var value = row[header]
+			if value == nil {
+				excel.AddCellString(r, "")
+				continue
+			}
+
+			var str = fmt.Sprint(value)
+			if schema.IsInteger(columns[k].Column) {
+				if n, e := strconv.ParseInt(str, 10, 64); e == nil {
+					excel.AddCellInteger(r, n)
+					continue
+				}
+			}
+
+			excel.AddCellString(r, str)
+		}
+	}
+
+	return file.Save(filePath)
+}
@@ -0,0 +1,417 @@
+package selectcmd
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/macinnir/dvc/core/lib/schema"
+	"github.com/macinnir/dvc/core/lib/subset"
+)
+
+// SelectColumn is a column in the select list
+type SelectColumn struct {
+	Table  *schema.Table
+	Column *schema.Column
+}
+
+// Alias is the name of the column in the result set
+func (c *SelectColumn) Alias(qualified bool) string {
+	if qualified {
+		return c.Table.Name + "." + c.Column.Name
+	}
+	return c.Column.Name
+}
+
+func (c *SelectColumn) sql() string {
+	return fmt.Sprintf("`%s`.`%s`", c.Table.Name, c.Column.Name)
+}
+
+// Join is a join from a table already in the query to another table
+type Join struct {
+	Table      *schema.Table
+	Left       bool
+	FromTable  string
+	FromColumn string
+	ToColumn   string
+}
+
+// Condition is a single condition in the where clause
+type Condition struct {
+	Column   *SelectColumn
+	Operator string
+	Values   []interface{}
+}
+
+// OrderBy is a single column in the order by clause
+type OrderBy struct {
+	Column *SelectColumn
+	Desc   bool
+}
+
+// Query is a select statement validated against the schema
+type Query struct {
+	Table      *schema.Table
+	Columns    []*SelectColumn
+	Joins      []*Join
+	Conditions []*Condition
+	Order      []*OrderBy
+	Limit      int
+	Offset     int
+}
+
+// NewQuery returns a new Query on `table`
+func NewQuery(table *schema.Table) *Query {
+	return &Query{
+		Table:      table,
+		Columns:    []*SelectColumn{},
+		Joins:      []*Join{},
+		Conditions: []*Condition{},
+		Order:      []*OrderBy{},
+	}
+}
+
+// tables returns the root table followed by the joined tables
+func (q *Query) tables() []*schema.Table {
+	var tables = []*schema.Table{q.Table}
+	for k := range q.Joins {
+		tables = append(tables, q.Joins[k].Table)
+	}
+	return tables
+}
+
+// Join adds a join to `table` along a relationship with a table already in the query. Declared relationships
+// are used first, then the naming convention (e.g. `User`.`AccountID` => `Account`.`AccountID`).
+func (q *Query) Join(table *schema.Table, relationships []*subset.Relationship, left bool) error {
+
+	for _, t := range q.tables() {
+		if t.Name == table.Name {
+			return fmt.Errorf("Table `%s` is already part of the query", table.Name)
+		}
+	}
+
+	var join = &Join{Table: table, Left: left}
+
+	for _, t := range q.tables() {
+
+		for _, r := range relationships {
+			switch {
+			case r.ParentTable == t.Name && r.ChildTable == table.Name:
+				join.FromTable, join.FromColumn, join.ToColumn = t.Name, r.ParentColumn, r.ChildColumn
+			case r.ChildTable == t.Name && r.ParentTable == table.Name:
+				join.FromTable, join.FromColumn, join.ToColumn = t.Name, r.ChildColumn, r.ParentColumn
+			default:
+				continue
+			}
+			q.Joins = append(q.Joins, join)
+			return nil
+		}
+	}
+
+	for _, t := range q.tables() {
+
+		// t references table
+		if pk := primaryKey(table); pk != nil {
+			if _, ok := t.Columns[pk.Name]; ok {
+				join.FromTable, join.FromColumn, join.ToColumn = t.Name, pk.Name, pk.Name
+				q.Joins = append(q.Joins, join)
+				return nil
+			}
+		}
+
+		// table references t
+		if pk := primaryKey(t); pk != nil {
+			if _, ok := table.Columns[pk.Name]; ok {
+				join.FromTable, join.FromColumn, join.ToColumn = t.Name, pk.Name, pk.Name
+				q.Joins = append(q.Joins, join)
+				return nil
+			}
+		}
+	}
+
+	return fmt.Errorf("No relationship found between `%s` and the tables in the query", table.Name)
+}
+
+func primaryKey(table *schema.Table) *schema.Column {
+	var pk *schema.Column
+	for _, column := range table.Columns {
+		if column.ColumnKey == "PRI" {
+			if pk != nil {
+				// Composite keys can't be joined by convention
+				return nil
+			}
+			pk = column
+		}
+	}
+	return pk
+}
+
+// ResolveColumn finds a column by `Column` or `Table.Column`. Unqualified names are looked up on the
+// root table first and then on the joined tables.
+func (q *Query) ResolveColumn(name string) (*SelectColumn, error) {
+
+	name = strings.Trim(strings.TrimSpace(name), "`")
+
+	if strings.Contains(name, ".") {
+		var parts = strings.SplitN(name, ".", 2)
+		var tableName, columnName = strings.Trim(parts[0], "`"), strings.Trim(parts[1], "`")
+		for _, t := range q.tables() {
+			if t.Name != tableName {
+				continue
+			}
+			if column, ok := t.Columns[columnName]; ok {
+				return &SelectColumn{t, column}, nil
+			}
+			return nil, fmt.Errorf("Unknown column `%s` on table `%s`", columnName, tableName)
+		}
+		return nil, fmt.Errorf("Table `%s` is not part of the query", tableName)
+	}
+
+	if column, ok := q.Table.Columns[name]; ok {
+		return &SelectColumn{q.Table, column}, nil
+	}
+
+	var found *SelectColumn
+	for _, join := range q.Joins {
+		if column, ok := join.Table.Columns[name]; ok {
+			if found != nil {
+				return nil, fmt.Errorf("Column `%s` is ambiguous; use `Table.%s`", name, name)
+			}
+			found = &SelectColumn{join.Table, column}
+		}
+	}
+
+	if found == nil {
+		return nil, fmt.Errorf("Unknown column `%s`", name)
+	}
+
+	return found, nil
+}
+
+// Select sets the columns to select. Each name can also be `Table.*`.
+func (q *Query) Select(names []string) error {
+
+	for _, name := range names {
+
+		name = strings.TrimSpace(name)
+		if len(name) == 0 {
+			continue
+		}
+
+		if strings.HasSuffix(name, ".*") {
+			var tableName = strings.TrimSuffix(name, ".*")
+			var found = false
+			for _, t := range q.tables() {
+				if t.Name == tableName {
+					found = true
+					for _, column := range t.ToSortedColumns() {
+						q.Columns = append(q.Columns, &SelectColumn{t, column})
+					}
+				}
+			}
+			if !found {
+				return fmt.Errorf("Table `%s` is not part of the query", tableName)
+			}
+			continue
+		}
+
+		var column, e = q.ResolveColumn(name)
+		if e != nil {
+			return e
+		}
+
+		q.Columns = append(q.Columns, column)
+	}
+
+	return nil
+}
+
+// SelectedColumns returns the selected columns, or every column of every table if none were selected
+func (q *Query) SelectedColumns() []*SelectColumn {
+
+	if len(q.Columns) > 0 {
+		return q.Columns
+	}
+
+	var columns = []*SelectColumn{}
+	for _, t := range q.tables() {
+		for _, column := range t.ToSortedColumns() {
+			columns = append(columns, &SelectColumn{t, column})
+		}
+	}
+
+	return columns
+}
+
+// Qualified is true if column aliases need the table name to be unique
+func (q *Query) Qualified() bool {
+	return len(q.Joins) > 0
+}
+
+var conditionRegexp = regexp.MustCompile(`(?i)^\s*([A-Za-z0-9_.` + "`" + `]+)\s*(<=|>=|<>|!=|=|<|>|NOT\s+LIKE\b|LIKE\b|NOT\s+IN\b|IN\b|IS\s+NOT\s+NULL\s*$|IS\s+NULL\s*$)\s*(.*)$`)
+
+// Where adds a condition in the form `Column Operator Value`, e.g. `AccountID = 1`, `Name LIKE foo%`,
+// `UserID IN (1,2,3)` or `DateDeleted IS NULL`. Values are validated against the column type and bound as parameters.
+func (q *Query) Where(expr string) error {
+
+	var matches = conditionRegexp.FindStringSubmatch(expr)
+	if matches == nil {
+		return fmt.Errorf("Invalid condition `%s`; expected `Column Operator Value`", expr)
+	}
+
+	var e error
+	var column *SelectColumn
+	if column, e = q.ResolveColumn(matches[1]); e != nil {
+		return e
+	}
+
+	var operator = strings.ToUpper(strings.Join(strings.Fields(matches[2]), " "))
+	var rawValue = strings.TrimSpace(matches[3])
+	var condition = &Condition{Column: column, Operator: operator, Values: []interface{}{}}
+
+	switch operator {
+	case "IS NULL", "IS NOT NULL":
+		if len(rawValue) > 0 {
+			return fmt.Errorf("Invalid condition `%s`", expr)
+		}
+	case "IN", "NOT IN":
+		rawValue = strings.TrimSuffix(strings.TrimPrefix(rawValue, "("), ")")
+		for _, part := range strings.Split(rawValue, ",") {
+			var value interface{}
+			if value, e = ParseValue(column.Column, part); e != nil {
+				return e
+			}
+			condition.Values = append(condition.Values, value)
+		}
+	case "LIKE", "NOT LIKE":
+		condition.Values = append(condition.Values, unquote(rawValue))
+	default:
+		var value interface{}
+		if value, e = ParseValue(column.Column, rawValue); e != nil {
+			return e
+		}
+		condition.Values = append(condition.Values, value)
+	}
+
+	if len(condition.Values) == 0 && operator != "IS NULL" && operator != "IS NOT NULL" {
+		return fmt.Errorf("Missing value in condition `%s`", expr)
+	}
+
+	q.Conditions = append(q.Conditions, condition)
+
+	return nil
+}
+
+func unquote(str string) string {
+	str = strings.TrimSpace(str)
+	if len(str) >= 2 && (str[0] == '\'' || str[0] == '"') && str[len(str)-1] == str[0] {
+		return str[1 : len(str)-1]
+	}
+	return str
+}
+
+// ParseValue converts a command line value to the column's type, returning an error if it can't be stored in the column
+func ParseValue(column *schema.Column, raw string) (interface{}, error) {
+	return schema.ParseValue(column, unquote(raw))
+}
+
+// OrderBy adds a column to the order by clause. `-Column` or `Column DESC` sorts descending.
+func (q *Query) OrderBy(expr string) error {
+
+	var fields = strings.Fields(expr)
+	if len(fields) == 0 || len(fields) > 2 {
+		return fmt.Errorf("Invalid order `%s`", expr)
+	}
+
+	var name = fields[0]
+	var desc = false
+
+	if strings.HasPrefix(name, "-") {
+		name = name[1:]
+		desc = true
+	}
+
+	if len(fields) == 2 {
+		switch strings.ToUpper(fields[1]) {
+		case "ASC":
+			desc = false
+		case "DESC":
+			desc = true
+		default:
+			return fmt.Errorf("Invalid order direction `%s`", fields[1])
+		}
+	}
+
+	var column, e = q.ResolveColumn(name)
+	if e != nil {
+		return e
+	}
+
+	q.Order = append(q.Order, &OrderBy{Column: column, Desc: desc})
+
+	return nil
+}
+
+// SQL builds the select statement and its parameters
+func (q *Query) SQL() (string, []interface{}) {
+
+	var args = []interface{}{}
+	var sb strings.Builder
+
+	var qualified = q.Qualified()
+	var columns = q.SelectedColumns()
+	var selects = make([]string, len(columns))
+	for k := range columns {
+		selects[k] = fmt.Sprintf("%s AS `%s`", columns[k].sql(), columns[k].Alias(qualified))
+	}
+
+	sb.WriteString("SELECT " + strings.Join(selects, ", "))
+	sb.WriteString(fmt.Sprintf(" FROM `%s`", q.Table.Name))
+
+	for _, join := range q.Joins {
+		var joinType = "INNER JOIN"
+		if join.Left {
+			joinType = "LEFT JOIN"
+		}
+		sb.WriteString(fmt.Sprintf(" %s `%s` ON `%s`.`%s` = `%s`.`%s`", joinType, join.Table.Name, join.Table.Name, join.ToColumn, join.FromTable, join.FromColumn))
+	}
+
+	if len(q.Conditions) > 0 {
+		var conditions = make([]string, len(q.Conditions))
+		for k, c := range q.Conditions {
+			switch c.Operator {
+			case "IS NULL", "IS NOT NULL":
+				conditions[k] = c.Column.sql() + " " + c.Operator
+			case "IN", "NOT IN":
+				conditions[k] = fmt.Sprintf("%s %s (%s)", c.Column.sql(), c.Operator, strings.TrimSuffix(strings.Repeat("?,", len(c.Values)), ","))
+			default:
+				conditions[k] = c.Column.sql() + " " + c.Operator + " ?"
+			}
+			args = append(args, c.Values...)
+		}
+		sb.WriteString(" WHERE " + strings.Join(conditions, " AND "))
+	}
+
+	if len(q.Order) > 0 {
+		var orders = make([]string, len(q.Order))
+		for k, o := range q.Order {
+			orders[k] = o.Column.sql()
+			if o.Desc {
+				orders[k] += " DESC"
+			}
+		}
+		sb.WriteString(" ORDER BY " + strings.Join(orders, ", "))
+	}
+
+	if q.Limit > 0 {
+		sb.WriteString(fmt.Sprintf(" LIMIT %d", q.Limit))
+		if q.Offset > 0 {
+			sb.WriteString(fmt.Sprintf(" OFFSET %d", q.Offset))
+		}
+	} else if q.Offset > 0 {
+		// MySQL requires a limit with an offset
+		sb.WriteString(fmt.Sprintf(" LIMIT 18446744073709551615 OFFSET %d", q.Offset))
+	}
+
+	return sb.String(), args
+}
@@ -0,0 +1,106 @@
+package selectcmd
+
+import (
+	"testing"
+
+	"github.com/macinnir/dvc/core/lib/schema"
+	"github.com/macinnir/dvc/core/lib/subset"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testTables() (*schema.Table, *schema.Table) {
+
+	var account = &schema.Table{
+		Name: "Account",
+		Columns: map[string]*schema.Column{
+			"AccountID": {Name: "AccountID", DataType: "bigint", IsUnsigned: true, ColumnKey: "PRI"},
+			"Name":      {Name: "Name", DataType: "varchar", MaxLength: 10},
+		},
+	}
+
+	var user = &schema.Table{
+		Name: "User",
+		Columns: map[string]*schema.Column{
+			"UserID":    {Name: "UserID", DataType: "bigint", IsUnsigned: true, ColumnKey: "PRI"},
+			"AccountID": {Name: "AccountID", DataType: "bigint", IsUnsigned: true},
+			"Name":      {Name: "Name", DataType: "varchar", MaxLength: 50},
+			"Age":       {Name: "Age", DataType: "tinyint"},
+			"Role":      {Name: "Role", DataType: "enum", Type: "enum('admin','member')"},
+		},
+	}
+
+	return user, account
+}
+
+func TestQuerySQL(t *testing.T) {
+
+	var user, _ = testTables()
+	var q = NewQuery(user)
+	q.Limit = 10
+	q.Offset = 20
+
+	require.Nil(t, q.Select([]string{"UserID", "Name"}))
+	require.Nil(t, q.Where("Age >= 18"))
+	require.Nil(t, q.Where("Role IN (admin, member)"))
+	require.Nil(t, q.Where("Name like 'Jo%'"))
+	require.Nil(t, q.Where("AccountID IS NOT NULL"))
+	require.Nil(t, q.OrderBy("-Age"))
+	require.Nil(t, q.OrderBy("Name ASC"))
+
+	var query, args = q.SQL()
+
+	assert.Equal(t, "SELECT `User`.`UserID` AS `UserID`, `User`.`Name` AS `Name` FROM `User`"+
+		" WHERE `User`.`Age` >= ? AND `User`.`Role` IN (?,?) AND `User`.`Name` LIKE ? AND `User`.`AccountID` IS NOT NULL"+
+		" ORDER BY `User`.`Age` DESC, `User`.`Name` LIMIT 10 OFFSET 20", query)
+	assert.Equal(t, []interface{}{int64(18), "admin", "member", "Jo%"}, args)
+}
+
+func TestQueryWhereValidation(t *testing.T) {
+
+	var user, _ = testTables()
+	var q = NewQuery(user)
+
+	assert.NotNil(t, q.Where("Foo = 1"))
+	assert.NotNil(t, q.Where("Age = abc"))
+	assert.NotNil(t, q.Where("Age = 300"))
+	assert.NotNil(t, q.Where("UserID = -1"))
+	assert.NotNil(t, q.Where("Role = owner"))
+	assert.NotNil(t, q.Where("Age"))
+	assert.NotNil(t, q.Where("Age IS NULL 1"))
+	assert.Nil(t, q.Where("Age = -5"))
+	assert.Len(t, q.Conditions, 1)
+}
+
+func TestQueryJoin(t *testing.T) {
+
+	var user, account = testTables()
+
+	// Naming convention
+	var q = NewQuery(user)
+	require.Nil(t, q.Join(account, nil, false))
+	require.Nil(t, q.Select([]string{"UserID", "Account.Name"}))
+
+	var query, _ = q.SQL()
+	assert.Equal(t, "SELECT `User`.`UserID` AS `User.UserID`, `Account`.`Name` AS `Account.Name` FROM `User`"+
+		" INNER JOIN `Account` ON `Account`.`AccountID` = `User`.`AccountID`", query)
+
+	// Unqualified columns prefer the root table
+	var c, e = q.ResolveColumn("Name")
+	require.Nil(t, e)
+	assert.Equal(t, "User", c.Table.Name)
+
+	// Declared relationships
+	q = NewQuery(account)
+	require.Nil(t, q.Join(user, []*subset.Relationship{{ParentTable: "Account", ParentColumn: "AccountID", ChildTable: "User", ChildColumn: "AccountID"}}, true))
+	query, _ = q.SQL()
+	assert.Contains(t, query, " LEFT JOIN `User` ON `User`.`AccountID` = `Account`.`AccountID`")
+
+	// Columns only on a joined table resolve without a prefix
+	c, e = q.ResolveColumn("Age")
+	require.Nil(t, e)
+	assert.Equal(t, "User", c.Table.Name)
+
+	assert.NotNil(t, q.Join(user, nil, false))
+	assert.NotNil(t, NewQuery(user).Join(&schema.Table{Name: "Other", Columns: map[string]*schema.Column{}}, nil, false))
+}
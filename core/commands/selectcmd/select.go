@@ -1,34 +1,252 @@
 package selectcmd
 
 import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/macinnir/dvc/core/connectors"
 	"github.com/macinnir/dvc/core/lib"
+	"github.com/macinnir/dvc/core/lib/executor"
+	"github.com/macinnir/dvc/core/lib/schema"
+	"github.com/macinnir/dvc/core/lib/subset"
 	"go.uber.org/zap"
 )
 
 const CommandName = "select"
 
-// CommandSelect selects rows from the database
+type joinArg struct {
+	table string
+	left  bool
+}
+
+// Cmd selects rows from a table
+// dvc select [table] --columns [a,b] --where [condition] --order [column] --limit [n] --format [format]
 func Cmd(logger *zap.Logger, config *lib.Config, args []string) error {
 
-	return nil
-	// database := c.loadDatabase()
+	var e error
+	var tableName = ""
+	var connectionName = ""
+	var columns = []string{}
+	var conditions = []string{}
+	var orders = []string{}
+	var joins = []joinArg{}
+	var limit = 100
+	var offset = 0
+	var format = FormatTable
+	var outputPath = ""
+
+	for len(args) > 0 {
+
+		if !strings.HasPrefix(args[0], "-") {
+			if len(tableName) > 0 {
+				return fmt.Errorf("Unexpected argument `%s`", args[0])
+			}
+			tableName = args[0]
+			args = args[1:]
+			continue
+		}
+
+		if len(args) < 2 {
+			return fmt.Errorf("Missing value for argument `%s`", args[0])
+		}
+
+		switch args[0] {
+		case "-c", "--connection":
+			connectionName = args[1]
+		case "--columns":
+			columns = append(columns, strings.Split(args[1], ",")...)
+		case "-w", "--where":
+			conditions = append(conditions, args[1])
+		case "--order":
+			orders = append(orders, strings.Split(args[1], ",")...)
+		case "-j", "--join":
+			joins = append(joins, joinArg{args[1], false})
+		case "--left-join":
+			joins = append(joins, joinArg{args[1], true})
+		case "-l", "--limit":
+			if limit, e = strconv.Atoi(args[1]); e != nil || limit < 0 {
+				return fmt.Errorf("Invalid limit `%s`", args[1])
+			}
+		case "--offset":
+			if offset, e = strconv.Atoi(args[1]); e != nil || offset < 0 {
+				return fmt.Errorf("Invalid offset `%s`", args[1])
+			}
+		case "-f", "--format":
+			format = strings.ToLower(args[1])
+			if !IsValidFormat(format) {
+				return fmt.Errorf("Unknown format `%s`", args[1])
+			}
+		case "-o", "--output":
+			outputPath = args[1]
+		default:
+			return fmt.Errorf("Unknown argument `%s`", args[0])
+		}
+
+		args = args[2:]
+	}
+
+	if len(tableName) == 0 {
+		fmt.Println("Usage: dvc select [table] --where [condition]")
+		return errors.New("A table name is required")
+	}
+
+	var schemaList *schema.SchemaList
+	if schemaList, e = schema.LoadLocalSchemas(); e != nil {
+		return e
+	}
+
+	var dbConfig *lib.ConfigDatabase
+	var localSchema *schema.Schema
+	if dbConfig, localSchema, e = resolveConnection(config, schemaList, connectionName, tableName); e != nil {
+		return e
+	}
+
+	// Build and validate the query against the local schema before connecting
+	var query = NewQuery(localSchema.Tables[tableName])
+	query.Limit = limit
+	query.Offset = offset
+
+	var relationships []*subset.Relationship
+	if relationships, e = subset.ParseRelationships(dbConfig); e != nil {
+		return e
+	}
+
+	for _, join := range joins {
+		var joinTable, ok = localSchema.Tables[join.table]
+		if !ok {
+			return fmt.Errorf("Table `%s` not found in schema `%s`", join.table, localSchema.Name)
+		}
+		if e = query.Join(joinTable, relationships, join.left); e != nil {
+			return e
+		}
+	}
+
+	if e = query.Select(columns); e != nil {
+		return e
+	}
+
+	for _, condition := range conditions {
+		if e = query.Where(condition); e != nil {
+			return e
+		}
+	}
+
+	for _, order := range orders {
+		if e = query.OrderBy(order); e != nil {
+			return e
+		}
+	}
+
+	var sqlQuery, queryArgs = query.SQL()
+	logger.Debug("Select", zap.String("connection", dbConfig.Key), zap.String("query", sqlQuery), zap.Any("args", queryArgs))
+
+	var connector connectors.IConnector
+	if connector, e = connectors.DBConnectorFactory(dbConfig); e != nil {
+		return fmt.Errorf("Error creating connector for connection %s: %w", dbConfig.Key, e)
+	}
+
+	var server = executor.NewExecutor(dbConfig, connector).Connect()
+	defer server.Connection.Close()
+
+	var rows *sql.Rows
+	if rows, e = server.Connection.Query(sqlQuery, queryArgs...); e != nil {
+		return fmt.Errorf("Error selecting from `%s`: %w", tableName, e)
+	}
+
+	defer rows.Close()
+
+	var results []map[string]interface{}
+	if results, e = subset.ScanRows(rows); e != nil {
+		return e
+	}
+
+	var selected = query.SelectedColumns()
+	var headers = make([]string, len(selected))
+	for k := range selected {
+		headers[k] = selected[k].Alias(query.Qualified())
+	}
+
+	if format == FormatXLSX {
+		if len(outputPath) == 0 {
+			outputPath = tableName + ".xlsx"
+		}
+		if e = WriteXLSX(outputPath, tableName, selected, headers, results); e != nil {
+			return e
+		}
+		fmt.Printf("Wrote %d rows to %s\n", len(results), outputPath)
+		return nil
+	}
+
+	var out = os.Stdout
+	if len(outputPath) > 0 {
+		if out, e = os.Create(outputPath); e != nil {
+			return e
+		}
+		defer out.Close()
+	}
+
+	switch format {
+	case FormatJSON:
+		e = WriteJSON(out, selected, headers, results)
+	case FormatCSV:
+		e = WriteCSV(out, headers, results)
+	default:
+		e = WriteTable(out, headers, results)
+		if e == nil {
+			fmt.Printf("%d rows\n", len(results))
+		}
+	}
+
+	return e
+}
+
+// resolveConnection finds the connection and local schema for a table. Without a connection name the first
+// connection whose schema contains the table is used.
+func resolveConnection(config *lib.Config, schemaList *schema.SchemaList, connectionName, tableName string) (*lib.ConfigDatabase, *schema.Schema, error) {
+
+	var schemaByName = func(name string) *schema.Schema {
+		for k := range schemaList.Schemas {
+			if schemaList.Schemas[k].Name == name {
+				return schemaList.Schemas[k]
+			}
+		}
+		return nil
+	}
+
+	for k := range config.Databases {
+
+		var dbConfig = config.Databases[k]
+
+		if len(connectionName) > 0 && dbConfig.Key != connectionName {
+			continue
+		}
 
-	// reader := bufio.NewReader(os.Stdin)
+		var localSchema = schemaByName(lib.ExtractRootNameFromKey(dbConfig.Key))
 
-	// tableName := ""
+		if localSchema == nil {
+			if len(connectionName) > 0 {
+				return nil, nil, fmt.Errorf("No local schema found for connection %s", connectionName)
+			}
+			continue
+		}
 
-	// if len(args) > 0 {
-	// 	tableName = args[0]
-	// 	args = args[1:]
-	// } else {
-	// 	tableName = lib.ReadCliInput(reader, "Table Name:")
-	// }
+		if _, ok := localSchema.Tables[tableName]; !ok {
+			if len(connectionName) > 0 {
+				return nil, nil, fmt.Errorf("Table `%s` not found in schema `%s`", tableName, localSchema.Name)
+			}
+			continue
+		}
 
-	// if _, ok := database.Tables[tableName]; !ok {
-	// 	fmt.Printf("Unknown table `%s`\n", tableName)
-	// 	return
-	// }
+		return dbConfig, localSchema, nil
+	}
 
-	// query := fmt.Sprintf("SELECT * FROM `%s` LIMIT 100\n", tableName)
+	if len(connectionName) > 0 {
+		return nil, nil, errors.New("Unknown connection key: " + connectionName)
+	}
 
+	return nil, nil, fmt.Errorf("Table `%s` not found in any configured connection", tableName)
 }
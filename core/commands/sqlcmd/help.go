@@ -0,0 +1,25 @@
+package sqlcmd
+
+import "fmt"
+
+func Help() {
+	fmt.Println(`
+	sql [connection] [[-r|--read-only]]
+
+		Opens an interactive SQL console on [connection] (defaults to the first connection). Statements end
+		with ; and can span multiple lines. Results are shown as tables. History is saved to .dvc/sql_history
+		and can be recalled with the arrow keys. Tab completes table names, column names and keywords from
+		the local schema.
+
+		Connections with "readOnly": true in the config, or opened with --read-only, refuse anything other
+		than SELECT, SHOW, DESCRIBE and EXPLAIN. On MySQL and PostgreSQL the session is also set to read-only
+		transactions, so the server refuses writes as well.
+
+		Input can also be piped in: dvc sql app_0 < report.sql
+
+			\d 			List tables
+			\d [table] 	Describe a table
+			\c 			Clear the current statement
+			\q 			Quit
+	`)
+}
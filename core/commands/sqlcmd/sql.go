@@ -0,0 +1,184 @@
+package sqlcmd
+
+import (
+	"bufio"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"strings"
+
+	"github.com/macinnir/dvc/core/connectors"
+	"github.com/macinnir/dvc/core/lib"
+	"github.com/macinnir/dvc/core/lib/console"
+	"github.com/macinnir/dvc/core/lib/executor"
+	"github.com/macinnir/dvc/core/lib/schema"
+	"go.uber.org/zap"
+	"golang.org/x/crypto/ssh/terminal"
+)
+
+const CommandName = "sql"
+
+// Cmd opens an interactive SQL console on a connection
+// dvc sql [connection]
+func Cmd(logger *zap.Logger, config *lib.Config, args []string) error {
+
+	var e error
+	var connectionName = ""
+	var readOnly = false
+
+	for len(args) > 0 {
+
+		switch args[0] {
+		case "-r", "--read-only":
+			readOnly = true
+		default:
+			if strings.HasPrefix(args[0], "-") || len(connectionName) > 0 {
+				return fmt.Errorf("Unknown argument `%s`", args[0])
+			}
+			connectionName = args[0]
+		}
+
+		args = args[1:]
+	}
+
+	if len(connectionName) == 0 {
+		if len(config.Databases) == 0 {
+			return errors.New("No connections configured")
+		}
+		connectionName = config.Databases[0].Key
+	}
+
+	var dbConfig *lib.ConfigDatabase
+	for k := range config.Databases {
+		if config.Databases[k].Key == connectionName {
+			dbConfig = config.Databases[k]
+			break
+		}
+	}
+
+	if dbConfig == nil {
+		return errors.New("Unknown connection key: " + connectionName)
+	}
+
+	readOnly = readOnly || dbConfig.ReadOnly
+
+	var completer = console.NewCompleter()
+	var schemaList *schema.SchemaList
+	if schemaList, e = schema.LoadLocalSchemas(); e != nil {
+		logger.Warn("Could not load the local schemas; autocomplete and \\d are unavailable", zap.Error(e))
+	} else {
+		var schemaName = lib.ExtractRootNameFromKey(dbConfig.Key)
+		for k := range schemaList.Schemas {
+			if schemaList.Schemas[k].Name == schemaName {
+				completer = console.NewCompleter(schemaList.Schemas[k])
+			}
+		}
+	}
+
+	var connector connectors.IConnector
+	if connector, e = connectors.DBConnectorFactory(dbConfig); e != nil {
+		return fmt.Errorf("Error creating connector for connection %s: %w", connectionName, e)
+	}
+
+	var server = executor.NewExecutor(dbConfig, connector).Connect()
+	defer server.Connection.Close()
+
+	var ctx = context.Background()
+
+	// The server refuses writes too, in case a statement gets past IsReadOnly
+	var conn console.Conn = server.Connection
+	if readOnly {
+		var readOnlyConn *sql.Conn
+		if readOnlyConn, e = console.ReadOnlyConn(ctx, server.Connection, dbConfig.Type); e != nil {
+			return fmt.Errorf("Error making connection %s read-only: %w", connectionName, e)
+		}
+		defer readOnlyConn.Close()
+		conn = readOnlyConn
+	}
+
+	// Piped input (e.g. `dvc sql app_0 < query.sql`) runs without line editing or history
+	if !terminal.IsTerminal(int(os.Stdin.Fd())) {
+		var c = console.NewConsole(conn, completer, os.Stdout, readOnly)
+		return c.Loop(ctx, &lineScanner{bufio.NewScanner(os.Stdin)}, "")
+	}
+
+	var state *terminal.State
+	if state, e = terminal.MakeRaw(int(os.Stdin.Fd())); e != nil {
+		return e
+	}
+
+	defer terminal.Restore(int(os.Stdin.Fd()), state)
+
+	var rw = &terminalIO{Reader: os.Stdin, Writer: os.Stdout}
+	var t = terminal.NewTerminal(rw, console.Prompt)
+	t.AutoCompleteCallback = completer.Complete
+
+	if width, height, e := terminal.GetSize(int(os.Stdout.Fd())); e == nil {
+		t.SetSize(width, height)
+	}
+
+	var history []string
+	if history, e = console.LoadHistory(lib.SQLHistoryFilePath, console.MaxHistory); e != nil {
+		logger.Warn("Could not load history", zap.Error(e))
+	}
+
+	loadHistory(t, rw, history)
+
+	var mode = ""
+	if readOnly {
+		mode = " (read-only)"
+	}
+
+	fmt.Fprintf(t, "Connected to %s%s. Type \\? for help.\n", connectionName, mode)
+
+	return console.NewConsole(conn, completer, t, readOnly).Loop(ctx, t, lib.SQLHistoryFilePath)
+}
+
+// terminalIO is the terminal's input and output, which can be swapped out while history is loaded
+type terminalIO struct {
+	io.Reader
+	io.Writer
+}
+
+// loadHistory feeds previous entries through the terminal, with its output discarded, so they can be recalled
+// with the arrow keys. The terminal has no other way of adding history.
+func loadHistory(t *terminal.Terminal, rw *terminalIO, history []string) {
+
+	if len(history) == 0 {
+		return
+	}
+
+	var reader, writer = rw.Reader, rw.Writer
+
+	rw.Reader = strings.NewReader(strings.Join(history, "\r") + "\r")
+	rw.Writer = ioutil.Discard
+
+	for range history {
+		if _, e := t.ReadLine(); e != nil {
+			break
+		}
+	}
+
+	rw.Reader, rw.Writer = reader, writer
+}
+
+// lineScanner reads lines from non-interactive input
+type lineScanner struct {
+	scanner *bufio.Scanner
+}
+
+func (s *lineScanner) ReadLine() (string, error) {
+	if !s.scanner.Scan() {
+		if e := s.scanner.Err(); e != nil {
+			return "", e
+		}
+		return "", io.EOF
+	}
+	return s.scanner.Text(), nil
+}
+
+func (s *lineScanner) SetPrompt(prompt string) {}
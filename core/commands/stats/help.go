@@ -0,0 +1,19 @@
+package stats
+
+import "fmt"
+
+func Help() {
+	fmt.Println(`
+	stats [[-c|--connection] [connection]] [[-t|--table] [table]] [--growth] [--no-save]
+
+		Shows per table row counts (estimated), data size, index size, fragmentation (free space) and how much of
+		the auto increment primary key's range has been used.
+
+		Every run stores a snapshot in .dvc/stats/[connection].jsonl.
+
+			-c, --connection 	Only show this connection (defaults to all connections)
+			-t, --table 		Only show this table
+			--growth 			Show growth since the first snapshot and warn about primary keys close to overflow
+			--no-save 			Don't store a snapshot for this run
+	`)
+}
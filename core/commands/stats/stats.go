@@ -0,0 +1,215 @@
+package stats
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/macinnir/dvc/core/connectors"
+	"github.com/macinnir/dvc/core/lib"
+	"github.com/macinnir/dvc/core/lib/executor"
+	"github.com/macinnir/dvc/core/lib/schema"
+	libstats "github.com/macinnir/dvc/core/lib/stats"
+	"go.uber.org/zap"
+)
+
+const CommandName = "stats"
+
+// Cmd shows table statistics
+// dvc stats [-c connection] [-t table] [--growth] [--no-save]
+func Cmd(logger *zap.Logger, config *lib.Config, args []string) error {
+
+	var e error
+	var connectionName = ""
+	var tableName = ""
+	var showGrowth = false
+	var save = true
+
+	for len(args) > 0 {
+
+		if args[0] == "--growth" {
+			showGrowth = true
+			args = args[1:]
+			continue
+		}
+
+		if args[0] == "--no-save" {
+			save = false
+			args = args[1:]
+			continue
+		}
+
+		if len(args) < 2 {
+			return fmt.Errorf("Missing value for argument `%s`", args[0])
+		}
+
+		switch args[0] {
+		case "-c", "--connection":
+			connectionName = args[1]
+		case "-t", "--table":
+			tableName = args[1]
+		default:
+			return fmt.Errorf("Unknown argument `%s`", args[0])
+		}
+
+		args = args[2:]
+	}
+
+	var found = false
+
+	for k := range config.Databases {
+
+		var dbConfig = config.Databases[k]
+		if len(connectionName) > 0 && dbConfig.Key != connectionName {
+			continue
+		}
+
+		found = true
+
+		var snapshot *libstats.Snapshot
+		if snapshot, e = fetchSnapshot(dbConfig); e != nil {
+			return e
+		}
+
+		var snapshots []*libstats.Snapshot
+		if snapshots, e = libstats.LoadSnapshots(dbConfig.Key); e != nil {
+			return e
+		}
+
+		snapshots = append(snapshots, snapshot)
+
+		if save {
+			if e = libstats.SaveSnapshot(snapshot); e != nil {
+				return fmt.Errorf("Error saving snapshot: %w", e)
+			}
+		}
+
+		fmt.Printf("\n%s (%s)\n", dbConfig.Key, dbConfig.Name)
+
+		if showGrowth {
+			printGrowth(snapshots, tableName)
+		} else {
+			printStats(snapshot, tableName)
+		}
+	}
+
+	if !found {
+		return errors.New("Unknown connection key: " + connectionName)
+	}
+
+	return nil
+}
+
+func fetchSnapshot(dbConfig *lib.ConfigDatabase) (*libstats.Snapshot, error) {
+
+	var e error
+	var connector connectors.IConnector
+
+	if connector, e = connectors.DBConnectorFactory(dbConfig); e != nil {
+		return nil, fmt.Errorf("Error creating connector for connection %s: %w", dbConfig.Key, e)
+	}
+
+	var server = executor.NewExecutor(dbConfig, connector).Connect()
+	defer server.Connection.Close()
+
+	var database *schema.Database
+	if database, e = connector.FetchDatabase(dbConfig.Key, server, dbConfig.Name); e != nil {
+		return nil, fmt.Errorf("Error fetching schema for connection %s: %w", dbConfig.Key, e)
+	}
+
+	return libstats.NewSnapshot(dbConfig.Key, database, time.Now()), nil
+}
+
+func printStats(snapshot *libstats.Snapshot, tableName string) {
+
+	var t = lib.NewCLITable([]string{"Table", "Rows", "Data", "Index", "Free", "Frag", "Primary Key", "Auto Inc", "Headroom", "Used"})
+	var warnings = []string{}
+
+	for _, s := range snapshot.Tables {
+
+		if len(tableName) > 0 && s.Name != tableName {
+			continue
+		}
+
+		t.Row()
+		t.Col(s.Name)
+		t.Colf("~%d", s.Rows)
+		t.Col(libstats.FormatBytes(s.DataLength))
+		t.Col(libstats.FormatBytes(s.IndexLength))
+		t.Col(libstats.FormatBytes(s.DataFree))
+		t.Colf("%.1f%%", s.Fragmentation()*100)
+
+		if s.HasAutoIncrement() {
+			t.Colf("%s %s", s.PrimaryKey, s.PrimaryKeyType)
+			t.Colf("%d", s.AutoIncrement)
+			t.Colf("%d", s.Headroom())
+			t.Colf("%.4f%%", s.KeyUsage()*100)
+		} else {
+			t.Col(s.PrimaryKey)
+			t.Col("")
+			t.Col("")
+			t.Col("")
+		}
+
+		if s.KeyUsage() >= libstats.KeyUsageWarning {
+			warnings = append(warnings, fmt.Sprintf("%s.%s (%s) has used %.1f%% of its range", s.Name, s.PrimaryKey, s.PrimaryKeyType, s.KeyUsage()*100))
+		}
+	}
+
+	fmt.Println(t.String())
+	printWarnings(warnings)
+}
+
+func printGrowth(snapshots []*libstats.Snapshot, tableName string) {
+
+	var growth = libstats.CalculateGrowth(snapshots)
+	var first = time.Unix(snapshots[0].Time, 0)
+
+	fmt.Printf("%d snapshots since %s\n", len(snapshots), first.Format("2006-01-02 15:04:05"))
+
+	var t = lib.NewCLITable([]string{"Table", "Rows", "+Rows", "Rows/Day", "Size", "+Size", "Size/Day", "Used", "Overflow"})
+	var warnings = []string{}
+
+	for _, g := range growth {
+
+		if len(tableName) > 0 && g.Name != tableName {
+			continue
+		}
+
+		t.Row()
+		t.Col(g.Name)
+		t.Colf("~%d", g.To.Rows)
+		t.Colf("%+d", g.RowsDelta())
+		t.Colf("%.1f", g.RowsPerDay)
+		t.Col(libstats.FormatBytes(g.To.TotalLength()))
+		t.Col(libstats.FormatBytes(g.BytesDelta()))
+		t.Col(libstats.FormatBytes(int64(g.BytesPerDay)))
+
+		if g.To.HasAutoIncrement() {
+			t.Colf("%.4f%%", g.To.KeyUsage()*100)
+			var days = g.DaysUntilOverflow()
+			if math.IsInf(days, 1) {
+				t.Col("never")
+			} else {
+				t.Colf("%.0f days", days)
+			}
+		} else {
+			t.Col("")
+			t.Col("")
+		}
+
+		if w := g.Warning(); len(w) > 0 {
+			warnings = append(warnings, w)
+		}
+	}
+
+	fmt.Println(t.String())
+	printWarnings(warnings)
+}
+
+func printWarnings(warnings []string) {
+	for k := range warnings {
+		fmt.Println("WARNING: " + warnings[k])
+	}
+}
@@ -0,0 +1,24 @@
+package subset
+
+import "fmt"
+
+func Help() {
+	fmt.Println(`
+	subset --root [table] [--where [condition]] [[-c|--connection] [connection]] [[-t|--to] [connection]] [[-o|--output] [file]]
+
+		Extracts a referentially consistent set of rows, starting with the rows in the root table that
+		match the where condition. Child rows are followed from the root rows, and parent rows are followed
+		from every extracted row so that the result does not reference anything that is missing.
+
+		Relationships are read from the foreign keys on the source database and from the onetomany,
+		manytoone and onetoone maps on the connection config (e.g. "Account.AccountID": "User.AccountID").
+
+		Default behavior is to print the rows as sql insert statements.
+
+			-r, --root 			The table to start from (required)
+			-w, --where 		The condition used to select the root rows (e.g. "AccountID IN (1,2)")
+			-c, --connection 	The source connection (defaults to the first connection)
+			-t, --to 			Load the rows into this connection instead of printing them
+			-o, --output 		Write the sql to a file instead of stdout
+	`)
+}
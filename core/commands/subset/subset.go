@@ -0,0 +1,164 @@
+package subset
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/macinnir/dvc/core/connectors"
+	"github.com/macinnir/dvc/core/lib"
+	"github.com/macinnir/dvc/core/lib/executor"
+	"github.com/macinnir/dvc/core/lib/schema"
+	libsubset "github.com/macinnir/dvc/core/lib/subset"
+	"go.uber.org/zap"
+)
+
+const CommandName = "subset"
+
+// insertBatchSize is the number of rows per INSERT statement
+const insertBatchSize = 100
+
+// Cmd extracts a referentially consistent subset of rows
+// dvc subset --root [table] --where [condition] [-c connection] [--to connection] [-o file]
+func Cmd(logger *zap.Logger, config *lib.Config, args []string) error {
+
+	var e error
+	var rootTable = ""
+	var where = ""
+	var fromConnectionName = ""
+	var toConnectionName = ""
+	var outputPath = ""
+
+	for len(args) > 0 {
+
+		if len(args) < 2 {
+			return fmt.Errorf("Missing value for argument `%s`", args[0])
+		}
+
+		switch args[0] {
+		case "-r", "--root":
+			rootTable = args[1]
+		case "-w", "--where":
+			where = args[1]
+		case "-c", "--connection":
+			fromConnectionName = args[1]
+		case "-t", "--to":
+			toConnectionName = args[1]
+		case "-o", "--output":
+			outputPath = args[1]
+		default:
+			return fmt.Errorf("Unknown argument `%s`", args[0])
+		}
+
+		args = args[2:]
+	}
+
+	if len(rootTable) == 0 {
+		return errors.New("A root table is required (--root)")
+	}
+
+	configs := map[string]*lib.ConfigDatabase{}
+	for k := range config.Databases {
+		configs[config.Databases[k].Key] = config.Databases[k]
+	}
+
+	if len(fromConnectionName) == 0 {
+		if len(config.Databases) == 0 {
+			return errors.New("No connections configured")
+		}
+		fromConnectionName = config.Databases[0].Key
+	}
+
+	var fromConfig, toConfig *lib.ConfigDatabase
+	var ok bool
+
+	if fromConfig, ok = configs[fromConnectionName]; !ok {
+		return errors.New("Unknown connection key: " + fromConnectionName)
+	}
+
+	if len(toConnectionName) > 0 {
+		if toConfig, ok = configs[toConnectionName]; !ok {
+			return errors.New("Unknown connection key: " + toConnectionName)
+		}
+		if toConnectionName == fromConnectionName {
+			return errors.New("Source and destination connections must be different")
+		}
+	}
+
+	// Source
+	var connector connectors.IConnector
+	if connector, e = connectors.DBConnectorFactory(fromConfig); e != nil {
+		return fmt.Errorf("Error creating connector for connection %s: %w", fromConnectionName, e)
+	}
+
+	var server = executor.NewExecutor(fromConfig, connector).Connect()
+	defer server.Connection.Close()
+
+	var database *schema.Database
+	if database, e = connector.FetchDatabase(fromConnectionName, server, fromConfig.Name); e != nil {
+		return fmt.Errorf("Error fetching schema for connection %s: %w", fromConnectionName, e)
+	}
+
+	// Relationships: foreign keys plus whatever is declared in the config
+	var foreignKeys, declared []*libsubset.Relationship
+	if foreignKeys, e = libsubset.FetchForeignKeys(server.Connection, fromConfig.Name); e != nil {
+		return e
+	}
+
+	if declared, e = libsubset.ParseRelationships(fromConfig); e != nil {
+		return e
+	}
+
+	var relationships = libsubset.MergeRelationships(foreignKeys, declared)
+
+	logger.Info("Extracting subset",
+		zap.String("connection", fromConnectionName),
+		zap.String("root", rootTable),
+		zap.String("where", where),
+		zap.Int("relationships", len(relationships)),
+	)
+
+	var s = libsubset.NewSubset(server.Connection, database.ToSchema(fromConnectionName), relationships)
+	if e = s.Extract(rootTable, where); e != nil {
+		return e
+	}
+
+	for _, tableName := range s.SortedTableNames() {
+		logger.Info("Extracted", zap.String("table", tableName), zap.Int("rows", len(s.Tables[tableName].Keys)))
+	}
+
+	// Load into another connection
+	if toConfig != nil {
+
+		var toConnector connectors.IConnector
+		if toConnector, e = connectors.DBConnectorFactory(toConfig); e != nil {
+			return fmt.Errorf("Error creating connector for connection %s: %w", toConnectionName, e)
+		}
+
+		var toServer = executor.NewExecutor(toConfig, toConnector).Connect()
+		defer toServer.Connection.Close()
+
+		if e = s.Load(toServer.Connection, insertBatchSize); e != nil {
+			return e
+		}
+
+		fmt.Printf("Loaded %d rows into %s\n", s.RowCount(), toConnectionName)
+		return nil
+	}
+
+	// Write a dump
+	var w io.Writer = os.Stdout
+	if len(outputPath) > 0 {
+		var f *os.File
+		if f, e = os.Create(outputPath); e != nil {
+			return e
+		}
+		defer f.Close()
+		w = f
+	}
+
+	fmt.Fprintf(w, "--\n-- DVC Subset\n-- Connection: `%s`\n-- Root: `%s`\n-- Where: %s\n-- Rows: %d\n--\n\n", fromConnectionName, rootTable, where, s.RowCount())
+
+	return s.WriteSQL(w, insertBatchSize)
+}
@@ -4,18 +4,27 @@ import "fmt"
 
 func Help() {
 	fmt.Println(`
-	transfer [fromDatabase] [toDatabase] [[table_name]]
-	
-		Transfer schema and data from one database to another (on the same server).
+	transfer [fromConnection] [toConnection] [[table_name]]
 
-		If a table_name is provided, only product sql (or run) for that specific table. 
+		Transfer schema and data from one connection to another. The connections can be on different servers.
 
-		[-r|--run] 
+		If a table_name is provided, only transfer that specific table. Tables missing on the destination are created.
 
-		Default behavior is to print the sql statements, without performing any further action. 
+		Rows are copied in primary key order in batches. Progress is written to a checkpoint file after every
+		batch so that an interrupted run picks up where it left off. When all tables have been copied, row counts
+		and checksums are compared between both sides and the checkpoint file is removed. Tables without a primary
+		key are skipped.
 
-			-r, --run 		Run the sql. 
+		[-r|--run]
 
+		Default behavior is to print the tables that would be transferred, without performing any further action.
+
+			-r, --run 		Run the transfer.
+			-b, --batch 	Rows per batch (default 1000)
+			--rate 			Maximum rows per second (default unlimited)
+			--checkpoint 	Checkpoint file path (default .dvc/transfer.[from].[to].json)
+			--restart 		Ignore any existing checkpoint and start over
+			--no-verify 	Skip the row count and checksum verification
 
 	`)
 
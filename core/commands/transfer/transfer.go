@@ -1,211 +1,267 @@
 package transfer
 
 import (
+	"context"
+	"database/sql"
 	"errors"
 	"fmt"
-	"os"
+	"path"
+	"sort"
+	"strconv"
 
 	"github.com/macinnir/dvc/core/connectors"
 	"github.com/macinnir/dvc/core/lib"
 	"github.com/macinnir/dvc/core/lib/executor"
-	"github.com/macinnir/dvc/core/lib/importer"
 	"github.com/macinnir/dvc/core/lib/schema"
+	libsql "github.com/macinnir/dvc/core/lib/sql"
+	libtransfer "github.com/macinnir/dvc/core/lib/transfer"
 	"go.uber.org/zap"
 )
 
 const CommandName = "transfer"
 
-type Transfer struct{}
-
-// Clone clones a database
+// Cmd copies schema and data from one connection to another
 func Cmd(logger *zap.Logger, config *lib.Config, args []string) error {
 
 	var e error
-	var remoteSchemas map[string]*schema.Schema
-	doRun := false
+	var doRun = false
+	var restart = false
+	var verify = true
+	var checkpointPath = ""
+	var options = libtransfer.Options{
+		BatchSize: 1000,
+	}
 
-	fromConnectionName := ""
-	toConnectionName := ""
-	tableName := ""
+	var positional = []string{}
+
+	for len(args) > 0 {
+
+		switch args[0] {
+		case "-r", "--run":
+			doRun = true
+			args = args[1:]
+			continue
+		case "--restart":
+			restart = true
+			args = args[1:]
+			continue
+		case "--no-verify":
+			verify = false
+			args = args[1:]
+			continue
+		case "-b", "--batch", "--rate", "--checkpoint":
+			if len(args) < 2 {
+				return fmt.Errorf("Missing value for argument `%s`", args[0])
+			}
+		default:
+			positional = append(positional, args[0])
+			args = args[1:]
+			continue
+		}
 
-	if len(args) < 2 {
-		fmt.Println("Usage: dvc transfer [source_database] [destination_database] [[table_name]] [[-r|--run]]")
-		return errors.New("Insufficient arguments...")
-	}
-	fromConnectionName = args[0]
-	toConnectionName = args[1]
+		switch args[0] {
+		case "-b", "--batch":
+			if options.BatchSize, e = strconv.Atoi(args[1]); e != nil || options.BatchSize <= 0 {
+				return fmt.Errorf("Invalid batch size `%s`", args[1])
+			}
+		case "--rate":
+			if options.RowsPerSecond, e = strconv.Atoi(args[1]); e != nil || options.RowsPerSecond < 0 {
+				return fmt.Errorf("Invalid rate `%s`", args[1])
+			}
+		case "--checkpoint":
+			checkpointPath = args[1]
+		}
 
-	if fromConnectionName == toConnectionName {
-		return errors.New("Source and destination databases must be different")
+		args = args[2:]
 	}
 
-	if len(args) > 2 {
-		doRun = args[2] == "-r" || args[2] == "--run"
-		if !doRun {
-			tableName = args[2]
-		}
+	if len(positional) < 2 {
+		fmt.Println("Usage: dvc transfer [source_connection] [destination_connection] [[table_name]] [[-r|--run]]")
+		return errors.New("Insufficient arguments...")
 	}
 
-	if len(args) > 3 {
-		doRun = args[3] == "-r" || args[3] == "--run"
+	var fromConnectionName = positional[0]
+	var toConnectionName = positional[1]
+	var tableName = ""
+
+	if len(positional) > 2 {
+		tableName = positional[2]
 	}
 
-	fmt.Println("Args: ", len(args))
-	fmt.Printf("From: %s; To: %s; Table: %s\n", fromConnectionName, toConnectionName, tableName)
+	if fromConnectionName == toConnectionName {
+		return errors.New("Source and destination connections must be different")
+	}
 
-	if remoteSchemas, e = importer.FetchAllSchemas(config); e != nil {
-		return e
+	configs := map[string]*lib.ConfigDatabase{}
+	for k := range config.Databases {
+		configs[config.Databases[k].Key] = config.Databases[k]
 	}
 
-	if _, ok := remoteSchemas[fromConnectionName]; !ok {
+	if _, ok := configs[fromConnectionName]; !ok {
 		return errors.New("Unknown connection key: " + fromConnectionName)
 	}
 
-	if _, ok := remoteSchemas[toConnectionName]; !ok {
+	if _, ok := configs[toConnectionName]; !ok {
 		return errors.New("Unknown connection key: " + toConnectionName)
 	}
 
-	sql := []string{}
-	tables := []string{}
+	if len(checkpointPath) == 0 {
+		checkpointPath = path.Join(lib.MetaDirectory, fmt.Sprintf("transfer.%s.%s.json", fromConnectionName, toConnectionName))
+	}
 
-	configs := map[string]*lib.ConfigDatabase{}
-	for k := range config.Databases {
-		configs[config.Databases[k].Key] = config.Databases[k]
+	// Connections
+	var source, dest *schema.Server
+	var sourceSchema, destSchema *schema.Database
+
+	if source, sourceSchema, e = connect(fromConnectionName, configs[fromConnectionName]); e != nil {
+		return e
 	}
+	defer source.Connection.Close()
 
-	// Migrate single table
-	if len(tableName) > 0 {
-		// if _, ok := remoteSchemas[fromConnectionName].Tables[tableName]; !ok {
-		// 	return errors.New("Table `" + tableName + "` not found in source schema")
-		// }
+	if dest, destSchema, e = connect(toConnectionName, configs[toConnectionName]); e != nil {
+		return e
+	}
+	defer dest.Connection.Close()
 
-		tables = []string{tableName}
+	// Tables
+	var tables = []*schema.Table{}
+	if len(tableName) > 0 {
+		if _, ok := sourceSchema.Tables[tableName]; !ok {
+			return errors.New("Table `" + tableName + "` not found in source schema")
+		}
+		tables = append(tables, sourceSchema.Tables[tableName])
 	} else {
-		for tableName := range remoteSchemas[fromConnectionName].Tables {
-			tables = append(tables, tableName)
+		for k := range sourceSchema.Tables {
+			tables = append(tables, sourceSchema.Tables[k])
 		}
+		sort.Slice(tables, func(i, j int) bool { return tables[i].Name < tables[j].Name })
 	}
 
-	sourceDatabaseConfig := configs[fromConnectionName]
-	sourceDatabaseName := sourceDatabaseConfig.Name
-
-	destinationDatabaseConfig := configs[toConnectionName]
-	destinationDatabaseName := destinationDatabaseConfig.Name
+	var checkpoint *libtransfer.Checkpoint
+	if checkpoint, e = libtransfer.LoadCheckpoint(checkpointPath, fromConnectionName, toConnectionName); e != nil {
+		return fmt.Errorf("Error loading checkpoint %s: %w", checkpointPath, e)
+	}
 
-	for k := range tables {
-		sql = append(sql, transferSQL(sourceDatabaseName, destinationDatabaseName, tables[k])...)
+	if restart {
+		checkpoint.Tables = map[string]*libtransfer.TableCheckpoint{}
 	}
 
-	if doRun {
-		var connector connectors.IConnector
-		if connector, e = connectors.DBConnectorFactory(sourceDatabaseConfig); e != nil {
-			return e
-		}
+	var q = libsql.Query{}
+
+	// Dry run: describe what would happen
+	if !doRun {
+		fmt.Printf("From: %s; To: %s; Checkpoint: %s\n", fromConnectionName, toConnectionName, checkpointPath)
+		for _, table := range tables {
 
-		server := executor.NewExecutor(
-			sourceDatabaseConfig,
-			connector,
-		).Connect()
-
-		for k := range sql {
-			fmt.Println("RUNNING: " + sql[k])
-			_, e = server.Connection.Exec(sql[k])
-			if e != nil {
-				fmt.Printf("SQL ERROR: %s\n", e.Error())
-				fmt.Printf("SQL: %s\n", sql[k])
-				os.Exit(1)
+			var action = "copy"
+			if _, ok := destSchema.Tables[table.Name]; !ok {
+				action = "create + copy"
 			}
+
+			var cp = checkpoint.Table(table.Name)
+			if cp.Done {
+				action = "done"
+			} else if cp.Rows > 0 {
+				action = fmt.Sprintf("resume after %v (%d rows)", cp.LastKey, cp.Rows)
+			}
+
+			if len(libtransfer.PrimaryKeyColumns(table)) == 0 {
+				action = "skip (no primary key)"
+			}
+
+			fmt.Printf("\t%s: ~%d rows, %s\n", table.Name, table.Rows, action)
 		}
-	} else {
+		fmt.Println("Run again with -r|--run to perform the transfer")
+		return nil
+	}
 
-		for k := range sql {
-			fmt.Println(sql[k])
+	// Tables without a primary key can't be copied in batches, so they are skipped as the dry run shows
+	var copied = make([]*schema.Table, 0, len(tables))
+	for _, table := range tables {
+		if len(libtransfer.PrimaryKeyColumns(table)) == 0 {
+			logger.Warn("Skipping table without a primary key", zap.String("table", table.Name))
+			continue
 		}
+		copied = append(copied, table)
 	}
-	// connector, e := connectors.DBConnectorFactory(config)
 
-	// for k := range remoteSchemas {
-	// 	fmt.Println(k)
-	// }
+	var ctx = context.Background()
 
-	return nil
-}
+	var conn *sql.Conn
+	if conn, e = dest.Connection.Conn(ctx); e != nil {
+		return e
+	}
+	defer conn.Close()
 
-func transferSQL(fromDatabase, toDatabase, tableName string) []string {
-	return []string{
-		fmt.Sprintf("CREATE TABLE `%s`.`%s` LIKE `%s`.`%s`", toDatabase, tableName, fromDatabase, tableName),
-		fmt.Sprintf("INSERT INTO `%s`.`%s` SELECT * FROM `%s`.`%s`", toDatabase, tableName, fromDatabase, tableName),
-		fmt.Sprintf("DROP TABLE `%s`.`%s`", fromDatabase, tableName),
+	if _, e = conn.ExecContext(ctx, "SET FOREIGN_KEY_CHECKS = 0"); e != nil {
+		return e
 	}
-}
+	defer conn.ExecContext(ctx, "SET FOREIGN_KEY_CHECKS = 1")
 
-// 	config := &lib.Config{}
+	var t = libtransfer.NewTransfer(source.Connection, conn, checkpoint, options, logger)
 
-// 	if len(args) == 0 {
-// 		lib.Error("Missing target database name", c.Options)
-// 		return
-// 	}
+	for _, table := range copied {
 
-// 	targetDatabase := args[0]
+		if _, ok := destSchema.Tables[table.Name]; !ok {
 
-// 	config.DatabaseType = c.Config.DatabaseType
-// 	config.Connection.Username = c.Config.Connection.Username
-// 	config.Connection.Password = c.Config.Connection.Password
-// 	config.Connection.Host = c.Config.Connection.Host
-// 	config.Connection.DatabaseName = targetDatabase
+			var createSQL string
+			if createSQL, e = q.CreateTable(table); e != nil {
+				return fmt.Errorf("Error generating create table SQL for table %s: %w", table.Name, e)
+			}
 
-// 	connector, _ := connectorFactory(c.Config.DatabaseType, config)
-// 	cmp, _ := compare.NewCompare(config, c.Options, connector)
+			logger.Info("Creating table", zap.String("table", table.Name))
+			if _, e = conn.ExecContext(ctx, createSQL); e != nil {
+				return fmt.Errorf("Error creating table %s: %w", table.Name, e)
+			}
+		}
 
-// 	// Do the comparison
-// 	schemaFile := c.Config.Connection.DatabaseName + ".schema.json"
-// 	var sql string
-// 	var e error
+		if e = t.Table(ctx, table); e != nil {
+			return e
+		}
+	}
+
+	if !verify {
+		return nil
+	}
+
+	var failed = 0
+	for _, table := range copied {
 
-// 	if sql, e = cmp.CompareSchema(schemaFile); e != nil {
-// 		lib.Error(e.Error(), c.Options)
-// 		os.Exit(1)
-// 	}
+		var v *libtransfer.Verification
+		if v, e = t.Verify(ctx, table); e != nil {
+			return e
+		}
 
-// 	if len(sql) == 0 {
-// 		fmt.Printf("No schema changes found with target %s\n", config.Connection.DatabaseName)
-// 		lib.Info("No changes found.", c.Options)
-// 		os.Exit(0)
-// 	} else {
-// 		writeSQLToLog(sql)
-// 		e = cmp.ApplyChangeset(sql)
-// 		if e != nil {
-// 			lib.Error(e.Error(), c.Options)
-// 			os.Exit(1)
-// 		}
-// 	}
+		fmt.Println(v.String())
 
-// 	// database := c.loadDatabase()
+		if !v.OK() {
+			failed++
+		}
+	}
 
-// 	// reader := bufio.NewReader(os.Stdin)
+	if failed > 0 {
+		return fmt.Errorf("Verification failed for %d table(s)", failed)
+	}
 
-// 	// dbName := ""
+	return checkpoint.Remove()
+}
 
-// 	// if len(args) > 0 {
-// 	// 	dbName = args[0]
-// 	// } else {
-// 	// 	dbName = lib.ReadCliInput(reader, "Destination Database:")
-// 	// }
+func connect(connectionName string, config *lib.ConfigDatabase) (*schema.Server, *schema.Database, error) {
 
-// 	// if len(dbName) == 0 {
-// 	// 	fmt.Println("No target database specified")
-// 	// 	return
-// 	// }
+	var e error
+	var connector connectors.IConnector
 
-// 	// fmt.Println(sql)
+	if connector, e = connectors.DBConnectorFactory(config); e != nil {
+		return nil, nil, fmt.Errorf("Error creating connector for connection %s: %w", connectionName, e)
+	}
 
-// 	// doInsertYN := lib.ReadCliInput(reader, "Run above SQL (Y/n")
+	var server = executor.NewExecutor(config, connector).Connect()
 
-// 	// if doInsertYN != "Y" {
-// 	// 	return
-// 	// }
+	var database *schema.Database
+	if database, e = connector.FetchDatabase(connectionName, server, config.Name); e != nil {
+		server.Connection.Close()
+		return nil, nil, fmt.Errorf("Error fetching schema for connection %s: %w", connectionName, e)
+	}
 
-// 	// connector, _ := connectorFactory(c.Config.DatabaseType, c.Config)
-// 	// x := lib.NewExecutor(c.Config, connector)
-// 	// x.RunSQL(sql)
-// }
+	return server, database, nil
+}
@@ -152,7 +152,7 @@ func (ss *MySQL) FetchDatabase(schemaName string, server *schema.Server, databas
 func (ss *MySQL) fetchDatabaseTables(schemaName string, server *schema.Server, databaseName string) (tables map[string]*schema.Table, e error) {
 
 	var rows *sql.Rows
-	query := "select t.`TABLE_NAME`, t.`ENGINE`, t.`VERSION`, t.`ROW_FORMAT`, t.`TABLE_ROWS`, t.`DATA_LENGTH`, t.`TABLE_COLLATION`, COALESCE(t.`AUTO_INCREMENT`, 0) AS `AUTO_INCREMENT`, ccsa.CHARACTER_SET_NAME FROM information_schema.tables t JOIN information_schema.`COLLATION_CHARACTER_SET_APPLICABILITY` ccsa ON ccsa.`COLLATION_NAME` = t.`TABLE_COLLATION` WHERE TABLE_SCHEMA = '" + databaseName + "'"
+	query := "select t.`TABLE_NAME`, t.`ENGINE`, t.`VERSION`, t.`ROW_FORMAT`, t.`TABLE_ROWS`, t.`DATA_LENGTH`, COALESCE(t.`INDEX_LENGTH`, 0) AS `INDEX_LENGTH`, COALESCE(t.`DATA_FREE`, 0) AS `DATA_FREE`, t.`TABLE_COLLATION`, COALESCE(t.`AUTO_INCREMENT`, 0) AS `AUTO_INCREMENT`, ccsa.CHARACTER_SET_NAME FROM information_schema.tables t JOIN information_schema.`COLLATION_CHARACTER_SET_APPLICABILITY` ccsa ON ccsa.`COLLATION_NAME` = t.`TABLE_COLLATION` WHERE TABLE_SCHEMA = '" + databaseName + "'"
 
 	if rows, e = server.Connection.Query(query); e != nil {
 		return
@@ -175,6 +175,8 @@ func (ss *MySQL) fetchDatabaseTables(schemaName string, server *schema.Server, d
 			&table.RowFormat,
 			&table.Rows,
 			&table.DataLength,
+			&table.IndexLength,
+			&table.DataFree,
 			&table.Collation,
 			&table.AutoIncrement,
 			&table.CharacterSet,
@@ -205,6 +207,7 @@ func (ss *MySQL) FetchTableColumns(server *schema.Server, databaseName string, t
 			COLUMN_NAME,
 			-- ORDINAL_POSITION,
 			COALESCE(COLUMN_DEFAULT, '') as COLUMN_DEFAULT,
+			COLUMN_DEFAULT IS NOT NULL AS HAS_DEFAULT,
 			CASE IS_NULLABLE
 				WHEN 'YES' THEN 1
 				ELSE 0
@@ -239,6 +242,7 @@ func (ss *MySQL) FetchTableColumns(server *schema.Server, databaseName string, t
 			&column.Name,
 			// &column.Position,
 			&column.Default,
+			&column.HasDefault,
 			&column.IsNullable,
 			&column.DataType,
 			&column.MaxLength,
@@ -28,6 +28,43 @@ type ConfigDatabase struct {
 	OneToMany map[string]string `json:"onetomany"`
 	OneToOne  map[string]string `json:"onetoone"`
 	ManyToOne map[string]string `json:"manytoone"`
+	// ReadOnly connections only accept statements that read data in `dvc sql`
+	ReadOnly bool `json:"readOnly"`
+	// Replicas are read replicas of the database. Fields left empty are taken from the primary.
+	Replicas []*ConfigDatabaseReplica `json:"replicas"`
+	// MaxReplicaLag is how many seconds behind a replica can be and still be read from (0 for no limit)
+	MaxReplicaLag int `json:"maxReplicaLag"`
+	// ReplicaLagInterval is how often, in seconds, the lag of the replicas is checked (0 for every 5 seconds)
+	ReplicaLagInterval int `json:"replicaLagInterval"`
+}
+
+// ConfigDatabaseReplica is a read replica of a database
+type ConfigDatabaseReplica struct {
+	Host string `json:"host"`
+	User string `json:"user"`
+	Pass string `json:"pass"`
+}
+
+// Replica returns the connection config of replica `k`
+func (c *ConfigDatabase) Replica(k int) *ConfigDatabase {
+
+	var r = *c
+	r.Replicas = nil
+	r.ReadOnly = true
+
+	if len(c.Replicas[k].Host) > 0 {
+		r.Host = c.Replicas[k].Host
+	}
+
+	if len(c.Replicas[k].User) > 0 {
+		r.User = c.Replicas[k].User
+	}
+
+	if len(c.Replicas[k].Pass) > 0 {
+		r.Pass = c.Replicas[k].Pass
+	}
+
+	return &r
 }
 
 // Config contains a set of configuration values used throughout the application
@@ -41,6 +78,9 @@ type Config struct {
 	TypescriptPermissionsPath string            `json:"TypescriptPermissionsPath"`
 	TypescriptRoutesPath      string            `json:"TypescriptRoutesPath"`
 	Cache                     map[string]*CacheConfig
+	Shards                    map[string]*ShardConfig `json:"shards"`
+	VersionColumns            map[string]string       `json:"versionColumns"` // Table => column used for optimistic locking
+	Audit                     map[string]*AuditConfig `json:"audit"`
 	Packages                  struct {
 		Cache    string `json:"cache"`
 		Models   string `json:"models"`
@@ -69,6 +109,29 @@ type Config struct {
 	} `json:"dirs"`
 }
 
+// ShardConfig configures a sharded table. The table's DAL spreads its records across the connections it is
+// bootstrapped with.
+//
+//	"shards": {
+//	   "Event": { "key": "AccountID" }
+//	}
+type ShardConfig struct {
+	// Key is the column the table is sharded on. If empty, the table is sharded on its primary key, whose values
+	// are built with utils/shard.
+	Key string `json:"key"`
+}
+
+// AuditConfig configures an audited table. Its DAL writes a change record of each create, update and delete to the
+// Audit table of the core_log schema.
+//
+//	"audit": {
+//	   "User": { "exclude": ["Password"] }
+//	}
+type AuditConfig struct {
+	// Exclude are columns whose values are left out of change records (e.g. secrets)
+	Exclude []string `json:"exclude"`
+}
+
 // TODO revisit this
 //
 //	"User": {
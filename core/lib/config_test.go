@@ -43,3 +43,32 @@ func TestGetDatabasesByRootName(t *testing.T) {
 	assert.Equal(t, "Foo_3", fooDatabases[3].Key)
 
 }
+
+func TestConfigDatabase_Replica(t *testing.T) {
+
+	config := &ConfigDatabase{
+		Key:  "Foo",
+		Name: "foo",
+		Host: "10.0.0.1",
+		User: "app",
+		Pass: "secret",
+		Replicas: []*ConfigDatabaseReplica{
+			{Host: "10.0.0.2"},
+			{Host: "10.0.0.3", User: "reader", Pass: "other"},
+		},
+	}
+
+	r := config.Replica(0)
+	assert.Equal(t, "10.0.0.2", r.Host)
+	assert.Equal(t, "app", r.User)
+	assert.Equal(t, "secret", r.Pass)
+	assert.Equal(t, "foo", r.Name)
+	assert.True(t, r.ReadOnly)
+	assert.Nil(t, r.Replicas)
+
+	r = config.Replica(1)
+	assert.Equal(t, "reader", r.User)
+	assert.Equal(t, "other", r.Pass)
+
+	assert.Equal(t, "10.0.0.1", config.Host)
+}
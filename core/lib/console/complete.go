@@ -0,0 +1,182 @@
+package console
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/macinnir/dvc/core/lib/schema"
+)
+
+var sqlKeywords = []string{
+	"SELECT", "FROM", "WHERE", "AND", "OR", "NOT", "NULL", "IS", "IN", "LIKE", "BETWEEN", "ORDER", "BY", "GROUP",
+	"HAVING", "LIMIT", "OFFSET", "JOIN", "LEFT", "INNER", "ON", "AS", "DISTINCT", "COUNT", "SUM", "MIN", "MAX",
+	"AVG", "ASC", "DESC", "INSERT", "INTO", "VALUES", "UPDATE", "SET", "DELETE", "SHOW", "TABLES", "DESCRIBE",
+	"EXPLAIN", "CREATE", "TABLE", "ALTER", "DROP", "INDEX", "COLUMNS",
+}
+
+// Completer completes table names, column names and keywords from the local schema
+type Completer struct {
+	tables     map[string]*schema.Table
+	tableNames []string
+}
+
+// NewCompleter returns a Completer for the tables in `schemas`
+func NewCompleter(schemas ...*schema.Schema) *Completer {
+
+	var c = &Completer{
+		tables:     map[string]*schema.Table{},
+		tableNames: []string{},
+	}
+
+	for _, s := range schemas {
+		for name, table := range s.Tables {
+			if _, ok := c.tables[name]; !ok {
+				c.tableNames = append(c.tableNames, name)
+			}
+			c.tables[name] = table
+		}
+	}
+
+	sort.Strings(c.tableNames)
+
+	return c
+}
+
+// Table returns a table by name, or nil
+func (c *Completer) Table(name string) *schema.Table {
+	return c.tables[name]
+}
+
+// TableNames returns the sorted table names
+func (c *Completer) TableNames() []string {
+	return c.tableNames
+}
+
+// isWordRune returns true for runes that can be part of an identifier being completed
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_' || r == '.'
+}
+
+// Candidates returns the word being completed at `pos` and everything it could complete to. Unqualified words
+// complete to tables, columns of the tables named in the line and keywords; `Table.` completes to that table's columns.
+func (c *Completer) Candidates(line string, pos int) (string, []string) {
+
+	var runes = []rune(line)
+	if pos > len(runes) {
+		pos = len(runes)
+	}
+
+	var start = pos
+	for start > 0 && isWordRune(runes[start-1]) {
+		start--
+	}
+
+	var word = string(runes[start:pos])
+	var candidates = []string{}
+
+	var add = func(options []string, prefix string) {
+		for _, option := range options {
+			if strings.HasPrefix(strings.ToLower(option), strings.ToLower(prefix)) {
+				candidates = append(candidates, option)
+			}
+		}
+	}
+
+	// Meta commands only take table names
+	if strings.HasPrefix(strings.TrimSpace(line), "\\") {
+		add(c.tableNames, word)
+		return word, candidates
+	}
+
+	if dot := strings.LastIndex(word, "."); dot >= 0 {
+		if table, ok := c.tables[word[0:dot]]; ok {
+			var columns = []string{}
+			for _, column := range table.ToSortedColumns() {
+				columns = append(columns, word[0:dot+1]+column.Name)
+			}
+			add(columns, word)
+		}
+		return word, candidates
+	}
+
+	add(c.tableNames, word)
+
+	// Columns of the tables in the statement
+	var seen = map[string]bool{}
+	for _, w := range strings.FieldsFunc(line, func(r rune) bool { return !isWordRune(r) }) {
+		var table, ok = c.tables[w]
+		if !ok || seen[w] {
+			continue
+		}
+		seen[w] = true
+		var columns = []string{}
+		for _, column := range table.ToSortedColumns() {
+			columns = append(columns, column.Name)
+		}
+		add(columns, word)
+	}
+
+	if len(word) > 0 {
+		add(sqlKeywords, word)
+	}
+
+	return word, dedupe(candidates)
+}
+
+func dedupe(values []string) []string {
+	var seen = map[string]bool{}
+	var result = []string{}
+	for _, v := range values {
+		if !seen[v] {
+			seen[v] = true
+			result = append(result, v)
+		}
+	}
+	return result
+}
+
+// Complete is a terminal autocomplete callback. Tab completes the word at the cursor to the longest prefix shared
+// by every candidate, followed by a space when there is only one candidate.
+func (c *Completer) Complete(line string, pos int, key rune) (string, int, bool) {
+
+	if key != '\t' {
+		return "", 0, false
+	}
+
+	var word, candidates = c.Candidates(line, pos)
+	if len(candidates) == 0 {
+		return "", 0, false
+	}
+
+	var completion = candidates[0]
+	for _, candidate := range candidates[1:] {
+		completion = commonPrefix(completion, candidate)
+	}
+
+	// Keywords follow the case the user is typing in
+	if len(word) > 0 && strings.ToLower(word) == word && completion == strings.ToUpper(completion) {
+		completion = strings.ToLower(completion)
+	}
+
+	var runes = []rune(line)
+
+	if len(candidates) == 1 && (pos == len(runes) || !unicode.IsSpace(runes[pos])) {
+		completion += " "
+	}
+
+	var start = pos - len([]rune(word))
+	var newLine = string(runes[0:start]) + completion + string(runes[pos:])
+
+	return newLine, start + len([]rune(completion)), true
+}
+
+// commonPrefix returns the case-insensitive common prefix of two strings, in the case of `a`
+func commonPrefix(a, b string) string {
+	var ar, br = []rune(a), []rune(b)
+	var k = 0
+	for k < len(ar) && k < len(br) && unicode.ToLower(ar[k]) == unicode.ToLower(br[k]) {
+		k++
+	}
+	return string(ar[0:k])
+}
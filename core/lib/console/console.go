@@ -0,0 +1,302 @@
+package console
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/macinnir/dvc/core/lib"
+	"github.com/macinnir/dvc/core/lib/schema"
+)
+
+// ErrReadOnly is returned for statements that write to a read-only connection
+var ErrReadOnly = errors.New("connection is read-only; only SELECT, SHOW, DESCRIBE and EXPLAIN statements are allowed")
+
+const (
+	// Prompt is shown at the start of a statement
+	Prompt = "dvc> "
+	// ContinuationPrompt is shown while a statement spans multiple lines
+	ContinuationPrompt = "  -> "
+)
+
+// LineReader reads input lines, e.g. a terminal with line editing
+type LineReader interface {
+	ReadLine() (string, error)
+	SetPrompt(prompt string)
+}
+
+// Conn is a connection statements run on, e.g. a *sql.DB or a *sql.Conn
+type Conn interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+}
+
+// Console runs statements and meta commands against a connection
+type Console struct {
+	db        Conn
+	completer *Completer
+	out       io.Writer
+	readOnly  bool
+}
+
+// NewConsole returns a new Console
+func NewConsole(db Conn, completer *Completer, out io.Writer, readOnly bool) *Console {
+	return &Console{
+		db:        db,
+		completer: completer,
+		out:       out,
+		readOnly:  readOnly,
+	}
+}
+
+// ReadOnlyConn returns a single connection of db on which the server itself refuses writes, for MySQL and
+// PostgreSQL. Unlike a connection of the pool, it isn't reopened without that setting if the server drops it.
+func ReadOnlyConn(ctx context.Context, db *sql.DB, dialect string) (*sql.Conn, error) {
+
+	var statement string
+	switch dialect {
+	case "", schema.SchemaTypeMySQL:
+		statement = "SET SESSION TRANSACTION READ ONLY"
+	case schema.SchemaTypePostgreSQL:
+		statement = "SET SESSION CHARACTERISTICS AS TRANSACTION READ ONLY"
+	}
+
+	conn, e := db.Conn(ctx)
+	if e != nil {
+		return nil, e
+	}
+
+	if len(statement) > 0 {
+		if _, e = conn.ExecContext(ctx, statement); e != nil {
+			conn.Close()
+			return nil, e
+		}
+	}
+
+	return conn, nil
+}
+
+// Loop reads and runs statements until the input ends or the user quits. Every statement and meta command is
+// appended to the history file, if one is given.
+func (c *Console) Loop(ctx context.Context, r LineReader, historyPath string) error {
+
+	var buffer = &Buffer{}
+
+	for {
+
+		if buffer.IsEmpty() {
+			r.SetPrompt(Prompt)
+		} else {
+			r.SetPrompt(ContinuationPrompt)
+		}
+
+		var line, e = r.ReadLine()
+		if e == io.EOF {
+			return nil
+		}
+
+		if e != nil {
+			return e
+		}
+
+		if strings.TrimSpace(line) == "\\c" {
+			buffer.Reset()
+			continue
+		}
+
+		if buffer.IsEmpty() && IsMetaCommand(line) {
+			c.appendHistory(historyPath, line)
+			if c.Meta(line) {
+				return nil
+			}
+			continue
+		}
+
+		for _, statement := range buffer.Add(line) {
+
+			c.appendHistory(historyPath, statement+";")
+
+			if e = c.Run(ctx, statement); e != nil {
+				fmt.Fprintf(c.out, "ERROR: %s\n", e.Error())
+			}
+		}
+	}
+}
+
+func (c *Console) appendHistory(historyPath, entry string) {
+	if len(historyPath) == 0 {
+		return
+	}
+	if e := AppendHistory(historyPath, entry); e != nil {
+		fmt.Fprintf(c.out, "Could not save history: %s\n", e.Error())
+	}
+}
+
+// IsMetaCommand returns true if the line is a meta command (e.g. `\d User`) rather than SQL
+func IsMetaCommand(line string) bool {
+	var trimmed = strings.TrimSpace(line)
+	switch strings.ToLower(trimmed) {
+	case "quit", "exit", "help":
+		return true
+	}
+	return strings.HasPrefix(trimmed, "\\")
+}
+
+// Meta runs a meta command. Returns true if the session should end.
+func (c *Console) Meta(line string) bool {
+
+	var fields = strings.Fields(strings.TrimSpace(line))
+
+	switch strings.ToLower(fields[0]) {
+	case "\\q", "quit", "exit":
+		return true
+	case "\\?", "help":
+		fmt.Fprint(c.out, MetaHelp)
+	case "\\d":
+		if len(fields) > 1 {
+			c.describe(fields[1])
+		} else {
+			c.listTables()
+		}
+	default:
+		fmt.Fprintf(c.out, "Unknown command `%s`. Type \\? for help.\n", fields[0])
+	}
+
+	return false
+}
+
+// MetaHelp lists the meta commands
+const MetaHelp = `  \d          List tables
+  \d [table]  Describe a table
+  \c          Clear the current statement
+  \q          Quit
+  \?          Show this help
+
+Statements end with ; and can span multiple lines. Tab completes table names, column names and keywords.
+`
+
+func (c *Console) listTables() {
+
+	var t = lib.NewCLITable([]string{"Table", "Columns"})
+	for _, name := range c.completer.TableNames() {
+		t.Row()
+		t.Col(name)
+		t.Colf("%d", len(c.completer.Table(name).Columns))
+	}
+
+	fmt.Fprintln(c.out, t.String())
+}
+
+func (c *Console) describe(tableName string) {
+
+	var table = c.completer.Table(strings.Trim(tableName, "`;"))
+	if table == nil {
+		fmt.Fprintf(c.out, "Unknown table `%s`\n", tableName)
+		return
+	}
+
+	fmt.Fprintln(c.out, DescribeTable(table))
+}
+
+// DescribeTable renders a table's columns from the schema
+func DescribeTable(table *schema.Table) string {
+
+	var t = lib.NewCLITable([]string{"Column", "Type", "Null", "Key", "Default", "Extra"})
+
+	for _, column := range table.ToSortedColumns() {
+		t.Row()
+		t.Col(column.Name)
+		t.Col(column.Type)
+		if column.IsNullable {
+			t.Col("YES")
+		} else {
+			t.Col("NO")
+		}
+		t.Col(column.ColumnKey)
+		t.Col(column.Default)
+		t.Col(column.Extra)
+	}
+
+	return t.String()
+}
+
+// Run executes a single statement and writes its result
+func (c *Console) Run(ctx context.Context, statement string) error {
+
+	if c.readOnly && !IsReadOnly(statement) {
+		return ErrReadOnly
+	}
+
+	var start = time.Now()
+
+	if !ReturnsRows(statement) {
+
+		var result, e = c.db.ExecContext(ctx, statement)
+		if e != nil {
+			return e
+		}
+
+		var affected, _ = result.RowsAffected()
+		fmt.Fprintf(c.out, "%d rows affected (%.3fs)\n", affected, time.Since(start).Seconds())
+		return nil
+	}
+
+	var rows, e = c.db.QueryContext(ctx, statement)
+	if e != nil {
+		return e
+	}
+
+	defer rows.Close()
+
+	var columns []string
+	if columns, e = rows.Columns(); e != nil {
+		return e
+	}
+
+	if len(columns) == 0 {
+		fmt.Fprintf(c.out, "OK (%.3fs)\n", time.Since(start).Seconds())
+		return nil
+	}
+
+	var t = lib.NewCLITable(columns)
+	var count = 0
+	var values = make([]interface{}, len(columns))
+	var pointers = make([]interface{}, len(columns))
+	for k := range values {
+		pointers[k] = &values[k]
+	}
+
+	for rows.Next() {
+
+		if e = rows.Scan(pointers...); e != nil {
+			return e
+		}
+
+		t.Row()
+		for k := range values {
+			switch v := values[k].(type) {
+			case nil:
+				t.Col("NULL")
+			case []byte:
+				t.Col(string(v))
+			default:
+				t.Col(fmt.Sprint(v))
+			}
+		}
+
+		count++
+	}
+
+	if e = rows.Err(); e != nil {
+		return e
+	}
+
+	fmt.Fprintln(c.out, t.String())
+	fmt.Fprintf(c.out, "%d rows (%.3fs)\n", count, time.Since(start).Seconds())
+
+	return nil
+}
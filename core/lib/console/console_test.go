@@ -0,0 +1,130 @@
+package console
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"testing"
+
+	"github.com/macinnir/dvc/core/lib/schema"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSplitStatements(t *testing.T) {
+
+	var statements, rest = SplitStatements("SELECT 'a;b' FROM `x;y`; -- c;\nSELECT 2 /* ; */;\nSELECT")
+	assert.Equal(t, []string{"SELECT 'a;b' FROM `x;y`", "-- c;\nSELECT 2 /* ; */"}, statements)
+	assert.Equal(t, "SELECT", rest)
+
+	var b = &Buffer{}
+	assert.Empty(t, b.Add("SELECT *"))
+	assert.False(t, b.IsEmpty())
+	assert.Equal(t, []string{"SELECT *\nFROM User"}, b.Add("FROM User;"))
+	assert.True(t, b.IsEmpty())
+}
+
+func TestIsReadOnly(t *testing.T) {
+
+	var tests = []struct {
+		statement string
+		readOnly  bool
+	}{
+		{"SELECT * FROM User WHERE Name = 'DELETE'", true},
+		{"select `Update` from User", true},
+		{"SHOW CREATE TABLE User", true},
+		{"describe User", true},
+		{"EXPLAIN SELECT 1", true},
+		{"/* DROP */ SELECT 1", true},
+		{"SELECT /*+ MAX_EXECUTION_TIME(1000) */ * FROM User", true},
+		{"DELETE FROM User", false},
+		{"UPDATE User SET Name = 'a'", false},
+		{"SELECT * FROM User INTO OUTFILE '/tmp/x'", false},
+		{"SELECT * FROM User FOR UPDATE", false},
+		{"SELECT 1 /*! INTO OUTFILE '/tmp/x' */", false},
+		{"SELECT 1 /*!50100INTO OUTFILE '/tmp/x' */", false},
+		{"SELECT * FROM User FOR SHARE", false},
+		{"WITH x AS (SELECT 1) DELETE FROM User", false},
+		{"EXPLAIN ANALYZE DELETE FROM User", false},
+		{"SET autocommit = 0", false},
+		{"", false},
+	}
+
+	for _, test := range tests {
+		assert.Equal(t, test.readOnly, IsReadOnly(test.statement), test.statement)
+	}
+}
+
+func TestComplete(t *testing.T) {
+
+	var c = NewCompleter(&schema.Schema{
+		Tables: map[string]*schema.Table{
+			"User": {Name: "User", Columns: map[string]*schema.Column{
+				"UserID": {Name: "UserID"},
+				"Email":  {Name: "Email"},
+			}},
+			"UserRole": {Name: "UserRole", Columns: map[string]*schema.Column{}},
+		},
+	})
+
+	var line, pos, ok = c.Complete("SELECT * FROM Us", 16, '\t')
+	assert.True(t, ok)
+	assert.Equal(t, "SELECT * FROM User", line)
+	assert.Equal(t, 18, pos)
+
+	line, _, ok = c.Complete("SELECT Em FROM User", 9, '\t')
+	assert.True(t, ok)
+	assert.Equal(t, "SELECT Email FROM User", line)
+
+	line, _, ok = c.Complete("SELECT User.U", 13, '\t')
+	assert.True(t, ok)
+	assert.Equal(t, "SELECT User.UserID ", line)
+
+	line, _, ok = c.Complete("sel", 3, '\t')
+	assert.True(t, ok)
+	assert.Equal(t, "select ", line)
+
+	_, _, ok = c.Complete("SELECT Zz", 9, '\t')
+	assert.False(t, ok)
+
+	_, _, ok = c.Complete("SELECT Us", 9, 'a')
+	assert.False(t, ok)
+
+	var _, candidates = c.Candidates("\\d U", 4)
+	assert.Equal(t, []string{"User", "UserRole"}, candidates)
+}
+
+// recordingConn is a database connection that records the statements it runs
+type recordingConn struct {
+	log *[]string
+}
+
+func (c recordingConn) Connect(context.Context) (driver.Conn, error) { return c, nil }
+func (c recordingConn) Driver() driver.Driver                        { return nil }
+func (c recordingConn) Prepare(q string) (driver.Stmt, error)        { return nil, driver.ErrSkip }
+func (c recordingConn) Close() error                                 { return nil }
+func (c recordingConn) Begin() (driver.Tx, error)                    { return nil, driver.ErrSkip }
+
+func (c recordingConn) ExecContext(ctx context.Context, q string, args []driver.NamedValue) (driver.Result, error) {
+	*c.log = append(*c.log, q)
+	return driver.RowsAffected(0), nil
+}
+
+func TestReadOnlyConn(t *testing.T) {
+
+	var log = []string{}
+	var db = sql.OpenDB(recordingConn{&log})
+	t.Cleanup(func() { db.Close() })
+
+	ctx := context.Background()
+
+	conn, e := ReadOnlyConn(ctx, db, schema.SchemaTypeMySQL)
+	require.Nil(t, e)
+	conn.Close()
+
+	conn, e = ReadOnlyConn(ctx, db, schema.SchemaTypePostgreSQL)
+	require.Nil(t, e)
+	conn.Close()
+
+	assert.Equal(t, []string{"SET SESSION TRANSACTION READ ONLY", "SET SESSION CHARACTERISTICS AS TRANSACTION READ ONLY"}, log)
+}
@@ -0,0 +1,66 @@
+package console
+
+import (
+	"bufio"
+	"os"
+	"path"
+	"strings"
+
+	"github.com/macinnir/dvc/core/lib"
+)
+
+// MaxHistory is the number of history entries loaded into the console
+const MaxHistory = 100
+
+// LoadHistory returns the last `max` entries in the history file, oldest first
+func LoadHistory(filePath string, max int) ([]string, error) {
+
+	var entries = []string{}
+
+	if !lib.FileExists(filePath) {
+		return entries, nil
+	}
+
+	var f, e = os.Open(filePath)
+	if e != nil {
+		return nil, e
+	}
+
+	defer f.Close()
+
+	var scanner = bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+
+	for scanner.Scan() {
+		if len(strings.TrimSpace(scanner.Text())) == 0 {
+			continue
+		}
+		entries = append(entries, scanner.Text())
+		if len(entries) > max {
+			entries = entries[1:]
+		}
+	}
+
+	return entries, scanner.Err()
+}
+
+// AppendHistory adds an entry to the history file. Multi-line statements are stored on a single line.
+func AppendHistory(filePath string, entry string) error {
+
+	var e error
+
+	if e = lib.EnsureDir(path.Dir(filePath)); e != nil {
+		return e
+	}
+
+	var f *os.File
+	if f, e = os.OpenFile(filePath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600); e != nil {
+		return e
+	}
+
+	defer f.Close()
+
+	_, e = f.WriteString(strings.Join(strings.Fields(entry), " ") + "\n")
+
+	return e
+}
@@ -0,0 +1,222 @@
+package console
+
+import (
+	"strings"
+	"unicode"
+)
+
+// readOnlyKeywords are the statements allowed on a read-only connection
+var readOnlyKeywords = map[string]bool{
+	"SELECT":   true,
+	"SHOW":     true,
+	"DESCRIBE": true,
+	"DESC":     true,
+	"EXPLAIN":  true,
+	"WITH":     true,
+}
+
+// writeKeywords change data or schema when they appear anywhere in a statement
+var writeKeywords = map[string]bool{
+	"INSERT":   true,
+	"UPDATE":   true,
+	"DELETE":   true,
+	"REPLACE":  true,
+	"DROP":     true,
+	"TRUNCATE": true,
+	"ALTER":    true,
+	"CREATE":   true,
+	"RENAME":   true,
+	"GRANT":    true,
+	"REVOKE":   true,
+	"LOAD":     true,
+	"INTO":     true,
+	"LOCK":     true,
+}
+
+// Buffer collects input lines until one or more complete statements (terminated by `;` outside of quotes
+// and comments) are available
+type Buffer struct {
+	text strings.Builder
+}
+
+// Add appends a line and returns any statements it completed
+func (b *Buffer) Add(line string) []string {
+
+	if b.text.Len() > 0 {
+		b.text.WriteString("\n")
+	}
+
+	b.text.WriteString(line)
+
+	var statements, rest = SplitStatements(b.text.String())
+
+	b.text.Reset()
+	b.text.WriteString(rest)
+
+	return statements
+}
+
+// IsEmpty returns true if there is no pending input
+func (b *Buffer) IsEmpty() bool {
+	return len(strings.TrimSpace(b.text.String())) == 0
+}
+
+// Reset discards any pending input
+func (b *Buffer) Reset() {
+	b.text.Reset()
+}
+
+// SplitStatements splits `input` into complete statements and the unterminated remainder
+func SplitStatements(input string) (statements []string, rest string) {
+
+	statements = []string{}
+
+	var start = 0
+	var runes = []rune(input)
+	var quote rune
+	var lineComment, blockComment = false, false
+
+	for k := 0; k < len(runes); k++ {
+
+		var r = runes[k]
+		var next rune
+		if k+1 < len(runes) {
+			next = runes[k+1]
+		}
+
+		switch {
+		case lineComment:
+			if r == '\n' {
+				lineComment = false
+			}
+		case blockComment:
+			if r == '*' && next == '/' {
+				blockComment = false
+				k++
+			}
+		case quote != 0:
+			if r == '\\' && quote != '`' {
+				k++
+			} else if r == quote {
+				quote = 0
+			}
+		case r == '\'' || r == '"' || r == '`':
+			quote = r
+		case r == '#' || (r == '-' && next == '-'):
+			lineComment = true
+		case r == '/' && next == '*':
+			blockComment = true
+			k++
+		case r == ';':
+			if statement := strings.TrimSpace(string(runes[start:k])); len(statement) > 0 {
+				statements = append(statements, statement)
+			}
+			start = k + 1
+		}
+	}
+
+	return statements, strings.TrimLeftFunc(string(runes[start:]), unicode.IsSpace)
+}
+
+// Keywords returns the upper cased words in a statement outside of quotes and comments. The contents of MySQL's
+// executable comments (`/*! ... */`) and optimizer hints (`/*+ ... */`) are code, and their words are included.
+func Keywords(statement string) []string {
+
+	var words = []string{}
+	var current strings.Builder
+	var runes = []rune(statement)
+	var quote rune
+	var lineComment, blockComment = false, false
+
+	var flush = func() {
+		if current.Len() > 0 {
+			words = append(words, strings.ToUpper(current.String()))
+			current.Reset()
+		}
+	}
+
+	for k := 0; k < len(runes); k++ {
+
+		var r = runes[k]
+		var next rune
+		if k+1 < len(runes) {
+			next = runes[k+1]
+		}
+
+		switch {
+		case lineComment:
+			if r == '\n' {
+				lineComment = false
+			}
+		case blockComment:
+			if r == '*' && next == '/' {
+				blockComment = false
+				k++
+			}
+		case quote != 0:
+			if r == '\\' && quote != '`' {
+				k++
+			} else if r == quote {
+				quote = 0
+			}
+		case r == '\'' || r == '"' || r == '`':
+			flush()
+			quote = r
+		case r == '#' || (r == '-' && next == '-'):
+			flush()
+			lineComment = true
+		case r == '/' && next == '*' && k+2 < len(runes) && (runes[k+2] == '!' || runes[k+2] == '+'):
+			flush()
+			k += 2
+			// e.g. /*!50100 ... */ only runs on servers from that version on
+			for k+1 < len(runes) && unicode.IsDigit(runes[k+1]) {
+				k++
+			}
+		case r == '/' && next == '*':
+			flush()
+			blockComment = true
+			k++
+		case unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_':
+			current.WriteRune(r)
+		default:
+			flush()
+		}
+	}
+
+	flush()
+
+	return words
+}
+
+// IsReadOnly returns true if a statement only reads data. Anything that isn't a plain SELECT, SHOW, DESCRIBE or
+// EXPLAIN (including SELECT ... INTO and SELECT ... FOR UPDATE) is treated as a write.
+func IsReadOnly(statement string) bool {
+
+	var keywords = Keywords(statement)
+	if len(keywords) == 0 || !readOnlyKeywords[keywords[0]] {
+		return false
+	}
+
+	// e.g. SHOW CREATE TABLE
+	switch keywords[0] {
+	case "SHOW", "DESCRIBE", "DESC":
+		return true
+	}
+
+	for k, keyword := range keywords {
+		if writeKeywords[keyword] {
+			return false
+		}
+		if keyword == "SHARE" && k > 0 && keywords[k-1] == "FOR" {
+			return false
+		}
+	}
+
+	return true
+}
+
+// ReturnsRows returns true if a statement produces a result set
+func ReturnsRows(statement string) bool {
+	var keywords = Keywords(statement)
+	return len(keywords) > 0 && readOnlyKeywords[keywords[0]]
+}
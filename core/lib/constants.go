@@ -15,6 +15,10 @@ const (
 	ChangeFilePath      = ".dvc/changes.log"
 	TablesCacheFilePath = ".dvc/tables-cache.json"
 	RoutesFilePath      = ".dvc/routes.json"
+	SeedConfigFilePath  = ".dvc/seed.json"
+	StatsDirectory      = ".dvc/stats"
+	MigrationsDirectory = ".dvc/migrations"
+	SQLHistoryFilePath  = ".dvc/sql_history"
 	CoreCacheConfig     = "core/cache.json"
 
 	GenDir                   = "gen"
@@ -30,6 +34,7 @@ const (
 	RepoGenDir          = "gen/repos"
 	RepoInterfaceGenDir = "gen/definitions/repos"
 	CollectionGenDir    = "gen/definitions/collections"
+	MocksGenDir         = "gen/mocks"
 
 	AppServicesDir   = "app/services"
 	AppDTOsDir       = "app/definitions/dtos"
@@ -9,6 +9,7 @@ import (
 	"os"
 	"path"
 	"sort"
+	"strings"
 	"text/template"
 
 	"github.com/macinnir/dvc/core/lib"
@@ -90,7 +91,9 @@ func GenerateGoDAL(config *lib.Config, table *schema.Table, dir string) (e error
 		StringColumns     []*schema.Column
 		SpecialColumns    []*schema.Column
 		InsertSQL         string
+		InsertWithIDSQL   string
 		InsertArgs        string
+		InsertColumns     []*schema.Column
 		UpdateSQL         string
 		UpdateArgs        string
 		PrimaryKey        string
@@ -105,6 +108,15 @@ func GenerateGoDAL(config *lib.Config, table *schema.Table, dir string) (e error
 		FileFoot          string
 		HasNull           bool
 		HasSpecialColumns bool
+		IsSharded         bool
+		ShardByID         bool
+		ShardKey          string
+		VersionColumn     string
+		Upserts           []*dalUpsert
+		UpsertReturning   bool
+		Relations         []*genutil.Relation
+		Audit             bool
+		AuditExclude      string
 	}{
 		BasePackage:       config.BasePackage,
 		Table:             table,
@@ -211,25 +223,38 @@ func GenerateGoDAL(config *lib.Config, table *schema.Table, dir string) (e error
 
 	data.InsertArgs = insertColumnArgs.String()
 	data.InsertSQL = "INSERT INTO `" + data.Table.Name + "` (" + insertColumnNames.String() + ") VALUES (" + insertColumnVals.String() + ")"
+	data.InsertWithIDSQL = "INSERT INTO `" + data.Table.Name + "` (`" + data.PrimaryKey + "`, " + insertColumnNames.String() + ") VALUES (?, " + insertColumnVals.String() + ")"
+	data.InsertColumns = insertColumns
 
-	var updateColumnNames bytes.Buffer
-	var updateColumnArgs bytes.Buffer
+	if data.VersionColumn, e = genutil.VersionColumn(config, table); e != nil {
+		return
+	}
 
-	for k, col := range data.UpdateColumns {
+	var updateColumnNames = []string{}
+	var updateColumnArgs = []string{}
 
-		updateColumnNames.WriteString("`" + col.Name + "` = ?")
-		updateColumnArgs.WriteString("model." + col.Name)
-		updateColumnArgs.WriteString(", ")
+	for _, col := range data.UpdateColumns {
 
-		if k < len(data.UpdateColumns)-1 {
-			updateColumnNames.WriteString(", ")
+		// The version column is incremented rather than set
+		if col.Name == data.VersionColumn {
+			continue
 		}
+
+		updateColumnNames = append(updateColumnNames, "`"+col.Name+"` = ?")
+		updateColumnArgs = append(updateColumnArgs, "model."+col.Name)
 	}
 
-	updateColumnArgs.WriteString("model." + data.PrimaryKey)
+	updateColumnArgs = append(updateColumnArgs, "model."+data.PrimaryKey)
+	var updateWhere = "`" + data.PrimaryKey + "` = ?"
+
+	if len(data.VersionColumn) > 0 {
+		updateColumnNames = append(updateColumnNames, "`"+data.VersionColumn+"` = `"+data.VersionColumn+"` + 1")
+		updateColumnArgs = append(updateColumnArgs, "model."+data.VersionColumn)
+		updateWhere += " AND `" + data.VersionColumn + "` = ?"
+	}
 
-	data.UpdateArgs = updateColumnArgs.String()
-	data.UpdateSQL = "UPDATE `" + data.Table.Name + "` SET " + updateColumnNames.String() + " WHERE `" + data.PrimaryKey + "` = ?"
+	data.UpdateArgs = strings.Join(updateColumnArgs, ", ")
+	data.UpdateSQL = "UPDATE `" + data.Table.Name + "` SET " + strings.Join(updateColumnNames, ", ") + " WHERE " + updateWhere
 
 	_, data.IsDeleted = table.Columns["IsDeleted"]
 	_, data.IsDateCreated = table.Columns["DateCreated"]
@@ -240,6 +265,80 @@ func GenerateGoDAL(config *lib.Config, table *schema.Table, dir string) (e error
 		data.IDType = "string"
 	}
 
+	if shardConfig, ok := config.Shards[table.Name]; ok {
+		data.IsSharded = true
+		data.ShardKey = shardConfig.Key
+		if len(data.ShardKey) == 0 || data.ShardKey == data.PrimaryKey {
+			data.ShardKey = data.PrimaryKey
+			data.ShardByID = true
+		}
+		if _, ok := table.Columns[data.ShardKey]; !ok {
+			return fmt.Errorf("shard key %s is not a column of %s", data.ShardKey, table.Name)
+		}
+	}
+
+	if data.Relations, e = genutil.Relations(config, table); e != nil {
+		return
+	}
+
+	var auditExclude []string
+	if data.Audit, auditExclude, e = genutil.Audit(config, table); e != nil {
+		return
+	}
+
+	for k := range auditExclude {
+		if k > 0 {
+			data.AuditExclude += ", "
+		}
+		data.AuditExclude += "models." + table.Name + "_Column_" + auditExclude[k]
+	}
+
+	var uniqueIndexes [][]*schema.Column
+	if uniqueIndexes, e = genutil.UniqueIndexes(config, table); e != nil {
+		return
+	}
+
+	var dialect = genutil.Dialect(config, table)
+	data.UpsertReturning = dialect == schema.SchemaTypePostgreSQL
+
+	for _, keyColumns := range uniqueIndexes {
+
+		// Unique indexes are only enforced within a shard
+		if data.IsSharded && !containsColumn(keyColumns, data.ShardKey) {
+			continue
+		}
+
+		var upsert = &dalUpsert{}
+		var names = make([]string, len(keyColumns))
+		var keyArgs = make([]string, len(keyColumns))
+		for k := range keyColumns {
+			names[k] = keyColumns[k].Name
+			keyArgs[k] = "model." + keyColumns[k].Name
+		}
+
+		upsert.Name = strings.Join(names, "And")
+		upsert.Fields = strings.Join(names, ", ")
+
+		if upsert.SQL, e = upsertSQL(dialect, table.Name, data.PrimaryKey, data.IDType == "int64", insertColumns, keyColumns, data.VersionColumn, data.IsDeleted); e != nil {
+			return
+		}
+
+		// MySQL only reports the ID of an updated row through LAST_INSERT_ID, so other keys are looked up, along with
+		// the version the update incremented
+		if !data.UpsertReturning && data.IDType != "int64" {
+			var selected = "`" + data.PrimaryKey + "`"
+			if len(data.VersionColumn) > 0 {
+				selected += ", `" + data.VersionColumn + "`"
+			}
+			upsert.KeySQL = "SELECT " + selected + " FROM `" + table.Name + "` WHERE `" + strings.Join(names, "` = ? AND `") + "` = ?"
+			upsert.KeyArgs = strings.Join(keyArgs, ", ")
+		} else if !data.UpsertReturning && len(data.VersionColumn) > 0 {
+			upsert.VersionSQL = "SELECT `" + data.VersionColumn + "` FROM `" + table.Name + "` WHERE `" + data.PrimaryKey + "` = ?"
+		}
+
+		data.Upserts = append(data.Upserts, upsert)
+	}
+
 	var buf bytes.Buffer
 	if e = DALTemplate.Execute(&buf, data); e != nil {
 		return
@@ -250,6 +349,95 @@ func GenerateGoDAL(config *lib.Config, table *schema.Table, dir string) (e error
 	return
 }
 
+// dalUpsert is an UpsertBy method of a DAL
+type dalUpsert struct {
+	Name       string
+	Fields     string
+	SQL        string
+	KeySQL     string
+	KeyArgs    string
+	VersionSQL string
+}
+
+func containsColumn(columns []*schema.Column, name string) bool {
+	for k := range columns {
+		if columns[k].Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// upsertSQL returns a statement that inserts insertColumns into a table or, if a row with the same keyColumns
+// exists, updates that row instead. The date it was created is kept, the version column (if any) incremented and
+// the row undeleted.
+func upsertSQL(dialect, table, primaryKey string, intID bool, insertColumns, keyColumns []*schema.Column, versionColumn string, isDeleted bool) (string, error) {
+
+	var quote = func(name string) string { return "`" + name + "`" }
+	if dialect == schema.SchemaTypePostgreSQL {
+		quote = func(name string) string { return `"` + name + `"` }
+	}
+
+	var names = make([]string, len(insertColumns))
+	var vals = make([]string, len(insertColumns))
+	var updates = []string{}
+
+	for k, col := range insertColumns {
+
+		names[k] = quote(col.Name)
+		vals[k] = "?"
+		if dialect == schema.SchemaTypePostgreSQL {
+			vals[k] = fmt.Sprintf("$%d", k+1)
+		}
+
+		if containsColumn(keyColumns, col.Name) || col.Name == "DateCreated" || col.Name == versionColumn {
+			continue
+		}
+
+		if dialect == schema.SchemaTypePostgreSQL {
+			updates = append(updates, quote(col.Name)+" = EXCLUDED."+quote(col.Name))
+		} else {
+			updates = append(updates, quote(col.Name)+" = VALUES("+quote(col.Name)+")")
+		}
+	}
+
+	if isDeleted {
+		updates = append(updates, quote("IsDeleted")+" = 0")
+	}
+
+	if len(versionColumn) > 0 {
+		updates = append(updates, quote(versionColumn)+" = "+quote(table)+"."+quote(versionColumn)+" + 1")
+	}
+
+	var insert = "INSERT INTO " + quote(table) + " (" + strings.Join(names, ", ") + ") VALUES (" + strings.Join(vals, ", ") + ")"
+
+	switch dialect {
+	case schema.SchemaTypeMySQL:
+		if intID {
+			updates = append([]string{quote(primaryKey) + " = LAST_INSERT_ID(" + quote(primaryKey) + ")"}, updates...)
+		} else if len(updates) == 0 {
+			updates = append(updates, quote(primaryKey)+" = "+quote(primaryKey))
+		}
+		return insert + " ON DUPLICATE KEY UPDATE " + strings.Join(updates, ", "), nil
+	case schema.SchemaTypePostgreSQL:
+		var keys = make([]string, len(keyColumns))
+		for k := range keyColumns {
+			keys[k] = quote(keyColumns[k].Name)
+		}
+		// DO NOTHING wouldn't return the existing row
+		if len(updates) == 0 {
+			updates = append(updates, keys[0]+" = EXCLUDED."+keys[0])
+		}
+		var returning = quote(primaryKey)
+		if len(versionColumn) > 0 {
+			returning += ", " + quote(versionColumn)
+		}
+		return insert + " ON CONFLICT (" + strings.Join(keys, ", ") + ") DO UPDATE SET " + strings.Join(updates, ", ") + " RETURNING " + returning, nil
+	}
+
+	return "", fmt.Errorf("upserts are not supported on %s databases", dialect)
+}
+
 // GenerateDALSQL generates a constants file filled with sql statements
 func GenerateDALSQL(dir string, database *schema.Schema) (e error) {
 
@@ -280,7 +468,11 @@ var bootstrapFileTpl = template.Must(template.New("dals-bootstrap").Parse(`// Ge
 package definitions
 
 import (
+	"context"
+	"strconv"
+
 	query "github.com/macinnir/goquery"
+	"github.com/macinnir/dvc/core/lib/utils/db"
 	"{{ .LogPackage }}"
 	"{{ .ModelsPackage }}"
 	"{{ .DALPackage }}"
@@ -290,16 +482,65 @@ import (
 type DAL struct {
 	{{range .Tables}}
 	{{.Name}} *dal.{{.Name}}DAL{{end}}
+
+	conns map[string][]query.DBInterface
+	log   log.ILog
 }
 
-// BootstrapDAL bootstraps all of the DAL methods
-func BootstrapDAL(db map[string][]query.DBInterface, log log.ILog) *DAL {
+// BootstrapDAL bootstraps all of the DAL methods. Every query they run is reported to instruments, if any are
+// given (e.g. db.NewSlowQueryLog, db.NewQueryMetrics or db.NewTracing).
+func BootstrapDAL(conns map[string][]query.DBInterface, log log.ILog, instruments ...db.Instrument) *DAL {
 
-	d := &DAL{}
-	{{range .Tables}}
-	d.{{.Name}} = dal.New{{.Name}}DAL(db[models.{{.Name}}_SchemaName], log){{end}}
+	if len(instruments) > 0 {
+		conns = db.InstrumentConns(conns, instruments...)
+	}
 
+	d := &DAL{conns: conns, log: log}
+	{{range .Tables}}
+	d.{{.Name}} = dal.New{{.Name}}DAL(conns[models.{{.Name}}_SchemaName], log){{end}}
+{{if .Audited}}
+	// Audited tables write their change records to the {{.AuditSchema}} schema
+	auditLog := db.NewAuditLog(conns["{{.AuditSchema}}"])
+	{{range .Audited}}
+	d.{{.}}.WithAuditor(auditLog){{end}}
+{{end}}
 	return d
+}
+
+// RunInTx runs fn with a DAL bound to a transaction on each schema's connections (one per shard). The transaction
+// commits if fn returns nil and rolls back otherwise, and is retried on deadlock. RunInTx on the DAL passed to fn
+// uses a savepoint.
+func (d *DAL) RunInTx(ctx context.Context, fn func(tx *DAL) error) error {
+
+	conns := map[string]query.DBInterface{}
+	for schemaName := range d.conns {
+		for shardID := range d.conns[schemaName] {
+			conns[schemaName+"."+strconv.Itoa(shardID)] = d.conns[schemaName][shardID]
+		}
+	}
+
+	return db.RunInTx(ctx, conns, func(txs map[string]query.DBInterface) error {
+		txConns := make(map[string][]query.DBInterface, len(d.conns))
+		for schemaName := range d.conns {
+			txConns[schemaName] = make([]query.DBInterface, len(d.conns[schemaName]))
+			for shardID := range d.conns[schemaName] {
+				txConns[schemaName][shardID] = txs[schemaName+"."+strconv.Itoa(shardID)]
+			}
+		}
+		// The transactions report to the instruments of the connections they were begun on
+		return fn(BootstrapDAL(txConns, d.log))
+	})
+}
+
+// AfterCommit runs fn once the DAL's transaction commits, or immediately outside of RunInTx
+func (d *DAL) AfterCommit(fn func()) {
+	for schemaName := range d.conns {
+		if len(d.conns[schemaName]) > 0 && db.InTx(d.conns[schemaName][0]) {
+			db.AfterCommit(d.conns[schemaName][0], fn)
+			return
+		}
+	}
+	fn()
 }`))
 
 // GenerateDALsBootstrapFile generates a dal bootstrap file in golang
@@ -322,14 +563,26 @@ func GenerateDALsBootstrapFile(config *lib.Config, schemaList *schema.SchemaList
 		LogPackage    string
 		ModelsPackage string
 		DALPackage    string
+		Audited       []string
+		AuditSchema   string
 	}{
 		BasePackage:   config.BasePackage,
 		Tables:        tables,
 		LogPackage:    "github.com/macinnir/dvc/core/lib/utils/log",
 		ModelsPackage: fmt.Sprintf("%s/%s", config.BasePackage, "gen/definitions/models"),
 		DALPackage:    fmt.Sprintf("%s/%s", config.BasePackage, "gen/dal"),
+		Audited:       []string{},
+		AuditSchema:   lib.CoreSchemasLogName,
 	}
 
+	for name := range tables {
+		if _, ok := config.Audit[name]; ok {
+			data.Audited = append(data.Audited, name)
+		}
+	}
+
+	sort.Strings(data.Audited)
+
 	// // lib.Debugf("Generating dal bootstrap file at path %s", g.Options, p)
 	buffer := bytes.Buffer{}
 
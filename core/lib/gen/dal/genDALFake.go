@@ -0,0 +1,535 @@
+package dal
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"path"
+	"sort"
+	"strings"
+	"text/template"
+
+	"github.com/macinnir/dvc/core/lib"
+	"github.com/macinnir/dvc/core/lib/gen/genutil"
+	"github.com/macinnir/dvc/core/lib/schema"
+)
+
+// DALFakeTemplate is the template of an in-memory fake of a DAL. It builds on the DAL's mock (see gen.GenMocks).
+var DALFakeTemplate = template.Must(template.New("template-dal-fake-file").Funcs(template.FuncMap{
+	"dataTypeToGoTypeString": schema.DataTypeToGoTypeString,
+	"toArgName":              genutil.ToArgName,
+}).Parse(`// Generated Code; DO NOT EDIT.
+
+package dalmocks
+
+import (
+	"context"
+	"fmt"
+	"iter"
+	"slices"
+	"strings"
+	"time"
+
+	"{{.BasePackage}}/gen/definitions/models"
+	"github.com/macinnir/dvc/core/lib/utils/errors"
+	"github.com/macinnir/dvc/core/lib/utils/mock"
+	query "github.com/macinnir/goquery"
+	"gopkg.in/guregu/null.v3"
+)
+
+// {{.Table.Name}}DALFake is an in-memory dal.I{{.Table.Name}}DAL that keeps {{.Table.Name}} records in Records, by
+// primary key. Its {{.Table.Name}}DALMock records every call, and its Funcs can be replaced to stub a method. Methods
+// that need a database (e.g. Select, Raw and the relationship loaders) aren't faked, and return zero values unless
+// their Func is set.
+type {{.Table.Name}}DALFake struct {
+	*{{.Table.Name}}DALMock
+	Records *mock.Store[{{.IDType}}, models.{{.Table.Name}}]
+}
+
+// New{{.Table.Name}}DALFake returns a {{.Table.Name}}DALFake without records
+func New{{.Table.Name}}DALFake() *{{.Table.Name}}DALFake {
+
+	f := &{{.Table.Name}}DALFake{
+		{{.Table.Name}}DALMock: &{{.Table.Name}}DALMock{},
+		Records: mock.NewStore[{{.IDType}}, models.{{.Table.Name}}](),
+	}
+
+	m := f.{{.Table.Name}}DALMock
+
+	m.CreateFunc = f.create
+	m.CreateContextFunc = func(ctx context.Context, model *models.{{.Table.Name}}) error { return f.create(model) }
+	m.CreateManyFunc = f.createMany
+	m.CreateManyContextFunc = func(ctx context.Context, modelSlice []*models.{{.Table.Name}}) error { return f.createMany(modelSlice) }
+	m.CreateManyStreamFunc = f.createManyStream
+	m.CreateManyStreamContextFunc = func(ctx context.Context, seq iter.Seq[*models.{{.Table.Name}}], chunkSize int) error {
+		return f.createManyStream(seq, chunkSize)
+	}
+	m.BulkLoadFunc = f.createMany
+	m.BulkLoadContextFunc = func(ctx context.Context, modelSlice []*models.{{.Table.Name}}) error { return f.createMany(modelSlice) }
+
+	m.UpdateFunc = f.update
+	m.UpdateContextFunc = func(ctx context.Context, model *models.{{.Table.Name}}) error { return f.update(model) }
+	m.UpdateManyFunc = f.updateMany
+	m.UpdateManyContextFunc = func(ctx context.Context, modelSlice []*models.{{.Table.Name}}) error { return f.updateMany(modelSlice) }
+	m.UpdateFieldsFunc = f.updateFields
+	m.UpdateFieldsContextFunc = func(ctx context.Context, model *models.{{.Table.Name}}, cols ...query.Column) error {
+		return f.updateFields(model, cols...)
+	}
+	m.PatchFunc = func(model *models.{{.Table.Name}}) error { return f.updateFields(model, model.Changed()...) }
+	m.PatchContextFunc = func(ctx context.Context, model *models.{{.Table.Name}}) error { return f.updateFields(model, model.Changed()...) }
+{{if .IsDeleted}}
+	m.DeleteFunc = f.delete
+	m.DeleteContextFunc = func(ctx context.Context, {{.PrimaryKey | toArgName}} {{.IDType}}) error { return f.delete({{.PrimaryKey | toArgName}}) }
+	m.DeleteManyFunc = f.deleteMany
+	m.DeleteManyContextFunc = func(ctx context.Context, modelSlice []*models.{{.Table.Name}}) error { return f.deleteMany(modelSlice) }
+	m.RestoreFunc = f.restore
+	m.RestoreContextFunc = func(ctx context.Context, {{.PrimaryKey | toArgName}} {{.IDType}}) error { return f.restore({{.PrimaryKey | toArgName}}) }
+{{end}}
+	m.DeleteHardFunc = f.deleteHard
+	m.DeleteHardContextFunc = func(ctx context.Context, {{.PrimaryKey | toArgName}} {{.IDType}}) error { return f.deleteHard({{.PrimaryKey | toArgName}}) }
+	m.DeleteManyHardFunc = f.deleteManyHard
+	m.DeleteManyHardContextFunc = func(ctx context.Context, modelSlice []*models.{{.Table.Name}}) error { return f.deleteManyHard(modelSlice) }
+
+	m.FromIDFunc = f.fromID
+	m.FromIDContextFunc = func(ctx context.Context, {{.PrimaryKey | toArgName}} {{.IDType}}, mustExist bool) (*models.{{.Table.Name}}, error) {
+		return f.fromID({{.PrimaryKey | toArgName}}, mustExist)
+	}
+	m.FromIDsFunc = f.fromIDs
+	m.FromIDsContextFunc = func(ctx context.Context, {{.PrimaryKey | toArgName}}s []{{.IDType}}) ([]*models.{{.Table.Name}}, error) {
+		return f.fromIDs({{.PrimaryKey | toArgName}}s)
+	}
+	m.FromIDsMapFunc = f.fromIDsMap
+	m.FromIDsMapContextFunc = func(ctx context.Context, {{.PrimaryKey | toArgName}}s []{{.IDType}}) (map[{{.IDType}}]*models.{{.Table.Name}}, error) {
+		return f.fromIDsMap({{.PrimaryKey | toArgName}}s)
+	}
+	m.ManyPagedFunc = func(limit, offset int64, orderBy, orderDir string) ([]*models.{{.Table.Name}}, error) {
+		return f.many(nil, limit, offset, orderBy, orderDir), nil
+	}
+	m.ManyPagedContextFunc = func(ctx context.Context, limit, offset int64, orderBy, orderDir string) ([]*models.{{.Table.Name}}, error) {
+		return f.many(nil, limit, offset, orderBy, orderDir), nil
+	}
+{{range $col := .UpdateColumns}}
+	// {{$col.Name}}
+	m.Set{{$col.Name}}Func = func({{$.PrimaryKey | toArgName}} {{$.IDType}}, {{$col.Name | toArgName}} {{$col | dataTypeToGoTypeString}}) error {
+		return f.set({{$.PrimaryKey | toArgName}}, func(model *models.{{$.Table.Name}}) {
+			model.{{$col.Name}} = {{$col.Name | toArgName}}{{if and $.VersionColumn (ne $col.Name $.VersionColumn)}}
+			model.{{$.VersionColumn}}++{{end}}
+		})
+	}
+	m.Set{{$col.Name}}ContextFunc = func(ctx context.Context, {{$.PrimaryKey | toArgName}} {{$.IDType}}, {{$col.Name | toArgName}} {{$col | dataTypeToGoTypeString}}) error {
+		return m.Set{{$col.Name}}Func({{$.PrimaryKey | toArgName}}, {{$col.Name | toArgName}})
+	}
+	m.ManyFrom{{$col.Name}}Func = func({{$col.Name | toArgName}} {{$col | dataTypeToGoTypeString}}, limit, offset int64, orderBy, orderDir string) ([]*models.{{$.Table.Name}}, error) {
+		return f.many(func(model *models.{{$.Table.Name}}) bool { return mock.Equal(model.{{$col.Name}}, {{$col.Name | toArgName}}) }, limit, offset, orderBy, orderDir), nil
+	}
+	m.ManyFrom{{$col.Name}}ContextFunc = func(ctx context.Context, {{$col.Name | toArgName}} {{$col | dataTypeToGoTypeString}}, limit, offset int64, orderBy, orderDir string) ([]*models.{{$.Table.Name}}, error) {
+		return m.ManyFrom{{$col.Name}}Func({{$col.Name | toArgName}}, limit, offset, orderBy, orderDir)
+	}
+{{- if or (eq $col.GoType "int64") (eq $col.GoType "int")}}
+	m.ManyFrom{{$col.Name}}sFunc = func({{$col.Name | toArgName}}s []{{$col | dataTypeToGoTypeString}}, limit, offset int64, orderBy, orderDir string) ([]*models.{{$.Table.Name}}, error) {
+		return f.many(func(model *models.{{$.Table.Name}}) bool { return slices.Contains({{$col.Name | toArgName}}s, model.{{$col.Name}}) }, limit, offset, orderBy, orderDir), nil
+	}
+	m.ManyFrom{{$col.Name}}sContextFunc = func(ctx context.Context, {{$col.Name | toArgName}}s []{{$col | dataTypeToGoTypeString}}, limit, offset int64, orderBy, orderDir string) ([]*models.{{$.Table.Name}}, error) {
+		return m.ManyFrom{{$col.Name}}sFunc({{$col.Name | toArgName}}s, limit, offset, orderBy, orderDir)
+	}
+{{- end}}
+	m.CountFrom{{$col.Name}}Func = func({{$col.Name | toArgName}} {{$col | dataTypeToGoTypeString}}) (int64, error) {
+		return int64(len(f.many(func(model *models.{{$.Table.Name}}) bool { return mock.Equal(model.{{$col.Name}}, {{$col.Name | toArgName}}) }, 0, 0, "", ""))), nil
+	}
+	m.CountFrom{{$col.Name}}ContextFunc = func(ctx context.Context, {{$col.Name | toArgName}} {{$col | dataTypeToGoTypeString}}) (int64, error) {
+		return m.CountFrom{{$col.Name}}Func({{$col.Name | toArgName}})
+	}
+	m.SingleFrom{{$col.Name}}Func = func({{$col.Name | toArgName}} {{$col | dataTypeToGoTypeString}}, mustExist bool) (*models.{{$.Table.Name}}, error) {
+		return f.single(func(model *models.{{$.Table.Name}}) bool { return mock.Equal(model.{{$col.Name}}, {{$col.Name | toArgName}}) }, mustExist)
+	}
+	m.SingleFrom{{$col.Name}}ContextFunc = func(ctx context.Context, {{$col.Name | toArgName}} {{$col | dataTypeToGoTypeString}}, mustExist bool) (*models.{{$.Table.Name}}, error) {
+		return m.SingleFrom{{$col.Name}}Func({{$col.Name | toArgName}}, mustExist)
+	}
+{{end}}{{range $upsert := .Upserts}}
+	// UpsertBy{{$upsert.Name}}
+	m.UpsertBy{{$upsert.Name}}Func = func(model *models.{{$.Table.Name}}) error {
+		return f.upsert(model, func(stored *models.{{$.Table.Name}}) bool {
+			return {{range $k, $col := $upsert.Columns}}{{if $k}} && {{end}}mock.Equal(stored.{{$col}}, model.{{$col}}){{end}}
+		})
+	}
+	m.UpsertBy{{$upsert.Name}}ContextFunc = func(ctx context.Context, model *models.{{$.Table.Name}}) error {
+		return m.UpsertBy{{$upsert.Name}}Func(model)
+	}
+	m.UpsertManyBy{{$upsert.Name}}Func = func(modelSlice []*models.{{$.Table.Name}}) error {
+		for _, model := range modelSlice {
+			if e := m.UpsertBy{{$upsert.Name}}Func(model); e != nil {
+				return e
+			}
+		}
+		return nil
+	}
+	m.UpsertManyBy{{$upsert.Name}}ContextFunc = func(ctx context.Context, modelSlice []*models.{{$.Table.Name}}) error {
+		return m.UpsertManyBy{{$upsert.Name}}Func(modelSlice)
+	}
+{{end}}
+	return f
+}
+
+// create stores a new {{.Table.Name}}{{if eq .IDType "int64"}}, assigning it the next ID if it has none{{end}}
+func (f *{{.Table.Name}}DALFake) create(model *models.{{.Table.Name}}) error {
+{{if eq .IDType "int64"}}
+	if model.{{.PrimaryKey}} == 0 {
+		model.{{.PrimaryKey}} = f.Records.NextID()
+	}
+{{end}}
+	if _, ok := f.Records.Get(model.{{.PrimaryKey}}); ok {
+		return fmt.Errorf("{{.Table.Name}}DALFake.Create: duplicate {{.PrimaryKey}} %v", model.{{.PrimaryKey}})
+	}
+{{if .IsDateCreated}}
+	model.DateCreated = time.Now().UnixNano() / 1000000{{end}}{{if .IsLastUpdated}}
+	model.LastUpdated = time.Now().UnixNano() / 1000000{{end}}
+	f.Records.Put(model.{{.PrimaryKey}}, model)
+	model.Snapshot()
+
+	return nil
+}
+
+// createMany stores new {{.Table.Name}} records, stopping at the first error
+func (f *{{.Table.Name}}DALFake) createMany(modelSlice []*models.{{.Table.Name}}) error {
+
+	for _, model := range modelSlice {
+		if e := f.create(model); e != nil {
+			return e
+		}
+	}
+
+	return nil
+}
+
+// createManyStream stores the new {{.Table.Name}} records produced by seq, in chunks of chunkSize
+func (f *{{.Table.Name}}DALFake) createManyStream(seq iter.Seq[*models.{{.Table.Name}}], chunkSize int) error {
+
+	var chunk = []*models.{{.Table.Name}}{}
+
+	for model := range seq {
+
+		if chunk = append(chunk, model); len(chunk) < chunkSize {
+			continue
+		}
+
+		if e := f.createMany(chunk); e != nil {
+			return e
+		}
+
+		chunk = []*models.{{.Table.Name}}{}
+	}
+
+	return f.createMany(chunk)
+}
+
+// update replaces a stored {{.Table.Name}}. Like an UPDATE, it does nothing if there is no record with its primary
+// key{{if .VersionColumn}}, and returns an errors.ConcurrentModificationError if the record's {{.VersionColumn}} no
+// longer matches{{end}}.
+func (f *{{.Table.Name}}DALFake) update(model *models.{{.Table.Name}}) error {
+{{if .VersionColumn}}
+	stored, ok := f.Records.Get(model.{{.PrimaryKey}})
+	if !ok {
+		return nil
+	}
+
+	if stored.{{.VersionColumn}} != model.{{.VersionColumn}} {
+		return errors.NewConcurrentModificationError("{{.Table.Name}}", model.{{.PrimaryKey}})
+	}
+
+	model.{{.VersionColumn}}++{{else}}
+	if _, ok := f.Records.Get(model.{{.PrimaryKey}}); !ok {
+		return nil
+	}
+{{end}}{{if .IsLastUpdated}}
+	model.LastUpdated = time.Now().UnixNano() / 1000000{{end}}
+	f.Records.Put(model.{{.PrimaryKey}}, model)
+	model.Snapshot()
+
+	return nil
+}
+
+// updateMany replaces stored {{.Table.Name}} records, stopping at the first error
+func (f *{{.Table.Name}}DALFake) updateMany(modelSlice []*models.{{.Table.Name}}) error {
+
+	for _, model := range modelSlice {
+		if e := f.update(model); e != nil {
+			return e
+		}
+	}
+
+	return nil
+}
+
+// updateFields copies the given columns of a {{.Table.Name}} onto the stored record, like update
+func (f *{{.Table.Name}}DALFake) updateFields(model *models.{{.Table.Name}}, cols ...query.Column) error {
+
+	if len(cols) == 0 {
+		return nil
+	}
+
+	stored, ok := f.Records.Get(model.{{.PrimaryKey}})
+	if !ok {
+		return nil
+	}
+{{if .VersionColumn}}
+	if stored.{{.VersionColumn}} != model.{{.VersionColumn}} {
+		return errors.NewConcurrentModificationError("{{.Table.Name}}", model.{{.PrimaryKey}})
+	}
+
+	model.{{.VersionColumn}}++{{end}}{{if .IsLastUpdated}}
+	model.LastUpdated = time.Now().UnixNano() / 1000000{{end}}
+	stored.ApplyUpdate(model, cols...)
+	f.Records.Put(model.{{.PrimaryKey}}, stored)
+	model.Snapshot()
+
+	return nil
+}
+
+// upsert stores a new {{.Table.Name}} or, if a stored one matches (deleted or not), replaces it like an upsert does:
+// model.{{.PrimaryKey}} is set to the stored record's{{if .IsDateCreated}}, its DateCreated is kept{{end}}{{if .VersionColumn}}, its {{.VersionColumn}} is incremented{{end}}{{if .IsDeleted}} and
+// it is restored{{end}}.
+func (f *{{.Table.Name}}DALFake) upsert(model *models.{{.Table.Name}}, match func(stored *models.{{.Table.Name}}) bool) error {
+
+	existing := f.Records.Filter(match)
+	if len(existing) == 0 {
+		return f.create(model)
+	}
+
+	stored := existing[0]
+	model.{{.PrimaryKey}} = stored.{{.PrimaryKey}}{{if .IsDateCreated}}
+	model.DateCreated = stored.DateCreated{{end}}{{if .VersionColumn}}
+	model.{{.VersionColumn}} = stored.{{.VersionColumn}} + 1{{end}}{{if .IsDeleted}}
+	model.IsDeleted = 0{{end}}{{if .IsLastUpdated}}
+	model.LastUpdated = time.Now().UnixNano() / 1000000{{end}}
+	f.Records.Put(model.{{.PrimaryKey}}, model)
+	model.Snapshot()
+
+	return nil
+}
+
+// set changes a stored {{.Table.Name}} with apply, if there is one
+func (f *{{.Table.Name}}DALFake) set({{.PrimaryKey | toArgName}} {{.IDType}}, apply func(model *models.{{.Table.Name}})) error {
+
+	if stored, ok := f.Records.Get({{.PrimaryKey | toArgName}}); ok {
+		apply(stored)
+		f.Records.Put({{.PrimaryKey | toArgName}}, stored)
+	}
+
+	return nil
+}
+{{if .IsDeleted}}
+// delete marks a stored {{.Table.Name}} as deleted
+func (f *{{.Table.Name}}DALFake) delete({{.PrimaryKey | toArgName}} {{.IDType}}) error {
+	return f.set({{.PrimaryKey | toArgName}}, func(model *models.{{.Table.Name}}) { model.IsDeleted = 1 })
+}
+
+// deleteMany marks stored {{.Table.Name}} records as deleted
+func (f *{{.Table.Name}}DALFake) deleteMany(modelSlice []*models.{{.Table.Name}}) error {
+
+	for _, model := range modelSlice {
+		f.delete(model.{{.PrimaryKey}})
+		model.IsDeleted = 1
+	}
+
+	return nil
+}
+
+// restore unmarks a deleted {{.Table.Name}}
+func (f *{{.Table.Name}}DALFake) restore({{.PrimaryKey | toArgName}} {{.IDType}}) error {
+	return f.set({{.PrimaryKey | toArgName}}, func(model *models.{{.Table.Name}}) { model.IsDeleted = 0 })
+}
+{{end}}
+// deleteHard removes a stored {{.Table.Name}}
+func (f *{{.Table.Name}}DALFake) deleteHard({{.PrimaryKey | toArgName}} {{.IDType}}) error {
+	f.Records.Delete({{.PrimaryKey | toArgName}})
+	return nil
+}
+
+// deleteManyHard removes stored {{.Table.Name}} records
+func (f *{{.Table.Name}}DALFake) deleteManyHard(modelSlice []*models.{{.Table.Name}}) error {
+
+	for _, model := range modelSlice {
+		f.Records.Delete(model.{{.PrimaryKey}})
+	}
+
+	return nil
+}
+
+// fromID returns a stored {{.Table.Name}}{{if .IsDeleted}} that isn't deleted{{end}} by its primary key. If there is none and mustExist is
+// true, an errors.RecordNotFoundError is returned.
+func (f *{{.Table.Name}}DALFake) fromID({{.PrimaryKey | toArgName}} {{.IDType}}, mustExist bool) (*models.{{.Table.Name}}, error) {
+
+	model, ok := f.Records.Get({{.PrimaryKey | toArgName}})
+	if !ok{{if .IsDeleted}} || model.IsDeleted == 1{{end}} {
+		if mustExist {
+			return nil, errors.NewRecordNotFoundError()
+		}
+		return nil, nil
+	}
+
+	model.Snapshot()
+	return model, nil
+}
+
+// fromIDs returns the stored {{.Table.Name}} records{{if .IsDeleted}} that aren't deleted{{end}} with the given primary keys
+func (f *{{.Table.Name}}DALFake) fromIDs({{.PrimaryKey | toArgName}}s []{{.IDType}}) ([]*models.{{.Table.Name}}, error) {
+	return f.many(func(model *models.{{.Table.Name}}) bool { return slices.Contains({{.PrimaryKey | toArgName}}s, model.{{.PrimaryKey}}) }, 0, 0, "", ""), nil
+}
+
+// fromIDsMap returns the stored {{.Table.Name}} records{{if .IsDeleted}} that aren't deleted{{end}} with the given primary keys, by primary key
+func (f *{{.Table.Name}}DALFake) fromIDsMap({{.PrimaryKey | toArgName}}s []{{.IDType}}) (map[{{.IDType}}]*models.{{.Table.Name}}, error) {
+
+	var result = map[{{.IDType}}]*models.{{.Table.Name}}{}
+
+	collection, _ := f.fromIDs({{.PrimaryKey | toArgName}}s)
+	for _, model := range collection {
+		result[model.{{.PrimaryKey}}] = model
+	}
+
+	return result, nil
+}
+
+// many returns a page of the stored {{.Table.Name}} records{{if .IsDeleted}} that aren't deleted and{{end}} that match (all of them if match is
+// nil), in primary key order or ordered by the orderBy column
+func (f *{{.Table.Name}}DALFake) many(match func(model *models.{{.Table.Name}}) bool, limit, offset int64, orderBy, orderDir string) []*models.{{.Table.Name}} {
+
+	var collection = f.Records.Filter(func(model *models.{{.Table.Name}}) bool {
+		return {{if .IsDeleted}}model.IsDeleted == 0 && {{end}}(match == nil || match(model))
+	})
+
+	if len(orderBy) > 0 {
+		mock.Sort(collection, func(model *models.{{.Table.Name}}) interface{} {
+			return model.Table_Column_Value(query.Column(orderBy))
+		}, strings.EqualFold(orderDir, "DESC"))
+	}
+
+	collection = mock.Page(collection, limit, offset)
+
+	for _, model := range collection {
+		model.Snapshot()
+	}
+
+	return collection
+}
+
+// single returns the first stored {{.Table.Name}} that matches. If there is none{{if .IsDeleted}} (or it is deleted){{end}} and mustExist is
+// true, an errors.RecordNotFoundError is returned.
+func (f *{{.Table.Name}}DALFake) single(match func(model *models.{{.Table.Name}}) bool, mustExist bool) (*models.{{.Table.Name}}, error) {
+
+	var collection = f.Records.Filter(match)
+	if len(collection) == 0{{if .IsDeleted}} || (collection[0].IsDeleted == 1 && mustExist){{end}} {
+		if mustExist {
+			return nil, errors.NewRecordNotFoundError()
+		}
+		return nil, nil
+	}
+
+	collection[0].Snapshot()
+	return collection[0], nil
+}
+`))
+
+// GenDALFakes generates an in-memory fake (see DALFakeTemplate) of the DAL of each table into dir
+func GenDALFakes(tables []*schema.Table, config *lib.Config, dir string) error {
+
+	lib.EnsureDir(dir)
+
+	for k := range tables {
+		if e := GenerateGoDALFake(config, tables[k], dir); e != nil {
+			return fmt.Errorf("GenDALFakes(%s): %w", tables[k].Name, e)
+		}
+	}
+
+	return nil
+}
+
+// GenerateGoDALFake generates the in-memory fake of the DAL of a table into dir
+func GenerateGoDALFake(config *lib.Config, table *schema.Table, dir string) (e error) {
+
+	var data = struct {
+		BasePackage   string
+		Table         *schema.Table
+		UpdateColumns []*schema.Column
+		PrimaryKey    string
+		IDType        string
+		IsDeleted     bool
+		IsDateCreated bool
+		IsLastUpdated bool
+		VersionColumn string
+		Upserts       []*dalFakeUpsert
+	}{
+		BasePackage:   config.BasePackage,
+		Table:         table,
+		UpdateColumns: []*schema.Column{},
+		IDType:        "int64",
+	}
+
+	for _, column := range table.Columns {
+
+		if column.ColumnKey == "PRI" {
+			data.PrimaryKey = column.Name
+			if column.DataType == "varchar" {
+				data.IDType = "string"
+			}
+		}
+
+		if genutil.IsUpdateColumn(column) {
+			data.UpdateColumns = append(data.UpdateColumns, column)
+		}
+	}
+
+	sort.Slice(data.UpdateColumns, func(a, b int) bool { return data.UpdateColumns[a].Name < data.UpdateColumns[b].Name })
+
+	_, data.IsDeleted = table.Columns["IsDeleted"]
+	_, data.IsDateCreated = table.Columns["DateCreated"]
+	_, data.IsLastUpdated = table.Columns["LastUpdated"]
+
+	if data.VersionColumn, e = genutil.VersionColumn(config, table); e != nil {
+		return
+	}
+
+	var uniqueIndexes [][]*schema.Column
+	if uniqueIndexes, e = genutil.UniqueIndexes(config, table); e != nil {
+		return
+	}
+
+	var shardConfig, isSharded = config.Shards[table.Name]
+	var shardKey = data.PrimaryKey
+	if isSharded && len(shardConfig.Key) > 0 {
+		shardKey = shardConfig.Key
+	}
+
+	for _, keyColumns := range uniqueIndexes {
+
+		// Like the DAL, a sharded table only has upserts by keys that include its shard key
+		if isSharded && !containsColumn(keyColumns, shardKey) {
+			continue
+		}
+
+		var upsert = &dalFakeUpsert{Columns: make([]string, len(keyColumns))}
+		for k := range keyColumns {
+			upsert.Columns[k] = keyColumns[k].Name
+		}
+
+		upsert.Name = strings.Join(upsert.Columns, "And")
+		data.Upserts = append(data.Upserts, upsert)
+	}
+
+	var buf bytes.Buffer
+	if e = DALFakeTemplate.Execute(&buf, data); e != nil {
+		return
+	}
+
+	var code []byte
+	if code, e = lib.FormatCode(buf.String()); e != nil {
+		return
+	}
+
+	return ioutil.WriteFile(path.Join(dir, table.Name+"DALFake.go"), code, lib.DefaultFileMode)
+}
+
+// dalFakeUpsert is an UpsertBy method of a DAL fake
+type dalFakeUpsert struct {
+	Name    string
+	Columns []string
+}
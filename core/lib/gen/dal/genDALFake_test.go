@@ -0,0 +1,61 @@
+package dal
+
+import (
+	"go/parser"
+	"go/token"
+	"os"
+	"path"
+	"testing"
+
+	"github.com/macinnir/dvc/core/lib"
+	"github.com/macinnir/dvc/core/lib/schema"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenerateGoDALFake(t *testing.T) {
+
+	table := &schema.Table{
+		Name: "Foo",
+		Columns: map[string]*schema.Column{
+			"FooID":     {Name: "FooID", ColumnKey: "PRI", DataType: "bigint", Extra: "auto_increment"},
+			"Name":      {Name: "Name", DataType: "varchar"},
+			"Code":      {Name: "Code", DataType: "varchar", ColumnKey: "UNI"},
+			"IsDeleted": {Name: "IsDeleted", DataType: "tinyint"},
+			"Version":   {Name: "Version", DataType: "int"},
+		},
+	}
+
+	dir := t.TempDir()
+	config := &lib.Config{
+		BasePackage:    "example.com/app",
+		VersionColumns: map[string]string{"Foo": "Version"},
+	}
+
+	require.Nil(t, GenerateGoDALFake(config, table, dir))
+
+	src, e := os.ReadFile(path.Join(dir, "FooDALFake.go"))
+	require.Nil(t, e)
+
+	_, e = parser.ParseFile(token.NewFileSet(), "FooDALFake.go", src, 0)
+	require.Nil(t, e, string(src))
+
+	assert.Contains(t, string(src), "package dalmocks")
+	assert.Contains(t, string(src), "Records *mock.Store[int64, models.Foo]")
+	assert.Contains(t, string(src), "m.CreateFunc = f.create")
+	assert.Contains(t, string(src), "return errors.NewConcurrentModificationError(\"Foo\", model.FooID)")
+	assert.Contains(t, string(src), "m.DeleteFunc = f.delete")
+	assert.Contains(t, string(src), "return model.IsDeleted == 0 && (match == nil || match(model))")
+
+	// Deleted records aren't read by ID, even when they needn't exist
+	assert.Contains(t, string(src), "if !ok || model.IsDeleted == 1 {")
+
+	// Upserts match stored records by their unique index
+	assert.Contains(t, string(src), "return mock.Equal(stored.Code, model.Code)")
+	assert.Contains(t, string(src), "m.UpsertManyByCodeFunc = func(modelSlice []*models.Foo) error {")
+	assert.Contains(t, string(src), "model.Version = stored.Version + 1\n\tmodel.IsDeleted = 0")
+
+	// Setting a column other than the version bumps the version
+	assert.Contains(t, string(src), "model.Name = name\n\t\t\tmodel.Version++")
+	assert.NotContains(t, string(src), "model.Version = version\n\t\t\tmodel.Version++")
+}
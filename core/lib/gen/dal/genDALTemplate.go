@@ -19,10 +19,12 @@ import (
 	"{{ .BasePackage }}/gen/definitions/models" 
 	"github.com/macinnir/dvc/core/lib/utils/log"
 	"github.com/macinnir/dvc/core/lib/utils/errors"
+	"github.com/macinnir/dvc/core/lib/utils/db"
 	query "github.com/macinnir/goquery"
 	"database/sql"
 	"context"
-	"fmt"{{ if .HasNull }}
+	"fmt"
+	"iter"{{ if .HasNull }}
 	"gopkg.in/guregu/null.v3"{{ end }}{{ if or .IsDateCreated .IsLastUpdated }}
 	"time"{{ end }}{{ if .HasSpecialColumns }}
 	"strconv"
@@ -31,63 +33,231 @@ import (
 
 // {{.Table.Name}}DAL is a data repository for {{.Table.Name}} objects
 type {{.Table.Name}}DAL struct {
-	db  []query.DBInterface
-	log log.ILog
+	db    []query.DBInterface
+	log   log.ILog
+	stmts []*db.StatementCache{{if .Audit}}
+	audit db.Auditor{{end}}
 }
 
 // New{{.Table.Name}}DAL returns a new instance of {{.Table.Name}}Repo
-func New{{.Table.Name}}DAL(db []query.DBInterface, log log.ILog) *{{.Table.Name}}DAL {
-	return &{{.Table.Name}}DAL{db, log}
+func New{{.Table.Name}}DAL(conn []query.DBInterface, log log.ILog) *{{.Table.Name}}DAL {
+	return &{{.Table.Name}}DAL{db: conn, log: log}
 }
 
-func (r *{{.Table.Name}}DAL) Raw(q string, args ...interface{}) ([]*models.{{.Table.Name}}, error) { 
-	return (&models.{{.Table.Name}}{}).Raw(r.db[0], fmt.Sprintf(q, args...)) 
+// WithStatementCache keeps the prepared statements this DAL runs open for reuse
+func (r *{{.Table.Name}}DAL) WithStatementCache() {
+	r.stmts = make([]*db.StatementCache, len(r.db))
+	for k := range r.db {
+		r.stmts[k] = db.NewStatementCache(r.db[k])
+	}
+}
+
+{{if .Audit}}
+// WithAuditor records the creates, updates and deletes of {{.Table.Name}} objects with auditor (e.g. a db.AuditLog)
+func (r *{{.Table.Name}}DAL) WithAuditor(auditor db.Auditor) {
+	r.audit = auditor
+}
+
+// History returns the change records of the {{.Table.Name}} with the given primary key, oldest first
+func (r *{{.Table.Name}}DAL) History({{.PrimaryKey | toArgName}} {{.IDType}}) ([]*db.AuditRecord, error) {
+	return r.HistoryContext(context.Background(), {{.PrimaryKey | toArgName}})
+}
+
+// HistoryContext is History with a context
+func (r *{{.Table.Name}}DAL) HistoryContext(ctx context.Context, {{.PrimaryKey | toArgName}} {{.IDType}}) ([]*db.AuditRecord, error) {
+
+	if r.audit == nil {
+		return nil, fmt.Errorf("{{.Table.Name}}DAL.History(%v): %w", {{.PrimaryKey | toArgName}}, db.ErrNoAuditConnection)
+	}
+
+	records, e := r.audit.History(ctx, "{{.Table.Name}}", {{.PrimaryKey | toArgName}})
+	if e != nil {
+		return nil, fmt.Errorf("{{.Table.Name}}DAL.History(%v): %w", {{.PrimaryKey | toArgName}}, e)
+	}
+
+	return records, nil
+}
+
+// auditRecord returns the change record of an action on model{{if .AuditExclude}}, leaving out the excluded columns{{end}}
+func (r *{{.Table.Name}}DAL) auditRecord(ctx context.Context, action string, model *models.{{.Table.Name}}, diff db.Diff) *db.AuditRecord {
+	return db.NewAuditRecord(ctx, "{{.Table.Name}}", model.{{.PrimaryKey}}, action, diff{{if .AuditExclude}}.Without({{.AuditExclude}}){{end}})
+}
+
+// writeAudit writes the change records of a write made on conn, if the DAL has an auditor. In a transaction a
+// failure is returned, so that the write rolls back with it. Outside of one the write has already committed, so a
+// failure is logged rather than failing a write that succeeded.
+func (r *{{.Table.Name}}DAL) writeAudit(ctx context.Context, conn query.DBInterface, records ...*db.AuditRecord) error {
+
+	if r.audit == nil {
+		return nil
+	}
+
+	e := r.audit.Audit(ctx, records...)
+	if e != nil && !db.InTx(conn) {
+		r.log.Errorf("{{.Table.Name}}DAL.writeAudit([](%d)) > %s", len(records), e.Error())
+		return nil
+	}
+
+	return e
+}
+{{end}}
+// conn returns the connection queries are run on
+func (r *{{.Table.Name}}DAL) conn() query.DBInterface {
+	return r.connAt(0)
+}
+
+// connAt returns the connection to shard shardID
+func (r *{{.Table.Name}}DAL) connAt(shardID int) query.DBInterface {
+	if r.stmts != nil {
+		return r.stmts[shardID]
+	}
+	return r.db[shardID]
+}
+
+// shards returns the connection to the shard set on ctx with db.WithShardKey or, if there is none, to every shard
+func (r *{{.Table.Name}}DAL) shards(ctx context.Context) []query.DBInterface {
+{{- if .IsSharded}}
+	if key, ok := db.ShardKeyFromContext(ctx); ok {
+		return []query.DBInterface{r.shardConn(ctx, key)}
+	}
+
+	var conns = make([]query.DBInterface, len(r.db))
+	for k := range r.db {
+		conns[k] = r.connAt(k)
+	}
+	return conns
+{{- else}}
+	return []query.DBInterface{r.conn()}
+{{- end}}
+}
+
+// shardConn returns the connection to the shard holding shard key key, unless a shard key is set on ctx
+func (r *{{.Table.Name}}DAL) shardConn(ctx context.Context, key interface{}) query.DBInterface {
+{{- if .IsSharded}}
+	if k, ok := db.ShardKeyFromContext(ctx); ok {
+		key = k
+	}
+	return r.connAt(db.ShardIndex(key, len(r.db), {{.ShardByID}}))
+{{- else}}
+	return r.conn()
+{{- end}}
+}
+
+// shardsForID returns the connections to the shards that may hold the record with primary key id
+func (r *{{.Table.Name}}DAL) shardsForID(ctx context.Context, id {{.IDType}}) []query.DBInterface {
+{{- if .ShardByID}}
+	return []query.DBInterface{r.shardConn(ctx, id)}
+{{- else}}
+	return r.shards(ctx)
+{{- end}}
+}
+
+// modelConn returns the connection to the shard holding model
+func (r *{{.Table.Name}}DAL) modelConn(ctx context.Context, model *models.{{.Table.Name}}) query.DBInterface {
+	return r.shardConn(ctx, {{if .IsSharded}}model.{{.ShardKey}}{{else}}nil{{end}})
+}
+{{- if .IsSharded}}
+
+// byShard groups models by the shard holding them
+func (r *{{.Table.Name}}DAL) byShard(ctx context.Context, modelSlice []*models.{{.Table.Name}}) [][]*models.{{.Table.Name}} {
+
+	if _, ok := db.ShardKeyFromContext(ctx); ok {
+		return [][]*models.{{.Table.Name}}{modelSlice}
+	}
+
+	var shards = make([][]*models.{{.Table.Name}}, len(r.db))
+	for k := range modelSlice {
+		shardID := db.ShardIndex(modelSlice[k].{{.ShardKey}}, len(r.db), {{.ShardByID}})
+		shards[shardID] = append(shards[shardID], modelSlice[k])
+	}
+
+	var groups = [][]*models.{{.Table.Name}}{}
+	for k := range shards {
+		if len(shards[k]) > 0 {
+			groups = append(groups, shards[k])
+		}
+	}
+
+	return groups
+}
+{{- end}}
+
+func (r *{{.Table.Name}}DAL) Raw(q string, args ...interface{}) ([]*models.{{.Table.Name}}, error) {
+	return r.RawContext(context.Background(), q, args...)
+}
+
+// RawContext is Raw with a context
+func (r *{{.Table.Name}}DAL) RawContext(ctx context.Context, q string, args ...interface{}) ([]*models.{{.Table.Name}}, error) { 
+	return (&models.{{.Table.Name}}{}).RawContext(ctx, r.conn(), fmt.Sprintf(q, args...)) 
 }
 
 func (r *{{.Table.Name}}DAL) Select() *models.{{.Table.Name}}DALSelector { 
-	return (&models.{{.Table.Name}}{}).Select(r.db[0])
+	return (&models.{{.Table.Name}}{}).Select(r.conn()){{if .IsSharded}}.OnShards(r.shards){{end}}
 }
 
 func (r *{{.Table.Name}}DAL) Count() *models.{{.Table.Name}}DALCounter { 
-	return (&models.{{.Table.Name}}{}).Count(r.db[0])
+	return (&models.{{.Table.Name}}{}).Count(r.conn()){{if .IsSharded}}.OnShards(r.shards){{end}}
 }
 
 func (r *{{.Table.Name}}DAL) Sum(col query.Column) *models.{{.Table.Name}}DALSummer { 
-	return (&models.{{.Table.Name}}{}).Sum(r.db[0], col)
+	return (&models.{{.Table.Name}}{}).Sum(r.conn(), col)
 }
 
 func (r *{{.Table.Name}}DAL) Min(col query.Column) *models.{{.Table.Name}}DALMinner { 
-	return (&models.{{.Table.Name}}{}).Min(r.db[0], col)
+	return (&models.{{.Table.Name}}{}).Min(r.conn(), col)
 }
 
 func (r *{{.Table.Name}}DAL) Max(col query.Column) *models.{{.Table.Name}}DALMaxer { 
-	return (&models.{{.Table.Name}}{}).Max(r.db[0], col)
+	return (&models.{{.Table.Name}}{}).Max(r.conn(), col)
 }
 
 func (r *{{.Table.Name}}DAL) Get() *models.{{.Table.Name}}DALGetter { 
-	return (&models.{{.Table.Name}}{}).Get(r.db[0])
+	return (&models.{{.Table.Name}}{}).Get(r.conn()){{if .IsSharded}}.OnShards(r.shards){{end}}
 }
 
 // Create creates a new {{.Table.Name}} entry in the database
-func (r *{{.Table.Name}}DAL) Create(model *models.{{.Table.Name}}) error { {{if .IsDateCreated}}
-	
+func (r *{{.Table.Name}}DAL) Create(model *models.{{.Table.Name}}) error {
+	return r.CreateContext(context.Background(), model)
+}
+
+// CreateContext is Create with a context
+func (r *{{.Table.Name}}DAL) CreateContext(ctx context.Context, model *models.{{.Table.Name}}) error {
+
+	if e := db.BeforeCreate(ctx, model); e != nil {
+		return fmt.Errorf("{{.Table.Name}}DAL.Insert: %w", e)
+	}
+{{if .IsDateCreated}}
 	model.DateCreated = time.Now().UnixNano() / 1000000{{end}}
 	{{if .IsLastUpdated}}
 	model.LastUpdated = time.Now().UnixNano() / 1000000
 	{{end}}
-	e := model.Create(r.db[0])
+	e := model.CreateContext(ctx, r.modelConn(ctx, model))
 	if e != nil {
 		r.log.Errorf("{{.Table.Name}}DAL.Insert > %s", e.Error())
 		return fmt.Errorf("{{.Table.Name}}DAL.Insert: %w", e)	
 	}
 
 	// r.log.Debugf("{{.Table.Name}}DAL.Insert(%d)", model.{{.PrimaryKey}})
+{{if .Audit}}
+	if e = r.writeAudit(ctx, r.modelConn(ctx, model), r.auditRecord(ctx, db.AuditCreate, model, db.CreatedDiff(model))); e != nil {
+		return fmt.Errorf("{{.Table.Name}}DAL.Insert: %w", e)
+	}
+{{end}}
+	if e = db.AfterCreate(ctx, model); e != nil {
+		return fmt.Errorf("{{.Table.Name}}DAL.Insert: %w", e)
+	}
 
 	return nil
 }
 
-// CreateMany creates {{.Table.Name}} objects in chunks
+// CreateMany creates {{.Table.Name}} objects with multi-row inserts kept under the server's max_allowed_packet, in a
+// transaction. Records without a primary key get their auto-increment IDs assigned in order.
 func (r *{{.Table.Name}}DAL) CreateMany(modelSlice []*models.{{.Table.Name}}) error {
+	return r.CreateManyContext(context.Background(), modelSlice)
+}
+
+// CreateManyContext is CreateMany with a context
+func (r *{{.Table.Name}}DAL) CreateManyContext(ctx context.Context, modelSlice []*models.{{.Table.Name}}) error {
 
 	var e error 
 
@@ -98,7 +268,7 @@ func (r *{{.Table.Name}}DAL) CreateMany(modelSlice []*models.{{.Table.Name}}) er
 
 	// Don't use a transaction if only a single value
 	if len(modelSlice) == 1 {
-		e = r.Create(modelSlice[0])
+		e = r.CreateContext(ctx, modelSlice[0])
 		if e != nil { 
 			return fmt.Errorf("{{.Table.Name}}CreateMany([](%d)): %w", len(modelSlice), e)
 		}
@@ -106,75 +276,420 @@ func (r *{{.Table.Name}}DAL) CreateMany(modelSlice []*models.{{.Table.Name}}) er
 		return nil 
 	}
 
-	chunkSize := 25
-	chunks := [][]*models.{{.Table.Name}}{}
+{{if .IsSharded}}	if groups := r.byShard(ctx, modelSlice); len(groups) > 1 {
+		for k := range groups {
+			if e = r.CreateManyContext(db.WithShardKey(ctx, groups[k][0].{{.ShardKey}}), groups[k]); e != nil {
+				return e
+			}
+		}
+		return nil
+	}
 
-	for i := 0; i < len(modelSlice); i += chunkSize {
-		end := i + chunkSize
-		if end > len(modelSlice) {
-			end = len(modelSlice)
+{{end}}	// Any failing BeforeCreate hook aborts the whole batch
+	if e = db.EachModel(ctx, modelSlice, db.BeforeCreate); e != nil {
+		return fmt.Errorf("{{.Table.Name}}CreateMany([](%d)): %w", len(modelSlice), e)
+	}
+
+{{if or .IsDateCreated .IsLastUpdated}}	now := time.Now().UnixNano() / 1000000
+
+{{end}}	// Records with a primary key are inserted with it, the others get auto-increment IDs
+	var rows, rowsWithID = [][]interface{}{}, [][]interface{}{}
+	var created = []*models.{{.Table.Name}}{}
+
+	for _, model := range modelSlice {
+{{- if .IsDateCreated}}
+		model.DateCreated = now{{end}}
+{{- if .IsLastUpdated}}
+		model.LastUpdated = now{{end}}
+
+		if {{if eq .IDType "string"}}len(model.{{.PrimaryKey}}) > 0{{else}}model.{{.PrimaryKey}} > 0{{end}} {
+			rowsWithID = append(rowsWithID, []interface{}{model.{{.PrimaryKey}}, {{.InsertArgs}}})
+			continue
 		}
-		chunks = append(chunks, modelSlice[i:end])
+
+		rows = append(rows, []interface{}{ {{.InsertArgs}} })
+		created = append(created, model)
 	}
 
-	for chunkID, chunk := range chunks {
+	e = db.Transaction(ctx, r.modelConn(ctx, modelSlice[0]), func(tx query.DBInterface) error {
+
+		if _, e := db.InsertMany(ctx, tx, "{{.InsertWithIDSQL}}", rowsWithID); e != nil {
+			return e
+		}
 
-		var tx *sql.Tx
-		ctx := context.Background()
-		tx, e = r.db[0].BeginTx(ctx, nil)
+		ids, e := db.InsertMany(ctx, tx, "{{.InsertSQL}}", rows)
 		if e != nil {
-			return fmt.Errorf("{{.Table.Name}}CreateMany([](%d)) (Chunk %d): %w", len(modelSlice), chunkID, e)
+			return e
+		}
+{{if eq .IDType "int64"}}
+		for k := range created {
+			created[k].{{.PrimaryKey}} = ids[k]
+		}
+{{else}}
+		_ = ids
+{{end}}
+		return nil
+	})
+
+	if e != nil {
+		r.log.Errorf("{{.Table.Name}}.CreateMany([](%d)) > %s", len(modelSlice), e.Error())
+		return fmt.Errorf("{{.Table.Name}}CreateMany([](%d)): %w", len(modelSlice), e)
+	}
+
+	for _, model := range modelSlice {
+		model.Snapshot()
+	}
+{{if .Audit}}
+	if r.audit != nil {
+		var records = make([]*db.AuditRecord, len(modelSlice))
+		for k, model := range modelSlice {
+			records[k] = r.auditRecord(ctx, db.AuditCreate, model, db.CreatedDiff(model))
+		}
+		if e = r.writeAudit(ctx, r.modelConn(ctx, modelSlice[0]), records...); e != nil {
+			return fmt.Errorf("{{.Table.Name}}CreateMany([](%d)): %w", len(modelSlice), e)
+		}
+	}
+{{end}}
+	if e = db.EachModel(ctx, modelSlice, db.AfterCreate); e != nil {
+		return fmt.Errorf("{{.Table.Name}}CreateMany([](%d)): %w", len(modelSlice), e)
+	}
+
+	return nil 
+}
+
+// CreateManyStream creates the {{.Table.Name}} objects produced by seq (see db.ChanSeq to read them from a channel)
+// with CreateMany, in chunks of chunkSize. Each chunk is created in a transaction of its own, so a failed chunk
+// leaves the ones before it in place. A chunkSize less than 1 is an errors.ArgumentError.
+func (r *{{.Table.Name}}DAL) CreateManyStream(seq iter.Seq[*models.{{.Table.Name}}], chunkSize int) error {
+	return r.CreateManyStreamContext(context.Background(), seq, chunkSize)
+}
+
+// CreateManyStreamContext is CreateManyStream with a context
+func (r *{{.Table.Name}}DAL) CreateManyStreamContext(ctx context.Context, seq iter.Seq[*models.{{.Table.Name}}], chunkSize int) error {
+
+	if chunkSize < 1 {
+		return fmt.Errorf("{{.Table.Name}}DAL.CreateManyStream(%d): %w", chunkSize, errors.NewArgumentError("the chunk size is less than 1"))
+	}
+
+	return db.Chunks(ctx, seq, chunkSize, func(chunk []*models.{{.Table.Name}}) error {
+		return r.CreateManyContext(ctx, chunk)
+	})
+}
+
+// BulkLoad creates {{.Table.Name}} objects with LOAD DATA LOCAL INFILE, which is much faster than CreateMany for very
+// large batches. The server must allow local_infile. LOAD DATA doesn't return the auto-increment IDs it assigns, so
+// every record must have its primary key set; one without it is an errors.ArgumentError and nothing is loaded.
+func (r *{{.Table.Name}}DAL) BulkLoad(modelSlice []*models.{{.Table.Name}}) error {
+	return r.BulkLoadContext(context.Background(), modelSlice)
+}
+
+// BulkLoadContext is BulkLoad with a context
+func (r *{{.Table.Name}}DAL) BulkLoadContext(ctx context.Context, modelSlice []*models.{{.Table.Name}}) error {
+
+{{if .IsSharded}}	if groups := r.byShard(ctx, modelSlice); len(groups) > 1 {
+		for k := range groups {
+			if e := r.BulkLoadContext(db.WithShardKey(ctx, groups[k][0].{{.ShardKey}}), groups[k]); e != nil {
+				return e
+			}
 		}
+		return nil
+	}
 
-		for insertID, model := range chunk {
+{{end}}	if len(modelSlice) == 0 {
+		return nil
+	}
+
+	for k, model := range modelSlice {
+		if {{if eq .IDType "string"}}len(model.{{.PrimaryKey}}) == 0{{else}}model.{{.PrimaryKey}} == 0{{end}} {
+			return fmt.Errorf("{{.Table.Name}}BulkLoad([](%d)): %w", len(modelSlice), errors.NewArgumentError(fmt.Sprintf("record %d has no {{.PrimaryKey}}", k)))
+		}
+	}
 
-			{{if .IsDateCreated}}
-			model.DateCreated = time.Now().UnixNano() / 1000000{{end}}
-			{{if .IsLastUpdated}}
-			model.LastUpdated = time.Now().UnixNano() / 1000000{{end}}
+	if e := db.EachModel(ctx, modelSlice, db.BeforeCreate); e != nil {
+		return fmt.Errorf("{{.Table.Name}}BulkLoad([](%d)): %w", len(modelSlice), e)
+	}
+{{if or .IsDateCreated .IsLastUpdated}}
+	now := time.Now().UnixNano() / 1000000
+{{end}}
+	var rows = make([][]interface{}, len(modelSlice))
+	for k, model := range modelSlice {
+{{- if .IsDateCreated}}
+		model.DateCreated = now{{end}}
+{{- if .IsLastUpdated}}
+		model.LastUpdated = now{{end}}
+		rows[k] = []interface{}{model.{{.PrimaryKey}}, {{.InsertArgs}}}
+	}
 
-			var result sql.Result 
-			result, e = tx.ExecContext(ctx, "{{.InsertSQL}}", {{.InsertArgs}})
-			if e != nil {
-				r.log.Errorf("{{.Table.Name}}.CreateMany([](%d)) (Chunk %d.%d) > %s", len(modelSlice), chunkID, insertID, e.Error())
-				break
-			} else {
-				// r.log.Debugf("{{.Table.Name}}.CreateMany([](%d)) (Chunk %d.%d)", len(modelSlice), chunkID, insertID)
-				model.{{.PrimaryKey}}, _ = result.LastInsertId()
+	_, e := db.LoadData(ctx, r.modelConn(ctx, modelSlice[0]), "{{.Table.Name}}", []string{"{{.PrimaryKey}}"{{range .InsertColumns}}, "{{.Name}}"{{end}}}, rows)
+	if e != nil {
+		r.log.Errorf("{{.Table.Name}}.BulkLoad([](%d)) > %s", len(modelSlice), e.Error())
+		return fmt.Errorf("{{.Table.Name}}BulkLoad([](%d)): %w", len(modelSlice), e)
+	}
+{{if .Audit}}
+	if r.audit != nil {
+		var records = make([]*db.AuditRecord, len(modelSlice))
+		for k, model := range modelSlice {
+			records[k] = r.auditRecord(ctx, db.AuditCreate, model, db.CreatedDiff(model))
+		}
+		if e = r.writeAudit(ctx, r.modelConn(ctx, modelSlice[0]), records...); e != nil {
+			return fmt.Errorf("{{.Table.Name}}BulkLoad([](%d)): %w", len(modelSlice), e)
+		}
+	}
+{{end}}
+	if e = db.EachModel(ctx, modelSlice, db.AfterCreate); e != nil {
+		return fmt.Errorf("{{.Table.Name}}BulkLoad([](%d)): %w", len(modelSlice), e)
+	}
+
+	return nil
+}
+
+{{range $upsert := .Upserts}}
+// UpsertBy{{$upsert.Name}} creates a {{$.Table.Name}} entry or, if one with the same {{$upsert.Fields}} exists, updates it. Either way
+// model.{{$.PrimaryKey}} is set to the primary key of the entry. Upserts run the BeforeCreate and AfterCreate hooks.
+func (r *{{$.Table.Name}}DAL) UpsertBy{{$upsert.Name}}(model *models.{{$.Table.Name}}) error {
+	return r.UpsertBy{{$upsert.Name}}Context(context.Background(), model)
+}
+
+// UpsertBy{{$upsert.Name}}Context is UpsertBy{{$upsert.Name}} with a context
+func (r *{{$.Table.Name}}DAL) UpsertBy{{$upsert.Name}}Context(ctx context.Context, model *models.{{$.Table.Name}}) error {
+
+	if e := db.BeforeCreate(ctx, model); e != nil {
+		return fmt.Errorf("{{$.Table.Name}}DAL.UpsertBy{{$upsert.Name}}: %w", e)
+	}
+
+	if e := r.upsertBy{{$upsert.Name}}(ctx, r.modelConn(ctx, model), model); e != nil {
+		r.log.Errorf("{{$.Table.Name}}DAL.UpsertBy{{$upsert.Name}} > %s", e.Error())
+		return fmt.Errorf("{{$.Table.Name}}DAL.UpsertBy{{$upsert.Name}}: %w", e)
+	}
+
+	if e := db.AfterCreate(ctx, model); e != nil {
+		return fmt.Errorf("{{$.Table.Name}}DAL.UpsertBy{{$upsert.Name}}: %w", e)
+	}
+
+	return nil
+}
+
+// UpsertManyBy{{$upsert.Name}} upserts {{$.Table.Name}} objects by {{$upsert.Fields}} in chunks (see UpsertBy{{$upsert.Name}})
+func (r *{{$.Table.Name}}DAL) UpsertManyBy{{$upsert.Name}}(modelSlice []*models.{{$.Table.Name}}) error {
+	return r.UpsertManyBy{{$upsert.Name}}Context(context.Background(), modelSlice)
+}
+
+// UpsertManyBy{{$upsert.Name}}Context is UpsertManyBy{{$upsert.Name}} with a context
+func (r *{{$.Table.Name}}DAL) UpsertManyBy{{$upsert.Name}}Context(ctx context.Context, modelSlice []*models.{{$.Table.Name}}) error {
+
+	if len(modelSlice) == 0 {
+		return nil
+	}
+
+{{if $.IsSharded}}	if groups := r.byShard(ctx, modelSlice); len(groups) > 1 {
+		for k := range groups {
+			if e := r.UpsertManyBy{{$upsert.Name}}Context(db.WithShardKey(ctx, groups[k][0].{{$.ShardKey}}), groups[k]); e != nil {
+				return e
 			}
 		}
+		return nil
+	}
 
-		if e != nil {
-			return fmt.Errorf("{{.Table.Name}}CreateMany([](%d)) (Chunk %d): %w", len(modelSlice), chunkID, e)
+{{end}}	// Any failing BeforeCreate hook aborts the whole batch
+	if e := db.EachModel(ctx, modelSlice, db.BeforeCreate); e != nil {
+		return fmt.Errorf("{{$.Table.Name}}DAL.UpsertManyBy{{$upsert.Name}}([](%d)): %w", len(modelSlice), e)
+	}
+
+	chunkSize := 25
+
+	for i := 0; i < len(modelSlice); i += chunkSize {
+		end := i + chunkSize
+		if end > len(modelSlice) {
+			end = len(modelSlice)
 		}
+		chunk := modelSlice[i:end]
+
+		e := db.Transaction(ctx, r.modelConn(ctx, modelSlice[0]), func(tx query.DBInterface) error {
+			for _, model := range chunk {
+				if e := r.upsertBy{{$upsert.Name}}(ctx, tx, model); e != nil {
+					return e
+				}
+			}
+			return nil
+		})
 
-		e = tx.Commit()
 		if e != nil {
-			return fmt.Errorf("{{.Table.Name}}CreateMany([](%d)) (Chunk %d Commit): %w", len(modelSlice), chunkID, e)
+			r.log.Errorf("{{$.Table.Name}}DAL.UpsertManyBy{{$upsert.Name}}([](%d)) (Chunk %d) > %s", len(modelSlice), i/chunkSize, e.Error())
+			return fmt.Errorf("{{$.Table.Name}}DAL.UpsertManyBy{{$upsert.Name}}([](%d)) (Chunk %d): %w", len(modelSlice), i/chunkSize, e)
+		}
+
+		if e = db.EachModel(ctx, chunk, db.AfterCreate); e != nil {
+			return fmt.Errorf("{{$.Table.Name}}DAL.UpsertManyBy{{$upsert.Name}}([](%d)) (Chunk %d): %w", len(modelSlice), i/chunkSize, e)
 		}
 	}
 
-	return nil 
+	return nil
+}
+
+// upsertBy{{$upsert.Name}} upserts model on conn and sets its primary key{{if $.VersionColumn}} and {{$.VersionColumn}}{{end}}{{if $.Audit}}, then audits the values written{{end}}
+func (r *{{$.Table.Name}}DAL) upsertBy{{$upsert.Name}}(ctx context.Context, conn query.DBInterface, model *models.{{$.Table.Name}}) error {
+{{- if $.IsDateCreated}}
+	model.DateCreated = time.Now().UnixNano() / 1000000{{end}}{{if $.IsLastUpdated}}
+	model.LastUpdated = time.Now().UnixNano() / 1000000{{end}}
+{{if $.UpsertReturning}}
+	if e := db.QueryRowContext(ctx, conn, {{printf "%q" $upsert.SQL}}, {{$.InsertArgs}}).Scan(&model.{{$.PrimaryKey}}{{if $.VersionColumn}}, &model.{{$.VersionColumn}}{{end}}); e != nil {
+		return e
+	}
+{{- else if $upsert.KeySQL}}
+	if _, e := db.ExecContext(ctx, conn, {{printf "%q" $upsert.SQL}}, {{$.InsertArgs}}); e != nil {
+		return e
+	}
+
+	if e := db.QueryRowContext(ctx, conn, {{printf "%q" $upsert.KeySQL}}, {{$upsert.KeyArgs}}).Scan(&model.{{$.PrimaryKey}}{{if $.VersionColumn}}, &model.{{$.VersionColumn}}{{end}}); e != nil {
+		return e
+	}
+{{- else}}
+	result, e := db.ExecContext(ctx, conn, {{printf "%q" $upsert.SQL}}, {{$.InsertArgs}})
+	if e != nil {
+		return e
+	}
+
+	if model.{{$.PrimaryKey}}, e = result.LastInsertId(); e != nil {
+		return e
+	}
+{{- if $upsert.VersionSQL}}
 
+	var affected int64
+	if affected, e = result.RowsAffected(); e != nil {
+		return e
+	}
+
+	// 2 rows affected means an existing row was updated, and its {{$.VersionColumn}} incremented
+	if affected == 2 {
+		if e = db.QueryRowContext(ctx, conn, {{printf "%q" $upsert.VersionSQL}}, model.{{$.PrimaryKey}}).Scan(&model.{{$.VersionColumn}}); e != nil {
+			return e
+		}
+	}
+{{- end}}
+{{- end}}
+
+	model.Snapshot()
+{{if $.Audit}}
+	return r.writeAudit(ctx, conn, r.auditRecord(ctx, db.AuditUpsert, model, db.CreatedDiff(model)))
+{{- else}}
+	return nil
+{{- end}}
+}
+{{end}}
+{{range $relation := .Relations}}
+// Load{{$relation.Name}} loads the {{$relation.Table}} {{if $relation.Many}}records{{else}}record{{end}} of each model with a single query, keyed by {{$.Table.Name}}.{{$relation.Column}}
+func (r *{{$.Table.Name}}DAL) Load{{$relation.Name}}(modelSlice []*models.{{$.Table.Name}}) (map[{{$relation.KeyType}}]{{if $relation.Many}}[]{{end}}*models.{{$relation.Table}}, error) {
+	return r.Load{{$relation.Name}}Context(context.Background(), modelSlice)
 }
 
+// Load{{$relation.Name}}Context is Load{{$relation.Name}} with a context
+func (r *{{$.Table.Name}}DAL) Load{{$relation.Name}}Context(ctx context.Context, modelSlice []*models.{{$.Table.Name}}) (map[{{$relation.KeyType}}]{{if $relation.Many}}[]{{end}}*models.{{$relation.Table}}, error) {
+	return models.{{$.Table.Name}}Slice(modelSlice).Load{{$relation.Name}}(ctx, r.conn())
+}
+{{end}}
 // Update updates an existing {{.Table.Name}} entry in the database
 func (r *{{.Table.Name}}DAL) Update(model *models.{{.Table.Name}}) error {
+	return r.UpdateContext(context.Background(), model)
+}
+
+// UpdateContext is Update with a context{{if .VersionColumn}}. If the record was changed since it was read (its
+// {{.VersionColumn}} no longer matches), an errors.ConcurrentModificationError is returned.{{end}}
+func (r *{{.Table.Name}}DAL) UpdateContext(ctx context.Context, model *models.{{.Table.Name}}) error {
 	var e error
+
+	if e = db.BeforeUpdate(ctx, model); e != nil {
+		return fmt.Errorf("{{.Table.Name}}DAL.Update(%d): %w", model.{{.PrimaryKey}}, e)
+	}
 {{if .IsLastUpdated}}
 	model.LastUpdated = time.Now().UnixNano() / 1000000{{end}}
-	_, e = r.db[0].Exec("{{.UpdateSQL}}", {{.UpdateArgs}})
+{{- if .Audit}}
+	diff := model.Diff()
+{{- end}}
+{{- if .VersionColumn}}
+	e = db.UpdateVersioned(ctx, r.modelConn(ctx, model), "{{.Table.Name}}", model.{{.PrimaryKey}}, "{{.UpdateSQL}}", {{.UpdateArgs}})
+{{- else}}
+	_, e = db.ExecContext(ctx, r.modelConn(ctx, model), "{{.UpdateSQL}}", {{.UpdateArgs}})
+{{- end}}
 	if e != nil {
 		r.log.Errorf("{{.Table.Name}}DAL.Update(%d) > %s", model.{{.PrimaryKey}}, e.Error())
 		return fmt.Errorf("{{.Table.Name}}DAL.Update(%d): %w", model.{{.PrimaryKey}}, e)
 	} else {
 		// r.log.Debugf("{{.Table.Name}}DAL.Update(%d)", model.{{.PrimaryKey}})
 	}
+
+	// Retried transactions update the model again from the same state
+	model.RestoreOnRollback(r.modelConn(ctx, model))
+{{- if .VersionColumn}}
+	model.{{.VersionColumn}}++
+{{- end}}
+	model.Snapshot()
+{{if .Audit}}
+	if e = r.writeAudit(ctx, r.modelConn(ctx, model), r.auditRecord(ctx, db.AuditUpdate, model, diff)); e != nil {
+		return fmt.Errorf("{{.Table.Name}}DAL.Update(%d): %w", model.{{.PrimaryKey}}, e)
+	}
+{{end}}
+	if e = db.AfterUpdate(ctx, model); e != nil {
+		return fmt.Errorf("{{.Table.Name}}DAL.Update(%d): %w", model.{{.PrimaryKey}}, e)
+	}
+
 	return nil
 }
 
+// UpdateFields updates the given columns of an existing {{.Table.Name}} entry in the database
+func (r *{{.Table.Name}}DAL) UpdateFields(model *models.{{.Table.Name}}, cols ...query.Column) error {
+	return r.UpdateFieldsContext(context.Background(), model, cols...)
+}
+
+// UpdateFieldsContext is UpdateFields with a context
+func (r *{{.Table.Name}}DAL) UpdateFieldsContext(ctx context.Context, model *models.{{.Table.Name}}, cols ...query.Column) error {
+
+	if len(cols) == 0 {
+		return nil
+	}
+
+	if e := db.BeforeUpdate(ctx, model); e != nil {
+		return fmt.Errorf("{{.Table.Name}}DAL.UpdateFields(%d, %v): %w", model.{{.PrimaryKey}}, cols, e)
+	}
+{{if .IsLastUpdated}}
+	model.LastUpdated = time.Now().UnixNano() / 1000000
+	cols = append(cols[:len(cols):len(cols)], models.{{.Table.Name}}_Column_LastUpdated)
+{{end}}{{if .Audit}}
+	diff := model.Diff().Only(cols...)
+{{end}}
+	if e := model.UpdateFieldsContext(ctx, r.modelConn(ctx, model), cols...); e != nil {
+		r.log.Errorf("{{.Table.Name}}DAL.UpdateFields(%d, %v) > %s", model.{{.PrimaryKey}}, cols, e.Error())
+		return fmt.Errorf("{{.Table.Name}}DAL.UpdateFields(%d, %v): %w", model.{{.PrimaryKey}}, cols, e)
+	}
+{{if .Audit}}
+	if e := r.writeAudit(ctx, r.modelConn(ctx, model), r.auditRecord(ctx, db.AuditUpdate, model, diff)); e != nil {
+		return fmt.Errorf("{{.Table.Name}}DAL.UpdateFields(%d, %v): %w", model.{{.PrimaryKey}}, cols, e)
+	}
+{{end}}
+	if e := db.AfterUpdate(ctx, model); e != nil {
+		return fmt.Errorf("{{.Table.Name}}DAL.UpdateFields(%d, %v): %w", model.{{.PrimaryKey}}, cols, e)
+	}
+
+	return nil
+}
+
+// Patch updates the columns of an existing {{.Table.Name}} entry that changed since it was read (see
+// models.{{.Table.Name}}.Changed)
+func (r *{{.Table.Name}}DAL) Patch(model *models.{{.Table.Name}}) error {
+	return r.PatchContext(context.Background(), model)
+}
+
+// PatchContext is Patch with a context
+func (r *{{.Table.Name}}DAL) PatchContext(ctx context.Context, model *models.{{.Table.Name}}) error {
+	return r.UpdateFieldsContext(ctx, model, model.Changed()...)
+}
+
 // UpdateMany updates a slice of {{.Table.Name}} objects in chunks
 func (r {{.Table.Name}}DAL) UpdateMany(modelSlice []*models.{{.Table.Name}}) error {
+	return r.UpdateManyContext(context.Background(), modelSlice)
+}
+
+// UpdateManyContext is UpdateMany with a context
+func (r {{.Table.Name}}DAL) UpdateManyContext(ctx context.Context, modelSlice []*models.{{.Table.Name}}) error {
 	var e error
 
 	// No records 
@@ -184,13 +699,27 @@ func (r {{.Table.Name}}DAL) UpdateMany(modelSlice []*models.{{.Table.Name}}) err
 
 	// Don't use a transaction if only a single value
 	if len(modelSlice) == 1 {
-		e = r.Update(modelSlice[0])
+		e = r.UpdateContext(ctx, modelSlice[0])
 		if e != nil { 
 			return fmt.Errorf("{{.Table.Name}}UpdateMany([](%d)): %w", len(modelSlice), e)
 		}
 		return nil
 	}
 
+{{if .IsSharded}}	if groups := r.byShard(ctx, modelSlice); len(groups) > 1 {
+		for k := range groups {
+			if e = r.UpdateManyContext(db.WithShardKey(ctx, groups[k][0].{{.ShardKey}}), groups[k]); e != nil {
+				return e
+			}
+		}
+		return nil
+	}
+
+{{end}}	// Any failing BeforeUpdate hook aborts the whole batch
+	if e = db.EachModel(ctx, modelSlice, db.BeforeUpdate); e != nil {
+		return fmt.Errorf("{{.Table.Name}}UpdateMany([](%d)): %w", len(modelSlice), e)
+	}
+
 	chunkSize := 25
 	chunks := [][]*models.{{.Table.Name}}{}
 
@@ -203,35 +732,56 @@ func (r {{.Table.Name}}DAL) UpdateMany(modelSlice []*models.{{.Table.Name}}) err
 	}
 
 	for chunkID, chunk := range chunks {
-
-		var tx *sql.Tx
-		ctx := context.Background()
-		tx, e = r.db[0].BeginTx(ctx, nil)
-		if e != nil {
-			return fmt.Errorf("{{.Table.Name}}UpdateMany([](%d)) (Chunk %d): %w", len(modelSlice), chunkID, e)
-		}
-
-		for updateID, model := range chunk {
+{{if .Audit}}
+		var records []*db.AuditRecord
+{{end}}
+		e = db.Transaction(ctx, r.modelConn(ctx, modelSlice[0]), func(tx query.DBInterface) error { 
+{{if .Audit}}
+			// The transaction is retried on deadlock
+			records = records[:0]
+{{end}}
+			for updateID, model := range chunk {
 {{if .IsLastUpdated}}
-			model.LastUpdated = time.Now().UnixNano() / 1000000{{end}}
+				model.LastUpdated = time.Now().UnixNano() / 1000000{{end}}
+{{- if .Audit}}
+				records = append(records, r.auditRecord(ctx, db.AuditUpdate, model, model.Diff()))
+{{- end}}
+
+{{- if .VersionColumn}}
+				if e := db.UpdateVersioned(ctx, tx, "{{.Table.Name}}", model.{{.PrimaryKey}}, "{{.UpdateSQL}}", {{.UpdateArgs}}); e != nil {
+{{- else}}
+				if _, e := db.ExecContext(ctx, tx, "{{.UpdateSQL}}", {{.UpdateArgs}}); e != nil {
+{{- end}}
+					r.log.Errorf("{{.Table.Name}}.UpdateMany([](%d)) (Chunk %d.%d) > %s", len(modelSlice), chunkID, updateID, e.Error())
+					return fmt.Errorf("{{.Table.Name}}UpdateMany([](%d)) (Chunk %d.%d): %w", len(modelSlice), chunkID, updateID, e)
+				}
+
+				// Retried transactions update the model again from the same state
+				model.RestoreOnRollback(tx)
+{{- if .VersionColumn}}
+				model.{{.VersionColumn}}++
+{{- end}}
+				model.Snapshot()
 
-			_, e = tx.ExecContext(ctx, "{{.UpdateSQL}}", {{.UpdateArgs}})
-			if e != nil {
-				r.log.Errorf("{{.Table.Name}}.UpdateMany([](%d)) (Chunk %d.%d) > %s", len(modelSlice), chunkID, updateID, e.Error())
-				return fmt.Errorf("{{.Table.Name}}UpdateMany([](%d)) (Chunk %d.%d): %w", len(modelSlice), chunkID, updateID, e)
-			} else {
 				// r.log.Debugf("{{.Table.Name}}.UpdateMany([](%d)) (Chunk %d.%d)", len(modelSlice), chunkID, updateID)
 			}
-		}
+
+			return nil 
+		})
 
 		if e != nil {
 			return fmt.Errorf("{{.Table.Name}}UpdateMany([](%d)) (Chunk %d): %w", len(modelSlice), chunkID, e)
 		}
 
-		e = tx.Commit()
+{{- if .Audit}}
 
-		if e != nil { 
-			return fmt.Errorf("{{.Table.Name}}UpdateMany([](%d)) (Chunk %d Commit): %w", len(modelSlice), chunkID, e)
+		if e = r.writeAudit(ctx, r.modelConn(ctx, modelSlice[0]), records...); e != nil {
+			return fmt.Errorf("{{.Table.Name}}UpdateMany([](%d)) (Chunk %d): %w", len(modelSlice), chunkID, e)
+		}
+{{- end}}
+
+		if e = db.EachModel(ctx, chunk, db.AfterUpdate); e != nil {
+			return fmt.Errorf("{{.Table.Name}}UpdateMany([](%d)) (Chunk %d): %w", len(modelSlice), chunkID, e)
 		}
 	}
 
@@ -241,19 +791,53 @@ func (r {{.Table.Name}}DAL) UpdateMany(modelSlice []*models.{{.Table.Name}}) err
 
 // Delete marks an existing {{.Table.Name}} entry in the database as deleted
 func (r *{{.Table.Name}}DAL) Delete({{.PrimaryKey | toArgName}} {{.IDType}}) error {
-	var e error
-	_, e = r.db[0].Exec("UPDATE ` + "`{{.Table.Name}}` SET `IsDeleted` = 1 WHERE `{{.PrimaryKey}}` = ?" + `", {{.PrimaryKey | toArgName}})
+	return r.DeleteContext(context.Background(), {{.PrimaryKey | toArgName}})
+}
+
+// DeleteContext is Delete with a context
+func (r *{{.Table.Name}}DAL) DeleteContext(ctx context.Context, {{.PrimaryKey | toArgName}} {{.IDType}}) error {
+	model, e := r.deletedModel(ctx, {{.PrimaryKey | toArgName}})
+	if e == nil {
+		e = db.BeforeDelete(ctx, model)
+	}
 	if e != nil {
+		return fmt.Errorf("{{.Table.Name}}DAL.Delete(%d): %w", {{.PrimaryKey | toArgName}}, e)
+	}
+
+	conns := r.shardsForID(ctx, {{.PrimaryKey | toArgName}})
+{{if .IsLastUpdated}}	lastUpdated := time.Now().UnixNano() / 1000000
+	e = db.ExecEach(ctx, conns, "UPDATE ` + "`{{.Table.Name}}` SET `IsDeleted` = 1, `LastUpdated` = ? WHERE `{{.PrimaryKey}}` = ?" + `", lastUpdated, {{.PrimaryKey | toArgName}})
+{{else}}	e = db.ExecEach(ctx, conns, "UPDATE ` + "`{{.Table.Name}}` SET `IsDeleted` = 1 WHERE `{{.PrimaryKey}}` = ?" + `", {{.PrimaryKey | toArgName}})
+{{end}}	if e != nil {
 		r.log.Errorf("{{.Table.Name}}DAL.Delete(%d) > %s", {{.PrimaryKey | toArgName}}, e.Error())
 		return fmt.Errorf("{{.Table.Name}}DAL.Delete(%d): %w", {{.PrimaryKey | toArgName}}, e)
 	} else {
 		// r.log.Debugf("{{.Table.Name}}DAL.Delete(%d)", {{.PrimaryKey | toArgName}})
 	}
+
+{{- if .Audit}}
+
+	if e = r.writeAudit(ctx, conns[0], r.auditRecord(ctx, db.AuditDelete, model, db.DeletedDiff(model))); e != nil {
+		return fmt.Errorf("{{.Table.Name}}DAL.Delete(%d): %w", {{.PrimaryKey | toArgName}}, e)
+	}
+{{- end}}
+
+	model.IsDeleted = 1{{if .IsLastUpdated}}
+	model.LastUpdated = lastUpdated{{end}}
+
+	if e = db.AfterDelete(ctx, model); e != nil {
+		return fmt.Errorf("{{.Table.Name}}DAL.Delete(%d): %w", {{.PrimaryKey | toArgName}}, e)
+	}
 	return nil
 }
 
 // DeleteMany marks {{.Table.Name}} objects in chunks as deleted
 func (r {{.Table.Name}}DAL) DeleteMany(modelSlice []*models.{{.Table.Name}}) error {
+	return r.DeleteManyContext(context.Background(), modelSlice)
+}
+
+// DeleteManyContext is DeleteMany with a context
+func (r {{.Table.Name}}DAL) DeleteManyContext(ctx context.Context, modelSlice []*models.{{.Table.Name}}) error {
 
 	var e error 
 
@@ -264,7 +848,7 @@ func (r {{.Table.Name}}DAL) DeleteMany(modelSlice []*models.{{.Table.Name}}) err
 
 	// Don't use a transaction if only a single value
 	if len(modelSlice) == 1 {
-		e = r.Delete(modelSlice[0].{{.PrimaryKey}})
+		e = r.DeleteContext(ctx, modelSlice[0].{{.PrimaryKey}})
 
 		if e != nil {
 			return fmt.Errorf("{{.Table.Name}}DeleteMany([](%d)): %w", len(modelSlice), e)
@@ -272,6 +856,20 @@ func (r {{.Table.Name}}DAL) DeleteMany(modelSlice []*models.{{.Table.Name}}) err
 		return nil
 	}
 
+{{if .IsSharded}}	if groups := r.byShard(ctx, modelSlice); len(groups) > 1 {
+		for k := range groups {
+			if e = r.DeleteManyContext(db.WithShardKey(ctx, groups[k][0].{{.ShardKey}}), groups[k]); e != nil {
+				return e
+			}
+		}
+		return nil
+	}
+
+{{end}}	// Any failing BeforeDelete hook aborts the whole batch
+	if e = db.EachModel(ctx, modelSlice, db.BeforeDelete); e != nil {
+		return fmt.Errorf("{{.Table.Name}}DeleteMany([](%d)): %w", len(modelSlice), e)
+	}
+
 	chunkSize := 25
 	chunks := [][]*models.{{.Table.Name}}{}
 
@@ -284,55 +882,192 @@ func (r {{.Table.Name}}DAL) DeleteMany(modelSlice []*models.{{.Table.Name}}) err
 	}
 
 	for chunkID, chunk := range chunks {
+{{if .IsLastUpdated}}
+		lastUpdated := time.Now().UnixNano() / 1000000
+{{end}}
+		e = db.Transaction(ctx, r.modelConn(ctx, modelSlice[0]), func(tx query.DBInterface) error { 
 
-		var tx *sql.Tx
-		ctx := context.Background()
-		tx, e = r.db[0].BeginTx(ctx, nil)
-		if e != nil {
-			return fmt.Errorf("{{.Table.Name}}DeleteMany([](%d)) (Chunk %d): %w", len(modelSlice), chunkID, e)
-		}
-
-		for deleteID, model := range chunk {
+			for deleteID, model := range chunk {
 {{if .IsLastUpdated}}
-			model.LastUpdated = time.Now().UnixNano() / 1000000{{end}}
-			_, e = tx.ExecContext(ctx, "UPDATE ` + "`{{.Table.Name}}` SET `IsDeleted`= 1 WHERE `{{.PrimaryKey}}` = ?" + `", model.{{.PrimaryKey}})
-			if e != nil {
-				r.log.Errorf("{{.Table.Name}}.DeleteMany([](%d)) (Chunk %d.%d) > %s", len(modelSlice), chunkID, deleteID, e.Error())
-				return fmt.Errorf("{{.Table.Name}}DeleteMany([](%d)) (Chunk %d.%d): %w", len(modelSlice), chunkID, deleteID, e)
-			} else {
+				if _, e := db.ExecContext(ctx, tx, "UPDATE ` + "`{{.Table.Name}}` SET `IsDeleted` = 1, `LastUpdated` = ? WHERE `{{.PrimaryKey}}` = ?" + `", lastUpdated, model.{{.PrimaryKey}}); e != nil {
+{{- else}}
+				if _, e := db.ExecContext(ctx, tx, "UPDATE ` + "`{{.Table.Name}}` SET `IsDeleted` = 1 WHERE `{{.PrimaryKey}}` = ?" + `", model.{{.PrimaryKey}}); e != nil {
+{{- end}}
+					r.log.Errorf("{{.Table.Name}}.DeleteMany([](%d)) (Chunk %d.%d) > %s", len(modelSlice), chunkID, deleteID, e.Error())
+					return fmt.Errorf("{{.Table.Name}}DeleteMany([](%d)) (Chunk %d.%d): %w", len(modelSlice), chunkID, deleteID, e)
+				}
+
 				// r.log.Debugf("{{.Table.Name}}.DeleteMany([](%d)) (Chunk %d.%d)", len(modelSlice), chunkID, deleteID)
 			}
-		}
+
+			return nil 
+		})
 
 		if e != nil {
 			return fmt.Errorf("{{.Table.Name}}DeleteMany([](%d)) (Chunk %d): %w", len(modelSlice), chunkID, e)
 		}
 
-		e = tx.Commit()
+{{- if .Audit}}
 
-		if e != nil { 
-			return fmt.Errorf("{{.Table.Name}}DeleteMany([](%d)) (Chunk %d Commit): %w", len(modelSlice), chunkID, e)
-		} 
+		if r.audit != nil {
+			var records = make([]*db.AuditRecord, len(chunk))
+			for k, model := range chunk {
+				records[k] = r.auditRecord(ctx, db.AuditDelete, model, db.DeletedDiff(model))
+			}
+			if e = r.writeAudit(ctx, r.modelConn(ctx, modelSlice[0]), records...); e != nil {
+				return fmt.Errorf("{{.Table.Name}}DeleteMany([](%d)) (Chunk %d): %w", len(modelSlice), chunkID, e)
+			}
+		}
+{{- end}}
+
+		for _, model := range chunk {
+			model.IsDeleted = 1{{if .IsLastUpdated}}
+			model.LastUpdated = lastUpdated{{end}}
+		}
+
+		if e = db.EachModel(ctx, chunk, db.AfterDelete); e != nil {
+			return fmt.Errorf("{{.Table.Name}}DeleteMany([](%d)) (Chunk %d): %w", len(modelSlice), chunkID, e)
+		}
 	}
 
 	return nil
 
 }{{end}}
 
-// DeleteHard performs a SQL DELETE operation on a {{.Table.Name}} entry in the database
+{{if .IsDeleted}}// Restore unmarks a deleted {{.Table.Name}} entry in the database
+func (r *{{.Table.Name}}DAL) Restore({{.PrimaryKey | toArgName}} {{.IDType}}) error {
+	return r.RestoreContext(context.Background(), {{.PrimaryKey | toArgName}})
+}
+
+// RestoreContext is Restore with a context
+func (r *{{.Table.Name}}DAL) RestoreContext(ctx context.Context, {{.PrimaryKey | toArgName}} {{.IDType}}) error {
+	model, e := r.updatedModel(ctx, {{.PrimaryKey | toArgName}})
+	if e == nil {
+		e = db.BeforeUpdate(ctx, model)
+	}
+	if e != nil {
+		return fmt.Errorf("{{.Table.Name}}DAL.Restore(%d): %w", {{.PrimaryKey | toArgName}}, e)
+	}
+{{if .Audit}}
+	diff := db.Diff{string(models.{{.Table.Name}}_Column_IsDeleted): &db.Change{Before: model.IsDeleted, After: 0}}
+{{end}}
+	conns := r.shardsForID(ctx, {{.PrimaryKey | toArgName}})
+{{if .IsLastUpdated}}	lastUpdated := time.Now().UnixNano() / 1000000
+	e = db.ExecEach(ctx, conns, "UPDATE ` + "`{{.Table.Name}}` SET `IsDeleted` = 0, `LastUpdated` = ? WHERE `{{.PrimaryKey}}` = ?" + `", lastUpdated, {{.PrimaryKey | toArgName}})
+{{else}}	e = db.ExecEach(ctx, conns, "UPDATE ` + "`{{.Table.Name}}` SET `IsDeleted` = 0 WHERE `{{.PrimaryKey}}` = ?" + `", {{.PrimaryKey | toArgName}})
+{{end}}
+	if e != nil {
+		r.log.Errorf("{{.Table.Name}}DAL.Restore(%d) > %s", {{.PrimaryKey | toArgName}}, e.Error())
+		return fmt.Errorf("{{.Table.Name}}DAL.Restore(%d): %w", {{.PrimaryKey | toArgName}}, e)
+	}
+
+	model.IsDeleted = 0{{if .IsLastUpdated}}
+	model.LastUpdated = lastUpdated{{end}}
+{{if .Audit}}
+	if e = r.writeAudit(ctx, conns[0], r.auditRecord(ctx, db.AuditUpdate, model, diff)); e != nil {
+		return fmt.Errorf("{{.Table.Name}}DAL.Restore(%d): %w", {{.PrimaryKey | toArgName}}, e)
+	}
+{{end}}
+	if e = db.AfterUpdate(ctx, model); e != nil {
+		return fmt.Errorf("{{.Table.Name}}DAL.Restore(%d): %w", {{.PrimaryKey | toArgName}}, e)
+	}
+	return nil
+}
+
+{{end}}// DeleteHard performs a SQL DELETE operation on a {{.Table.Name}} entry in the database
 func (r *{{.Table.Name}}DAL) DeleteHard({{.PrimaryKey | toArgName}} {{.IDType}}) error {
-	_, e := r.db[0].Exec("DELETE FROM ` + "`{{.Table.Name}}`" + ` WHERE {{.PrimaryKey}} = ?", {{.PrimaryKey | toArgName}})
+	return r.DeleteHardContext(context.Background(), {{.PrimaryKey | toArgName}})
+}
+
+// DeleteHardContext is DeleteHard with a context
+func (r *{{.Table.Name}}DAL) DeleteHardContext(ctx context.Context, {{.PrimaryKey | toArgName}} {{.IDType}}) error {
+	model, e := r.deletedModel(ctx, {{.PrimaryKey | toArgName}})
+	if e == nil {
+		e = db.BeforeDelete(ctx, model)
+	}
+	if e != nil {
+		return fmt.Errorf("{{.Table.Name}}DAL.HardDelete(%d): %w", {{.PrimaryKey | toArgName}}, e)
+	}
+
+	conns := r.shardsForID(ctx, {{.PrimaryKey | toArgName}})
+	e = db.ExecEach(ctx, conns, "DELETE FROM ` + "`{{.Table.Name}}`" + ` WHERE ` + "`{{.PrimaryKey}}` = ?" + `", {{.PrimaryKey | toArgName}})
 	if e != nil {
 		r.log.Errorf("{{.Table.Name}}DAL.HardDelete(%d) > %s", {{.PrimaryKey | toArgName}}, e.Error())
 		return fmt.Errorf("{{.Table.Name}}DAL.HardDelete(%d): %w", {{.PrimaryKey | toArgName}}, e)
 	} else {
 		// r.log.Debugf("{{.Table.Name}}DAL.HardDelete(%d)", {{.PrimaryKey | toArgName}})
 	}
+
+{{- if .Audit}}
+
+	if e = r.writeAudit(ctx, conns[0], r.auditRecord(ctx, db.AuditDelete, model, db.DeletedDiff(model))); e != nil {
+		return fmt.Errorf("{{.Table.Name}}DAL.HardDelete(%d): %w", {{.PrimaryKey | toArgName}}, e)
+	}
+{{- end}}
+
+	if e = db.AfterDelete(ctx, model); e != nil {
+		return fmt.Errorf("{{.Table.Name}}DAL.HardDelete(%d): %w", {{.PrimaryKey | toArgName}}, e)
+	}
 	return nil
 }
 
+// deletedModel returns the model the delete hooks run on{{if .Audit}}, and whose values are audited,{{end}} when deleting by
+// primary key. If models.{{.Table.Name}} has delete hooks (see db.HasDeleteHooks){{if .Audit}} or the DAL has an auditor{{end}} the record is
+// read, otherwise (or if there is no record) only its primary key is set.
+func (r *{{.Table.Name}}DAL) deletedModel(ctx context.Context, {{.PrimaryKey | toArgName}} {{.IDType}}) (*models.{{.Table.Name}}, error) {
+
+	var model = &models.{{.Table.Name}}{ {{.PrimaryKey}}: {{.PrimaryKey | toArgName}} }
+	if !db.HasDeleteHooks(model){{if .Audit}} && r.audit == nil{{end}} {
+		return model, nil
+	}
+
+	record, e := r.{{if .IsDeleted}}fromIDWithDeleted(ctx, {{.PrimaryKey | toArgName}}){{else}}FromIDContext(ctx, {{.PrimaryKey | toArgName}}, false){{end}}
+	if e != nil || record == nil {
+		return model, e
+	}
+
+	return record, nil
+}
+
+// updatedModel returns the model the update hooks run on{{if .Audit}}, and whose values are audited,{{end}} when updating by primary key
+// (see Set<Col>{{if .IsDeleted}} and Restore{{end}}). If models.{{.Table.Name}} has update hooks (see db.HasUpdateHooks){{if .Audit}} or the DAL has an
+// auditor{{end}} the record is read, otherwise (or if there is no record) only its primary key is set.
+func (r *{{.Table.Name}}DAL) updatedModel(ctx context.Context, {{.PrimaryKey | toArgName}} {{.IDType}}) (*models.{{.Table.Name}}, error) {
+
+	var model = &models.{{.Table.Name}}{ {{.PrimaryKey}}: {{.PrimaryKey | toArgName}} }
+	if !db.HasUpdateHooks(model){{if .Audit}} && r.audit == nil{{end}} {
+		return model, nil
+	}
+
+	record, e := r.{{if .IsDeleted}}fromIDWithDeleted(ctx, {{.PrimaryKey | toArgName}}){{else}}FromIDContext(ctx, {{.PrimaryKey | toArgName}}, false){{end}}
+	if e != nil || record == nil {
+		return model, e
+	}
+
+	return record, nil
+}
+{{if .IsDeleted}}
+// fromIDWithDeleted reads the record with the primary key whether or not it is deleted, or returns nil if there is none
+func (r *{{.Table.Name}}DAL) fromIDWithDeleted(ctx context.Context, {{.PrimaryKey | toArgName}} {{.IDType}}) (*models.{{.Table.Name}}, error) {
+
+	model, e := (&models.{{.Table.Name}}{}).Get(r.conn()){{if .IsSharded}}.OnShards(func(ctx context.Context) []query.DBInterface { 
+		return r.shardsForID(ctx, {{.PrimaryKey | toArgName}})
+	}){{end}}.WithDeleted().Filter(db.EQ(models.{{.Table.Name}}_Column_{{.PrimaryKey}}, {{.PrimaryKey | toArgName}})).RunContext(ctx)
+
+	if e == sql.ErrNoRows {
+		return nil, nil
+	}
+
+	return model, e
+}
+{{end}}
 // DeleteManyHard deletes {{.Table.Name}} objects in chunks
 func (r {{.Table.Name}}DAL) DeleteManyHard(modelSlice []*models.{{.Table.Name}}) error {
+	return r.DeleteManyHardContext(context.Background(), modelSlice)
+}
+
+// DeleteManyHardContext is DeleteManyHard with a context
+func (r {{.Table.Name}}DAL) DeleteManyHardContext(ctx context.Context, modelSlice []*models.{{.Table.Name}}) error {
 
 	var e error
 	// No records 
@@ -342,13 +1077,27 @@ func (r {{.Table.Name}}DAL) DeleteManyHard(modelSlice []*models.{{.Table.Name}})
 
 	// Don't use a transaction if only a single value
 	if len(modelSlice) == 1 {
-		e = r.DeleteHard(modelSlice[0].{{.PrimaryKey}})
+		e = r.DeleteHardContext(ctx, modelSlice[0].{{.PrimaryKey}})
 		if e != nil {
 			return fmt.Errorf("{{.Table.Name}}DeleteManyHard([](%d)): %w", len(modelSlice), e)
 		}	
 		return nil
 	}
 
+{{if .IsSharded}}	if groups := r.byShard(ctx, modelSlice); len(groups) > 1 {
+		for k := range groups {
+			if e = r.DeleteManyHardContext(db.WithShardKey(ctx, groups[k][0].{{.ShardKey}}), groups[k]); e != nil {
+				return e
+			}
+		}
+		return nil
+	}
+
+{{end}}	// Any failing BeforeDelete hook aborts the whole batch
+	if e = db.EachModel(ctx, modelSlice, db.BeforeDelete); e != nil {
+		return fmt.Errorf("{{.Table.Name}}DeleteManyHard([](%d)): %w", len(modelSlice), e)
+	}
+
 	chunkSize := 25
 	chunks := [][]*models.{{.Table.Name}}{}
 
@@ -362,32 +1111,40 @@ func (r {{.Table.Name}}DAL) DeleteManyHard(modelSlice []*models.{{.Table.Name}})
 
 	for chunkID, chunk := range chunks {
 
-		var tx *sql.Tx
-		ctx := context.Background()
-		tx, e = r.db[0].BeginTx(ctx, nil)
-		if e != nil {
-			return fmt.Errorf("{{.Table.Name}}DeleteManyHard([](%d)) (Chunk %d): %w", len(modelSlice), chunkID, e)
-		}
+		e = db.Transaction(ctx, r.modelConn(ctx, modelSlice[0]), func(tx query.DBInterface) error { 
+
+			for deleteID, model := range chunk {
 
-		for deleteID, model := range chunk {
+				if _, e := db.ExecContext(ctx, tx, "DELETE FROM ` + "`{{.Table.Name}}` WHERE `{{.PrimaryKey}}` = ?" + `", model.{{.PrimaryKey}}); e != nil {
+					r.log.Errorf("{{.Table.Name}}.DeleteManyHard([](%d)) (Chunk %d.%d) > %s", len(modelSlice), chunkID, deleteID, e.Error())
+					return e
+				}
 
-			_, e = tx.ExecContext(ctx, "DELETE FROM ` + "`{{.Table.Name}}` WHERE `{{.PrimaryKey}}` = ?" + `", model.{{.PrimaryKey}})
-			if e != nil {
-				r.log.Errorf("{{.Table.Name}}.DeleteManyHard([](%d)) (Chunk %d.%d) > %s", len(modelSlice), chunkID, deleteID, e.Error())
-				break
-			} else {
 				// r.log.Debugf("{{.Table.Name}}.DeleteManyHard([](%d)) (Chunk %d.%d)", len(modelSlice), chunkID, deleteID)
 			}
-		}
+
+			return nil 
+		})
 
 		if e != nil {
 			return fmt.Errorf("{{.Table.Name}}DeleteManyHard([](%d)) (Chunk %d): %w", len(modelSlice), chunkID, e)
 		}
 
-		e = tx.Commit()
+{{- if .Audit}}
 
-		if e != nil {
-			return fmt.Errorf("{{.Table.Name}}DeleteManyHard([](%d)) (Chunk %d Commit): %w", len(modelSlice), chunkID, e)
+		if r.audit != nil {
+			var records = make([]*db.AuditRecord, len(chunk))
+			for k, model := range chunk {
+				records[k] = r.auditRecord(ctx, db.AuditDelete, model, db.DeletedDiff(model))
+			}
+			if e = r.writeAudit(ctx, r.modelConn(ctx, modelSlice[0]), records...); e != nil {
+				return fmt.Errorf("{{.Table.Name}}DeleteManyHard([](%d)) (Chunk %d): %w", len(modelSlice), chunkID, e)
+			}
+		}
+{{- end}}
+
+		if e = db.EachModel(ctx, chunk, db.AfterDelete); e != nil {
+			return fmt.Errorf("{{.Table.Name}}DeleteManyHard([](%d)) (Chunk %d): %w", len(modelSlice), chunkID, e)
 		}
 	}
 
@@ -396,8 +1153,15 @@ func (r {{.Table.Name}}DAL) DeleteManyHard(modelSlice []*models.{{.Table.Name}})
 
 // FromID gets a single {{.Table.Name}} object by its Primary Key
 func (r *{{.Table.Name}}DAL) FromID({{.PrimaryKey | toArgName}} {{.IDType}}, mustExist bool) (*models.{{.Table.Name}}, error) {
+	return r.FromIDContext(context.Background(), {{.PrimaryKey | toArgName}}, mustExist)
+}
+
+// FromIDContext is FromID with a context
+func (r *{{.Table.Name}}DAL) FromIDContext(ctx context.Context, {{.PrimaryKey | toArgName}} {{.IDType}}, mustExist bool) (*models.{{.Table.Name}}, error) {
 
-	model, e := (&models.{{.Table.Name}}{}).Get(r.db[0]).Where(query.EQ(models.{{.Table.Name}}_Column_{{.PrimaryKey}}, {{.PrimaryKey | toArgName}})).Run()
+	model, e := (&models.{{.Table.Name}}{}).Get(r.conn()){{if .IsSharded}}.OnShards(func(ctx context.Context) []query.DBInterface { 
+		return r.shardsForID(ctx, {{.PrimaryKey | toArgName}})
+	}){{end}}.Filter(db.EQ(models.{{.Table.Name}}_Column_{{.PrimaryKey}}, {{.PrimaryKey | toArgName}})).RunContext(ctx)
 
 	if model == nil {
 		if mustExist { 
@@ -419,10 +1183,6 @@ func (r *{{.Table.Name}}DAL) FromID({{.PrimaryKey | toArgName}} {{.IDType}}, mus
 		return nil, nil
 	case nil: 
 
-		{{ if .IsDeleted}}if model.IsDeleted == 1 && mustExist { 
-			return nil, errors.NewRecordNotFoundError()
-		}{{end}}
-
 		// r.log.Debugf("{{.Table.Name}}DAL.FromID(%d)", model.{{.PrimaryKey}})
 		return model, nil 
 
@@ -434,15 +1194,20 @@ func (r *{{.Table.Name}}DAL) FromID({{.PrimaryKey | toArgName}} {{.IDType}}, mus
 
 // FromIDs returns a slice of {{.Table.Name}} objects by a set of primary keys
 func (r *{{.Table.Name}}DAL) FromIDs({{.PrimaryKey | toArgName}}s []{{.IDType}}) ([]*models.{{.Table.Name}}, error) {
+	return r.FromIDsContext(context.Background(), {{.PrimaryKey | toArgName}}s)
+}
+
+// FromIDsContext is FromIDs with a context
+func (r *{{.Table.Name}}DAL) FromIDsContext(ctx context.Context, {{.PrimaryKey | toArgName}}s []{{.IDType}}) ([]*models.{{.Table.Name}}, error) {
 
 	// No records 
 	if len({{.PrimaryKey | toArgName}}s) == 0 {
 		return []*models.{{.Table.Name}}{}, nil 
 	}
 
-	model, e := (&models.{{.Table.Name}}{}).Select(r.db[0]).Where(
-		query.INInt64(models.{{.Table.Name}}_Column_{{.PrimaryKey}}, {{.PrimaryKey | toArgName}}s...),
-	).Run()
+	model, e := r.Select().Filter(
+		db.INInt64(models.{{.Table.Name}}_Column_{{.PrimaryKey}}, {{.PrimaryKey | toArgName}}s...),
+	).RunContext(ctx)
 
 	if e != nil {
 		r.log.Errorf("{{.Table.Name}}DAL.FromIDs(%v) > %s", {{.PrimaryKey | toArgName}}s, e.Error())
@@ -456,9 +1221,14 @@ func (r *{{.Table.Name}}DAL) FromIDs({{.PrimaryKey | toArgName}}s []{{.IDType}})
 
 // FromIDsMap returns a map of {{.Table.Name}} objects from primary keys indexed by a set of primary keys
 func (r *{{.Table.Name}}DAL) FromIDsMap({{.PrimaryKey | toArgName}}s []{{.IDType}}) (map[{{.IDType}}]*models.{{.Table.Name}}, error) {
+	return r.FromIDsMapContext(context.Background(), {{.PrimaryKey | toArgName}}s)
+}
 
+// FromIDsMapContext is FromIDsMap with a context
+func (r *{{.Table.Name}}DAL) FromIDsMapContext(ctx context.Context, {{.PrimaryKey | toArgName}}s []{{.IDType}}) (map[{{.IDType}}]*models.{{.Table.Name}}, error) {
 
-	model, e := r.FromIDs({{.PrimaryKey | toArgName}}s)
+
+	model, e := r.FromIDsContext(ctx, {{.PrimaryKey | toArgName}}s)
 	if e != nil { 
 		return map[{{.IDType}}]*models.{{.Table.Name}}{}, fmt.Errorf("{{.Table.Name}}DAL.FromIDsMap(%v): %w", {{.PrimaryKey | toArgName}}s, e)
 	}
@@ -479,9 +1249,19 @@ func (r *{{.Table.Name}}DAL) FromIDsMap({{.PrimaryKey | toArgName}}s []{{.IDType
 
 // Get{{$col.Name}} gets the {{$col.Name}} column on a {{$.Table.Name}} object
 func (r *{{$.Table.Name}}DAL) Get{{$col.Name}}({{$.PrimaryKey | toArgName}} {{$.IDType}}) ([]float64, error) {
+	return r.Get{{$col.Name}}Context(context.Background(), {{$.PrimaryKey | toArgName}})
+}
+
+// Get{{$col.Name}}Context is Get{{$col.Name}} with a context
+func (r *{{$.Table.Name}}DAL) Get{{$col.Name}}Context(ctx context.Context, {{$.PrimaryKey | toArgName}} {{$.IDType}}) ([]float64, error) {
 	
 	var vectorString null.String
-	err := r.db[0].QueryRow("SELECT VEC_ToText(` + "`{{.Name}}`) AS `{{.Name}}` FROM " + "`{{$.Table.Name}}`" + ` WHERE ` + "`{{$.PrimaryKey}}` = ?" + `", {{$.PrimaryKey | toArgName}}).Scan(&vectorString)
+	var err error
+	for _, conn := range r.shardsForID(ctx, {{$.PrimaryKey | toArgName}}) { 
+		if err = db.QueryRowContext(ctx, db.ReadConn(ctx, conn), "SELECT VEC_ToText(` + "`{{.Name}}`) AS `{{.Name}}` FROM " + "`{{$.Table.Name}}`" + ` WHERE ` + "`{{$.PrimaryKey}}` = ?" + `", {{$.PrimaryKey | toArgName}}).Scan(&vectorString); err != sql.ErrNoRows { 
+			break
+		}
+	}
 	if err != nil {
 		r.log.Errorf("{{$.Table.Name}}DAL.Get{{$col.Name}}(%d) > %s", {{$.PrimaryKey | toArgName}}, err.Error())
 		return nil, fmt.Errorf("{{$.Table.Name}}DAL.Get{{$col.Name}}(%d): %w", {{$.PrimaryKey | toArgName}}, err)
@@ -518,6 +1298,11 @@ func (r *{{$.Table.Name}}DAL) Get{{$col.Name}}({{$.PrimaryKey | toArgName}} {{$.
 
 // Set{{$col.Name}} sets the {{$col.Name}} column on a {{$.Table.Name}} object
 func (r *{{$.Table.Name}}DAL) Set{{$col.Name}}Vector({{$.PrimaryKey | toArgName}} {{$.IDType}}, {{$col.Name | toArgName}} []float64) error {
+	return r.Set{{$col.Name}}VectorContext(context.Background(), {{$.PrimaryKey | toArgName}}, {{$col.Name | toArgName}})
+}
+
+// Set{{$col.Name}}VectorContext is Set{{$col.Name}}Vector with a context
+func (r *{{$.Table.Name}}DAL) Set{{$col.Name}}VectorContext(ctx context.Context, {{$.PrimaryKey | toArgName}} {{$.IDType}}, {{$col.Name | toArgName}} []float64) error {
 	
 	stringSlice := make([]string, 0, len({{$col.Name | toArgName}}))
 
@@ -531,13 +1316,31 @@ func (r *{{$.Table.Name}}DAL) Set{{$col.Name}}Vector({{$.PrimaryKey | toArgName}
 	// 4. Join the string slice into a single string with a delimiter (e.g., a comma and space)
 	result := "[" + strings.Join(stringSlice, ", ") + "]"
 
-	_, e := r.db[0].Exec("UPDATE ` + "`{{$.Table.Name}}` SET `{{$col.Name}}` = VEC_FromText(?) WHERE `{{$.PrimaryKey}}` = ?" + `", result, {{$.PrimaryKey | toArgName}})
+	model, e := r.updatedModel(ctx, {{$.PrimaryKey | toArgName}})
+	if e == nil {
+		model.{{$col.Name}} = result
+		e = db.BeforeUpdate(ctx, model)
+	}
+	if e != nil {
+		return fmt.Errorf("{{$.Table.Name}}DAL.Set{{$col.Name}}(%d, %v): %w", {{$.PrimaryKey | toArgName}}, {{$col.Name | toArgName}}, e)
+	}
+
+	conns := r.shardsForID(ctx, {{$.PrimaryKey | toArgName}})
+	e = db.ExecEach(ctx, conns, "UPDATE ` + "`{{$.Table.Name}}` SET `{{$col.Name}}` = VEC_FromText(?){{if $.VersionColumn}}, `{{$.VersionColumn}}` = `{{$.VersionColumn}}` + 1{{end}} WHERE `{{$.PrimaryKey}}` = ?" + `", model.{{$col.Name}}, {{$.PrimaryKey | toArgName}})
 	if e != nil {
 		r.log.Errorf("{{$.Table.Name}}DAL.Set{{$col.Name}}(%d, %v) > %s", {{$.PrimaryKey | toArgName}}, {{$col.Name | toArgName}}, e.Error())
 		return fmt.Errorf("{{$.Table.Name}}DAL.Set{{$col.Name}}(%d, %v): %w", {{$.PrimaryKey | toArgName}}, {{$col.Name | toArgName}}, e)
 	} else {
 		// r.log.Debugf("{{$.Table.Name}}DAL.Set{{$col.Name}}(%d, %v)", {{$.PrimaryKey | toArgName}}, {{$col.Name | toArgName}})
 	}
+{{if $.Audit}}
+	if e = r.writeAudit(ctx, conns[0], r.auditRecord(ctx, db.AuditUpdate, model, model.Diff().Only(models.{{$.Table.Name}}_Column_{{$col.Name}}))); e != nil {
+		return fmt.Errorf("{{$.Table.Name}}DAL.Set{{$col.Name}}(%d, %v): %w", {{$.PrimaryKey | toArgName}}, {{$col.Name | toArgName}}, e)
+	}
+{{end}}
+	if e = db.AfterUpdate(ctx, model); e != nil {
+		return fmt.Errorf("{{$.Table.Name}}DAL.Set{{$col.Name}}(%d, %v): %w", {{$.PrimaryKey | toArgName}}, {{$col.Name | toArgName}}, e)
+	}
 	return nil 
 }
 {{end}}
@@ -546,25 +1349,50 @@ func (r *{{$.Table.Name}}DAL) Set{{$col.Name}}Vector({{$.PrimaryKey | toArgName}
 {{range $col := .UpdateColumns}}
 // Set{{$col.Name}} sets the {{$col.Name}} column on a {{$.Table.Name}} object
 func (r *{{$.Table.Name}}DAL) Set{{$col.Name}}({{$.PrimaryKey | toArgName}} {{$.IDType}}, {{$col.Name | toArgName}} {{$col | dataTypeToGoTypeString}}) error {
-	_, e := r.db[0].Exec("UPDATE ` + "`{{$.Table.Name}}` SET `{{$col.Name}}` = ? WHERE `{{$.PrimaryKey}}` = ?" + `", {{$col.Name | toArgName}}, {{$.PrimaryKey | toArgName}})
+	return r.Set{{$col.Name}}Context(context.Background(), {{$.PrimaryKey | toArgName}}, {{$col.Name | toArgName}})
+}
+
+// Set{{$col.Name}}Context is Set{{$col.Name}} with a context
+func (r *{{$.Table.Name}}DAL) Set{{$col.Name}}Context(ctx context.Context, {{$.PrimaryKey | toArgName}} {{$.IDType}}, {{$col.Name | toArgName}} {{$col | dataTypeToGoTypeString}}) error {
+	model, e := r.updatedModel(ctx, {{$.PrimaryKey | toArgName}})
+	if e == nil {
+		model.{{$col.Name}} = {{$col.Name | toArgName}}
+		e = db.BeforeUpdate(ctx, model)
+	}
+	if e != nil {
+		return fmt.Errorf("{{$.Table.Name}}DAL.Set{{$col.Name}}(%d, %v): %w", {{$.PrimaryKey | toArgName}}, {{$col.Name | toArgName}}, e)
+	}
+
+	conns := r.shardsForID(ctx, {{$.PrimaryKey | toArgName}})
+	e = db.ExecEach(ctx, conns, "UPDATE ` + "`{{$.Table.Name}}` SET `{{$col.Name}}` = ?{{if and $.VersionColumn (ne $col.Name $.VersionColumn)}}, `{{$.VersionColumn}}` = `{{$.VersionColumn}}` + 1{{end}} WHERE `{{$.PrimaryKey}}` = ?" + `", model.{{$col.Name}}, {{$.PrimaryKey | toArgName}})
 	if e != nil {
 		r.log.Errorf("{{$.Table.Name}}DAL.Set{{$col.Name}}(%d, %v) > %s", {{$.PrimaryKey | toArgName}}, {{$col.Name | toArgName}}, e.Error())
 		return fmt.Errorf("{{$.Table.Name}}DAL.Set{{$col.Name}}(%d, %v): %w", {{$.PrimaryKey | toArgName}}, {{$col.Name | toArgName}}, e)
 	} else {
 		// r.log.Debugf("{{$.Table.Name}}DAL.Set{{$col.Name}}(%d, %v)", {{$.PrimaryKey | toArgName}}, {{$col.Name | toArgName}})
 	}
+{{if $.Audit}}
+	if e = r.writeAudit(ctx, conns[0], r.auditRecord(ctx, db.AuditUpdate, model, model.Diff().Only(models.{{$.Table.Name}}_Column_{{$col.Name}}))); e != nil {
+		return fmt.Errorf("{{$.Table.Name}}DAL.Set{{$col.Name}}(%d, %v): %w", {{$.PrimaryKey | toArgName}}, {{$col.Name | toArgName}}, e)
+	}
+{{end}}
+	if e = db.AfterUpdate(ctx, model); e != nil {
+		return fmt.Errorf("{{$.Table.Name}}DAL.Set{{$col.Name}}(%d, %v): %w", {{$.PrimaryKey | toArgName}}, {{$col.Name | toArgName}}, e)
+	}
 	return nil 
 }
 
 // ManyFrom{{$col.Name}} returns a slice of {{$.Table.Name}} models from {{$col.Name}}
 func (r *{{$.Table.Name}}DAL) ManyFrom{{$col.Name}}({{$col.Name | toArgName}} {{$col | dataTypeToGoTypeString}}, limit, offset int64, orderBy, orderDir string) ([]*models.{{$.Table.Name}}, error) {
+	return r.ManyFrom{{$col.Name}}Context(context.Background(), {{$col.Name | toArgName}}, limit, offset, orderBy, orderDir)
+}
+
+// ManyFrom{{$col.Name}}Context is ManyFrom{{$col.Name}} with a context
+func (r *{{$.Table.Name}}DAL) ManyFrom{{$col.Name}}Context(ctx context.Context, {{$col.Name | toArgName}} {{$col | dataTypeToGoTypeString}}, limit, offset int64, orderBy, orderDir string) ([]*models.{{$.Table.Name}}, error) {
 	
-	q := (&models.{{$.Table.Name}}{}).Select(r.db[0]).Where(
-		query.EQ(models.{{$.Table.Name}}_Column_{{$col.Name}}, {{$col.Name | toArgName}}), 
+	q := r.Select().Filter(
+		db.EQ(models.{{$.Table.Name}}_Column_{{$col.Name}}, {{$col.Name | toArgName}}), 
 	)
-	
-	{{if $.IsDeleted}}
-		q.Where(query.And(), query.EQ(models.{{$.Table.Name}}_Column_IsDeleted, 0)){{end}}
 
 	if len(orderBy) > 0 { 
 		q.OrderBy(query.Column(orderBy), query.OrderDirFromString(orderDir))
@@ -574,7 +1402,7 @@ func (r *{{$.Table.Name}}DAL) ManyFrom{{$col.Name}}({{$col.Name | toArgName}} {{
 		q.Limit(limit, offset) 
 	}
 
-	collection, e := q.Run()
+	collection, e := q.RunContext(ctx)
 
 	if e != nil {
 		r.log.Errorf("{{$.Table.Name}}DAL.ManyFrom{{$col.Name}}({{if or (eq $col.GoType "int") (eq $col.GoType "int64")}}%d{{else}}%s{{end}}, %d, %d, %s, %s) > %s", {{$col.Name | toArgName}}, limit, offset, orderBy, orderDir, e.Error())
@@ -589,16 +1417,19 @@ func (r *{{$.Table.Name}}DAL) ManyFrom{{$col.Name}}({{$col.Name | toArgName}} {{
 {{if or (eq $col.GoType "int64") (eq $col.GoType "int")}}
 // ManyFrom{{$col.Name}}s returns a slice of {{$.Table.Name}} models from {{$col.Name}}s
 func (r *{{$.Table.Name}}DAL) ManyFrom{{$col.Name}}s({{$col.Name | toArgName}}s []{{$col | dataTypeToGoTypeString}}, limit, offset int64, orderBy, orderDir string) ([]*models.{{$.Table.Name}}, error) {
+	return r.ManyFrom{{$col.Name}}sContext(context.Background(), {{$col.Name | toArgName}}s, limit, offset, orderBy, orderDir)
+}
+
+// ManyFrom{{$col.Name}}sContext is ManyFrom{{$col.Name}}s with a context
+func (r *{{$.Table.Name}}DAL) ManyFrom{{$col.Name}}sContext(ctx context.Context, {{$col.Name | toArgName}}s []{{$col | dataTypeToGoTypeString}}, limit, offset int64, orderBy, orderDir string) ([]*models.{{$.Table.Name}}, error) {
 		
 	// No records 
 	if len({{$col.Name | toArgName}}s) == 0 {
 		return nil, nil 
 	}
 
-	q := (&models.{{$.Table.Name}}{}).Select(r.db[0]).Where(
-		query.INInt{{if eq $col.GoType "int64"}}64{{end}}(models.{{$.Table.Name}}_Column_{{$col.Name}}, {{$col.Name | toArgName}}s...), {{if $.IsDeleted}}		
-		query.And(), 
-		query.EQ(models.{{$.Table.Name}}_Column_IsDeleted, 0),{{end}}
+	q := r.Select().Filter(
+		db.INInt{{if eq $col.GoType "int64"}}64{{end}}(models.{{$.Table.Name}}_Column_{{$col.Name}}, {{$col.Name | toArgName}}s...),
 	)
 
 	if len(orderBy) > 0 { 
@@ -609,7 +1440,7 @@ func (r *{{$.Table.Name}}DAL) ManyFrom{{$col.Name}}s({{$col.Name | toArgName}}s
 		q.Limit(limit, offset) 
 	}
 	
-	collection, e := q.Run()
+	collection, e := q.RunContext(ctx)
 
 	if e != nil {
 		r.log.Errorf("{{$.Table.Name}}DAL.ManyFrom{{$col.Name}}s(%v, %d, %d, %s, %s) > %s", {{$col.Name | toArgName}}s, limit, offset, orderBy, orderDir, e.Error())
@@ -622,12 +1453,15 @@ func (r *{{$.Table.Name}}DAL) ManyFrom{{$col.Name}}s({{$col.Name | toArgName}}s
 
 // CountFrom{{$col.Name}} returns the number of {{$.Table.Name}} records from {{$col.Name}}
 func (r *{{$.Table.Name}}DAL) CountFrom{{$col.Name}}({{$col.Name | toArgName}} {{$col | dataTypeToGoTypeString}}) (int64, error) {
+	return r.CountFrom{{$col.Name}}Context(context.Background(), {{$col.Name | toArgName}})
+}
+
+// CountFrom{{$col.Name}}Context is CountFrom{{$col.Name}} with a context
+func (r *{{$.Table.Name}}DAL) CountFrom{{$col.Name}}Context(ctx context.Context, {{$col.Name | toArgName}} {{$col | dataTypeToGoTypeString}}) (int64, error) {
 	
-	count, e := (&models.{{$.Table.Name}}{}).Count(r.db[0]).Where(
-		query.EQ(models.{{$.Table.Name}}_Column_{{$col.Name}}, {{$col.Name | toArgName}}),{{if $.IsDeleted}}		
-		query.And(), 
-		query.EQ(models.{{$.Table.Name}}_Column_IsDeleted, 0), {{end}}
-	).Run()
+	count, e := r.Count().Filter(
+		db.EQ(models.{{$.Table.Name}}_Column_{{$col.Name}}, {{$col.Name | toArgName}}),
+	).RunContext(ctx)
 
 	if e != nil {
 		r.log.Errorf("{{$.Table.Name}}DAL.CountFrom{{$col.Name}}({{$col | dataTypeToFormatString}}) > %s", {{$col.Name | toArgName}}, e.Error())
@@ -640,12 +1474,15 @@ func (r *{{$.Table.Name}}DAL) CountFrom{{$col.Name}}({{$col.Name | toArgName}} {
 
 // SingleFrom{{$col.Name}} returns a single {{$.Table.Name}} record by its {{$col.Name}}
 func (r *{{$.Table.Name}}DAL) SingleFrom{{$col.Name}}({{$col.Name | toArgName}} {{$col | dataTypeToGoTypeString}}, mustExist bool) (*models.{{$.Table.Name}}, error) {
+	return r.SingleFrom{{$col.Name}}Context(context.Background(), {{$col.Name | toArgName}}, mustExist)
+}
 
-	model, e := (&models.{{$.Table.Name}}{}).Get(r.db[0]).Where(
-		query.EQ(models.{{$.Table.Name}}_Column_{{$col.Name}}, {{$col.Name | toArgName}}),{{if $.IsDeleted}}
-		query.And(), 
-		query.EQ(models.{{$.Table.Name}}_Column_IsDeleted, 0), {{end}}
-	).Run()
+// SingleFrom{{$col.Name}}Context is SingleFrom{{$col.Name}} with a context
+func (r *{{$.Table.Name}}DAL) SingleFrom{{$col.Name}}Context(ctx context.Context, {{$col.Name | toArgName}} {{$col | dataTypeToGoTypeString}}, mustExist bool) (*models.{{$.Table.Name}}, error) {
+
+	model, e := r.Get().Filter(
+		db.EQ(models.{{$.Table.Name}}_Column_{{$col.Name}}, {{$col.Name | toArgName}}),
+	).RunContext(ctx)
 
 	if model == nil {
 		if mustExist { 
@@ -667,11 +1504,6 @@ func (r *{{$.Table.Name}}DAL) SingleFrom{{$col.Name}}({{$col.Name | toArgName}}
 		return nil, nil
 	case nil: 
 
-		{{if $.IsDeleted}}if model.IsDeleted == 1 && mustExist { 
-			return nil, errors.NewRecordNotFoundError()
-		}{{end}}
-
-		
 		// r.log.Debugf("{{$.Table.Name}}DAL.SingleFrom{{$col.Name}}({{if $col.IsString}}%s{{end}}{{if not $col.IsString}}%d{{end}})", model.{{$col.Name}})
 		return model, nil 
 
@@ -683,12 +1515,14 @@ func (r *{{$.Table.Name}}DAL) SingleFrom{{$col.Name}}({{$col.Name | toArgName}}
 
 // ManyPaged returns a slice of {{.Table.Name}} models
 func (r *{{.Table.Name}}DAL) ManyPaged(limit, offset int64, orderBy, orderDir string) ([]*models.{{.Table.Name}}, error) {
+	return r.ManyPagedContext(context.Background(), limit, offset, orderBy, orderDir)
+}
+
+// ManyPagedContext is ManyPaged with a context
+func (r *{{.Table.Name}}DAL) ManyPagedContext(ctx context.Context, limit, offset int64, orderBy, orderDir string) ([]*models.{{.Table.Name}}, error) {
+
+	q := r.Select()
 
-	q := (&models.{{.Table.Name}}{}).Select(r.db[0]){{if $.IsDeleted}}		
-	q.Where(
-		query.EQ(models.{{.Table.Name}}_Column_IsDeleted, 0),
-	)
-	{{end}}
 	if len(orderBy) > 0 { 
 		q.OrderBy(query.Column(orderBy), query.OrderDirFromString(orderDir))
 	}
@@ -697,7 +1531,7 @@ func (r *{{.Table.Name}}DAL) ManyPaged(limit, offset int64, orderBy, orderDir st
 		q.Limit(limit, offset) 
 	}
 	
-	collection, e := q.Run()
+	collection, e := q.RunContext(ctx)
 
 	if e != nil {
 		r.log.Errorf("{{.Table.Name}}DAL.ManyPaged(%d, %d, %s, %s) > %s", limit, offset, orderBy, orderDir, e.Error())
@@ -708,12 +1542,14 @@ func (r *{{.Table.Name}}DAL) ManyPaged(limit, offset int64, orderBy, orderDir st
 }
 {{ if gt (len .StringColumns) 0 }}{{ range $col := .StringColumns}}
 // Search{{$col.Name}} searches the {{$col.Name}} field in the {{$.Table.Name}} table
-func (r *{{$.Table.Name}}DAL) Search{{$col.Name}}(queryString string, limit, offset int64, leftOrRightOrBoth int) ([]*models.{{$.Table.Name}}, error) { 
+func (r *{{$.Table.Name}}DAL) Search{{$col.Name}}(queryString string, limit, offset int64, leftOrRightOrBoth int) ([]*models.{{$.Table.Name}}, error) {
+	return r.Search{{$col.Name}}Context(context.Background(), queryString, limit, offset, leftOrRightOrBoth)
+}
+
+// Search{{$col.Name}}Context is Search{{$col.Name}} with a context
+func (r *{{$.Table.Name}}DAL) Search{{$col.Name}}Context(ctx context.Context, queryString string, limit, offset int64, leftOrRightOrBoth int) ([]*models.{{$.Table.Name}}, error) { 
 
-	q := (&models.{{$.Table.Name}}{}).Select(r.db[0]){{if $.IsDeleted}}		
-	q.Where(
-		query.EQ(models.{{$.Table.Name}}_Column_IsDeleted, 0),
-	){{end}}
+	q := r.Select()
 
 	// Search left
 	switch leftOrRightOrBoth { 
@@ -728,13 +1564,13 @@ func (r *{{$.Table.Name}}DAL) Search{{$col.Name}}(queryString string, limit, off
 		queryString += "%"
 	} 
 
-	q.Where(query.Like(models.{{$.Table.Name}}_Column_{{$col.Name}}, queryString))
+	q.Filter(db.Like(models.{{$.Table.Name}}_Column_{{$col.Name}}, queryString))
 	
 	if limit > 0 { 
 		q.Limit(limit, offset) 
 	}
 	
-	collection, e := q.Run()
+	collection, e := q.RunContext(ctx)
 
 	if e != nil {
 		r.log.Errorf("{{$.Table.Name}}DAL.Search(%s, %d) > %s", queryString, limit, e.Error())
@@ -1,8 +1,15 @@
 package dal
 
 import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"os"
+	"path"
+	"strings"
 	"testing"
 
+	"github.com/macinnir/dvc/core/lib"
 	"github.com/macinnir/dvc/core/lib/schema"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -70,3 +77,471 @@ func TestGenerateDALSQL(t *testing.T) {
 	require.Nil(t, e)
 	assert.Equal(t, TestGenDatabase1String, out)
 }
+
+func TestGenerateGoDAL_ContextMethods(t *testing.T) {
+
+	dir := t.TempDir()
+	table := &schema.Table{
+		Name: "Foo",
+		Columns: map[string]*schema.Column{
+			"FooID":     {Name: "FooID", ColumnKey: "PRI", DataType: "bigint", Extra: "auto_increment"},
+			"Name":      {Name: "Name", DataType: "varchar"},
+			"IsDeleted": {Name: "IsDeleted", DataType: "tinyint"},
+		},
+	}
+
+	require.Nil(t, GenerateGoDAL(&lib.Config{BasePackage: "example.com/app"}, table, dir))
+
+	src, e := os.ReadFile(path.Join(dir, "FooDAL.go"))
+	require.Nil(t, e)
+
+	f, e := parser.ParseFile(token.NewFileSet(), "FooDAL.go", src, 0)
+	require.Nil(t, e, string(src))
+
+	methods := map[string]bool{}
+	for _, decl := range f.Decls {
+		if fn, ok := decl.(*ast.FuncDecl); ok && fn.Recv != nil {
+			methods[fn.Name.Name] = true
+		}
+	}
+
+	for _, name := range []string{
+		"RawContext",
+		"CreateContext",
+		"CreateManyContext",
+		"CreateManyStreamContext",
+		"BulkLoadContext",
+		"UpdateContext",
+		"UpdateManyContext",
+		"DeleteContext",
+		"DeleteManyContext",
+		"DeleteHardContext",
+		"DeleteManyHardContext",
+		"FromIDContext",
+		"FromIDsContext",
+		"FromIDsMapContext",
+		"SetNameContext",
+		"ManyFromNameContext",
+		"CountFromNameContext",
+		"SingleFromNameContext",
+		"ManyPagedContext",
+		"SearchNameContext",
+		"WithStatementCache",
+	} {
+		assert.True(t, methods[name], name)
+	}
+}
+
+func TestGenerateGoDAL_Sharded(t *testing.T) {
+
+	table := &schema.Table{
+		Name: "Foo",
+		Columns: map[string]*schema.Column{
+			"FooID":     {Name: "FooID", ColumnKey: "PRI", DataType: "bigint", Extra: "auto_increment"},
+			"AccountID": {Name: "AccountID", DataType: "bigint"},
+		},
+	}
+
+	for shardKey, expected := range map[string]string{
+		"":          "db.ShardIndex(key, len(r.db), true)",
+		"AccountID": "db.ShardIndex(key, len(r.db), false)",
+	} {
+
+		dir := t.TempDir()
+		config := &lib.Config{
+			BasePackage: "example.com/app",
+			Shards:      map[string]*lib.ShardConfig{"Foo": {Key: shardKey}},
+		}
+
+		require.Nil(t, GenerateGoDAL(config, table, dir))
+
+		src, e := os.ReadFile(path.Join(dir, "FooDAL.go"))
+		require.Nil(t, e)
+
+		_, e = parser.ParseFile(token.NewFileSet(), "FooDAL.go", src, 0)
+		require.Nil(t, e, string(src))
+
+		assert.Contains(t, string(src), expected)
+		assert.Contains(t, string(src), "Select(r.conn()).OnShards(r.shards)")
+		assert.Contains(t, string(src), "Count(r.conn()).OnShards(r.shards)")
+		assert.Contains(t, string(src), "func (r *FooDAL) byShard(")
+	}
+}
+
+func TestGenerateGoDAL_ShardKeyNotAColumn(t *testing.T) {
+
+	table := &schema.Table{
+		Name: "Foo",
+		Columns: map[string]*schema.Column{
+			"FooID": {Name: "FooID", ColumnKey: "PRI", DataType: "bigint", Extra: "auto_increment"},
+		},
+	}
+
+	config := &lib.Config{
+		BasePackage: "example.com/app",
+		Shards:      map[string]*lib.ShardConfig{"Foo": {Key: "AccountID"}},
+	}
+
+	assert.NotNil(t, GenerateGoDAL(config, table, t.TempDir()))
+}
+
+func TestGenerateGoDAL_VersionColumn(t *testing.T) {
+
+	table := &schema.Table{
+		Name: "Foo",
+		Columns: map[string]*schema.Column{
+			"FooID":   {Name: "FooID", ColumnKey: "PRI", DataType: "bigint", Extra: "auto_increment"},
+			"Name":    {Name: "Name", DataType: "varchar"},
+			"Version": {Name: "Version", DataType: "int"},
+		},
+	}
+
+	dir := t.TempDir()
+	config := &lib.Config{
+		BasePackage:    "example.com/app",
+		VersionColumns: map[string]string{"Foo": "Version"},
+	}
+
+	require.Nil(t, GenerateGoDAL(config, table, dir))
+
+	src, e := os.ReadFile(path.Join(dir, "FooDAL.go"))
+	require.Nil(t, e)
+
+	_, e = parser.ParseFile(token.NewFileSet(), "FooDAL.go", src, 0)
+	require.Nil(t, e, string(src))
+
+	assert.Contains(t, string(src), `db.UpdateVersioned(ctx, r.modelConn(ctx, model), "Foo", model.FooID, "UPDATE `+"`Foo` SET `Name` = ?, `Version` = `Version` + 1 WHERE `FooID` = ? AND `Version` = ?"+`", model.Name, model.FooID, model.Version)`)
+	assert.Contains(t, string(src), "model.Version++")
+	assert.Contains(t, string(src), "model.RestoreOnRollback(tx)\n\t\t\t\tmodel.Version++")
+	assert.Contains(t, string(src), "SET `Name` = ?, `Version` = `Version` + 1 WHERE `FooID` = ?\", model.Name, fooID)")
+}
+
+func TestGenerateGoDAL_VersionColumnNotAnInteger(t *testing.T) {
+
+	table := &schema.Table{
+		Name: "Foo",
+		Columns: map[string]*schema.Column{
+			"FooID":   {Name: "FooID", ColumnKey: "PRI", DataType: "bigint", Extra: "auto_increment"},
+			"Version": {Name: "Version", DataType: "varchar"},
+		},
+	}
+
+	config := &lib.Config{
+		BasePackage:    "example.com/app",
+		VersionColumns: map[string]string{"Foo": "Version"},
+	}
+
+	assert.NotNil(t, GenerateGoDAL(config, table, t.TempDir()))
+
+	config.VersionColumns["Foo"] = "Revision"
+	assert.NotNil(t, GenerateGoDAL(config, table, t.TempDir()))
+}
+
+func TestGenerateGoDAL_SoftDelete(t *testing.T) {
+
+	table := &schema.Table{
+		Name: "Foo",
+		Columns: map[string]*schema.Column{
+			"FooID":       {Name: "FooID", ColumnKey: "PRI", DataType: "bigint", Extra: "auto_increment"},
+			"IsDeleted":   {Name: "IsDeleted", DataType: "tinyint"},
+			"LastUpdated": {Name: "LastUpdated", DataType: "bigint"},
+		},
+	}
+
+	dir := t.TempDir()
+	require.Nil(t, GenerateGoDAL(&lib.Config{BasePackage: "example.com/app"}, table, dir))
+
+	src, e := os.ReadFile(path.Join(dir, "FooDAL.go"))
+	require.Nil(t, e)
+
+	_, e = parser.ParseFile(token.NewFileSet(), "FooDAL.go", src, 0)
+	require.Nil(t, e, string(src))
+
+	assert.Contains(t, string(src), "func (r *FooDAL) RestoreContext(")
+	assert.NotContains(t, string(src), "db.EQ(models.Foo_Column_IsDeleted, 0)")
+
+	// FromID leaves deleted records out; deletes read them with fromIDWithDeleted
+	assert.Contains(t, string(src), "model, e := (&models.Foo{}).Get(r.conn()).Filter(db.EQ(models.Foo_Column_FooID, fooID)).RunContext(ctx)")
+	assert.Contains(t, string(src), "model, e := (&models.Foo{}).Get(r.conn()).WithDeleted().Filter(db.EQ(models.Foo_Column_FooID, fooID)).RunContext(ctx)")
+	assert.Contains(t, string(src), "record, e := r.fromIDWithDeleted(ctx, fooID)")
+	assert.NotContains(t, string(src), "model.IsDeleted == 1")
+
+	// Soft deletes write LastUpdated, and the models match the rows
+	assert.Contains(t, string(src), "\"UPDATE `Foo` SET `IsDeleted` = 1, `LastUpdated` = ? WHERE `FooID` = ?\", lastUpdated, fooID)")
+	assert.Contains(t, string(src), "\"UPDATE `Foo` SET `IsDeleted` = 1, `LastUpdated` = ? WHERE `FooID` = ?\", lastUpdated, model.FooID)")
+	assert.Contains(t, string(src), "model.IsDeleted = 1\n\t\t\tmodel.LastUpdated = lastUpdated")
+	assert.Contains(t, string(src), "\"DELETE FROM `Foo` WHERE `FooID` = ?\", fooID)")
+
+	// Restores write LastUpdated too
+	assert.Contains(t, string(src), "\"UPDATE `Foo` SET `IsDeleted` = 0, `LastUpdated` = ? WHERE `FooID` = ?\", lastUpdated, fooID)")
+	assert.Contains(t, string(src), "model.IsDeleted = 0\n\tmodel.LastUpdated = lastUpdated")
+}
+
+func TestGenerateGoDAL_UpdateFields(t *testing.T) {
+
+	table := &schema.Table{
+		Name: "Foo",
+		Columns: map[string]*schema.Column{
+			"FooID":       {Name: "FooID", ColumnKey: "PRI", DataType: "bigint", Extra: "auto_increment"},
+			"Name":        {Name: "Name", DataType: "varchar"},
+			"LastUpdated": {Name: "LastUpdated", DataType: "bigint"},
+		},
+	}
+
+	dir := t.TempDir()
+	require.Nil(t, GenerateGoDAL(&lib.Config{BasePackage: "example.com/app"}, table, dir))
+
+	src, e := os.ReadFile(path.Join(dir, "FooDAL.go"))
+	require.Nil(t, e)
+
+	_, e = parser.ParseFile(token.NewFileSet(), "FooDAL.go", src, 0)
+	require.Nil(t, e, string(src))
+
+	assert.Contains(t, string(src), "func (r *FooDAL) UpdateFieldsContext(ctx context.Context, model *models.Foo, cols ...query.Column) error")
+	assert.Contains(t, string(src), "func (r *FooDAL) PatchContext(ctx context.Context, model *models.Foo) error")
+	assert.Contains(t, string(src), "models.Foo_Column_LastUpdated)")
+}
+
+func TestGenerateGoDAL_CreateMany(t *testing.T) {
+
+	table := &schema.Table{
+		Name: "Foo",
+		Columns: map[string]*schema.Column{
+			"FooID":       {Name: "FooID", ColumnKey: "PRI", DataType: "bigint", Extra: "auto_increment"},
+			"Name":        {Name: "Name", DataType: "varchar"},
+			"DateCreated": {Name: "DateCreated", DataType: "bigint"},
+		},
+	}
+
+	dir := t.TempDir()
+	require.Nil(t, GenerateGoDAL(&lib.Config{BasePackage: "example.com/app"}, table, dir))
+
+	src, e := os.ReadFile(path.Join(dir, "FooDAL.go"))
+	require.Nil(t, e)
+
+	_, e = parser.ParseFile(token.NewFileSet(), "FooDAL.go", src, 0)
+	require.Nil(t, e, string(src))
+
+	assert.Contains(t, string(src), "db.InsertMany(ctx, tx, \"INSERT INTO `Foo` (`FooID`, `DateCreated`, `Name`) VALUES (?, ?, ?)\", rowsWithID)")
+	assert.Contains(t, string(src), "ids, e := db.InsertMany(ctx, tx, \"INSERT INTO `Foo` (`DateCreated`, `Name`) VALUES (?, ?)\", rows)")
+	assert.Contains(t, string(src), "created[k].FooID = ids[k]")
+	assert.Contains(t, string(src), "db.LoadData(ctx, r.modelConn(ctx, modelSlice[0]), \"Foo\", []string{\"FooID\", \"DateCreated\", \"Name\"}, rows)")
+	assert.Contains(t, string(src), "if model.FooID == 0 {\n\t\t\treturn fmt.Errorf(\"FooBulkLoad([](%d)): %w\", len(modelSlice), errors.NewArgumentError(fmt.Sprintf(\"record %d has no FooID\", k)))")
+	assert.Contains(t, string(src), "func (r *FooDAL) CreateManyStreamContext(ctx context.Context, seq iter.Seq[*models.Foo], chunkSize int) error")
+	assert.Contains(t, string(src), "if chunkSize < 1 {\n\t\treturn fmt.Errorf(\"FooDAL.CreateManyStream(%d): %w\", chunkSize, errors.NewArgumentError(\"the chunk size is less than 1\"))")
+}
+
+func TestGenerateGoDAL_Hooks(t *testing.T) {
+
+	table := &schema.Table{
+		Name: "Foo",
+		Columns: map[string]*schema.Column{
+			"FooID":     {Name: "FooID", ColumnKey: "PRI", DataType: "bigint", Extra: "auto_increment"},
+			"Name":      {Name: "Name", DataType: "varchar"},
+			"IsDeleted": {Name: "IsDeleted", DataType: "tinyint"},
+		},
+	}
+
+	dir := t.TempDir()
+	require.Nil(t, GenerateGoDAL(&lib.Config{BasePackage: "example.com/app"}, table, dir))
+
+	src, e := os.ReadFile(path.Join(dir, "FooDAL.go"))
+	require.Nil(t, e)
+
+	_, e = parser.ParseFile(token.NewFileSet(), "FooDAL.go", src, 0)
+	require.Nil(t, e, string(src))
+
+	for _, expected := range []string{
+		"if e := db.BeforeCreate(ctx, model); e != nil {",
+		"if e = db.AfterCreate(ctx, model); e != nil {",
+		"if e = db.EachModel(ctx, modelSlice, db.BeforeCreate); e != nil {",
+		"if e = db.BeforeUpdate(ctx, model); e != nil {",
+		"if e = db.EachModel(ctx, modelSlice, db.BeforeUpdate); e != nil {",
+		"if e = db.EachModel(ctx, chunk, db.AfterUpdate); e != nil {",
+		"model, e := r.deletedModel(ctx, fooID)",
+		"if e = db.EachModel(ctx, modelSlice, db.BeforeDelete); e != nil {",
+		"if e = db.EachModel(ctx, chunk, db.AfterDelete); e != nil {",
+		"if !db.HasUpdateHooks(model) {",
+		"model.Name = name\n\t\te = db.BeforeUpdate(ctx, model)",
+		"model.IsDeleted = 0",
+	} {
+		assert.Contains(t, string(src), expected)
+	}
+}
+
+func TestGenerateGoDAL_Audit(t *testing.T) {
+
+	table := &schema.Table{
+		Name: "Foo",
+		Columns: map[string]*schema.Column{
+			"FooID":    {Name: "FooID", ColumnKey: "PRI", DataType: "bigint", Extra: "auto_increment"},
+			"Name":     {Name: "Name", DataType: "varchar"},
+			"Password": {Name: "Password", DataType: "varchar"},
+		},
+	}
+
+	config := &lib.Config{BasePackage: "example.com/app", Audit: map[string]*lib.AuditConfig{"Foo": {Exclude: []string{"Password"}}}}
+
+	dir := t.TempDir()
+	require.Nil(t, GenerateGoDAL(config, table, dir))
+
+	src, e := os.ReadFile(path.Join(dir, "FooDAL.go"))
+	require.Nil(t, e)
+
+	_, e = parser.ParseFile(token.NewFileSet(), "FooDAL.go", src, 0)
+	require.Nil(t, e, string(src))
+
+	for _, expected := range []string{
+		"func (r *FooDAL) WithAuditor(auditor db.Auditor) {",
+		"func (r *FooDAL) HistoryContext(ctx context.Context, fooID int64) ([]*db.AuditRecord, error) {",
+		"db.NewAuditRecord(ctx, \"Foo\", model.FooID, action, diff.Without(models.Foo_Column_Password))",
+		"r.writeAudit(ctx, r.modelConn(ctx, model), r.auditRecord(ctx, db.AuditCreate, model, db.CreatedDiff(model)))",
+		"if e != nil && !db.InTx(conn) {",
+		"func (r *FooDAL) updatedModel(ctx context.Context, fooID int64) (*models.Foo, error) {",
+		"r.auditRecord(ctx, db.AuditUpdate, model, model.Diff().Only(models.Foo_Column_Name))",
+		"diff := model.Diff().Only(cols...)",
+		"records = append(records, r.auditRecord(ctx, db.AuditUpdate, model, model.Diff()))",
+		"if !db.HasDeleteHooks(model) && r.audit == nil {",
+		"e = db.ExecEach(ctx, conns, \"DELETE FROM `Foo` WHERE `FooID` = ?\", fooID)",
+		"r.writeAudit(ctx, conns[0], r.auditRecord(ctx, db.AuditDelete, model, db.DeletedDiff(model)))",
+		"r.writeAudit(ctx, conns[0], r.auditRecord(ctx, db.AuditUpdate, model, model.Diff().Only(models.Foo_Column_Name)))",
+	} {
+		assert.Contains(t, string(src), expected)
+	}
+
+	delete(config.Audit, "Foo")
+	require.Nil(t, GenerateGoDAL(config, table, dir))
+
+	src, e = os.ReadFile(path.Join(dir, "FooDAL.go"))
+	require.Nil(t, e)
+	assert.NotContains(t, string(src), "Audit")
+	assert.NotContains(t, string(src), "r.writeAudit(ctx, r.conn()")
+
+	config.Audit["Foo"] = &lib.AuditConfig{Exclude: []string{"Secret"}}
+	assert.NotNil(t, GenerateGoDAL(config, table, dir))
+}
+
+func TestUpsertSQL(t *testing.T) {
+
+	var insertColumns = []*schema.Column{
+		{Name: "DateCreated"},
+		{Name: "Email"},
+		{Name: "Name"},
+		{Name: "Version"},
+	}
+	var keyColumns = insertColumns[1:2]
+
+	sql, e := upsertSQL(schema.SchemaTypeMySQL, "Foo", "FooID", true, insertColumns, keyColumns, "Version", true)
+	require.Nil(t, e)
+	assert.Equal(t, "INSERT INTO `Foo` (`DateCreated`, `Email`, `Name`, `Version`) VALUES (?, ?, ?, ?) ON DUPLICATE KEY UPDATE `FooID` = LAST_INSERT_ID(`FooID`), `Name` = VALUES(`Name`), `IsDeleted` = 0, `Version` = `Foo`.`Version` + 1", sql)
+
+	sql, e = upsertSQL(schema.SchemaTypePostgreSQL, "Foo", "FooID", true, insertColumns, keyColumns, "", false)
+	require.Nil(t, e)
+	assert.Equal(t, `INSERT INTO "Foo" ("DateCreated", "Email", "Name", "Version") VALUES ($1, $2, $3, $4) ON CONFLICT ("Email") DO UPDATE SET "Name" = EXCLUDED."Name", "Version" = EXCLUDED."Version" RETURNING "FooID"`, sql)
+
+	sql, e = upsertSQL(schema.SchemaTypePostgreSQL, "Foo", "FooID", true, insertColumns, keyColumns, "Version", false)
+	require.Nil(t, e)
+	assert.True(t, strings.HasSuffix(sql, `RETURNING "FooID", "Version"`), sql)
+
+	// Nothing to update
+	sql, e = upsertSQL(schema.SchemaTypeMySQL, "Foo", "FooID", false, keyColumns, keyColumns, "", false)
+	require.Nil(t, e)
+	assert.Equal(t, "INSERT INTO `Foo` (`Email`) VALUES (?) ON DUPLICATE KEY UPDATE `FooID` = `FooID`", sql)
+
+	_, e = upsertSQL(schema.SchemaTypeSQLServer, "Foo", "FooID", true, insertColumns, keyColumns, "", false)
+	assert.NotNil(t, e)
+}
+
+func TestGenerateGoDAL_Upsert(t *testing.T) {
+
+	table := &schema.Table{
+		Name:       "Foo",
+		SchemaName: "app",
+		Columns: map[string]*schema.Column{
+			"FooID":     {Name: "FooID", ColumnKey: "PRI", DataType: "bigint", Extra: "auto_increment"},
+			"AccountID": {Name: "AccountID", DataType: "bigint"},
+			"Code":      {Name: "Code", DataType: "varchar"},
+			"Email":     {Name: "Email", DataType: "varchar", ColumnKey: "UNI"},
+		},
+	}
+
+	config := &lib.Config{
+		BasePackage: "example.com/app",
+		Cache: map[string]*lib.CacheConfig{
+			"Foo": {Indices: []*lib.CacheConfigIndex{{Field: "AccountID,Code", Unique: true}}},
+		},
+	}
+
+	dir := t.TempDir()
+	require.Nil(t, GenerateGoDAL(config, table, dir))
+
+	src, e := os.ReadFile(path.Join(dir, "FooDAL.go"))
+	require.Nil(t, e)
+
+	_, e = parser.ParseFile(token.NewFileSet(), "FooDAL.go", src, 0)
+	require.Nil(t, e, string(src))
+
+	assert.Contains(t, string(src), "func (r *FooDAL) UpsertByEmailContext(ctx context.Context, model *models.Foo) error")
+	assert.Contains(t, string(src), "func (r *FooDAL) UpsertManyByAccountIDAndCodeContext(ctx context.Context, modelSlice []*models.Foo) error")
+	assert.Contains(t, string(src), "model.FooID, e = result.LastInsertId()")
+
+	assert.Contains(t, string(src), "if model.FooID, e = result.LastInsertId(); e != nil {\n\t\treturn e\n\t}\n\n\tmodel.Snapshot()")
+
+	// The version an update incremented is read back
+	config.VersionColumns = map[string]string{"Foo": "Version"}
+	table.Columns["Version"] = &schema.Column{Name: "Version", DataType: "int"}
+	require.Nil(t, GenerateGoDAL(config, table, dir))
+	src, e = os.ReadFile(path.Join(dir, "FooDAL.go"))
+	require.Nil(t, e)
+	assert.Contains(t, string(src), "if affected == 2 {\n\t\tif e = db.QueryRowContext(ctx, conn, \"SELECT `Version` FROM `Foo` WHERE `FooID` = ?\", model.FooID).Scan(&model.Version); e != nil {")
+	config.VersionColumns = nil
+	delete(table.Columns, "Version")
+
+	// Unique indexes without the shard key aren't unique across shards
+	config.Shards = map[string]*lib.ShardConfig{"Foo": {Key: "AccountID"}}
+	require.Nil(t, GenerateGoDAL(config, table, dir))
+	src, e = os.ReadFile(path.Join(dir, "FooDAL.go"))
+	require.Nil(t, e)
+	assert.NotContains(t, string(src), "UpsertByEmail")
+	assert.Contains(t, string(src), "func (r *FooDAL) UpsertByAccountIDAndCode(")
+
+	// PostgreSQL returns the primary key
+	config.Shards = nil
+	config.Databases = []*lib.ConfigDatabase{{Key: "app_local", Type: schema.SchemaTypePostgreSQL}}
+	require.Nil(t, GenerateGoDAL(config, table, dir))
+	src, e = os.ReadFile(path.Join(dir, "FooDAL.go"))
+	require.Nil(t, e)
+	assert.Contains(t, string(src), `RETURNING \"FooID\"", model.AccountID, model.Code, model.Email).Scan(&model.FooID)`)
+}
+
+func TestGenerateGoDAL_Relations(t *testing.T) {
+
+	table := &schema.Table{
+		Name:       "User",
+		SchemaName: "app",
+		Columns: map[string]*schema.Column{
+			"UserID":    {Name: "UserID", ColumnKey: "PRI", DataType: "bigint", Extra: "auto_increment"},
+			"AccountID": {Name: "AccountID", DataType: "bigint"},
+		},
+	}
+
+	config := &lib.Config{
+		BasePackage: "example.com/app",
+		Databases: []*lib.ConfigDatabase{{
+			Key:       "app_local",
+			OneToMany: map[string]string{"Account.AccountID": "User.AccountID", "User.UserID": "Invoice.UserID"},
+		}},
+	}
+
+	dir := t.TempDir()
+	require.Nil(t, GenerateGoDAL(config, table, dir))
+
+	src, e := os.ReadFile(path.Join(dir, "UserDAL.go"))
+	require.Nil(t, e)
+
+	_, e = parser.ParseFile(token.NewFileSet(), "UserDAL.go", src, 0)
+	require.Nil(t, e, string(src))
+
+	assert.Contains(t, string(src), "func (r *UserDAL) LoadAccountContext(ctx context.Context, modelSlice []*models.User) (map[int64]*models.Account, error)")
+	assert.Contains(t, string(src), "func (r *UserDAL) LoadInvoicesContext(ctx context.Context, modelSlice []*models.User) (map[int64][]*models.Invoice, error)")
+}
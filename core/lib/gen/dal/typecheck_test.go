@@ -0,0 +1,208 @@
+package dal
+
+import (
+	"os"
+	"os/exec"
+	"path"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/macinnir/dvc/core/lib"
+	"github.com/macinnir/dvc/core/lib/gen/model"
+	"github.com/macinnir/dvc/core/lib/schema"
+	"github.com/stretchr/testify/require"
+)
+
+// upsertVersionTest runs a generated DAL against a driver that keeps the Version of a single Account row like MySQL
+// would, to check that an upsert that updates the row leaves the model ready for an Update
+const upsertVersionTest = `package dal
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"io"
+	"strings"
+	"testing"
+
+	"example.com/app/gen/definitions/models"
+	query "github.com/macinnir/goquery"
+)
+
+type versionDriver struct{ version int64 }
+
+func (d *versionDriver) Connect(context.Context) (driver.Conn, error) { return d, nil }
+func (d *versionDriver) Driver() driver.Driver                        { return d }
+func (d *versionDriver) Open(string) (driver.Conn, error)             { return d, nil }
+func (d *versionDriver) Prepare(q string) (driver.Stmt, error)        { return &versionStmt{d, q}, nil }
+func (d *versionDriver) Close() error                                 { return nil }
+func (d *versionDriver) Begin() (driver.Tx, error)                    { return d, nil }
+func (d *versionDriver) Commit() error                                { return nil }
+func (d *versionDriver) Rollback() error                              { return nil }
+
+type versionStmt struct {
+	d *versionDriver
+	q string
+}
+
+func (s *versionStmt) Close() error  { return nil }
+func (s *versionStmt) NumInput() int { return -1 }
+
+func (s *versionStmt) Exec(args []driver.Value) (driver.Result, error) {
+	switch {
+	case strings.Contains(s.q, "ON DUPLICATE KEY UPDATE"):
+		s.d.version++
+		return versionResult(2), nil
+	case strings.HasPrefix(s.q, "UPDATE") && args[len(args)-1] == s.d.version:
+		s.d.version++
+		return versionResult(1), nil
+	}
+	return versionResult(0), nil
+}
+
+func (s *versionStmt) Query(args []driver.Value) (driver.Rows, error) {
+	return &versionRows{version: s.d.version}, nil
+}
+
+type versionResult int64
+
+func (r versionResult) LastInsertId() (int64, error) { return 1, nil }
+func (r versionResult) RowsAffected() (int64, error) { return int64(r), nil }
+
+type versionRows struct {
+	version int64
+	done    bool
+}
+
+func (r *versionRows) Columns() []string { return []string{"Version"} }
+func (r *versionRows) Close() error      { return nil }
+
+func (r *versionRows) Next(dest []driver.Value) error {
+	if r.done {
+		return io.EOF
+	}
+	r.done = true
+	dest[0] = r.version
+	return nil
+}
+
+type nopLog struct{}
+
+func (nopLog) Debug(args ...interface{})                 {}
+func (nopLog) Debugf(format string, args ...interface{}) {}
+func (nopLog) Info(args ...interface{})                  {}
+func (nopLog) Infof(format string, args ...interface{})  {}
+func (nopLog) Error(args ...interface{})                 {}
+func (nopLog) Errorf(format string, args ...interface{}) {}
+func (nopLog) Println(args ...interface{})               {}
+func (nopLog) Printf(format string, args ...interface{}) {}
+func (nopLog) Fatalf(format string, args ...interface{}) {}
+func (nopLog) Fatal(args ...interface{})                 {}
+func (nopLog) Warn(args ...interface{})                  {}
+
+func TestUpsertThenUpdate(t *testing.T) {
+
+	conn := sql.OpenDB(&versionDriver{version: 3})
+	defer conn.Close()
+
+	r := NewAccountDAL([]query.DBInterface{conn}, nopLog{})
+
+	model := &models.Account{Email: "a@example.com"}
+	if e := r.UpsertByEmail(model); e != nil {
+		t.Fatal(e)
+	}
+
+	if model.Version != 4 {
+		t.Fatalf("Version is %d after the upsert, not 4", model.Version)
+	}
+
+	model.Name = "A"
+	if e := r.Update(model); e != nil {
+		t.Fatal(e)
+	}
+}
+`
+
+// TestGenerate_TypeChecks generates the models and DALs of a sample schema into a module of their own and runs go vet
+// and a test of the upserts on them, so that template changes that don't compile are caught. It is skipped when the
+// module's dependencies aren't available (e.g. offline without a module cache).
+func TestGenerate_TypeChecks(t *testing.T) {
+
+	if testing.Short() {
+		t.Skip("skipped in short mode")
+	}
+
+	goMod, e := exec.Command("go", "env", "GOMOD").Output()
+	require.Nil(t, e)
+	root := path.Dir(strings.TrimSpace(string(goMod)))
+
+	// The sample app requires this module, with the same requirements and replacements
+	modFile, e := os.ReadFile(path.Join(root, "go.mod"))
+	require.Nil(t, e)
+	modFile = regexp.MustCompile(`(?m)^module .*$`).ReplaceAll(modFile, []byte("module example.com/app"))
+	modFile = append(modFile, []byte("\nrequire github.com/macinnir/dvc v0.0.0\n\nreplace github.com/macinnir/dvc => "+root+"\n")...)
+
+	dir := t.TempDir()
+	require.Nil(t, os.WriteFile(path.Join(dir, "go.mod"), modFile, lib.DefaultFileMode))
+	if sum, e := os.ReadFile(path.Join(root, "go.sum")); e == nil {
+		require.Nil(t, os.WriteFile(path.Join(dir, "go.sum"), sum, lib.DefaultFileMode))
+	}
+
+	var run = func(args ...string) ([]byte, error) {
+		cmd := exec.Command("go", args...)
+		cmd.Dir = dir
+		cmd.Env = append(os.Environ(), "GOFLAGS=-mod=mod")
+		return cmd.CombinedOutput()
+	}
+
+	if out, e := run("list", "github.com/macinnir/goquery", "gopkg.in/guregu/null.v3", "github.com/macinnir/dvc/core/lib/utils/db"); e != nil {
+		t.Skipf("dependencies unavailable: %s", out)
+	}
+
+	tables := []*schema.Table{
+		{Name: "Account", Columns: map[string]*schema.Column{
+			"AccountID":   {Name: "AccountID", ColumnKey: "PRI", DataType: "bigint", Extra: "auto_increment"},
+			"Name":        {Name: "Name", DataType: "varchar", MaxLength: 50},
+			"Email":       {Name: "Email", DataType: "varchar", MaxLength: 100, ColumnKey: "UNI"},
+			"Nickname":    {Name: "Nickname", DataType: "varchar", MaxLength: 50, IsNullable: true},
+			"Balance":     {Name: "Balance", DataType: "decimal", Precision: 10, NumericScale: 2},
+			"Role":        {Name: "Role", DataType: "enum", Type: "enum('admin','member')"},
+			"Version":     {Name: "Version", DataType: "int"},
+			"IsDeleted":   {Name: "IsDeleted", DataType: "tinyint"},
+			"DateCreated": {Name: "DateCreated", DataType: "bigint"},
+			"LastUpdated": {Name: "LastUpdated", DataType: "bigint"},
+		}},
+		{Name: "User", Columns: map[string]*schema.Column{
+			"UserID":    {Name: "UserID", ColumnKey: "PRI", DataType: "bigint", Extra: "auto_increment"},
+			"AccountID": {Name: "AccountID", DataType: "bigint", ColumnKey: "MUL"},
+			"Age":       {Name: "Age", DataType: "tinyint", IsUnsigned: true, IsNullable: true},
+		}},
+	}
+
+	config := &lib.Config{
+		BasePackage:    "example.com/app",
+		VersionColumns: map[string]string{"Account": "Version"},
+		Audit:          map[string]*lib.AuditConfig{"Account": nil},
+		Databases: []*lib.ConfigDatabase{{
+			Key:       "app",
+			OneToMany: map[string]string{"Account.AccountID": "User.AccountID"},
+		}},
+	}
+
+	for _, table := range tables {
+		table.SchemaName = "app"
+	}
+
+	t.Chdir(dir)
+	require.Nil(t, model.GenModels(tables, nil, config))
+	require.Nil(t, GenDALs(tables, config))
+
+	require.Nil(t, os.WriteFile(path.Join(lib.DalsGenDir, "upsert_test.go"), []byte(upsertVersionTest), lib.DefaultFileMode))
+
+	out, e := run("vet", "./gen/...")
+	require.Nil(t, e, string(out))
+
+	out, e = run("test", "./gen/dal")
+	require.Nil(t, e, string(out))
+}
@@ -0,0 +1,177 @@
+package gen
+
+import (
+	"bytes"
+	"io/ioutil"
+	"path"
+	"text/template"
+
+	"github.com/macinnir/dvc/core/lib"
+	"github.com/macinnir/dvc/core/lib/gen/genutil"
+	"github.com/macinnir/dvc/core/lib/schema"
+)
+
+// CacheFakeTemplate is the template of an in-memory fake of a cache. It builds on the cache's mock (see GenMocks).
+var CacheFakeTemplate = template.Must(template.New("template-cache-fake-file").Funcs(template.FuncMap{
+	"dataTypeToGoTypeString": schema.DataTypeToGoTypeString,
+	"toArgName":              genutil.ToArgName,
+	"columnsToMethodName":    columnsToMethodName,
+	"columnsToMethodParams":  columnsToMethodParams,
+}).Parse(`// Generated Code; DO NOT EDIT.
+
+package cachesmocks
+
+import (
+	"{{ .BasePackage }}/gen/definitions/models"
+	"github.com/macinnir/dvc/core/lib/utils/errors"
+	"github.com/macinnir/dvc/core/lib/utils/mock"
+	"gopkg.in/guregu/null.v3"
+)
+
+// {{.Table.Name}}CacheFake is an in-memory caches.I{{.Table.Name}}Cache that keeps {{.Table.Name}} objects in Records,
+// by primary key. Its indices are computed from the records. Its {{.Table.Name}}CacheMock records every call, and its
+// Funcs can be replaced to stub a method.
+type {{.Table.Name}}CacheFake struct {
+	*{{.Table.Name}}CacheMock
+	Records *mock.Store[int64, models.{{.Table.Name}}]
+}
+
+// New{{.Table.Name}}CacheFake returns an empty {{.Table.Name}}CacheFake
+func New{{.Table.Name}}CacheFake() *{{.Table.Name}}CacheFake {
+
+	f := &{{.Table.Name}}CacheFake{
+		{{.Table.Name}}CacheMock: &{{.Table.Name}}CacheMock{},
+		Records: mock.NewStore[int64, models.{{.Table.Name}}](),
+	}
+
+	m := f.{{.Table.Name}}CacheMock
+
+	m.FromIDFunc = func(id int64) (*models.{{.Table.Name}}, error) {
+		if model, ok := f.Records.Get(id); ok {
+			return model, nil
+		}
+		return nil, errors.NewRecordNotFoundError()
+	}
+	m.SaveFunc = func(model *models.{{.Table.Name}}) { f.Records.Put(model.{{.PrimaryKey}}, model) }
+	m.DeleteFunc = func(id int64) { f.Records.Delete(id) }
+	m.AllFunc = func(page, limit int64) ([]*models.{{.Table.Name}}, error) {
+		return mock.Page(f.Records.Filter(nil), limit, page*limit), nil
+	}
+	m.CountFunc = func() (int64, error) { return int64(f.Records.Len()), nil }
+{{range $index := .CacheConfig.Indices}}
+	// {{if $index.Index.Unique}}Unique {{end}}Index: {{$index.Index.Field}}
+{{- if not $index.Index.Unique}}
+	m.From{{$index.Columns | columnsToMethodName}}Func = func({{$index.Columns | columnsToMethodParams}}, page, limit int64) ([]*models.{{$.Table.Name}}, error) {
+		return mock.Page(f.Records.Filter(func(model *models.{{$.Table.Name}}) bool {
+			return {{range $k, $column := $index.Columns}}{{if $k}} && {{end}}mock.Equal(model.{{$column.Name}}, {{$column.Name | toArgName}}){{end}}
+		}), limit, page*limit), nil
+	}
+	m.CountFrom{{$index.Columns | columnsToMethodName}}Func = func({{$index.Columns | columnsToMethodParams}}) (int64, error) {
+		return int64(len(f.Records.Filter(func(model *models.{{$.Table.Name}}) bool {
+			return {{range $k, $column := $index.Columns}}{{if $k}} && {{end}}mock.Equal(model.{{$column.Name}}, {{$column.Name | toArgName}}){{end}}
+		}))), nil
+	}
+{{- else}}
+	m.From{{$index.Columns | columnsToMethodName}}Func = func({{$index.Columns | columnsToMethodParams}}) (*models.{{$.Table.Name}}, error) {
+		collection := f.Records.Filter(func(model *models.{{$.Table.Name}}) bool {
+			return {{range $k, $column := $index.Columns}}{{if $k}} && {{end}}mock.Equal(model.{{$column.Name}}, {{$column.Name | toArgName}}){{end}}
+		})
+		if len(collection) == 0 {
+			return nil, nil
+		}
+		return collection[0], nil
+	}
+{{- end}}
+{{end}}
+	return f
+}
+`))
+
+// RepoFakeTemplate is the template of the constructor of a repo on in-memory fakes of its cache and DAL
+var RepoFakeTemplate = template.Must(template.New("template-repo-fake-file").Funcs(template.FuncMap{
+	"toArgName": genutil.ToArgName,
+}).Parse(`// Generated Code; DO NOT EDIT.
+
+package reposmocks
+
+import (
+	"{{ .BasePackage }}/core/components/config"
+	"{{ .BasePackage }}/gen/mocks/cachesmocks"
+	"{{ .BasePackage }}/gen/mocks/dalmocks"
+	"{{ .BasePackage }}/gen/repos"
+	{{ if .CacheConfig.HasHashID }}"{{ .BasePackage }}/app/providers/hashid"{{end}}
+)
+
+// New{{.Table.Name}}RepoFake returns a repos.{{.Table.Name}}Repo on in-memory fakes of its cache and DAL, which are also
+// returned so tests can seed and inspect their records and calls
+func New{{.Table.Name}}RepoFake(
+	config *config.Config,
+	{{ if .CacheConfig.HasHashID }}idHasher hashid.IDHasherInterface,{{end}}{{range $agg := .CacheConfig.Properties}}
+	{{$agg.Aggregate.Table | toArgName}}Repo *repos.{{$agg.Aggregate.Table}}Repo,{{end}}
+) (*repos.{{.Table.Name}}Repo, *cachesmocks.{{.Table.Name}}CacheFake, *dalmocks.{{.Table.Name}}DALFake) {
+
+	cache := cachesmocks.New{{.Table.Name}}CacheFake()
+	dal := dalmocks.New{{.Table.Name}}DALFake()
+
+	return repos.New{{.Table.Name}}Repo(
+		config,
+		cache,
+		dal,
+		{{ if .CacheConfig.HasHashID }}idHasher,{{end}}{{range $agg := .CacheConfig.Properties}}
+		{{$agg.Aggregate.Table | toArgName}}Repo,{{end}}
+	), cache, dal
+}
+`))
+
+// GenCacheFakes generates an in-memory fake (see CacheFakeTemplate) of the cache of each cached table into dir
+func GenCacheFakes(basePackage string, tables []*schema.Table, cache map[string]*lib.CacheConfig, dir string) error {
+	return genFakes(CacheFakeTemplate, "CacheFake.go", basePackage, tables, cache, dir)
+}
+
+// GenRepoFakes generates a constructor of the repo of each cached table on fakes of its cache and DAL (see
+// RepoFakeTemplate) into dir
+func GenRepoFakes(basePackage string, tables []*schema.Table, cache map[string]*lib.CacheConfig, dir string) error {
+	return genFakes(RepoFakeTemplate, "RepoFake.go", basePackage, tables, cache, dir)
+}
+
+// genFakes executes a fake template for each cached table, writing <Table><suffix> files into dir
+func genFakes(tpl *template.Template, suffix, basePackage string, tables []*schema.Table, cache map[string]*lib.CacheConfig, dir string) error {
+
+	lib.EnsureDir(dir)
+
+	for _, table := range tables {
+
+		cacheConfig, ok := cache[table.Name]
+		if !ok {
+			continue
+		}
+
+		var data = struct {
+			BasePackage string
+			Table       *schema.Table
+			PrimaryKey  string
+			CacheConfig *CacheData
+		}{
+			BasePackage: basePackage,
+			Table:       table,
+			PrimaryKey:  genutil.FetchTablePrimaryKeyName(table),
+			CacheConfig: ParseIndices(cacheConfig, table),
+		}
+
+		var buf bytes.Buffer
+		if e := tpl.Execute(&buf, data); e != nil {
+			return e
+		}
+
+		code, e := lib.FormatCode(buf.String())
+		if e != nil {
+			return e
+		}
+
+		if e = ioutil.WriteFile(path.Join(dir, table.Name+suffix), code, lib.DefaultFileMode); e != nil {
+			return e
+		}
+	}
+
+	return nil
+}
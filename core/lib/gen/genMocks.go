@@ -0,0 +1,372 @@
+package gen
+
+import (
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"io/ioutil"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/macinnir/dvc/core/lib"
+)
+
+// MockPackage is the package generated mocks and fakes build on
+const MockPackage = "github.com/macinnir/dvc/core/lib/utils/mock"
+
+// GenMocks generates a mock (see GenerateMocks) for each interface in the Go files of srcDir and its subdirectories.
+// The mocks of the package in srcDir are written to destDir/<package>mocks, and those of its subpackages to
+// subdirectories of it named the same way (e.g. gen/definitions/services/users => gen/mocks/servicesmocks/usersmocks).
+func GenMocks(basePackage, srcDir, destDir string) error {
+
+	var e error
+	var files []string
+
+	if files, e = fetchExistingInterfaceFiles(srcDir); e != nil {
+		return e
+	}
+
+	for _, srcFile := range files {
+
+		var src []byte
+		if src, e = ioutil.ReadFile(srcFile); e != nil {
+			return e
+		}
+
+		var srcFileDir = filepath.Dir(srcFile)
+		var rel string
+		if rel, e = filepath.Rel(srcDir, srcFileDir); e != nil {
+			return e
+		}
+
+		var mockDir = path.Join(destDir, filepath.Base(srcDir)+"mocks")
+		if rel != "." {
+			for _, dir := range strings.Split(filepath.ToSlash(rel), "/") {
+				mockDir = path.Join(mockDir, dir+"mocks")
+			}
+		}
+
+		var mocks map[string][]byte
+		if mocks, e = GenerateMocks(src, path.Join(basePackage, filepath.ToSlash(srcFileDir)), path.Base(mockDir)); e != nil {
+			return fmt.Errorf("GenMocks(%s): %w", srcFile, e)
+		}
+
+		if len(mocks) == 0 {
+			continue
+		}
+
+		lib.EnsureDir(mockDir)
+
+		for fileName, code := range mocks {
+			if e = ioutil.WriteFile(path.Join(mockDir, fileName), code, lib.DefaultFileMode); e != nil {
+				return e
+			}
+		}
+	}
+
+	return nil
+}
+
+// GenerateMocks generates a mock in package mockPkg for each interface declared in src, the source of a file of the
+// package imported as pkgPath, and returns the code of each by file name. The mock of IFoo is a FooMock struct that
+// embeds mock.Recorder and has a FooFunc field for each method Foo. Its methods record their calls and run the Func
+// set for them, if any, or return zero values.
+func GenerateMocks(src []byte, pkgPath, mockPkg string) (map[string][]byte, error) {
+
+	var fset = token.NewFileSet()
+
+	file, e := parser.ParseFile(fset, "", src, 0)
+	if e != nil {
+		return nil, e
+	}
+
+	var pkgName = file.Name.Name
+	var mocks = map[string][]byte{}
+
+	for _, decl := range file.Decls {
+
+		genDecl, ok := decl.(*ast.GenDecl)
+		if !ok || genDecl.Tok != token.TYPE {
+			continue
+		}
+
+		for _, spec := range genDecl.Specs {
+
+			typeSpec := spec.(*ast.TypeSpec)
+			ifaceType, ok := typeSpec.Type.(*ast.InterfaceType)
+			if !ok || typeSpec.TypeParams != nil || !typeSpec.Name.IsExported() {
+				continue
+			}
+
+			var mockName = mockTypeName(typeSpec.Name.Name)
+
+			var sb strings.Builder
+			sb.WriteString("// Generated Code; DO NOT EDIT.\n\npackage " + mockPkg + "\n\nimport (\n")
+			sb.WriteString("\t" + pkgName + " " + strconv.Quote(pkgPath) + "\n")
+			sb.WriteString("\t" + strconv.Quote(MockPackage) + "\n")
+			for _, imp := range file.Imports {
+				sb.WriteString("\t")
+				if imp.Name != nil {
+					sb.WriteString(imp.Name.Name + " ")
+				}
+				sb.WriteString(imp.Path.Value + "\n")
+			}
+			sb.WriteString(")\n\n")
+
+			writeMock(&sb, pkgName, typeSpec.Name.Name, mockName, ifaceType)
+
+			code, e := lib.FormatCode(sb.String())
+			if e != nil {
+				return nil, fmt.Errorf("%s: %w", mockName, e)
+			}
+
+			mocks[mockName+".go"] = code
+		}
+	}
+
+	return mocks, nil
+}
+
+// mockTypeName returns the name of the mock of an interface, e.g. IFooDAL => FooDALMock
+func mockTypeName(ifaceName string) string {
+
+	var runes = []rune(ifaceName)
+	if len(runes) > 1 && runes[0] == 'I' && unicode.IsUpper(runes[1]) {
+		ifaceName = ifaceName[1:]
+	}
+
+	return ifaceName + "Mock"
+}
+
+// mockParam is a parameter of a mocked method
+type mockParam struct {
+	Name     string
+	Type     string
+	Variadic bool
+}
+
+// mockMethod is a method of a mocked interface
+type mockMethod struct {
+	Name    string
+	Params  []*mockParam
+	Results []string
+}
+
+// signature returns the parameters and results of the method, with its results named if `named`
+func (m *mockMethod) signature(named bool) string {
+
+	var params = make([]string, len(m.Params))
+	for k, p := range m.Params {
+		params[k] = p.Name + " " + p.Type
+	}
+
+	var sig = "(" + strings.Join(params, ", ") + ")"
+
+	switch {
+	case len(m.Results) == 0:
+	case named:
+		var results = make([]string, len(m.Results))
+		for k := range m.Results {
+			results[k] = "r" + strconv.Itoa(k) + " " + m.Results[k]
+		}
+		sig += " (" + strings.Join(results, ", ") + ")"
+	case len(m.Results) == 1:
+		sig += " " + m.Results[0]
+	default:
+		sig += " (" + strings.Join(m.Results, ", ") + ")"
+	}
+
+	return sig
+}
+
+// writeMock writes the mock of an interface
+func writeMock(sb *strings.Builder, pkgName, ifaceName, mockName string, iface *ast.InterfaceType) {
+
+	var embedded = []string{}
+	var methods = []*mockMethod{}
+
+	for _, field := range iface.Methods.List {
+
+		funcType, ok := field.Type.(*ast.FuncType)
+		if !ok {
+			embedded = append(embedded, types.ExprString(qualifyExpr(field.Type, pkgName)))
+			continue
+		}
+
+		for _, name := range field.Names {
+			methods = append(methods, newMockMethod(name.Name, funcType, pkgName))
+		}
+	}
+
+	fmt.Fprintf(sb, "// %s is a mock of %s.%s. It records its calls and runs the Func set for each method, if any.\n", mockName, pkgName, ifaceName)
+	fmt.Fprintf(sb, "type %s struct {\n\tmock.Recorder\n", mockName)
+	for _, e := range embedded {
+		sb.WriteString("\t" + e + "\n")
+	}
+	for _, m := range methods {
+		fmt.Fprintf(sb, "\n\t// %sFunc is run by %s\n\t%sFunc func%s\n", m.Name, m.Name, m.Name, m.signature(false))
+	}
+	sb.WriteString("}\n\n")
+
+	fmt.Fprintf(sb, "var _ %s.%s = (*%s)(nil)\n", pkgName, ifaceName, mockName)
+
+	for _, m := range methods {
+
+		var args = make([]string, len(m.Params))
+		var callArgs = make([]string, len(m.Params))
+		for k, p := range m.Params {
+			args[k] = p.Name
+			callArgs[k] = p.Name
+			if p.Variadic {
+				callArgs[k] += "..."
+			}
+		}
+
+		var recordArgs = ""
+		if len(args) > 0 {
+			recordArgs = ", " + strings.Join(args, ", ")
+		}
+
+		fmt.Fprintf(sb, "\n// %s records the call and runs %sFunc\n", m.Name, m.Name)
+		fmt.Fprintf(sb, "func (m *%s) %s%s {\n", mockName, m.Name, m.signature(true))
+		fmt.Fprintf(sb, "\tm.Record(%q%s)\n", m.Name, recordArgs)
+		fmt.Fprintf(sb, "\tif m.%sFunc != nil {\n", m.Name)
+		if len(m.Results) > 0 {
+			fmt.Fprintf(sb, "\t\treturn m.%sFunc(%s)\n\t}\n\treturn\n}\n", m.Name, strings.Join(callArgs, ", "))
+		} else {
+			fmt.Fprintf(sb, "\t\tm.%sFunc(%s)\n\t}\n}\n", m.Name, strings.Join(callArgs, ", "))
+		}
+	}
+}
+
+// newMockMethod returns the mockMethod of an interface method
+func newMockMethod(name string, funcType *ast.FuncType, pkgName string) *mockMethod {
+
+	var method = &mockMethod{Name: name}
+
+	// Names the parameters that aren't, and renames those that would shadow the receiver or the results
+	var used = map[string]bool{"m": true}
+	var paramName = func(name string, k int) string {
+		if name == "" || name == "_" || used[name] || (strings.HasPrefix(name, "r") && isDigits(name[1:])) {
+			name = "a" + strconv.Itoa(k)
+		}
+		used[name] = true
+		return name
+	}
+
+	if funcType.Params != nil {
+		for _, field := range funcType.Params.List {
+
+			var paramType = field.Type
+			var variadic = false
+
+			if ellipsis, ok := paramType.(*ast.Ellipsis); ok {
+				paramType = ellipsis.Elt
+				variadic = true
+			}
+
+			var typeString = types.ExprString(qualifyExpr(paramType, pkgName))
+			if variadic {
+				typeString = "..." + typeString
+			}
+
+			var names = field.Names
+			if len(names) == 0 {
+				names = []*ast.Ident{{}}
+			}
+
+			for _, n := range names {
+				method.Params = append(method.Params, &mockParam{
+					Name:     paramName(n.Name, len(method.Params)),
+					Type:     typeString,
+					Variadic: variadic,
+				})
+			}
+		}
+	}
+
+	if funcType.Results != nil {
+		for _, field := range funcType.Results.List {
+			var typeString = types.ExprString(qualifyExpr(field.Type, pkgName))
+			for k := 0; k < len(field.Names) || k == 0; k++ {
+				method.Results = append(method.Results, typeString)
+			}
+		}
+	}
+
+	return method
+}
+
+// isDigits returns true if s is a non-empty string of digits
+func isDigits(s string) bool {
+
+	if len(s) == 0 {
+		return false
+	}
+
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+
+	return true
+}
+
+// qualifyExpr returns a copy of a type expression of package pkgName in which the exported types of that package are
+// qualified by its name, e.g. Foo => pkg.Foo, so the type can be used from another package
+func qualifyExpr(expr ast.Expr, pkgName string) ast.Expr {
+
+	switch t := expr.(type) {
+	case *ast.Ident:
+		if t.IsExported() {
+			return &ast.SelectorExpr{X: ast.NewIdent(pkgName), Sel: ast.NewIdent(t.Name)}
+		}
+		return t
+	case *ast.StarExpr:
+		return &ast.StarExpr{X: qualifyExpr(t.X, pkgName)}
+	case *ast.ArrayType:
+		return &ast.ArrayType{Len: t.Len, Elt: qualifyExpr(t.Elt, pkgName)}
+	case *ast.MapType:
+		return &ast.MapType{Key: qualifyExpr(t.Key, pkgName), Value: qualifyExpr(t.Value, pkgName)}
+	case *ast.ChanType:
+		return &ast.ChanType{Dir: t.Dir, Value: qualifyExpr(t.Value, pkgName)}
+	case *ast.Ellipsis:
+		return &ast.Ellipsis{Elt: qualifyExpr(t.Elt, pkgName)}
+	case *ast.ParenExpr:
+		return &ast.ParenExpr{X: qualifyExpr(t.X, pkgName)}
+	case *ast.IndexExpr:
+		return &ast.IndexExpr{X: qualifyExpr(t.X, pkgName), Index: qualifyExpr(t.Index, pkgName)}
+	case *ast.IndexListExpr:
+		var indices = make([]ast.Expr, len(t.Indices))
+		for k := range t.Indices {
+			indices[k] = qualifyExpr(t.Indices[k], pkgName)
+		}
+		return &ast.IndexListExpr{X: qualifyExpr(t.X, pkgName), Indices: indices}
+	case *ast.FuncType:
+		return &ast.FuncType{Params: qualifyFields(t.Params, pkgName), Results: qualifyFields(t.Results, pkgName)}
+	default:
+		// Qualified types (pkg.Foo) and literal struct and interface types are used as they are
+		return expr
+	}
+}
+
+// qualifyFields qualifies the types of a list of parameters or results (see qualifyExpr)
+func qualifyFields(fields *ast.FieldList, pkgName string) *ast.FieldList {
+
+	if fields == nil {
+		return nil
+	}
+
+	var list = &ast.FieldList{}
+	for _, field := range fields.List {
+		list.List = append(list.List, &ast.Field{Names: field.Names, Type: qualifyExpr(field.Type, pkgName)})
+	}
+
+	return list
+}
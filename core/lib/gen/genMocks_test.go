@@ -0,0 +1,81 @@
+package gen
+
+import (
+	"go/parser"
+	"go/token"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenerateMocks(t *testing.T) {
+
+	src := `// Generated Code; DO NOT EDIT.
+
+package dal
+
+import (
+	"context"
+
+	"example.com/app/gen/definitions/models"
+	query "github.com/macinnir/goquery"
+)
+
+// IFooDAL describes FooDAL
+type IFooDAL interface {
+	Create(model *models.Foo) error
+	FromID(fooID int64, mustExist bool) (*models.Foo, error)
+	UpdateFields(ctx context.Context, model *models.Foo, cols ...query.Column) error
+	Bar() IBarDAL
+	Reset(int64, string)
+	WithStatementCache()
+}
+`
+
+	mocks, e := GenerateMocks([]byte(src), "example.com/app/gen/definitions/dal", "dalmocks")
+	require.Nil(t, e)
+	require.Len(t, mocks, 1)
+
+	code := string(mocks["FooDALMock.go"])
+
+	_, e = parser.ParseFile(token.NewFileSet(), "", code, 0)
+	require.Nil(t, e, code)
+
+	for _, expected := range []string{
+		"package dalmocks",
+		`dal "example.com/app/gen/definitions/dal"`,
+		`"github.com/macinnir/dvc/core/lib/utils/mock"`,
+		"type FooDALMock struct {\n\tmock.Recorder\n",
+		"\tCreateFunc func(model *models.Foo) error\n",
+		"\tFromIDFunc func(fooID int64, mustExist bool) (*models.Foo, error)\n",
+		"\tBarFunc func() dal.IBarDAL\n",
+		"\tResetFunc func(a0 int64, a1 string)\n",
+		"var _ dal.IFooDAL = (*FooDALMock)(nil)",
+		"func (m *FooDALMock) FromID(fooID int64, mustExist bool) (r0 *models.Foo, r1 error) {\n" +
+			"\tm.Record(\"FromID\", fooID, mustExist)\n" +
+			"\tif m.FromIDFunc != nil {\n" +
+			"\t\treturn m.FromIDFunc(fooID, mustExist)\n" +
+			"\t}\n" +
+			"\treturn\n" +
+			"}\n",
+		"func (m *FooDALMock) UpdateFields(ctx context.Context, model *models.Foo, cols ...query.Column) (r0 error) {\n" +
+			"\tm.Record(\"UpdateFields\", ctx, model, cols)\n" +
+			"\tif m.UpdateFieldsFunc != nil {\n" +
+			"\t\treturn m.UpdateFieldsFunc(ctx, model, cols...)\n",
+		"func (m *FooDALMock) WithStatementCache() {\n" +
+			"\tm.Record(\"WithStatementCache\")\n" +
+			"\tif m.WithStatementCacheFunc != nil {\n" +
+			"\t\tm.WithStatementCacheFunc()\n" +
+			"\t}\n" +
+			"}\n",
+	} {
+		assert.True(t, strings.Contains(code, expected), "missing %q in:\n%s", expected, code)
+	}
+}
+
+func TestMockTypeName(t *testing.T) {
+	assert.Equal(t, "FooDALMock", mockTypeName("IFooDAL"))
+	assert.Equal(t, "IdentityMock", mockTypeName("Identity"))
+}
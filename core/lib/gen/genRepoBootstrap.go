@@ -110,6 +110,8 @@ var repoBootstrapFileTpl = template.Must(template.New("repo-bootstrap").Funcs(te
 package repos
 
 import (
+	"context"
+
 	"{{ .BasePackage }}/gen/caches" 
 	"{{ .BasePackage }}/gen/definitions"
 	"{{ .BasePackage }}/core/components/config"
@@ -119,6 +121,11 @@ import (
 // Cache is a container for all cache providers
 type Repos struct { {{range $cache := .Caches}}
 	{{$cache.Name}} *{{$cache.Name}}Repo{{end}}
+
+	caches   *caches.Caches
+	dal      *definitions.DAL
+	config   *config.Config
+	idHasher hashid.IDHasherInterface
 }
 
 // BootstrapRepos bootstraps the repos
@@ -137,9 +144,22 @@ func BootstrapRepos(
 		{{ if $cache.Config.HasHashID }}idHasher,{{end}}{{if $cache.Config.Aggregate }}{{range $agg := $cache.Config.Aggregate.Properties}}
 		{{$agg.Table | toArgName}}Repo,{{end}}{{end}}
 	)
+	{{$cache.Name | toArgName}}Repo.afterCommit = dal.AfterCommit
 	{{end}}
 
 	return &Repos{ {{range $cache := .Caches}}
 		{{$cache.Name}}: {{$cache.Name | toArgName}}Repo, {{end}}
+		caches:   caches,
+		dal:      dal,
+		config:   config,
+		idHasher: idHasher,
 	}
+}
+
+// RunInTx runs fn with repos bound to a transaction (see DAL.RunInTx). Cache writes made through the repos passed
+// to fn happen only after the transaction commits.
+func (r *Repos) RunInTx(ctx context.Context, fn func(tx *Repos) error) error {
+	return r.dal.RunInTx(ctx, func(txDAL *definitions.DAL) error {
+		return fn(BootstrapRepos(r.caches, txDAL, r.config, r.idHasher))
+	})
 }`))
@@ -33,8 +33,8 @@ import (
 	"{{ .BasePackage }}/gen/definitions/dal" 
 	"{{ .BasePackage }}/core/components/config" 
 	{{ if .CacheConfig.HasHashID }}"{{ .BasePackage }}/app/providers/hashid"{{end}}{{ if gt (len .CacheConfig.Location) 0}}
-	"{{ .BasePackage }}/{{ .CacheConfig.Location }}"{{end}}{{if gt (len .CacheConfig.Search) 0}}
-	query "github.com/macinnir/goquery"{{end}}
+	"{{ .BasePackage }}/{{ .CacheConfig.Location }}"{{end}}
+	query "github.com/macinnir/goquery"
 
 	"fmt"
 )
@@ -46,6 +46,7 @@ type {{.Table.Name}}Repo struct {
 	{{.Table.Name | toArgName}}DAL dal.I{{.Table.Name}}DAL
 	{{ if .CacheConfig.HasHashID }}idHasher             hashid.IDHasherInterface{{end}}{{ if gt (len .CacheConfig.Properties) 0}}{{range $agg := .CacheConfig.Properties}}
 	{{$agg.Aggregate.Table | toArgName}}Repo *{{$agg.Aggregate.Table}}Repo{{end}}{{end}}
+	afterCommit func(fn func())
 }
 
 // New{{.Table.Name}}Repo returns a new instance of {{.Table.Name}}Repo
@@ -62,9 +63,40 @@ func New{{.Table.Name}}Repo(
 		{{.Table.Name | toArgName}}DAL, 
 		{{ if .CacheConfig.HasHashID }}idHasher,{{end}}{{ if gt (len .CacheConfig.Properties) 0}}{{range $agg := .CacheConfig.Properties}}
 		{{$agg.Aggregate.Table | toArgName}}Repo,{{end}}{{end}}
+		func(fn func()) { fn() },
 	}
 }
 
+// cacheSave saves models to the cache once the current transaction (if any) commits
+func (r *{{.Table.Name}}Repo) cacheSave(modelSlice ...*models.{{.Table.Name}}) { 
+	r.afterCommit(func() { 
+		for k := range modelSlice { 
+			r.{{.Table.Name | toArgName}}Cache.Save(modelSlice[k]) 
+		}
+	})
+}
+
+// cachePatch copies the given columns onto the cached copy of a model once the current transaction (if any) commits
+func (r *{{.Table.Name}}Repo) cachePatch(model *models.{{.Table.Name}}, cols ...query.Column) { 
+	r.afterCommit(func() { 
+		cached, e := r.{{.Table.Name | toArgName}}Cache.FromID(model.{{.PrimaryKey}})
+		if e != nil || cached == nil { 
+			return
+		}
+		cached.ApplyUpdate(model, cols...)
+		r.{{.Table.Name | toArgName}}Cache.Save(cached)
+	})
+}
+
+// cacheDelete deletes models from the cache once the current transaction (if any) commits
+func (r *{{.Table.Name}}Repo) cacheDelete(ids ...int64) { 
+	r.afterCommit(func() { 
+		for k := range ids { 
+			r.{{.Table.Name | toArgName}}Cache.Delete(ids[k]) 
+		}
+	})
+}
+
 // FromID retrieves a {{.Table.Name}} model by its primary key
 func (r *{{.Table.Name}}Repo) FromID(id int64, mustExist bool) (*models.{{.Table.Name}}, error) { 
 	
@@ -84,8 +116,9 @@ func (r *{{.Table.Name}}Repo) FromID(id int64, mustExist bool) (*models.{{.Table
 		return nil, e 
 	}
 
-	if model != nil { 
-		r.{{.Table.Name | toArgName}}Cache.Save(model)
+	// Deleted models aren't cached
+	if model != nil{{if .IsDeleted}} && model.IsDeleted == 0{{end}} { 
+		r.cacheSave(model)
 	}
 
 	return model, e 
@@ -101,14 +134,14 @@ func (r *{{.Table.Name}}Repo) Reset({{.PrimaryKey | toArgName}} int64) error {
 		return e 
 	}
 
-	// No model in the database, so delete it from the cache
-	if model == nil { 
-		r.{{.Table.Name | toArgName}}Cache.Delete({{.PrimaryKey | toArgName}})
+	// No model in the database{{if .IsDeleted}} (or it was deleted){{end}}, so delete it from the cache
+	if model == nil{{if .IsDeleted}} || model.IsDeleted == 1{{end}} { 
+		r.cacheDelete({{.PrimaryKey | toArgName}})
 		return nil 
 	}
 
 	// Reset the cache 
-	r.{{.Table.Name | toArgName}}Cache.Save(model) 
+	r.cacheSave(model) 
 	return nil 
 }
 
@@ -150,7 +183,7 @@ func (r *{{.Table.Name}}Repo) Create(model *models.{{.Table.Name}}) error {
 		return e 
 	}
 
-	r.{{.Table.Name | toArgName}}Cache.Save(model) 
+	r.cacheSave(model) 
 	
 	return e 
 }
@@ -164,9 +197,7 @@ func (r *{{.Table.Name}}Repo) CreateMany(modelSlice []*models.{{.Table.Name}}) e
 		return e 
 	}
 
-	for k := range modelSlice { 
-		r.{{.Table.Name | toArgName}}Cache.Save(modelSlice[k]) 
-	}
+	r.cacheSave(modelSlice...)
 
 	return nil 
 }
@@ -177,7 +208,7 @@ func (r *{{.Table.Name}}Repo) Update(model *models.{{.Table.Name}}) error {
 	var e = r.{{.Table.Name | toArgName}}DAL.Update(model) 
 	
 	if e == nil { 
-		r.{{.Table.Name | toArgName}}Cache.Save(model) 
+		r.cacheSave(model) 
 	}
 	
 	return e 
@@ -186,27 +217,75 @@ func (r *{{.Table.Name}}Repo) Update(model *models.{{.Table.Name}}) error {
 // UpdateMany updates a slice of {{.Table.Name}} objects 
 func (r *{{.Table.Name}}Repo) UpdateMany(modelSlice []*models.{{.Table.Name}}) error { 
 
-	for k := range modelSlice { 
-		r.{{.Table.Name | toArgName}}Cache.Save(modelSlice[k]) 
+	if e := r.{{.Table.Name | toArgName}}DAL.UpdateMany(modelSlice); e != nil { 
+		return e 
+	}
+
+	r.cacheSave(modelSlice...)
+
+	return nil 
+}
+
+// UpdateFields updates only the given columns of a {{.Table.Name}} model and of its cached copy
+func (r *{{.Table.Name}}Repo) UpdateFields(model *models.{{.Table.Name}}, cols ...query.Column) error { 
+
+	if e := r.{{.Table.Name | toArgName}}DAL.UpdateFields(model, cols...); e != nil { 
+		return e 
 	}
 
-	return r.{{.Table.Name | toArgName}}DAL.UpdateMany(modelSlice)
+	r.cachePatch(model, cols...)
+
+	return nil 
+}
+
+// Patch updates only the columns of a {{.Table.Name}} model that changed since it was loaded
+func (r *{{.Table.Name}}Repo) Patch(model *models.{{.Table.Name}}) error { 
+	return r.UpdateFields(model, model.Changed()...)
 }
 
 // Delete removes a {{.Table.Name}} object from the cache
 func (r *{{.Table.Name}}Repo) Delete(id int64) error { 
-	r.{{.Table.Name | toArgName}}Cache.Delete(id) 
-	return r.{{.Table.Name | toArgName}}DAL.Delete(id)
+	if e := r.{{.Table.Name | toArgName}}DAL.Delete(id); e != nil { 
+		return e 
+	}
+
+	r.cacheDelete(id)
+
+	return nil 
 }
 
 // DeleteMany deletes a slice of {{.Table.Name}} objects 
 func (r *{{.Table.Name}}Repo) DeleteMany(modelSlice []*models.{{.Table.Name}}) error { 
 
+	if e := r.{{.Table.Name | toArgName}}DAL.DeleteMany(modelSlice); e != nil { 
+		return e 
+	}
+
 	for k := range modelSlice { 
-		r.{{.Table.Name | toArgName}}Cache.Delete(modelSlice[k].{{.PrimaryKey}}) 
+		r.cacheDelete(modelSlice[k].{{.PrimaryKey}}) 
+	}
+
+	return nil 
+}
+
+// DeleteHard permanently deletes a {{.Table.Name}} object and removes it from the cache
+func (r *{{.Table.Name}}Repo) DeleteHard(id int64) error { 
+	if e := r.{{.Table.Name | toArgName}}DAL.DeleteHard(id); e != nil { 
+		return e 
+	}
+
+	r.cacheDelete(id)
+
+	return nil 
+}
+
+// Restore unmarks a deleted {{.Table.Name}} object and adds it back to the cache
+func (r *{{.Table.Name}}Repo) Restore(id int64) error { 
+	if e := r.{{.Table.Name | toArgName}}DAL.Restore(id); e != nil { 
+		return e 
 	}
 
-	return r.{{.Table.Name | toArgName}}DAL.DeleteMany(modelSlice)
+	return r.Reset(id)
 }
 
 // All returns a slice of {{.Table.Name}} objects 
@@ -227,7 +306,7 @@ func (r *{{.Table.Name}}Repo) All(page, limit int64) ([]*models.{{.Table.Name}},
 
 		if len(items) > 0 { 
 			for k := range items { 
-				r.{{$.Table.Name | toArgName}}Cache.Save(items[k])
+				r.cacheSave(items[k])
 			}
 		}
 	}
@@ -253,7 +332,7 @@ func (r *{{.Table.Name}}Repo) AllAsCollection(page, limit int64) (*collections.{
 
 		if len(collection.Data) > 0 { 
 			for k := range collection.Data { 
-				r.{{$.Table.Name | toArgName}}Cache.Save(collection.Data[k])
+				r.cacheSave(collection.Data[k])
 			}
 		}
 
@@ -288,7 +367,7 @@ func (r *{{$.Table.Name}}Repo) From{{$index.Columns | columnsToMethodName}}({{$i
 
 		if len(items) > 0 { 
 			for k := range items { 
-				r.{{$.Table.Name | toArgName}}Cache.Save(items[k])
+				r.cacheSave(items[k])
 			}
 		}
 	}
@@ -324,7 +403,7 @@ func (r *{{$.Table.Name}}Repo) From{{$index.Columns | columnsToMethodName}}({{$i
 		}
 
 		if model != nil { 
-			r.{{$.Table.Name | toArgName}}Cache.Save(model) 
+			r.cacheSave(model) 
 		}
 	}
 
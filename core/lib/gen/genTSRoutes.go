@@ -30,6 +30,10 @@ func GenTSRoutes(controllers []*lib.Controller, config *lib.Config) error {
 		os.Remove(path.Join(config.TypescriptRoutesPath, files[k].Name()))
 	}
 
+	if e = ioutil.WriteFile(path.Join(config.TypescriptRoutesPath, "Page.ts"), []byte(tsPageInterface), 0777); e != nil {
+		return e
+	}
+
 	for k := range controllers {
 
 		g := NewTSRouteGenerator(controllers[k])
@@ -56,10 +60,24 @@ func GenTSRoutes(controllers []*lib.Controller, config *lib.Config) error {
 	return nil
 }
 
+// tsPageInterface is the type of routes returning a page of keyset paginated results (see db.Page)
+const tsPageInterface = `/**
+ * Generated Code; DO NOT EDIT
+ */
+export interface Page<T> {
+	Items: T[];
+	Next: string;
+	Prev: string;
+}
+`
+
 type TSRouteGenerator struct {
 	imports    map[string]struct{}
 	controller *lib.Controller
 
+	// hasPage is true when a route returns a page of results (see db.Page)
+	hasPage bool
+
 	// rootRoute is the base route for getting this object type
 	rootRoute *lib.ControllerRoute
 	itemRoute *lib.ControllerRoute
@@ -186,6 +204,10 @@ import axios from 'axios';
 
 	sort.Strings(imports)
 
+	if t.hasPage {
+		s.WriteString("import { Page } from './Page';\n")
+	}
+
 	for k := range imports {
 
 		importTypeDir := "models"
@@ -452,7 +474,14 @@ func (t *TSRouteGenerator) AddImport(importType string) {
 		return
 	}
 
-	if importType[0:2] == "[]" {
+	// Pages import the Page interface next to the routes and their items from wherever they are
+	if item, ok := schema.PageItemType(importType); ok {
+		t.hasPage = true
+		t.AddImport(item)
+		return
+	}
+
+	if len(importType) > 2 && importType[0:2] == "[]" {
 		importType = importType[2:]
 	}
 
@@ -190,3 +190,30 @@ func TestGenTSRoute(t *testing.T) {
 	}
 
 }
+
+func TestGenTSRoutesFromController_Page(t *testing.T) {
+
+	ctl := &lib.Controller{
+		Name:    "PostsController",
+		Package: "app",
+		Routes: []*lib.ControllerRoute{
+			{
+				Name:           "ListPosts",
+				Description:    "lists a page of posts",
+				Path:           "/posts?cursor={cursor:[A-Za-z0-9_-]*}",
+				Method:         "GET",
+				Params:         []lib.ControllerRouteParam{},
+				Queries:        []lib.ControllerRouteQuery{{Name: "cursor", Pattern: "[A-Za-z0-9_-]*", Type: "string", VariableName: "cursor", ValueRaw: "{cursor:[A-Za-z0-9_-]*}"}},
+				ResponseType:   "*db.Page[*models.Post]",
+				ResponseFormat: "JSON",
+				ResponseCode:   200,
+			},
+		},
+	}
+
+	routes, e := NewTSRouteGenerator(ctl).genTSRoutesFromController(ctl)
+	require.Nil(t, e)
+	assert.Contains(t, routes, "import { Page } from './Page';\n")
+	assert.Contains(t, routes, "import { Post } from 'gen/models/Post';\n")
+	assert.Contains(t, routes, "axios.get<Page<Post>>(")
+}
@@ -0,0 +1,29 @@
+package genutil
+
+import (
+	"fmt"
+
+	"github.com/macinnir/dvc/core/lib"
+	"github.com/macinnir/dvc/core/lib/schema"
+)
+
+// Audit returns whether `table` is audited (see lib.AuditConfig) and the columns left out of its change records
+func Audit(config *lib.Config, table *schema.Table) (bool, []string, error) {
+
+	audit, ok := config.Audit[table.Name]
+	if !ok {
+		return false, nil, nil
+	}
+
+	if audit == nil {
+		return true, []string{}, nil
+	}
+
+	for _, name := range audit.Exclude {
+		if _, ok := table.Columns[name]; !ok {
+			return false, nil, fmt.Errorf("audit: excluded column %s is not a column of %s", name, table.Name)
+		}
+	}
+
+	return true, audit.Exclude, nil
+}
@@ -0,0 +1,38 @@
+package genutil
+
+import (
+	"testing"
+
+	"github.com/macinnir/dvc/core/lib"
+	"github.com/macinnir/dvc/core/lib/schema"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAudit(t *testing.T) {
+
+	table := &schema.Table{
+		Name: "User",
+		Columns: map[string]*schema.Column{
+			"UserID":   {Name: "UserID"},
+			"Password": {Name: "Password"},
+		},
+	}
+
+	audited, exclude, e := Audit(&lib.Config{}, table)
+	assert.Nil(t, e)
+	assert.False(t, audited)
+	assert.Nil(t, exclude)
+
+	audited, exclude, e = Audit(&lib.Config{Audit: map[string]*lib.AuditConfig{"User": nil}}, table)
+	assert.Nil(t, e)
+	assert.True(t, audited)
+	assert.Empty(t, exclude)
+
+	audited, exclude, e = Audit(&lib.Config{Audit: map[string]*lib.AuditConfig{"User": {Exclude: []string{"Password"}}}}, table)
+	assert.Nil(t, e)
+	assert.True(t, audited)
+	assert.Equal(t, []string{"Password"}, exclude)
+
+	_, _, e = Audit(&lib.Config{Audit: map[string]*lib.AuditConfig{"User": {Exclude: []string{"Secret"}}}}, table)
+	assert.EqualError(t, e, "audit: excluded column Secret is not a column of User")
+}
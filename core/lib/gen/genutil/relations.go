@@ -0,0 +1,121 @@
+package genutil
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/macinnir/dvc/core/lib"
+	"github.com/macinnir/dvc/core/lib/schema"
+	"github.com/macinnir/dvc/core/lib/subset"
+)
+
+// Relation is a table related to another through the onetomany, onetoone and manytoone maps of their database
+// config. Records of the table are loaded by matching RelatedColumn against the Column of the other table.
+type Relation struct {
+	// Name names the loader, e.g. Account, Users or UsersByManagerID
+	Name          string
+	Table         string
+	Column        string
+	RelatedColumn string
+	// Many is true when a record of the other table can have several related records
+	Many bool
+	// KeyType is the Go type related records are keyed by (int64 or string)
+	KeyType string
+}
+
+// configDatabase returns the config of the database `table` is in, or nil if there is none
+func configDatabase(config *lib.Config, table *schema.Table) *lib.ConfigDatabase {
+	for _, database := range config.Databases {
+		if lib.ExtractRootNameFromKey(database.Key) == table.SchemaName {
+			return database
+		}
+	}
+	return nil
+}
+
+// Relations returns the tables related to `table` in its database config, sorted by name. A table is related
+// to its parents once and to its children once per column referencing it.
+func Relations(config *lib.Config, table *schema.Table) ([]*Relation, error) {
+
+	var relations = []*Relation{}
+
+	var database = configDatabase(config, table)
+	if database == nil {
+		return relations, nil
+	}
+
+	declared, e := subset.ParseRelationships(database)
+	if e != nil {
+		return nil, e
+	}
+
+	oneToOne, e := subset.ParseRelationships(&lib.ConfigDatabase{OneToOne: database.OneToOne})
+	if e != nil {
+		return nil, e
+	}
+
+	var single = map[string]bool{}
+	for k := range oneToOne {
+		single[oneToOne[k].String()] = true
+	}
+
+	var add = func(r *subset.Relationship, relation *Relation) error {
+
+		if r.ChildColumn != r.ParentColumn {
+			relation.Name += "By" + r.ChildColumn
+		}
+
+		column, ok := table.Columns[relation.Column]
+		if !ok {
+			return fmt.Errorf("relationship %s: %s has no column named %s", r, table.Name, relation.Column)
+		}
+
+		if relation.KeyType = RelationKeyType(column); len(relation.KeyType) == 0 {
+			return fmt.Errorf("relationship %s: %s.%s must be an integer or a string", r, table.Name, relation.Column)
+		}
+
+		for k := range relations {
+			if relations[k].Name == relation.Name {
+				return fmt.Errorf("relationship %s: %s is already related to %s as %s", r, table.Name, relation.Table, relation.Name)
+			}
+		}
+
+		relations = append(relations, relation)
+		return nil
+	}
+
+	for _, r := range subset.MergeRelationships(declared) {
+
+		if r.ChildTable == table.Name {
+			if e = add(r, &Relation{Name: r.ParentTable, Table: r.ParentTable, Column: r.ChildColumn, RelatedColumn: r.ParentColumn}); e != nil {
+				return nil, e
+			}
+		}
+
+		if r.ParentTable == table.Name {
+			var relation = &Relation{Name: r.ChildTable, Table: r.ChildTable, Column: r.ParentColumn, RelatedColumn: r.ChildColumn, Many: !single[r.String()]}
+			if relation.Many {
+				relation.Name += "s"
+			}
+			if e = add(r, relation); e != nil {
+				return nil, e
+			}
+		}
+	}
+
+	sort.Slice(relations, func(i, j int) bool { return relations[i].Name < relations[j].Name })
+
+	return relations, nil
+}
+
+// RelationKeyType returns the Go type records are keyed by when related through `column`, or an empty string if
+// it can't relate records
+func RelationKeyType(column *schema.Column) string {
+	switch schema.DataTypeToGoTypeString(column) {
+	case "int64", "int", "null.Int":
+		return "int64"
+	case "string", "null.String":
+		return "string"
+	}
+	return ""
+}
@@ -0,0 +1,51 @@
+package genutil
+
+import (
+	"testing"
+
+	"github.com/macinnir/dvc/core/lib"
+	"github.com/macinnir/dvc/core/lib/schema"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRelations(t *testing.T) {
+
+	user := &schema.Table{
+		Name:       "User",
+		SchemaName: "app",
+		Columns: map[string]*schema.Column{
+			"UserID":    {Name: "UserID", DataType: "bigint", ColumnKey: "PRI"},
+			"AccountID": {Name: "AccountID", DataType: "bigint"},
+			"ManagerID": {Name: "ManagerID", DataType: "bigint", IsNullable: true},
+		},
+	}
+
+	config := &lib.Config{Databases: []*lib.ConfigDatabase{{
+		Key:       "app_local",
+		OneToMany: map[string]string{"Account.AccountID": "User.AccountID"},
+		OneToOne:  map[string]string{"User.UserID": "UserProfile.UserID"},
+		ManyToOne: map[string]string{"User.ManagerID": "User.UserID"},
+	}}}
+
+	relations, e := Relations(config, user)
+	require.Nil(t, e)
+	require.Len(t, relations, 4)
+
+	assert.Equal(t, &Relation{Name: "Account", Table: "Account", Column: "AccountID", RelatedColumn: "AccountID", KeyType: "int64"}, relations[0])
+	assert.Equal(t, &Relation{Name: "UserByManagerID", Table: "User", Column: "ManagerID", RelatedColumn: "UserID", KeyType: "int64"}, relations[1])
+	assert.Equal(t, &Relation{Name: "UserProfile", Table: "UserProfile", Column: "UserID", RelatedColumn: "UserID", KeyType: "int64"}, relations[2])
+	assert.Equal(t, &Relation{Name: "UsersByManagerID", Table: "User", Column: "UserID", RelatedColumn: "ManagerID", Many: true, KeyType: "int64"}, relations[3])
+
+	// Other databases
+	user.SchemaName = "core"
+	relations, e = Relations(config, user)
+	require.Nil(t, e)
+	assert.Len(t, relations, 0)
+
+	// Missing column
+	user.SchemaName = "app"
+	delete(user.Columns, "ManagerID")
+	_, e = Relations(config, user)
+	assert.NotNil(t, e)
+}
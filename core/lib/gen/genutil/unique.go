@@ -0,0 +1,82 @@
+package genutil
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/macinnir/dvc/core/lib"
+	"github.com/macinnir/dvc/core/lib/schema"
+)
+
+// UniqueIndexes returns the columns of each unique index on `table`: its UNIQUE columns and the indices of its
+// cache config (see lib.Config.Cache) that are marked Unique. The primary key is not included.
+func UniqueIndexes(config *lib.Config, table *schema.Table) ([][]*schema.Column, error) {
+
+	var indexes = [][]*schema.Column{}
+	var seen = map[string]struct{}{}
+
+	var add = func(columns []*schema.Column) {
+		var names = make([]string, len(columns))
+		for k := range columns {
+			names[k] = columns[k].Name
+		}
+		var key = strings.Join(names, ",")
+		if _, ok := seen[key]; ok {
+			return
+		}
+		seen[key] = struct{}{}
+		indexes = append(indexes, columns)
+	}
+
+	var names = make([]string, 0, len(table.Columns))
+	for name := range table.Columns {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		if table.Columns[name].ColumnKey == "UNI" {
+			add([]*schema.Column{table.Columns[name]})
+		}
+	}
+
+	if cacheConfig, ok := config.Cache[table.Name]; ok {
+		for _, index := range cacheConfig.Indices {
+
+			if !index.Unique {
+				continue
+			}
+
+			var fields = strings.Split(index.Field, ",")
+			var columns = make([]*schema.Column, len(fields))
+
+			for k := range fields {
+				column, ok := table.Columns[strings.TrimSpace(fields[k])]
+				if !ok {
+					return nil, fmt.Errorf("unique index %s: %s has no column named %s", index.Field, table.Name, strings.TrimSpace(fields[k]))
+				}
+				columns[k] = column
+			}
+
+			if len(columns) == 1 && columns[0].ColumnKey == "PRI" {
+				continue
+			}
+
+			add(columns)
+		}
+	}
+
+	return indexes, nil
+}
+
+// Dialect returns the type of the database `table` is in (see lib.ConfigDatabase.Type), which is MySQL unless
+// configured otherwise.
+func Dialect(config *lib.Config, table *schema.Table) string {
+
+	if database := configDatabase(config, table); database != nil && len(database.Type) > 0 {
+		return database.Type
+	}
+
+	return schema.SchemaTypeMySQL
+}
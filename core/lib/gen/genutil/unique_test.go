@@ -0,0 +1,55 @@
+package genutil
+
+import (
+	"testing"
+
+	"github.com/macinnir/dvc/core/lib"
+	"github.com/macinnir/dvc/core/lib/schema"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUniqueIndexes(t *testing.T) {
+
+	table := &schema.Table{
+		Name: "Foo",
+		Columns: map[string]*schema.Column{
+			"FooID":     {Name: "FooID", ColumnKey: "PRI"},
+			"Email":     {Name: "Email", ColumnKey: "UNI"},
+			"AccountID": {Name: "AccountID", ColumnKey: "MUL"},
+			"Code":      {Name: "Code"},
+		},
+	}
+
+	config := &lib.Config{Cache: map[string]*lib.CacheConfig{
+		"Foo": {Indices: []*lib.CacheConfigIndex{
+			{Field: "AccountID"},
+			{Field: "Email", Unique: true},
+			{Field: "AccountID, Code", Unique: true},
+			{Field: "FooID", Unique: true},
+		}},
+	}}
+
+	indexes, e := UniqueIndexes(config, table)
+	require.Nil(t, e)
+	require.Len(t, indexes, 2)
+	assert.Equal(t, []*schema.Column{table.Columns["Email"]}, indexes[0])
+	assert.Equal(t, []*schema.Column{table.Columns["AccountID"], table.Columns["Code"]}, indexes[1])
+
+	config.Cache["Foo"].Indices = append(config.Cache["Foo"].Indices, &lib.CacheConfigIndex{Field: "Missing", Unique: true})
+	_, e = UniqueIndexes(config, table)
+	assert.NotNil(t, e)
+}
+
+func TestDialect(t *testing.T) {
+
+	table := &schema.Table{Name: "Foo", SchemaName: "app"}
+
+	assert.Equal(t, schema.SchemaTypeMySQL, Dialect(&lib.Config{}, table))
+
+	config := &lib.Config{Databases: []*lib.ConfigDatabase{
+		{Key: "core_local", Type: schema.SchemaTypeMySQL},
+		{Key: "app_local", Type: schema.SchemaTypePostgreSQL},
+	}}
+	assert.Equal(t, schema.SchemaTypePostgreSQL, Dialect(config, table))
+}
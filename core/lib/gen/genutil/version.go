@@ -0,0 +1,35 @@
+package genutil
+
+import (
+	"fmt"
+
+	"github.com/macinnir/dvc/core/lib"
+	"github.com/macinnir/dvc/core/lib/schema"
+)
+
+// VersionColumn returns the column `table` uses for optimistic locking (see lib.Config.VersionColumns), or an
+// empty string if it has none. The column must be an integer that isn't nullable.
+func VersionColumn(config *lib.Config, table *schema.Table) (string, error) {
+
+	name, ok := config.VersionColumns[table.Name]
+	if !ok {
+		return "", nil
+	}
+
+	column, ok := table.Columns[name]
+	if !ok {
+		return "", fmt.Errorf("version column %s is not a column of %s", name, table.Name)
+	}
+
+	switch column.DataType {
+	case "tinyint", "smallint", "mediumint", "int", "bigint":
+	default:
+		return "", fmt.Errorf("version column %s of %s must be an integer", name, table.Name)
+	}
+
+	if column.IsNullable {
+		return "", fmt.Errorf("version column %s of %s must be NOT NULL", name, table.Name)
+	}
+
+	return name, nil
+}
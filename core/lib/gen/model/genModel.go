@@ -3,9 +3,12 @@ package model
 import (
 	"bytes"
 	"fmt"
+	"math"
 	"os"
 	"path"
 	"sort"
+	"strconv"
+	"strings"
 
 	"github.com/macinnir/dvc/core/lib"
 	"github.com/macinnir/dvc/core/lib/gen/genutil"
@@ -14,8 +17,8 @@ import (
 
 // 0.607244
 
-// GenModels generates models
-func GenModels(tables []*schema.Table, config *lib.Config) error {
+// GenModels generates the models of `tables`. Their relations may point to any table of `schemaList`.
+func GenModels(tables []*schema.Table, schemaList *schema.SchemaList, config *lib.Config) error {
 
 	// start := time.Now()
 
@@ -23,12 +26,41 @@ func GenModels(tables []*schema.Table, config *lib.Config) error {
 
 	lib.EnsureDir(lib.ModelsGenDir)
 
+	// Relations are resolved against every table, not just the ones being generated
+	var tableMap = map[string]*schema.Table{}
+	if schemaList != nil {
+		for k := range schemaList.Schemas {
+			for name, table := range schemaList.Schemas[k].Tables {
+				tableMap[name] = table
+			}
+		}
+	}
+
+	for k := range tables {
+		tableMap[tables[k].Name] = tables[k]
+	}
+
 	for k := range tables {
 
 		var table = tables[k]
 
+		versionColumn, e := genutil.VersionColumn(config, table)
+		if e != nil {
+			return e
+		}
+
+		relations, e := genutil.Relations(config, table)
+		if e != nil {
+			return fmt.Errorf("%s: %w", table.Name, e)
+		}
+
+		relationVals, e := buildRelationVals(table, relations, tableMap)
+		if e != nil {
+			return e
+		}
+
 		fullPath := path.Join(lib.ModelsGenDir, table.Name+".go")
-		if e := buildGoModel(config.BasePackage, fullPath, table); e != nil {
+		if e := buildGoModel(config.BasePackage, fullPath, table, versionColumn, relationVals); e != nil {
 			return e
 		}
 		generatedModelCount++
@@ -39,10 +71,10 @@ func GenModels(tables []*schema.Table, config *lib.Config) error {
 	return nil
 }
 
-func buildGoModel(packageName, fullPath string, table *schema.Table) (e error) {
+func buildGoModel(packageName, fullPath string, table *schema.Table, versionColumn string, relations []GoModelRelationVal) (e error) {
 	// var modelNode *lib.GoStruct
 	var outFile []byte
-	outFile, e = buildFileFromModelNode(table)
+	outFile, e = buildFileFromModelNode(table, versionColumn, relations)
 	if e != nil {
 		fmt.Println("ERROR Building File From Model Node ", table, e.Error())
 		return
@@ -102,16 +134,29 @@ func buildModelNodeFromTable(table *schema.Table) (*lib.GoStruct, error) {
 }
 
 type GoModelTemplateVals struct {
-	Name          string
-	Schema        string
-	HasNull       bool
-	HasAccountID  bool
-	HasUserID     bool
-	UpdateColumns []GoModelTemplateFieldVal
-	InsertColumns []GoModelTemplateFieldVal
-	PrimaryKey    string
-	Fields        []GoModelTemplateFieldVal
-	SelectFields  []GoModelTemplateFieldVal
+	Name           string
+	Schema         string
+	HasNull        bool
+	HasAccountID   bool
+	HasUserID      bool
+	HasIsDeleted   bool
+	HasLastUpdated bool
+	UpdateColumns  []GoModelTemplateFieldVal
+	InsertColumns  []GoModelTemplateFieldVal
+	PrimaryKey     string
+	VersionColumn  string
+	Fields         []GoModelTemplateFieldVal
+	SelectFields   []GoModelTemplateFieldVal
+	Relations      []GoModelRelationVal
+	Validations    []string
+
+	// Parameterized statements
+	InsertSQL       string
+	InsertWithIDSQL string
+	UpdateSQL       string
+	DeleteSQL       string
+	DeleteHardSQL   string
+	RestoreSQL      string
 }
 
 // schema.GoTypeFormatString
@@ -123,13 +168,72 @@ type GoModelTemplateFieldVal struct {
 	FormatType string
 }
 
-func buildFileFromModelNode(table *schema.Table) ([]byte, error) {
+// GoModelRelationVal is a table whose records a slice of models can load. Value and RelatedValue are the keys of
+// a model `m` of either table, which are only set when Valid and RelatedValid (if any) are true.
+type GoModelRelationVal struct {
+	*genutil.Relation
+	Value        string
+	Valid        string
+	RelatedValue string
+	RelatedValid string
+	INFunc       string
+}
+
+// buildRelationVals checks the related columns of `relations` against their tables
+func buildRelationVals(table *schema.Table, relations []*genutil.Relation, tables map[string]*schema.Table) ([]GoModelRelationVal, error) {
+
+	var vals = make([]GoModelRelationVal, len(relations))
+
+	for k, relation := range relations {
+
+		relatedTable, ok := tables[relation.Table]
+		if !ok {
+			return nil, fmt.Errorf("%s: related table %s not found", table.Name, relation.Table)
+		}
+
+		relatedColumn, ok := relatedTable.Columns[relation.RelatedColumn]
+		if !ok {
+			return nil, fmt.Errorf("%s: related table %s has no column named %s", table.Name, relation.Table, relation.RelatedColumn)
+		}
+
+		if genutil.RelationKeyType(relatedColumn) != relation.KeyType {
+			return nil, fmt.Errorf("%s: %s.%s and %s.%s are different types", table.Name, table.Name, relation.Column, relation.Table, relation.RelatedColumn)
+		}
+
+		vals[k] = GoModelRelationVal{Relation: relation, INFunc: "INInt64"}
+		if relation.KeyType == "string" {
+			vals[k].INFunc = "INString"
+		}
+
+		vals[k].Value, vals[k].Valid = relationKey(table.Columns[relation.Column])
+		vals[k].RelatedValue, vals[k].RelatedValid = relationKey(relatedColumn)
+	}
+
+	return vals, nil
+}
+
+// relationKey returns the key of a model `m` when related through `column`, and the condition it is set on
+func relationKey(column *schema.Column) (value, valid string) {
+	switch schema.DataTypeToGoTypeString(column) {
+	case "int":
+		return "int64(m." + column.Name + ")", ""
+	case "null.Int":
+		return "m." + column.Name + ".Int64", "m." + column.Name + ".Valid"
+	case "null.String":
+		return "m." + column.Name + ".String", "m." + column.Name + ".Valid"
+	}
+	return "m." + column.Name, ""
+}
+
+func buildFileFromModelNode(table *schema.Table, versionColumn string, relations []GoModelRelationVal) ([]byte, error) {
 
 	var vals = GoModelTemplateVals{
-		Name:         table.Name,
-		Schema:       table.SchemaName,
-		Fields:       make([]GoModelTemplateFieldVal, len(table.Columns)),
-		SelectFields: []GoModelTemplateFieldVal{},
+		Name:          table.Name,
+		Schema:        table.SchemaName,
+		VersionColumn: versionColumn,
+		Relations:     relations,
+		Fields:        make([]GoModelTemplateFieldVal, len(table.Columns)),
+		SelectFields:  []GoModelTemplateFieldVal{},
 	}
 
 	var sortedColumns = make([]string, len(table.Columns))
@@ -154,6 +258,14 @@ func buildFileFromModelNode(table *schema.Table) ([]byte, error) {
 			vals.HasUserID = true
 		}
 
+		if col.Name == "IsDeleted" {
+			vals.HasIsDeleted = true
+		}
+
+		if col.Name == "LastUpdated" {
+			vals.HasLastUpdated = true
+		}
+
 		if col.ColumnKey == "PRI" {
 			vals.PrimaryKey = col.Name
 		}
@@ -185,8 +297,11 @@ func buildFileFromModelNode(table *schema.Table) ([]byte, error) {
 			vals.SelectFields = append(vals.SelectFields, field)
 		}
 
+		vals.Validations = append(vals.Validations, buildFieldValidations(col, field.GoType)...)
 	}
 
+	buildModelSQL(&vals)
+
 	var e error
 	var buf bytes.Buffer
 
@@ -197,3 +312,110 @@ func buildFileFromModelNode(table *schema.Table) ([]byte, error) {
 	return buf.Bytes(), nil
 
 }
+
+// buildFieldValidations returns the checks of a field of Go type goType (see schema.DataTypeToGoTypeString) against
+// its column that the Validate method of the model runs on a *errors.ValidationError `v`. Nullable fields are
+// only checked when Valid. Non-nullable string columns without a default are required to be non-empty.
+func buildFieldValidations(col *schema.Column, goType string) []string {
+
+	var value = "c." + col.Name
+	var condition = ""
+
+	switch goType {
+	case "int":
+		value = "int64(" + value + ")"
+	case "null.String":
+		value, condition = value+".String", value+".Valid"
+	case "null.Int":
+		value, condition = value+".Int64", value+".Valid"
+	case "null.Float":
+		value, condition = value+".Float64", value+".Valid"
+	}
+
+	var checks = []string{}
+	var field = strconv.Quote(col.Name)
+
+	switch {
+	case col.DataType == "enum":
+		checks = append(checks, "v.OneOf("+field+", "+value+quotedValues(schema.EnumValues(col))+")")
+	case col.DataType == "set":
+		checks = append(checks, "v.SetOf("+field+", "+value+quotedValues(schema.EnumValues(col))+")")
+	case schema.IsString(col):
+		if !col.IsNullable && !col.HasDefault && len(col.Default) == 0 {
+			checks = append(checks, "v.Required("+field+", "+value+")")
+		}
+		if (col.DataType == "char" || col.DataType == "varchar") && col.MaxLength > 0 {
+			checks = append(checks, fmt.Sprintf("v.MaxLength(%s, %s, %d)", field, value, col.MaxLength))
+		}
+	case schema.IsInteger(col) && (strings.HasPrefix(goType, "int") || goType == "null.Int"):
+		// Skips the checks the Go type already makes
+		if min, max := schema.IntegerRange(col); min > math.MinInt64 || max < math.MaxInt64 {
+			checks = append(checks, fmt.Sprintf("v.Range(%s, %s, %d, %d)", field, value, min, max))
+		}
+	case col.DataType == "decimal" && col.Precision > 0:
+		checks = append(checks, fmt.Sprintf("v.Decimal(%s, %s, %d, %d, %t)", field, value, col.Precision, col.NumericScale, col.IsUnsigned))
+	}
+
+	if len(condition) > 0 {
+		for k := range checks {
+			checks[k] = "if " + condition + " {\n\t\t" + checks[k] + "\n\t}"
+		}
+	}
+
+	return checks
+}
+
+// quotedValues returns values as Go string literals, each preceded by a comma
+func quotedValues(values []string) string {
+	var s = ""
+	for _, v := range values {
+		s += ", " + strconv.Quote(v)
+	}
+	return s
+}
+
+// buildModelSQL builds the INSERT, UPDATE and DELETE statements for a model with `?` placeholders in the order
+// of InsertColumns and UpdateColumns. Models with an IsDeleted column are deleted by setting it. If the model has a version column, the UPDATE increments it instead of
+// setting it and only matches the version the record was read with (an extra `?` after the primary key).
+func buildModelSQL(vals *GoModelTemplateVals) {
+
+	var insertColumns = make([]string, len(vals.InsertColumns))
+	for k := range vals.InsertColumns {
+		insertColumns[k] = "`" + vals.InsertColumns[k].Name + "`"
+	}
+
+	var updateColumns = make([]string, 0, len(vals.UpdateColumns))
+	for k := range vals.UpdateColumns {
+		if vals.UpdateColumns[k].Name != vals.VersionColumn {
+			updateColumns = append(updateColumns, "`"+vals.UpdateColumns[k].Name+"` = ?")
+		}
+	}
+
+	var pk = "`" + vals.PrimaryKey + "`"
+	var table = "`" + vals.Name + "`"
+	var updateWhere = pk + " = ?"
+
+	if len(vals.VersionColumn) > 0 {
+		var version = "`" + vals.VersionColumn + "`"
+		updateColumns = append(updateColumns, version+" = "+version+" + 1")
+		updateWhere += " AND " + version + " = ?"
+	}
+
+	vals.InsertSQL = "INSERT INTO " + table + " (" + strings.Join(insertColumns, ", ") + ") VALUES (" + placeholders(len(insertColumns)) + ")"
+	vals.InsertWithIDSQL = "INSERT INTO " + table + " (" + strings.Join(append([]string{pk}, insertColumns...), ", ") + ") VALUES (" + placeholders(len(insertColumns)+1) + ")"
+	vals.UpdateSQL = "UPDATE " + table + " SET " + strings.Join(updateColumns, ", ") + " WHERE " + updateWhere
+	vals.DeleteSQL = "DELETE FROM " + table + " WHERE " + pk + " = ?"
+
+	if vals.HasIsDeleted {
+		vals.DeleteHardSQL = vals.DeleteSQL
+		vals.DeleteSQL = "UPDATE " + table + " SET `IsDeleted` = 1 WHERE " + pk + " = ?"
+		vals.RestoreSQL = "UPDATE " + table + " SET `IsDeleted` = 0 WHERE " + pk + " = ?"
+	}
+}
+
+func placeholders(n int) string {
+	if n < 1 {
+		return ""
+	}
+	return strings.Repeat("?, ", n-1) + "?"
+}
@@ -1,9 +1,15 @@
 package model
 
 import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"os"
+	"path"
 	"testing"
 
 	"github.com/macinnir/dvc/core/lib"
+	"github.com/macinnir/dvc/core/lib/gen/genutil"
 	"github.com/macinnir/dvc/core/lib/schema"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -106,3 +112,406 @@ func TestBuildModelNodeFromTable(t *testing.T) {
 	assert.Equal(t, "null.String", m.Fields.Get(1).DataType)
 	assert.Contains(t, *m.Imports, lib.NullPackage)
 }
+
+// modelMethods parses a generated model file and returns its methods as `Receiver.Method`
+func modelMethods(t *testing.T, src []byte) map[string]bool {
+
+	f, e := parser.ParseFile(token.NewFileSet(), "model.go", src, 0)
+	require.Nil(t, e, string(src))
+
+	methods := map[string]bool{}
+	for _, decl := range f.Decls {
+		fn, ok := decl.(*ast.FuncDecl)
+		if !ok || fn.Recv == nil {
+			continue
+		}
+		recv := fn.Recv.List[0].Type
+		if star, ok := recv.(*ast.StarExpr); ok {
+			recv = star.X
+		}
+		methods[recv.(*ast.Ident).Name+"."+fn.Name.Name] = true
+	}
+
+	return methods
+}
+
+func TestBuildFileFromModelNode_ContextMethods(t *testing.T) {
+
+	table := &schema.Table{
+		Name:       "Foo",
+		SchemaName: "app",
+		Columns: map[string]*schema.Column{
+			"FooID": {Name: "FooID", DataType: "bigint", ColumnKey: "PRI", Extra: "auto_increment"},
+			"Name":  {Name: "Name", DataType: "varchar"},
+		},
+	}
+
+	src, e := buildFileFromModelNode(table, "", nil)
+	require.Nil(t, e)
+
+	methods := modelMethods(t, src)
+
+	for _, name := range []string{
+		"Foo.CreateContext",
+		"Foo.UpdateContext",
+		"Foo.DeleteContext",
+		"Foo.RawContext",
+		"FooDALSelector.RunContext",
+		"FooDALSelector.Filter",
+		"FooDALSelector.SQL",
+		"FooDALCounter.RunContext",
+		"FooDALSummer.RunContext",
+		"FooDALMinner.RunContext",
+		"FooDALMaxer.RunContext",
+		"FooDALGetter.RunContext",
+		"Foo.Table_Column_Value",
+		"FooDALSelector.OnShards",
+		"FooDALCounter.OnShards",
+		"FooDALGetter.OnShards",
+		"FooDALSelector.Primary",
+		"FooDALCounter.Primary",
+		"FooDALGetter.Primary",
+		"FooDALSummer.Primary",
+	} {
+		assert.True(t, methods[name], name)
+	}
+}
+
+func TestBuildModelSQL(t *testing.T) {
+
+	vals := GoModelTemplateVals{
+		Name:          "Foo",
+		PrimaryKey:    "FooID",
+		InsertColumns: []GoModelTemplateFieldVal{{Name: "A"}, {Name: "B"}},
+		UpdateColumns: []GoModelTemplateFieldVal{{Name: "B"}},
+	}
+
+	buildModelSQL(&vals)
+
+	assert.Equal(t, "INSERT INTO `Foo` (`A`, `B`) VALUES (?, ?)", vals.InsertSQL)
+	assert.Equal(t, "INSERT INTO `Foo` (`FooID`, `A`, `B`) VALUES (?, ?, ?)", vals.InsertWithIDSQL)
+	assert.Equal(t, "UPDATE `Foo` SET `B` = ? WHERE `FooID` = ?", vals.UpdateSQL)
+	assert.Equal(t, "DELETE FROM `Foo` WHERE `FooID` = ?", vals.DeleteSQL)
+}
+
+func TestBuildModelSQL_VersionColumn(t *testing.T) {
+
+	vals := GoModelTemplateVals{
+		Name:          "Foo",
+		PrimaryKey:    "FooID",
+		VersionColumn: "Version",
+		InsertColumns: []GoModelTemplateFieldVal{{Name: "B"}, {Name: "Version"}},
+		UpdateColumns: []GoModelTemplateFieldVal{{Name: "B"}, {Name: "Version"}},
+	}
+
+	buildModelSQL(&vals)
+
+	assert.Equal(t, "UPDATE `Foo` SET `B` = ?, `Version` = `Version` + 1 WHERE `FooID` = ? AND `Version` = ?", vals.UpdateSQL)
+}
+
+func TestBuildFileFromModelNode_VersionColumn(t *testing.T) {
+
+	table := &schema.Table{
+		Name:       "Foo",
+		SchemaName: "app",
+		Columns: map[string]*schema.Column{
+			"FooID":   {Name: "FooID", DataType: "bigint", ColumnKey: "PRI", Extra: "auto_increment"},
+			"Name":    {Name: "Name", DataType: "varchar"},
+			"Version": {Name: "Version", DataType: "int"},
+		},
+	}
+
+	src, e := buildFileFromModelNode(table, "Version", nil)
+	require.Nil(t, e)
+
+	assert.Contains(t, string(src), "db.UpdateVersioned(ctx, conn, string(Foo_TableName), c.FooID, Foo_UpdateSQL,")
+	assert.Contains(t, string(src), "c.Version++")
+
+	// A transaction retried after a deadlock updates the model with the version it was read with
+	assert.Contains(t, string(src), "c.RestoreOnRollback(conn)\n\tc.Version++")
+	assert.Contains(t, string(src), "c.Version = version")
+}
+
+func TestBuildModelSQL_IsDeleted(t *testing.T) {
+
+	vals := GoModelTemplateVals{
+		Name:         "Foo",
+		PrimaryKey:   "FooID",
+		HasIsDeleted: true,
+	}
+
+	buildModelSQL(&vals)
+
+	assert.Equal(t, "UPDATE `Foo` SET `IsDeleted` = 1 WHERE `FooID` = ?", vals.DeleteSQL)
+	assert.Equal(t, "DELETE FROM `Foo` WHERE `FooID` = ?", vals.DeleteHardSQL)
+	assert.Equal(t, "UPDATE `Foo` SET `IsDeleted` = 0 WHERE `FooID` = ?", vals.RestoreSQL)
+}
+
+func TestBuildFileFromModelNode_SoftDelete(t *testing.T) {
+
+	table := &schema.Table{
+		Name:       "Foo",
+		SchemaName: "app",
+		Columns: map[string]*schema.Column{
+			"FooID":     {Name: "FooID", DataType: "bigint", ColumnKey: "PRI", Extra: "auto_increment"},
+			"IsDeleted": {Name: "IsDeleted", DataType: "tinyint"},
+		},
+	}
+
+	src, e := buildFileFromModelNode(table, "", nil)
+	require.Nil(t, e)
+
+	methods := modelMethods(t, src)
+
+	for _, name := range []string{
+		"Foo.DeleteHardContext",
+		"Foo.RestoreContext",
+		"FooDALSelector.WithDeleted",
+		"FooDALSelector.OnlyDeleted",
+		"FooDALCounter.WithDeleted",
+		"FooDALGetter.OnlyDeleted",
+	} {
+		assert.True(t, methods[name], name)
+	}
+
+	assert.Contains(t, string(src), "r.stmt.SQL(where, r.deleted.Condition(Foo_Column_IsDeleted))")
+
+	// No IsDeleted column
+	delete(table.Columns, "IsDeleted")
+	src, e = buildFileFromModelNode(table, "", nil)
+	require.Nil(t, e)
+
+	methods = modelMethods(t, src)
+	assert.False(t, methods["FooDALSelector.WithDeleted"])
+	assert.False(t, methods["Foo.RestoreContext"])
+}
+
+func TestBuildFileFromModelNode_DirtyTracking(t *testing.T) {
+
+	table := &schema.Table{
+		Name:       "Foo",
+		SchemaName: "app",
+		Columns: map[string]*schema.Column{
+			"FooID":       {Name: "FooID", DataType: "bigint", ColumnKey: "PRI", Extra: "auto_increment"},
+			"Name":        {Name: "Name", DataType: "varchar"},
+			"LastUpdated": {Name: "LastUpdated", DataType: "bigint"},
+		},
+	}
+
+	src, e := buildFileFromModelNode(table, "", nil)
+	require.Nil(t, e)
+
+	methods := modelMethods(t, src)
+
+	for _, name := range []string{
+		"Foo.UpdateFieldsContext",
+		"Foo.Snapshot",
+		"Foo.Changed",
+		"Foo.Diff",
+		"Foo.ApplyUpdate",
+	} {
+		assert.True(t, methods[name], name)
+	}
+
+	assert.Contains(t, string(src), "db.UpdateColumnsSQL(")
+	assert.Contains(t, string(src), "c.LastUpdated = from.LastUpdated")
+}
+
+func TestBuildFileFromModelNode_Relations(t *testing.T) {
+
+	user := &schema.Table{
+		Name:       "User",
+		SchemaName: "app",
+		Columns: map[string]*schema.Column{
+			"UserID":      {Name: "UserID", DataType: "bigint", ColumnKey: "PRI", Extra: "auto_increment"},
+			"AccountID":   {Name: "AccountID", DataType: "bigint"},
+			"AccountCode": {Name: "AccountCode", DataType: "varchar", IsNullable: true},
+		},
+	}
+
+	account := &schema.Table{
+		Name: "Account",
+		Columns: map[string]*schema.Column{
+			"AccountID": {Name: "AccountID", DataType: "bigint", ColumnKey: "PRI", Extra: "auto_increment"},
+			"Code":      {Name: "Code", DataType: "varchar"},
+		},
+	}
+
+	tables := map[string]*schema.Table{"User": user, "Account": account}
+	config := &lib.Config{Databases: []*lib.ConfigDatabase{{
+		Key:       "app_local",
+		ManyToOne: map[string]string{"User.AccountID": "Account.AccountID", "User.AccountCode": "Account.Code"},
+	}}}
+
+	relations, e := genutil.Relations(config, user)
+	require.Nil(t, e)
+
+	vals, e := buildRelationVals(user, relations, tables)
+	require.Nil(t, e)
+	require.Len(t, vals, 2)
+	assert.Equal(t, "m.AccountID", vals[0].Value)
+	assert.Equal(t, "m.AccountCode.String", vals[1].Value)
+	assert.Equal(t, "m.AccountCode.Valid", vals[1].Valid)
+	assert.Equal(t, "INString", vals[1].INFunc)
+
+	src, e := buildFileFromModelNode(user, "", vals)
+	require.Nil(t, e)
+
+	methods := modelMethods(t, src)
+
+	for _, name := range []string{
+		"UserSlice.LoadAccount",
+		"UserSlice.LoadAccountByAccountCode",
+		"UserDALSelector.WithAccount",
+		"UserDALSelector.runShards",
+	} {
+		assert.True(t, methods[name], name)
+	}
+
+	assert.Contains(t, string(src), "Filter(db.INInt64(Account_Column_AccountID, keys...))")
+
+	// The related column has to be the same type
+	account.Columns["Code"].DataType = "bigint"
+	_, e = buildRelationVals(user, relations, tables)
+	assert.NotNil(t, e)
+}
+
+func TestGenModels_RelatedTableNotGenerated(t *testing.T) {
+
+	user := &schema.Table{
+		Name:       "User",
+		SchemaName: "app",
+		Columns: map[string]*schema.Column{
+			"UserID":    {Name: "UserID", DataType: "bigint", ColumnKey: "PRI", Extra: "auto_increment"},
+			"AccountID": {Name: "AccountID", DataType: "bigint"},
+		},
+	}
+
+	account := &schema.Table{
+		Name:       "Account",
+		SchemaName: "app",
+		Columns: map[string]*schema.Column{
+			"AccountID": {Name: "AccountID", DataType: "bigint", ColumnKey: "PRI", Extra: "auto_increment"},
+		},
+	}
+
+	schemaList := &schema.SchemaList{Schemas: []*schema.Schema{{Name: "app", Tables: map[string]*schema.Table{"User": user, "Account": account}}}}
+	config := &lib.Config{BasePackage: "example.com/app", Databases: []*lib.ConfigDatabase{{
+		Key:       "app_local",
+		ManyToOne: map[string]string{"User.AccountID": "Account.AccountID"},
+	}}}
+
+	t.Chdir(t.TempDir())
+
+	// Only User changed, but its relation to Account is still generated
+	require.Nil(t, GenModels([]*schema.Table{user}, schemaList, config))
+
+	src, e := os.ReadFile(path.Join(lib.ModelsGenDir, "User.go"))
+	require.Nil(t, e)
+	assert.Contains(t, string(src), "func (s UserSlice) LoadAccount(")
+
+	assert.NotNil(t, GenModels([]*schema.Table{user}, nil, config))
+}
+
+func TestBuildFileFromModelNode_Page(t *testing.T) {
+
+	table := &schema.Table{
+		Name:       "Foo",
+		SchemaName: "app",
+		Columns: map[string]*schema.Column{
+			"FooID": {Name: "FooID", DataType: "bigint", ColumnKey: "PRI", Extra: "auto_increment"},
+			"Name":  {Name: "Name", DataType: "varchar"},
+		},
+	}
+
+	src, e := buildFileFromModelNode(table, "", nil)
+	require.Nil(t, e)
+
+	methods := modelMethods(t, src)
+
+	for _, name := range []string{
+		"FooDALSelector.After",
+		"FooDALSelector.Before",
+		"FooDALSelector.Page",
+		"FooDALSelector.PageContext",
+	} {
+		assert.True(t, methods[name], name)
+	}
+
+	assert.Contains(t, string(src), "r.stmt.Page(Foo_PrimaryKey, r.cursor, r.before, limit)")
+	assert.Contains(t, string(src), "db.NewPage(r.stmt, rows, (*Foo).Table_Column_Value)")
+}
+
+func TestBuildFileFromModelNode_Each(t *testing.T) {
+
+	table := &schema.Table{
+		Name:       "Foo",
+		SchemaName: "app",
+		Columns: map[string]*schema.Column{
+			"FooID":     {Name: "FooID", DataType: "bigint", ColumnKey: "PRI", Extra: "auto_increment"},
+			"Name":      {Name: "Name", DataType: "varchar"},
+			"IsDeleted": {Name: "IsDeleted", DataType: "tinyint"},
+		},
+	}
+
+	src, e := buildFileFromModelNode(table, "", nil)
+	require.Nil(t, e)
+
+	methods := modelMethods(t, src)
+
+	for _, name := range []string{
+		"FooDALSelector.Each",
+		"FooDALSelector.All",
+		"FooDALSelector.Chan",
+		"FooDALSelector.Batches",
+	} {
+		assert.True(t, methods[name], name)
+	}
+
+	assert.Contains(t, string(src), "func (r *FooDALSelector) All(ctx context.Context) iter.Seq2[*Foo, error] {")
+	assert.Contains(t, string(src), "db.EachBatch(ctx, r.stmt, Foo_PrimaryKey, r.batch,")
+	assert.Contains(t, string(src), "q, args := batch.SQL(where, r.deleted.Condition(Foo_Column_IsDeleted))")
+}
+
+func TestBuildFileFromModelNode_Validate(t *testing.T) {
+
+	table := &schema.Table{
+		Name:       "Foo",
+		SchemaName: "app",
+		Columns: map[string]*schema.Column{
+			"FooID":  {Name: "FooID", DataType: "bigint", IsUnsigned: true, ColumnKey: "PRI", Extra: "auto_increment"},
+			"Name":   {Name: "Name", DataType: "varchar", MaxLength: 50},
+			"Code":   {Name: "Code", DataType: "char", MaxLength: 2, Default: "XX"},
+			"Title":  {Name: "Title", DataType: "varchar", MaxLength: 50, HasDefault: true},
+			"Note":   {Name: "Note", DataType: "varchar", MaxLength: 255, IsNullable: true},
+			"Rank":   {Name: "Rank", DataType: "tinyint", IsUnsigned: true},
+			"Status": {Name: "Status", DataType: "enum", Type: "enum('active','closed')"},
+			"Tags":   {Name: "Tags", DataType: "set", Type: "set('a','b')"},
+			"Amount": {Name: "Amount", DataType: "decimal", Precision: 10, NumericScale: 2, IsNullable: true},
+			"Count":  {Name: "Count", DataType: "bigint"},
+		},
+	}
+
+	src, e := buildFileFromModelNode(table, "", nil)
+	require.Nil(t, e)
+
+	assert.True(t, modelMethods(t, src)["Foo.Validate"])
+
+	code := string(src)
+	for _, expected := range []string{
+		"\tif c.Amount.Valid {\n\t\tv.Decimal(\"Amount\", c.Amount.Float64, 10, 2, false)\n\t}\n",
+		"\tv.MaxLength(\"Code\", c.Code, 2)\n",
+		"\tv.Range(\"FooID\", c.FooID, 0, 18446744073709551615)\n",
+		"\tv.Required(\"Name\", c.Name)\n\tv.MaxLength(\"Name\", c.Name, 50)\n",
+		"\tif c.Note.Valid {\n\t\tv.MaxLength(\"Note\", c.Note.String, 255)\n\t}\n",
+		"\tv.Range(\"Rank\", int64(c.Rank), 0, 255)\n",
+		"\tv.OneOf(\"Status\", c.Status, \"active\", \"closed\")\n",
+		"\tv.SetOf(\"Tags\", c.Tags, \"a\", \"b\")\n",
+	} {
+		assert.Contains(t, code, expected)
+	}
+
+	assert.NotContains(t, code, "v.Required(\"Code\"")
+	assert.NotContains(t, code, "v.Required(\"Title\"")
+	assert.NotContains(t, code, "\"Count\", c.Count")
+}
@@ -7,8 +7,12 @@ package models
 
 import (
 	query "github.com/macinnir/goquery"
+	"github.com/macinnir/dvc/core/lib/utils/db"
+	"github.com/macinnir/dvc/core/lib/utils/errors"
+	"context"
 	"encoding/json"
 	"fmt"
+	"iter"
 	"database/sql" {{ if .HasNull }}
 	"gopkg.in/guregu/null.v3"{{ end }}
 )
@@ -52,12 +56,42 @@ var (
 	// Primary Key
 	// {{ $.Name }}_PrimaryKey is the name of the table's primary key 
 	{{ $.Name }}_PrimaryKey query.Column = "{{.PrimaryKey}}"
+
+	// {{ $.Name }}_SelectFields are the quoted columns read by selectors
+	{{ $.Name }}_SelectFields = []string{ {{ range .SelectFields }}
+		"` + "`{{ .Name }}`" + `",{{ end }}
+	}
+)
+
+const (
+	// {{ $.Name }}_InsertSQL inserts a record with an auto-increment primary key
+	{{ $.Name }}_InsertSQL = "{{ .InsertSQL }}"
+
+	// {{ $.Name }}_InsertWithIDSQL inserts a record with its primary key set
+	{{ $.Name }}_InsertWithIDSQL = "{{ .InsertWithIDSQL }}"
+
+	// {{ $.Name }}_UpdateSQL updates a record by its primary key
+	{{ $.Name }}_UpdateSQL = "{{ .UpdateSQL }}"
+
+	// {{ $.Name }}_DeleteSQL {{ if .HasIsDeleted }}marks a record as deleted{{ else }}deletes a record{{ end }} by its primary key
+	{{ $.Name }}_DeleteSQL = "{{ .DeleteSQL }}"
+{{- if .HasIsDeleted }}
+
+	// {{ $.Name }}_DeleteHardSQL deletes a record by its primary key
+	{{ $.Name }}_DeleteHardSQL = "{{ .DeleteHardSQL }}"
+
+	// {{ $.Name }}_RestoreSQL unmarks a deleted record by its primary key
+	{{ $.Name }}_RestoreSQL = "{{ .RestoreSQL }}"
+{{- end }}
 )
 
 // {{ $.Name }} is a data model
 type {{ $.Name }} struct { 
 	{{ range .Fields }}
 	{{ .Name }} {{ .GoType }} ` + "`" + `db:"{{ .Name }}" json:"{{ .Name }}"` + "`" + `{{ end }}
+
+	// snapshot holds the values of the update columns when the model was read (see Changed)
+	snapshot []interface{}
 }
 
 {{ if .HasAccountID }}// Account satifies the IAccountable interface 
@@ -94,6 +128,15 @@ func (c *{{ $.Name }}) Table_PrimaryKey_Value() int64 {
 	return c.{{ $.PrimaryKey }}
 }
 
+// Table_Column_Value returns the value of a column
+func (c *{{ $.Name }}) Table_Column_Value(col query.Column) interface{} {
+	switch col { {{ range .Fields }}
+	case {{ $.Name }}_Column_{{ .Name }}:
+		return c.{{ .Name }}{{ end }}
+	}
+	return nil
+}
+
 // Table_InsertColumns is a list of all insert columns for this model
 func (c *{{ $.Name }}) Table_InsertColumns() []query.Column {
 	return {{ $.Name }}_InsertColumns
@@ -117,41 +160,218 @@ func (c *{{ $.Name }}) String() string {
 }
 
 // Update updates a {{ $.Name }} record
-func (c *{{ $.Name }}) Update(db query.DBInterface) error {
-	var e error 
-	var ql string 
-	ql, _ = query.Update(c).{{ range .UpdateColumns }}
-		Set({{ $.Name }}_Column_{{ .Name }}, c.{{ .Name }}{{ if eq .GoType "null.String" }}.String{{ end }}).{{ end }}
-		Where(query.EQ({{ $.Name }}_Column_{{ $.PrimaryKey }}, c.{{ $.PrimaryKey }})).
-		String()
-	_, e = db.Exec(ql) 
+func (c *{{ $.Name }}) Update(conn query.DBInterface) error {
+	return c.UpdateContext(context.Background(), conn)
+}
+
+// UpdateContext updates a {{ $.Name }} record{{ if .VersionColumn }}. If the record was changed since it was read
+// (its {{ .VersionColumn }} no longer matches), a ConcurrentModificationError is returned.{{ end }}
+func (c *{{ $.Name }}) UpdateContext(ctx context.Context, conn query.DBInterface) error {
+{{- if .VersionColumn }}
+	e := db.UpdateVersioned(ctx, conn, string({{ $.Name }}_TableName), c.{{ $.PrimaryKey }}, {{ $.Name }}_UpdateSQL, {{ range .UpdateColumns }}{{ if ne .Name $.VersionColumn }}
+		c.{{ .Name }},{{ end }}{{ end }}
+		c.{{ $.PrimaryKey }},
+		c.{{ .VersionColumn }},
+	) 
 	if e != nil {
 		return fmt.Errorf("{{ $.Name }}.Update(): %w", e)
 	}
 
-	return e 
+	c.RestoreOnRollback(conn)
+	c.{{ .VersionColumn }}++
+	c.Snapshot()
+{{- else }}
+	_, e := db.ExecContext(ctx, conn, {{ $.Name }}_UpdateSQL, {{ range .UpdateColumns }}
+		c.{{ .Name }},{{ end }}
+		c.{{ $.PrimaryKey }},
+	) 
+	if e != nil {
+		return fmt.Errorf("{{ $.Name }}.Update(): %w", e)
+	}
+
+	c.RestoreOnRollback(conn)
+	c.Snapshot()
+{{- end }}
+
+	return nil 
+}
+
+// UpdateFields updates the given columns of a {{ $.Name }} record
+func (c *{{ $.Name }}) UpdateFields(conn query.DBInterface, cols ...query.Column) error {
+	return c.UpdateFieldsContext(context.Background(), conn, cols...)
+}
+
+// UpdateFieldsContext updates the given columns of a {{ $.Name }} record, leaving the others as they are in the
+// database{{ if .VersionColumn }}. Like Update, it returns a ConcurrentModificationError if the record's
+// {{ .VersionColumn }} no longer matches{{ end }}.
+func (c *{{ $.Name }}) UpdateFieldsContext(ctx context.Context, conn query.DBInterface, cols ...query.Column) error {
+
+	q, setCols, e := db.UpdateColumnsSQL({{ $.Name }}_TableName, {{ $.Name }}_PrimaryKey, {{ $.Name }}_UpdateColumns, cols, "{{ .VersionColumn }}")
+	if e != nil {
+		return fmt.Errorf("{{ $.Name }}.UpdateFields(): %w", e)
+	}
+
+	var args = make([]interface{}, 0, len(setCols)+2)
+	for _, col := range setCols {
+		args = append(args, c.Table_Column_Value(col))
+	}
+
+	args = append(args, c.{{ $.PrimaryKey }})
+{{- if .VersionColumn }}
+	args = append(args, c.{{ .VersionColumn }})
+
+	if e = db.UpdateVersioned(ctx, conn, string({{ $.Name }}_TableName), c.{{ $.PrimaryKey }}, q, args...); e != nil {
+		return fmt.Errorf("{{ $.Name }}.UpdateFields(): %w", e)
+	}
+
+	c.RestoreOnRollback(conn)
+	c.{{ .VersionColumn }}++
+{{- else }}
+
+	if _, e = db.ExecContext(ctx, conn, q, args...); e != nil {
+		return fmt.Errorf("{{ $.Name }}.UpdateFields(): %w", e)
+	}
+
+	c.RestoreOnRollback(conn)
+{{- end }}
+
+	// The updated columns are no longer changed
+	if c.snapshot != nil {
+		for k, col := range {{ $.Name }}_UpdateColumns {
+			for _, setCol := range setCols {
+				if col == setCol {
+					c.snapshot[k] = c.Table_Column_Value(col)
+				}
+			}
+		}
+	}
+
+	return nil
+}
+
+// Snapshot records the values of the update columns, which Changed compares against. Models are snapshotted when
+// they are read, created or updated.
+func (c *{{ $.Name }}) Snapshot() {
+	c.snapshot = make([]interface{}, len({{ $.Name }}_UpdateColumns))
+	for k, col := range {{ $.Name }}_UpdateColumns {
+		c.snapshot[k] = c.Table_Column_Value(col)
+	}
+}
+
+// RestoreOnRollback puts the snapshot{{ if .VersionColumn }} and {{ .VersionColumn }}{{ end }} back as they are now if conn's transaction rolls
+// back, so that a transaction retried after a deadlock (see db.RunInTx) updates the model again from the same
+// state. Updates call it before changing them.
+func (c *{{ $.Name }}) RestoreOnRollback(conn query.DBInterface) {
+
+	var snapshot []interface{}
+	if c.snapshot != nil {
+		snapshot = append(make([]interface{}, 0, len(c.snapshot)), c.snapshot...)
+	}
+{{- if .VersionColumn }}
+	var version = c.{{ .VersionColumn }}
+{{- end }}
+
+	db.OnRollback(conn, func() {
+		c.snapshot = snapshot
+{{- if .VersionColumn }}
+		c.{{ .VersionColumn }} = version
+{{- end }}
+	})
+}
+
+// Changed returns the update columns whose values changed since the model was snapshotted, or all of them if it
+// never was (e.g. a model decoded from JSON){{ if .VersionColumn }}. {{ .VersionColumn }} is never returned.{{ end }}
+func (c *{{ $.Name }}) Changed() []query.Column {
+
+	var changed = []query.Column{}
+
+	for k, col := range {{ $.Name }}_UpdateColumns {
+{{- if .VersionColumn }}
+		if col == {{ $.Name }}_Column_{{ .VersionColumn }} {
+			continue
+		}
+{{- end }}
+		if c.snapshot == nil || c.Table_Column_Value(col) != c.snapshot[k] {
+			changed = append(changed, col)
+		}
+	}
+
+	return changed
+}
+
+// Diff returns the values before and after of the columns Changed returns. Before is nil if the model was never
+// snapshotted.
+func (c *{{ $.Name }}) Diff() db.Diff {
+
+	var diff = db.Diff{}
+
+	for k, col := range {{ $.Name }}_UpdateColumns {
+{{- if .VersionColumn }}
+		if col == {{ $.Name }}_Column_{{ .VersionColumn }} {
+			continue
+		}
+{{- end }}
+		if c.snapshot == nil {
+			diff[string(col)] = &db.Change{After: c.Table_Column_Value(col)}
+		} else if value := c.Table_Column_Value(col); value != c.snapshot[k] {
+			diff[string(col)] = &db.Change{Before: c.snapshot[k], After: value}
+		}
+	}
+
+	return diff
+}
+
+// ApplyUpdate copies the columns written by from.UpdateFields(conn, cols...){{ if or .HasLastUpdated .VersionColumn }} (and {{ if .HasLastUpdated }}LastUpdated{{ end }}{{ if and .HasLastUpdated .VersionColumn }} and {{ end }}{{ if .VersionColumn }}{{ .VersionColumn }}{{ end }}){{ end }}
+// onto c, e.g. to update a cached copy of the record
+func (c *{{ $.Name }}) ApplyUpdate(from *{{ $.Name }}, cols ...query.Column) {
+	for _, col := range cols {
+		switch col { {{ range .UpdateColumns }}
+		case {{ $.Name }}_Column_{{ .Name }}:
+			c.{{ .Name }} = from.{{ .Name }}{{ end }}
+		}
+	}
+{{- if .HasLastUpdated }}
+	c.LastUpdated = from.LastUpdated
+{{- end }}
+{{- if .VersionColumn }}
+	c.{{ .VersionColumn }} = from.{{ .VersionColumn }}
+{{- end }}
+}
+
+// Validate checks the values of c against the constraints of the columns of {{ $.Name }}, returning an
+// *errors.ValidationError with a message for each field that would not fit its column
+func (c *{{ $.Name }}) Validate() error {
+	v := errors.NewValidationError()
+{{- range .Validations }}
+	{{ . }}
+{{- end }}
+	return v.Err()
 }
 
 // Create inserts a {{ $.Name }} record
-func (c *{{ $.Name }}) Create(db query.DBInterface) error {
+func (c *{{ $.Name }}) Create(conn query.DBInterface) error {
+	return c.CreateContext(context.Background(), conn)
+}
+
+// CreateContext inserts a {{ $.Name }} record
+func (c *{{ $.Name }}) CreateContext(ctx context.Context, conn query.DBInterface) error {
 	
 	var e error 
-	
-	q := query.Insert(c)
+	var ql = {{ $.Name }}_InsertSQL
+	var args = []interface{}{ {{ range .InsertColumns }}
+		c.{{ .Name }},{{ end }}
+	}
 	
 	if c.{{ $.PrimaryKey }} > 0 { 
-		q.Set({{ $.Name }}_Column_{{ $.PrimaryKey }}, c.{{ $.PrimaryKey }})
+		ql = {{ $.Name }}_InsertWithIDSQL
+		args = append([]interface{}{c.{{ $.PrimaryKey }}}, args...)
 	}
-	
-	{{ range .InsertColumns }}
-		q.Set({{ $.Name }}_Column_{{ .Name }}, c.{{ .Name }}{{ if eq .GoType "null.String" }}.String{{ end }}){{ end }}
 
-	ql, _ := q.String()
 	var result sql.Result
-	result, e = db.Exec(ql)
+	result, e = db.ExecContext(ctx, conn, ql, args...)
 
 	if e != nil {
-		return fmt.Errorf("{{ $.Name }}.Create(): %w", e) // 177
+		return fmt.Errorf("{{ $.Name }}.Create(): %w", e)
 	}
 
 	// Assumes auto-increment
@@ -159,27 +379,69 @@ func (c *{{ $.Name }}) Create(db query.DBInterface) error {
 		c.{{ $.PrimaryKey }}, e = result.LastInsertId()
 	}
 
+	c.Snapshot()
+
 	return e 
 }
 
+// Delete {{ if .HasIsDeleted }}marks a {{ $.Name }} record as deleted{{ else }}deletes a {{ $.Name }} record{{ end }}
+func (c *{{ $.Name }}) Delete(conn query.DBInterface) error {
+	return c.DeleteContext(context.Background(), conn)
+}
 
-// Destroy deletes a {{ $.Name }} record
-func (c *{{ $.Name }}) Delete(db query.DBInterface) error {
-	var e error 
-	ql, _ := query.Delete(c).
-		Where(
-			query.EQ({{ $.Name }}_Column_{{ $.PrimaryKey }}, c.{{ $.PrimaryKey }}),
-		).String()
-
-	_, e = db.Exec(ql)
+// DeleteContext {{ if .HasIsDeleted }}marks a {{ $.Name }} record as deleted{{ else }}deletes a {{ $.Name }} record{{ end }}
+func (c *{{ $.Name }}) DeleteContext(ctx context.Context, conn query.DBInterface) error {
+	_, e := db.ExecContext(ctx, conn, {{ $.Name }}_DeleteSQL, c.{{ $.PrimaryKey }})
 	if e != nil {
 		return fmt.Errorf("{{ $.Name }}.Delete(): %w", e)
 	}
+{{- if .HasIsDeleted }}
+
+	c.IsDeleted = 1
+{{- end }}
+
+	return nil
+}
+{{- if .HasIsDeleted }}
+
+// DeleteHard deletes a {{ $.Name }} record
+func (c *{{ $.Name }}) DeleteHard(conn query.DBInterface) error {
+	return c.DeleteHardContext(context.Background(), conn)
+}
+
+// DeleteHardContext deletes a {{ $.Name }} record
+func (c *{{ $.Name }}) DeleteHardContext(ctx context.Context, conn query.DBInterface) error {
+	_, e := db.ExecContext(ctx, conn, {{ $.Name }}_DeleteHardSQL, c.{{ $.PrimaryKey }})
+	if e != nil {
+		return fmt.Errorf("{{ $.Name }}.DeleteHard(): %w", e)
+	}
+
+	return nil
+}
+
+// Restore unmarks a deleted {{ $.Name }} record
+func (c *{{ $.Name }}) Restore(conn query.DBInterface) error {
+	return c.RestoreContext(context.Background(), conn)
+}
+
+// RestoreContext unmarks a deleted {{ $.Name }} record
+func (c *{{ $.Name }}) RestoreContext(ctx context.Context, conn query.DBInterface) error {
+	_, e := db.ExecContext(ctx, conn, {{ $.Name }}_RestoreSQL, c.{{ $.PrimaryKey }})
+	if e != nil {
+		return fmt.Errorf("{{ $.Name }}.Restore(): %w", e)
+	}
+
+	c.IsDeleted = 0
 
-	return e
+	return nil
 }
+{{- end }}
 
-func (r *{{ $.Name }}) Raw(db query.DBInterface, queryRaw string) ([]*{{ $.Name }}, error) {
+func (r *{{ $.Name }}) Raw(conn query.DBInterface, queryRaw string) ([]*{{ $.Name }}, error) {
+	return r.RawContext(context.Background(), conn, queryRaw)
+}
+
+func (r *{{ $.Name }}) RawContext(ctx context.Context, conn query.DBInterface, queryRaw string) ([]*{{ $.Name }}, error) {
 
 	var e error
 	model := []*{{ $.Name }}{}
@@ -194,7 +456,7 @@ func (r *{{ $.Name }}) Raw(db query.DBInterface, queryRaw string) ([]*{{ $.Name
 	}
 
 	var rows *sql.Rows 
-	rows, e = db.Query(q) 
+	rows, e = db.QueryContext(ctx, db.ReadConn(ctx, conn), q) 
 
 	if e != nil {
 		if e == sql.ErrNoRows { 
@@ -211,6 +473,7 @@ func (r *{{ $.Name }}) Raw(db query.DBInterface, queryRaw string) ([]*{{ $.Name
 		); e != nil { 
 			return nil, fmt.Errorf("{{ $.Name }}DALRaw(%s).Run(): %w", q, e)
 		}
+		m.Snapshot()
 		model = append(model, m)
 	}
 
@@ -219,74 +482,269 @@ func (r *{{ $.Name }}) Raw(db query.DBInterface, queryRaw string) ([]*{{ $.Name
 	return model, nil
 }
 
-type I{{ $.Name }}DALSelector interface { 
-	Select(db query.DBInterface) I{{ $.Name }}DALSelector
+{{ if .Relations }}// {{ $.Name }}Slice is a slice of {{ $.Name }} models that can load their related records
+type {{ $.Name }}Slice []*{{ $.Name }}
+{{ range .Relations }}
+// Load{{ .Name }} loads the {{ .Table }} {{ if .Many }}records{{ else }}record{{ end }} of each model with a single query, keyed by {{ $.Name }}.{{ .Column }}
+func (s {{ $.Name }}Slice) Load{{ .Name }}(ctx context.Context, conn query.DBInterface) (map[{{ .KeyType }}]{{ if .Many }}[]{{ end }}*{{ .Table }}, error) {
+
+	var related = map[{{ .KeyType }}]{{ if .Many }}[]{{ end }}*{{ .Table }}{}
+	var keys = []{{ .KeyType }}{}
+	var seen = map[{{ .KeyType }}]struct{}{}
+
+	for _, m := range s {
+		{{ if .Valid }}if !{{ .Valid }} {
+			continue
+		}
+		{{ end }}if _, ok := seen[{{ .Value }}]; !ok {
+			seen[{{ .Value }}] = struct{}{}
+			keys = append(keys, {{ .Value }})
+		}
+	}
+
+	if len(keys) == 0 {
+		return related, nil
+	}
+
+	rows, e := (&{{ .Table }}{}).Select(conn).Filter(db.{{ .INFunc }}({{ .Table }}_Column_{{ .RelatedColumn }}, keys...)).RunContext(ctx)
+	if e != nil {
+		return nil, fmt.Errorf("{{ $.Name }}Slice.Load{{ .Name }}: %w", e)
+	}
+
+	for _, m := range rows {
+		{{ if .RelatedValid }}if !{{ .RelatedValid }} {
+			continue
+		}
+		{{ end }}{{ if .Many }}related[{{ .RelatedValue }}] = append(related[{{ .RelatedValue }}], m){{ else }}related[{{ .RelatedValue }}] = m{{ end }}
+	}
+
+	return related, nil
+}
+{{ end }}
+{{ end }}type I{{ $.Name }}DALSelector interface { 
+	Select(conn query.DBInterface) I{{ $.Name }}DALSelector
 }
 
+// {{ $.Name }}DALSelector selects {{ $.Name }} records. Conditions added with Filter are bound as parameters; 
+// where parts added with Where are rendered by goquery and ANDed with them.
 type {{ $.Name }}DALSelector struct {
 	db    	 query.DBInterface
+	shards   func(ctx context.Context) []query.DBInterface
 	q     	 *query.Q
+	stmt     *db.SelectStatement
+	hasWhere bool 
+	primary  bool
+	cursor   string
+	before   bool
+	batch    int64
+{{- if .HasIsDeleted }}
+	deleted  db.Deleted
+{{- end }}
+{{- if .Relations }}
+	loaders  []func(ctx context.Context, conn query.DBInterface, rows []*{{ $.Name }}) error
+{{- end }}
 	isSingle bool 
 }
 
-func (r *{{ $.Name }}) Select(db query.DBInterface) *{{ $.Name }}DALSelector {
+func (r *{{ $.Name }}) Select(conn query.DBInterface) *{{ $.Name }}DALSelector {
 	return &{{ $.Name }}DALSelector{
-		db:    db,
+		db:    conn,
 		q:     query.Select(r),
+		stmt:  db.NewSelect({{ $.Name }}_TableName, {{ $.Name }}_SelectFields...),
 	}
 }
 
 func (r *{{ $.Name }}DALSelector) Alias(alias string) *{{ $.Name }}DALSelector { 
 	r.q.Alias(alias) 
+	r.stmt.Alias(alias)
 	return r
 }
 
 func (r *{{ $.Name }}DALSelector) Where(whereParts ...*query.WherePart) *{{ $.Name }}DALSelector {
 	r.q.Where(whereParts...)
+	r.hasWhere = true
+	return r
+}
+
+// Filter adds conditions whose values are bound as parameters
+func (r *{{ $.Name }}DALSelector) Filter(conditions ...db.Condition) *{{ $.Name }}DALSelector {
+	r.stmt.Where(conditions...)
 	return r
 }
 
 func (r *{{ $.Name }}DALSelector) GroupBy(cols ...query.Column) *{{ $.Name }}DALSelector {
-	r.q.GroupBy(cols...)
+	r.stmt.GroupBy(cols...)
 	return r
 }
 
 func (r *{{ $.Name }}DALSelector) Limit(limit, offset int64) *{{ $.Name }}DALSelector {
-	r.q = r.q.Limit(limit, offset)
+	r.stmt.Limit(limit, offset)
 	return r
 }
 
 func (r *{{ $.Name }}DALSelector) OrderBy(col query.Column, dir query.OrderDir) *{{ $.Name }}DALSelector {
-	r.q = r.q.OrderBy(col, dir)
+	r.stmt.OrderBy(col, dir)
 	return r
 }
 
-func (r *{{ $.Name }}DALSelector) String() (string, error) { 
+// OnShards runs the select on the connections returned by shards, merging the rows when there are several
+func (r *{{ $.Name }}DALSelector) OnShards(shards func(ctx context.Context) []query.DBInterface) *{{ $.Name }}DALSelector {
+	r.shards = shards
+	return r
+}
+
+func (r *{{ $.Name }}DALSelector) where() (db.Condition, error) { 
+	if !r.hasWhere { 
+		return db.Condition{}, nil 
+	}
+	where, e := db.QueryWhere(r.q, {{ $.Name }}_PrimaryKey)
+	if e != nil {
+		return db.Condition{}, fmt.Errorf("{{ $.Name }}DAL.Query.String(): %w", e)
+	}
+	return where, nil 
+}
+
+// SQL returns the statement with placeholders and its arguments
+func (r *{{ $.Name }}DALSelector) SQL() (string, []interface{}, error) { 
 	
-	r.q.Fields({{ range .SelectFields }}
-		{{ if eq .DBType "vector" }}{{ else }}query.NewField(query.FieldTypeBasic, {{ $.Name }}_Column_{{ .Name }}),{{ end }}{{ end }}
-	)
+	where, e := r.where()
+	if e != nil { 
+		return "", nil, e 
+	}
+
+	q, args := r.stmt.SQL(where{{ if $.HasIsDeleted }}, r.deleted.Condition({{ $.Name }}_Column_IsDeleted){{ end }})
+	return q, args, nil 
+}
+
+// String returns the statement with placeholders 
+func (r *{{ $.Name }}DALSelector) String() (string, error) { 
+	q, _, e := r.SQL()
+	return q, e 
+}
+
+{{ if .HasIsDeleted }}// WithDeleted includes deleted records, which are excluded by default
+func (r *{{ $.Name }}DALSelector) WithDeleted() *{{ $.Name }}DALSelector {
+	r.deleted = db.WithDeleted
+	return r
+}
+
+// OnlyDeleted only includes deleted records
+func (r *{{ $.Name }}DALSelector) OnlyDeleted() *{{ $.Name }}DALSelector {
+	r.deleted = db.OnlyDeleted
+	return r
+}
+
+{{ end }}{{ range .Relations }}// With{{ .Name }} loads the {{ .Table }} records related to the selected models into dest (see {{ $.Name }}Slice.Load{{ .Name }})
+func (r *{{ $.Name }}DALSelector) With{{ .Name }}(dest *map[{{ .KeyType }}]{{ if .Many }}[]{{ end }}*{{ .Table }}) *{{ $.Name }}DALSelector {
+	r.loaders = append(r.loaders, func(ctx context.Context, conn query.DBInterface, rows []*{{ $.Name }}) (e error) {
+		*dest, e = {{ $.Name }}Slice(rows).Load{{ .Name }}(ctx, conn)
+		return e
+	})
+	return r
+}
+
+{{ end }}// Primary reads from the primary rather than a replica, e.g. to read a record that was just written
+func (r *{{ $.Name }}DALSelector) Primary() *{{ $.Name }}DALSelector {
+	r.primary = true
+	return r
+}
 
-	q, e := r.q.String()
+// After selects the page after cursor, which is the Next cursor of a page (see Page)
+func (r *{{ $.Name }}DALSelector) After(cursor string) *{{ $.Name }}DALSelector {
+	r.cursor = cursor
+	r.before = false
+	return r
+}
+
+// Before selects the page before cursor, which is the Prev cursor of a page (see Page)
+func (r *{{ $.Name }}DALSelector) Before(cursor string) *{{ $.Name }}DALSelector {
+	r.cursor = cursor
+	r.before = true
+	return r
+}
+
+// Page selects a page of limit records in the order of OrderBy and then of the primary key, starting from the
+// cursor set with After or Before (if any). Unlike Limit, it doesn't slow down as pages go further.
+func (r *{{ $.Name }}DALSelector) Page(limit int64) (*db.Page[*{{ $.Name }}], error) {
+	return r.PageContext(context.Background(), limit)
+}
+
+// PageContext is Page with a context
+func (r *{{ $.Name }}DALSelector) PageContext(ctx context.Context, limit int64) (*db.Page[*{{ $.Name }}], error) {
+
+	if e := r.stmt.Page({{ $.Name }}_PrimaryKey, r.cursor, r.before, limit); e != nil {
+		return nil, fmt.Errorf("{{ $.Name }}DALSelector.Page: %w", e)
+	}
+
+	rows, e := r.RunContext(ctx)
 	if e != nil {
-		return "", fmt.Errorf("{{ $.Name }}DAL.Query.String(): %w", e)
+		return nil, e
 	}
 
-	return q, nil 
+	return db.NewPage(r.stmt, rows, (*{{ $.Name }}).Table_Column_Value)
 }
 
 func (r *{{ $.Name }}DALSelector) Run() ([]*{{ $.Name }}, error) {
+	return r.RunContext(context.Background())
+}
 
-	var e error 
-	var q = "" 
-	var model = []*{{ $.Name }}{}
-	q, e = r.String()
+func (r *{{ $.Name }}DALSelector) RunContext(ctx context.Context) ([]*{{ $.Name }}, error) {
+
+	if r.primary {
+		ctx = db.WithPrimary(ctx)
+	}
+{{ if .Relations }}
+	rows, e := r.runShards(ctx)
+	if e != nil {
+		return nil, e
+	}
+
+	for _, load := range r.loaders {
+		if e = load(ctx, r.db, rows); e != nil {
+			return nil, e
+		}
+	}
+
+	return rows, nil
+}
+
+// runShards runs the select on each of its connections
+func (r *{{ $.Name }}DALSelector) runShards(ctx context.Context) ([]*{{ $.Name }}, error) {
+{{ end }}
+	var conns = []query.DBInterface{r.db}
+	if r.shards != nil { 
+		conns = r.shards(ctx)
+	}
+
+	where, e := r.where()
 	if e != nil { 
 		return nil, fmt.Errorf("{{ $.Name }}DALSelector.Query.String(): %w", e)
 	}
 
-	var rows *sql.Rows 
-	rows, e = r.db.Query(q) 
+	if len(conns) == 1 { 
+		q, args := r.stmt.SQL(where{{ if $.HasIsDeleted }}, r.deleted.Condition({{ $.Name }}_Column_IsDeleted){{ end }})
+		return r.run(ctx, conns[0], q, args)
+	}
+
+	var shardRows = make([][]*{{ $.Name }}, len(conns))
+	q, args := r.stmt.ShardSQL(where{{ if $.HasIsDeleted }}, r.deleted.Condition({{ $.Name }}_Column_IsDeleted){{ end }})
+
+	e = db.FanOut(ctx, conns, func(shardID int, conn query.DBInterface) (e error) { 
+		shardRows[shardID], e = r.run(ctx, conn, q, args)
+		return e 
+	})
+
+	if e != nil { 
+		return nil, e 
+	}
+
+	return db.MergeShardRows(r.stmt, shardRows, (*{{ $.Name }}).Table_Column_Value), nil 
+}
+
+func (r *{{ $.Name }}DALSelector) run(ctx context.Context, conn query.DBInterface, q string, args []interface{}) ([]*{{ $.Name }}, error) {
+
+	var model = []*{{ $.Name }}{}
+	rows, e := db.QueryContext(ctx, db.ReadConn(ctx, conn), q, args...) 
 
 	if e != nil {
 		if e == sql.ErrNoRows { 
@@ -297,10 +755,8 @@ func (r *{{ $.Name }}DALSelector) Run() ([]*{{ $.Name }}, error) {
 
 	defer rows.Close() 
 	for rows.Next() { 
-		m := &{{ $.Name }}{}
-		if e = rows.Scan({{ range .SelectFields }}
-			&m.{{ .Name }},{{ end }}
-		); e != nil { 
+		m, e := r.scan(rows)
+		if e != nil { 
 			return nil, fmt.Errorf("{{ $.Name }}DALSelector(%s).Run(): %w", q, e)
 		}
 
@@ -312,40 +768,218 @@ func (r *{{ $.Name }}DALSelector) Run() ([]*{{ $.Name }}, error) {
 	return model, nil
 }
 
-// Counter 
+// scan reads the current row into a new model
+func (r *{{ $.Name }}DALSelector) scan(rows *sql.Rows) (*{{ $.Name }}, error) { 
+
+	m := &{{ $.Name }}{}
+	if e := rows.Scan({{ range .SelectFields }}
+		&m.{{ .Name }},{{ end }}
+	); e != nil { 
+		return nil, e
+	}
+
+	m.Snapshot()
+	return m, nil
+}
+
+// Batches makes Each, All and Chan select the records in batches of size, each its own query, by keyset in the order
+// of OrderBy and then of the primary key (see Page), so that a long scan doesn't hold a connection or a transaction
+// open. Limit is ignored.
+func (r *{{ $.Name }}DALSelector) Batches(size int64) *{{ $.Name }}DALSelector {
+	r.batch = size
+	return r
+}
+
+// Each calls fn with each selected record, scanning the rows as they are read on a single connection (or, with
+// Batches, one query per batch), and stops at the first error, which is returned as it is. Records of sharded
+// tables are read one shard after the other. Relations set with With are not loaded.
+func (r *{{ $.Name }}DALSelector) Each(ctx context.Context, fn func(*{{ $.Name }}) error) error {
+
+	if r.primary {
+		ctx = db.WithPrimary(ctx)
+	}
+
+	var conns = []query.DBInterface{r.db}
+	if r.shards != nil { 
+		conns = r.shards(ctx)
+	}
+
+	where, e := r.where()
+	if e != nil { 
+		return fmt.Errorf("{{ $.Name }}DALSelector.Query.String(): %w", e)
+	}
+
+	for _, conn := range conns { 
+
+		if r.batch > 0 { 
+			e = db.EachBatch(ctx, r.stmt, {{ $.Name }}_PrimaryKey, r.batch, func(ctx context.Context, batch *db.SelectStatement) ([]*{{ $.Name }}, error) {
+				q, args := batch.SQL(where{{ if $.HasIsDeleted }}, r.deleted.Condition({{ $.Name }}_Column_IsDeleted){{ end }})
+				return r.run(ctx, conn, q, args)
+			}, (*{{ $.Name }}).Table_Column_Value, fn)
+		} else { 
+			q, args := r.stmt.SQL(where{{ if $.HasIsDeleted }}, r.deleted.Condition({{ $.Name }}_Column_IsDeleted){{ end }})
+			e = r.each(ctx, conn, q, args, fn)
+		}
+
+		if e != nil { 
+			return e 
+		}
+	}
+
+	return nil
+}
+
+// All returns an iterator over the selected records (see Each). An error ends the iteration and is yielded with a
+// nil record.
+func (r *{{ $.Name }}DALSelector) All(ctx context.Context) iter.Seq2[*{{ $.Name }}, error] {
+	return db.EachSeq(ctx, r.Each)
+}
+
+// Chan sends the selected records (see Each) to the returned channel from a goroutine. The channel is closed once
+// they are sent, after which the error channel receives the error, if any. Cancel ctx to stop early.
+func (r *{{ $.Name }}DALSelector) Chan(ctx context.Context, buffer int) (<-chan *{{ $.Name }}, <-chan error) {
+	return db.EachChan(ctx, buffer, r.Each)
+}
+
+func (r *{{ $.Name }}DALSelector) each(ctx context.Context, conn query.DBInterface, q string, args []interface{}, fn func(*{{ $.Name }}) error) error {
+
+	rows, e := db.QueryContext(ctx, db.ReadConn(ctx, conn), q, args...) 
+	if e != nil {
+		return fmt.Errorf("{{ $.Name }}DALSelector.Each(%s): %w", q, e)
+	}
+
+	defer rows.Close() 
+	for rows.Next() { 
+
+		m, e := r.scan(rows)
+		if e != nil { 
+			return fmt.Errorf("{{ $.Name }}DALSelector(%s).Each(): %w", q, e)
+		}
+
+		if e = fn(m); e != nil { 
+			return e 
+		}
+	}
+
+	if e = rows.Err(); e != nil { 
+		return fmt.Errorf("{{ $.Name }}DALSelector(%s).Each(): %w", q, e)
+	}
+
+	return nil
+}
+
+// Counter
 type {{ $.Name }}DALCounter struct {
-	db    query.DBInterface
-	q     *query.Q
+	db       query.DBInterface
+	shards   func(ctx context.Context) []query.DBInterface
+	q        *query.Q
+	stmt     *db.SelectStatement
+	hasWhere bool
+	primary  bool
+{{- if .HasIsDeleted }}
+	deleted  db.Deleted
+{{- end }}
 }
 
-func (r *{{ $.Name }}) Count(db query.DBInterface) *{{ $.Name }}DALCounter {
+func (r *{{ $.Name }}) Count(conn query.DBInterface) *{{ $.Name }}DALCounter {
 	return &{{ $.Name }}DALCounter{
-		db:    db,
-		q:     query.Select(r).Count(r.Table_PrimaryKey(), "c"),
+		db:    conn,
+		q:     query.Select(r),
+		stmt:  db.NewSelect({{ $.Name }}_TableName, db.Aggregate("COUNT", {{ $.Name }}_PrimaryKey)),
 	}
 }
 
 func (r *{{ $.Name }}DALCounter) Alias(alias string) *{{ $.Name }}DALCounter { 
 	r.q.Alias(alias) 
+	r.stmt.Alias(alias)
 	return r
 }
 
 func (ds *{{ $.Name }}DALCounter) Where(whereParts ...*query.WherePart) *{{ $.Name }}DALCounter {
 	ds.q.Where(whereParts...)
+	ds.hasWhere = true
+	return ds
+}
+
+// Filter adds conditions whose values are bound as parameters
+func (ds *{{ $.Name }}DALCounter) Filter(conditions ...db.Condition) *{{ $.Name }}DALCounter {
+	ds.stmt.Where(conditions...)
+	return ds
+}
+
+// OnShards runs the count on the connections returned by shards, adding up the counts when there are several
+func (ds *{{ $.Name }}DALCounter) OnShards(shards func(ctx context.Context) []query.DBInterface) *{{ $.Name }}DALCounter {
+	ds.shards = shards
+	return ds
+}
+
+{{ if .HasIsDeleted }}// WithDeleted includes deleted records, which are excluded by default
+func (ds *{{ $.Name }}DALCounter) WithDeleted() *{{ $.Name }}DALCounter {
+	ds.deleted = db.WithDeleted
+	return ds
+}
+
+// OnlyDeleted only includes deleted records
+func (ds *{{ $.Name }}DALCounter) OnlyDeleted() *{{ $.Name }}DALCounter {
+	ds.deleted = db.OnlyDeleted
+	return ds
+}
+
+{{ end }}// Primary reads from the primary rather than a replica, e.g. to read a record that was just written
+func (ds *{{ $.Name }}DALCounter) Primary() *{{ $.Name }}DALCounter {
+	ds.primary = true
 	return ds
 }
 
 func (ds *{{ $.Name }}DALCounter) Run() (int64, error) {
+	return ds.RunContext(context.Background())
+}
 
-	count := int64(0)
-	q, e := ds.q.String()
-	if e != nil {
-		return 0, fmt.Errorf("{{ $.Name }}DALCounter.Query.String(): %w", e)
+func (ds *{{ $.Name }}DALCounter) RunContext(ctx context.Context) (int64, error) {
+
+	if ds.primary {
+		ctx = db.WithPrimary(ctx)
+	}
+
+	var conns = []query.DBInterface{ds.db}
+	if ds.shards != nil { 
+		conns = ds.shards(ctx)
+	}
+
+	var where db.Condition
+	if ds.hasWhere { 
+		var e error 
+		if where, e = db.QueryWhere(ds.q, {{ $.Name }}_PrimaryKey); e != nil {
+			return 0, fmt.Errorf("{{ $.Name }}DALCounter.Query.String(): %w", e)
+		}
+	}
+
+	q, args := ds.stmt.SQL(where{{ if $.HasIsDeleted }}, ds.deleted.Condition({{ $.Name }}_Column_IsDeleted){{ end }})
+
+	if len(conns) == 1 { 
+		return ds.run(ctx, conns[0], q, args)
 	}
 
-	row := ds.db.QueryRow(q)
+	var counts = make([]int64, len(conns))
+	e := db.FanOut(ctx, conns, func(shardID int, conn query.DBInterface) (e error) { 
+		counts[shardID], e = ds.run(ctx, conn, q, args)
+		return e 
+	})
 
-	switch e = row.Scan(&count); e { 
+	var count int64 
+	for k := range counts { 
+		count += counts[k]
+	}
+
+	return count, e 
+}
+
+func (ds *{{ $.Name }}DALCounter) run(ctx context.Context, conn query.DBInterface, q string, args []interface{}) (int64, error) {
+
+	count := int64(0)
+	row := db.QueryRowContext(ctx, db.ReadConn(ctx, conn), q, args...)
+
+	switch e := row.Scan(&count); e { 
 	case sql.ErrNoRows: 
 		return 0, nil 
 	case nil: 
@@ -358,33 +992,78 @@ func (ds *{{ $.Name }}DALCounter) Run() (int64, error) {
 
 // Summer
 type {{ $.Name }}DALSummer struct {
-	db    query.DBInterface
-	q     *query.Q
+	db       query.DBInterface
+	q        *query.Q
+	stmt     *db.SelectStatement
+	hasWhere bool
+	primary  bool
+{{- if .HasIsDeleted }}
+	deleted  db.Deleted
+{{- end }}
 }
 
-func (r *{{ $.Name }}) Sum(db query.DBInterface, col query.Column) *{{ $.Name }}DALSummer {
+func (r *{{ $.Name }}) Sum(conn query.DBInterface, col query.Column) *{{ $.Name }}DALSummer {
 	return &{{ $.Name }}DALSummer{
-		db:    db,
-		q:     query.Select(r).Sum(col, "c"),
+		db:    conn,
+		q:     query.Select(r),
+		stmt:  db.NewSelect({{ $.Name }}_TableName, db.Aggregate("SUM", col)),
 	}
 }
 
 func (ds *{{ $.Name }}DALSummer) Where(whereParts ...*query.WherePart) *{{ $.Name }}DALSummer {
 	ds.q.Where(whereParts...)
+	ds.hasWhere = true
+	return ds
+}
+
+// Filter adds conditions whose values are bound as parameters
+func (ds *{{ $.Name }}DALSummer) Filter(conditions ...db.Condition) *{{ $.Name }}DALSummer {
+	ds.stmt.Where(conditions...)
+	return ds
+}
+
+{{ if .HasIsDeleted }}// WithDeleted includes deleted records, which are excluded by default
+func (ds *{{ $.Name }}DALSummer) WithDeleted() *{{ $.Name }}DALSummer {
+	ds.deleted = db.WithDeleted
+	return ds
+}
+
+// OnlyDeleted only includes deleted records
+func (ds *{{ $.Name }}DALSummer) OnlyDeleted() *{{ $.Name }}DALSummer {
+	ds.deleted = db.OnlyDeleted
+	return ds
+}
+
+{{ end }}// Primary reads from the primary rather than a replica, e.g. to read a record that was just written
+func (ds *{{ $.Name }}DALSummer) Primary() *{{ $.Name }}DALSummer {
+	ds.primary = true
 	return ds
 }
 
 func (ds *{{ $.Name }}DALSummer) Run() (float64, error) {
+	return ds.RunContext(context.Background())
+}
+
+func (ds *{{ $.Name }}DALSummer) RunContext(ctx context.Context) (float64, error) {
+
+	if ds.primary {
+		ctx = db.WithPrimary(ctx)
+	}
 
 	sum := float64(0)
-	q, e := ds.q.String()
-	if e != nil {
-		return 0, fmt.Errorf("{{ $.Name }}DALSummer.Query.String(): %w", e)
+
+	var where db.Condition
+	if ds.hasWhere { 
+		var e error 
+		if where, e = db.QueryWhere(ds.q, {{ $.Name }}_PrimaryKey); e != nil {
+			return 0, fmt.Errorf("{{ $.Name }}DALSummer.Query.String(): %w", e)
+		}
 	}
 
-	row := ds.db.QueryRow(q)
+	q, args := ds.stmt.SQL(where{{ if $.HasIsDeleted }}, ds.deleted.Condition({{ $.Name }}_Column_IsDeleted){{ end }})
+	row := db.QueryRowContext(ctx, db.ReadConn(ctx, ds.db), q, args...)
 
-	switch e = row.Scan(&sum); e { 
+	switch e := row.Scan(&sum); e { 
 	case sql.ErrNoRows: 
 		return 0, nil 
 	case nil: 
@@ -397,33 +1076,78 @@ func (ds *{{ $.Name }}DALSummer) Run() (float64, error) {
 
 // Minner
 type {{ $.Name }}DALMinner struct {
-	db    query.DBInterface
-	q     *query.Q
+	db       query.DBInterface
+	q        *query.Q
+	stmt     *db.SelectStatement
+	hasWhere bool
+	primary  bool
+{{- if .HasIsDeleted }}
+	deleted  db.Deleted
+{{- end }}
 }
 
-func (r *{{ $.Name }}) Min(db query.DBInterface, col query.Column) *{{ $.Name }}DALMinner {
+func (r *{{ $.Name }}) Min(conn query.DBInterface, col query.Column) *{{ $.Name }}DALMinner {
 	return &{{ $.Name }}DALMinner{
-		db:    db,
-		q:     query.Select(r).Min(col, "c"),
+		db:    conn,
+		q:     query.Select(r),
+		stmt:  db.NewSelect({{ $.Name }}_TableName, db.Aggregate("MIN", col)),
 	}
 }
 
 func (ds *{{ $.Name }}DALMinner) Where(whereParts ...*query.WherePart) *{{ $.Name }}DALMinner {
 	ds.q.Where(whereParts...)
+	ds.hasWhere = true
+	return ds
+}
+
+// Filter adds conditions whose values are bound as parameters
+func (ds *{{ $.Name }}DALMinner) Filter(conditions ...db.Condition) *{{ $.Name }}DALMinner {
+	ds.stmt.Where(conditions...)
+	return ds
+}
+
+{{ if .HasIsDeleted }}// WithDeleted includes deleted records, which are excluded by default
+func (ds *{{ $.Name }}DALMinner) WithDeleted() *{{ $.Name }}DALMinner {
+	ds.deleted = db.WithDeleted
+	return ds
+}
+
+// OnlyDeleted only includes deleted records
+func (ds *{{ $.Name }}DALMinner) OnlyDeleted() *{{ $.Name }}DALMinner {
+	ds.deleted = db.OnlyDeleted
+	return ds
+}
+
+{{ end }}// Primary reads from the primary rather than a replica, e.g. to read a record that was just written
+func (ds *{{ $.Name }}DALMinner) Primary() *{{ $.Name }}DALMinner {
+	ds.primary = true
 	return ds
 }
 
 func (ds *{{ $.Name }}DALMinner) Run() (float64, error) {
+	return ds.RunContext(context.Background())
+}
+
+func (ds *{{ $.Name }}DALMinner) RunContext(ctx context.Context) (float64, error) {
+
+	if ds.primary {
+		ctx = db.WithPrimary(ctx)
+	}
 
 	min := float64(0)
-	q, e := ds.q.String()
-	if e != nil {
-		return 0, fmt.Errorf("{{ $.Name }}DALMinner.Query.String(): %w", e)
+
+	var where db.Condition
+	if ds.hasWhere { 
+		var e error 
+		if where, e = db.QueryWhere(ds.q, {{ $.Name }}_PrimaryKey); e != nil {
+			return 0, fmt.Errorf("{{ $.Name }}DALMinner.Query.String(): %w", e)
+		}
 	}
 
-	row := ds.db.QueryRow(q)
+	q, args := ds.stmt.SQL(where{{ if $.HasIsDeleted }}, ds.deleted.Condition({{ $.Name }}_Column_IsDeleted){{ end }})
+	row := db.QueryRowContext(ctx, db.ReadConn(ctx, ds.db), q, args...)
 
-	switch e = row.Scan(&min); e { 
+	switch e := row.Scan(&min); e { 
 	case sql.ErrNoRows: 
 		return 0, nil 
 	case nil: 
@@ -436,33 +1160,78 @@ func (ds *{{ $.Name }}DALMinner) Run() (float64, error) {
 
 // Maxer
 type {{ $.Name }}DALMaxer struct {
-	db    query.DBInterface
-	q     *query.Q
+	db       query.DBInterface
+	q        *query.Q
+	stmt     *db.SelectStatement
+	hasWhere bool
+	primary  bool
+{{- if .HasIsDeleted }}
+	deleted  db.Deleted
+{{- end }}
 }
 
-func (r *{{ $.Name }}) Max(db query.DBInterface, col query.Column) *{{ $.Name }}DALMaxer {
+func (r *{{ $.Name }}) Max(conn query.DBInterface, col query.Column) *{{ $.Name }}DALMaxer {
 	return &{{ $.Name }}DALMaxer{
-		db:    db,
-		q:     query.Select(r).Max(col, "c"),
+		db:    conn,
+		q:     query.Select(r),
+		stmt:  db.NewSelect({{ $.Name }}_TableName, db.Aggregate("MAX", col)),
 	}
 }
 
 func (ds *{{ $.Name }}DALMaxer) Where(whereParts ...*query.WherePart) *{{ $.Name }}DALMaxer {
 	ds.q.Where(whereParts...)
+	ds.hasWhere = true
+	return ds
+}
+
+// Filter adds conditions whose values are bound as parameters
+func (ds *{{ $.Name }}DALMaxer) Filter(conditions ...db.Condition) *{{ $.Name }}DALMaxer {
+	ds.stmt.Where(conditions...)
+	return ds
+}
+
+{{ if .HasIsDeleted }}// WithDeleted includes deleted records, which are excluded by default
+func (ds *{{ $.Name }}DALMaxer) WithDeleted() *{{ $.Name }}DALMaxer {
+	ds.deleted = db.WithDeleted
+	return ds
+}
+
+// OnlyDeleted only includes deleted records
+func (ds *{{ $.Name }}DALMaxer) OnlyDeleted() *{{ $.Name }}DALMaxer {
+	ds.deleted = db.OnlyDeleted
+	return ds
+}
+
+{{ end }}// Primary reads from the primary rather than a replica, e.g. to read a record that was just written
+func (ds *{{ $.Name }}DALMaxer) Primary() *{{ $.Name }}DALMaxer {
+	ds.primary = true
 	return ds
 }
 
 func (ds *{{ $.Name }}DALMaxer) Run() (float64, error) {
+	return ds.RunContext(context.Background())
+}
+
+func (ds *{{ $.Name }}DALMaxer) RunContext(ctx context.Context) (float64, error) {
+
+	if ds.primary {
+		ctx = db.WithPrimary(ctx)
+	}
 
 	max := float64(0)
-	q, e := ds.q.String()
-	if e != nil {
-		return 0, fmt.Errorf("{{ $.Name }}DALMaxer.Query.String(): %w", e)
+
+	var where db.Condition
+	if ds.hasWhere { 
+		var e error 
+		if where, e = db.QueryWhere(ds.q, {{ $.Name }}_PrimaryKey); e != nil {
+			return 0, fmt.Errorf("{{ $.Name }}DALMaxer.Query.String(): %w", e)
+		}
 	}
 
-	row := ds.db.QueryRow(q)
+	q, args := ds.stmt.SQL(where{{ if $.HasIsDeleted }}, ds.deleted.Condition({{ $.Name }}_Column_IsDeleted){{ end }})
+	row := db.QueryRowContext(ctx, db.ReadConn(ctx, ds.db), q, args...)
 
-	switch e = row.Scan(&max); e { 
+	switch e := row.Scan(&max); e { 
 	case sql.ErrNoRows: 
 		return 0, nil 
 	case nil: 
@@ -475,52 +1244,118 @@ func (ds *{{ $.Name }}DALMaxer) Run() (float64, error) {
 
 type {{ $.Name }}DALGetter struct {
 	db    	 query.DBInterface
+	shards   func(ctx context.Context) []query.DBInterface
 	q     	 *query.Q
+	stmt     *db.SelectStatement
+	hasWhere bool
+	primary  bool
+{{- if .HasIsDeleted }}
+	deleted  db.Deleted
+{{- end }}
 }
 
-func (r *{{ $.Name }}) Get(db query.DBInterface) *{{ $.Name }}DALGetter {
+func (r *{{ $.Name }}) Get(conn query.DBInterface) *{{ $.Name }}DALGetter {
 	return &{{ $.Name }}DALGetter{
-		db:    db,
+		db:    conn,
 		q:     query.Select(r),
+		stmt:  db.NewSelect({{ $.Name }}_TableName, {{ $.Name }}_SelectFields...),
 	}
 }
 
 func (r *{{ $.Name }}DALGetter) Alias(alias string) *{{ $.Name }}DALGetter { 
 	r.q.Alias(alias) 
+	r.stmt.Alias(alias)
 	return r
 }
 
 func (ds *{{ $.Name }}DALGetter) Where(whereParts ...*query.WherePart) *{{ $.Name }}DALGetter {
 	ds.q.Where(whereParts...)
+	ds.hasWhere = true
+	return ds
+}
+
+// Filter adds conditions whose values are bound as parameters
+func (ds *{{ $.Name }}DALGetter) Filter(conditions ...db.Condition) *{{ $.Name }}DALGetter {
+	ds.stmt.Where(conditions...)
 	return ds
 }
 
 func (ds *{{ $.Name }}DALGetter) OrderBy(col query.Column, dir query.OrderDir) *{{ $.Name }}DALGetter {
-	ds.q = ds.q.OrderBy(col, dir)
+	ds.stmt.OrderBy(col, dir)
+	return ds
+}
+
+// OnShards looks for the record on each of the connections returned by shards in turn
+func (ds *{{ $.Name }}DALGetter) OnShards(shards func(ctx context.Context) []query.DBInterface) *{{ $.Name }}DALGetter {
+	ds.shards = shards
+	return ds
+}
+
+{{ if .HasIsDeleted }}// WithDeleted includes deleted records, which are excluded by default
+func (ds *{{ $.Name }}DALGetter) WithDeleted() *{{ $.Name }}DALGetter {
+	ds.deleted = db.WithDeleted
+	return ds
+}
+
+// OnlyDeleted only includes deleted records
+func (ds *{{ $.Name }}DALGetter) OnlyDeleted() *{{ $.Name }}DALGetter {
+	ds.deleted = db.OnlyDeleted
+	return ds
+}
+
+{{ end }}// Primary reads from the primary rather than a replica, e.g. to read a record that was just written
+func (ds *{{ $.Name }}DALGetter) Primary() *{{ $.Name }}DALGetter {
+	ds.primary = true
 	return ds
 }
 
 func (ds *{{ $.Name }}DALGetter) Run() (*{{ $.Name }}, error) {
+	return ds.RunContext(context.Background())
+}
 
-	model := &{{ $.Name }}{}
+func (ds *{{ $.Name }}DALGetter) RunContext(ctx context.Context) (*{{ $.Name }}, error) {
 
-	ds.q.Fields({{ range $.Fields }}
-		{{ if eq .DBType "vector" }}{{ else }}query.NewField(query.FieldTypeBasic, {{ $.Name }}_Column_{{ .Name }}),{{ end }}{{ end }}
-	)
-	q, e := ds.q.String()
-	if e != nil {
-		return nil, fmt.Errorf("{{ $.Name }}DALGetter.Query.String(): %w", e)
+	if ds.primary {
+		ctx = db.WithPrimary(ctx)
+	}
+
+	var conns = []query.DBInterface{ds.db}
+	if ds.shards != nil { 
+		conns = ds.shards(ctx)
 	}
 
-	row := ds.db.QueryRow(q)
+	var where db.Condition
+	if ds.hasWhere { 
+		var e error 
+		if where, e = db.QueryWhere(ds.q, {{ $.Name }}_PrimaryKey); e != nil {
+			return nil, fmt.Errorf("{{ $.Name }}DALGetter.Query.String(): %w", e)
+		}
+	}
+
+	q, args := ds.stmt.SQL(where{{ if $.HasIsDeleted }}, ds.deleted.Condition({{ $.Name }}_Column_IsDeleted){{ end }})
+
+	for k := range conns { 
+		if model, e := ds.run(ctx, conns[k], q, args); model != nil || e != nil { 
+			return model, e 
+		}
+	}
+
+	return nil, nil 
+}
+
+func (ds *{{ $.Name }}DALGetter) run(ctx context.Context, conn query.DBInterface, q string, args []interface{}) (*{{ $.Name }}, error) {
+
+	model := &{{ $.Name }}{}
+	row := db.QueryRowContext(ctx, db.ReadConn(ctx, conn), q, args...)
 
-	switch e = row.Scan({{ range .SelectFields }}
-		{{ if eq .DBType "vector" }}{{ else }}&model.{{ .Name }}, {{ end }}{{ end }}
+	switch e := row.Scan({{ range .SelectFields }}
+		&model.{{ .Name }},{{ end }}
 	); e { 
 	case sql.ErrNoRows: 
 		return nil, nil 
 	case nil: 
 		// fmt.Printf("{{ $.Name }}DALGetter.Get(%s).Run()\n", q)
+		model.Snapshot()
 		return model, nil 
 	default: 
 		return nil, fmt.Errorf("{{ $.Name }}DALGetter(%s).Run(): %w", q, e)
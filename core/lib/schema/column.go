@@ -0,0 +1,202 @@
+package schema
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ParseColumnDefinition builds a column from a MySQL column definition, e.g. `varchar(100) NULL`,
+// `int unsigned NOT NULL DEFAULT 0` or `enum('a','b') NOT NULL UNIQUE`. As in MySQL, columns are nullable
+// unless NOT NULL is given.
+func ParseColumnDefinition(name, definition string) (*Column, error) {
+
+	if len(name) == 0 {
+		return nil, fmt.Errorf("Column name cannot be empty")
+	}
+
+	var typeName, args, rest, e = splitColumnType(strings.TrimSpace(definition))
+	if e != nil {
+		return nil, e
+	}
+
+	var column = &Column{
+		Name:       name,
+		DataType:   strings.ToLower(typeName),
+		IsNullable: true,
+	}
+
+	if !IsValidSQLType(column.DataType) {
+		return nil, fmt.Errorf("Invalid SQL type `%s`", typeName)
+	}
+
+	var tokens = tokenizeColumnDefinition(rest)
+
+	for k := 0; k < len(tokens); k++ {
+
+		var token = strings.ToUpper(tokens[k])
+		var next = ""
+		if k+1 < len(tokens) {
+			next = strings.ToUpper(tokens[k+1])
+		}
+
+		switch {
+		case token == "UNSIGNED":
+			column.IsUnsigned = true
+		case token == "NOT" && next == "NULL":
+			column.IsNullable = false
+			k++
+		case token == "NULL":
+			column.IsNullable = true
+		case token == "DEFAULT":
+			if k+1 >= len(tokens) {
+				return nil, fmt.Errorf("Missing value for DEFAULT")
+			}
+			column.Default = strings.Trim(tokens[k+1], "'")
+			column.HasDefault = true
+			if strings.ToUpper(column.Default) == "NULL" {
+				column.Default = ""
+				column.HasDefault = false
+			}
+			k++
+		case token == "AUTO_INCREMENT":
+			column.Extra = "auto_increment"
+		case token == "PRIMARY" && next == "KEY":
+			column.ColumnKey = "PRI"
+			column.IsNullable = false
+			k++
+		case token == "UNIQUE":
+			column.ColumnKey = "UNI"
+			if next == "KEY" || next == "INDEX" {
+				k++
+			}
+		case token == "INDEX" || token == "KEY":
+			column.ColumnKey = "MUL"
+		case (token == "CHARACTER" && next == "SET") || token == "CHARSET":
+			if token == "CHARACTER" {
+				k++
+			}
+			if k+1 >= len(tokens) {
+				return nil, fmt.Errorf("Missing value for CHARACTER SET")
+			}
+			column.CharSet = tokens[k+1]
+			k++
+		case token == "COLLATE":
+			if k+1 >= len(tokens) {
+				return nil, fmt.Errorf("Missing value for COLLATE")
+			}
+			column.Collation = tokens[k+1]
+			k++
+		default:
+			return nil, fmt.Errorf("Unexpected `%s` in column definition", tokens[k])
+		}
+	}
+
+	switch column.DataType {
+	case "char", "varchar":
+		if column.MaxLength, e = strconv.Atoi(args); e != nil || column.MaxLength <= 0 {
+			return nil, fmt.Errorf("%s requires a length, e.g. %s(100)", column.DataType, column.DataType)
+		}
+		column.Type = fmt.Sprintf("%s(%d)", column.DataType, column.MaxLength)
+	case "text":
+		column.MaxLength = 65535
+		column.Type = column.DataType
+	case "decimal":
+		column.Precision, column.NumericScale = 10, 0
+		if len(args) > 0 {
+			var parts = strings.Split(args, ",")
+			if column.Precision, e = strconv.Atoi(strings.TrimSpace(parts[0])); e != nil {
+				return nil, fmt.Errorf("Invalid decimal precision `%s`", args)
+			}
+			if len(parts) > 1 {
+				if column.NumericScale, e = strconv.Atoi(strings.TrimSpace(parts[1])); e != nil {
+					return nil, fmt.Errorf("Invalid decimal scale `%s`", args)
+				}
+			}
+		}
+		column.Type = fmt.Sprintf("decimal(%d,%d)", column.Precision, column.NumericScale)
+	case "enum":
+		if len(args) == 0 {
+			return nil, fmt.Errorf("enum requires a list of values, e.g. enum('a','b')")
+		}
+		column.Type = "enum(" + args + ")"
+	default:
+		column.Type = column.DataType
+	}
+
+	if column.IsUnsigned {
+		if !IsInteger(column) && column.DataType != "decimal" {
+			return nil, fmt.Errorf("%s cannot be unsigned", column.DataType)
+		}
+		column.Type += " unsigned"
+	}
+
+	if len(column.Default) > 0 {
+		if _, e = ParseValue(column, column.Default); e != nil {
+			return nil, fmt.Errorf("Invalid default: %w", e)
+		}
+	}
+
+	column.FmtType = DataTypeToFormatString(column)
+	column.GoType = DataTypeToGoTypeString(column)
+	column.IsString = IsString(column)
+
+	return column, nil
+}
+
+// splitColumnType splits `decimal(10,2) NOT NULL` into `decimal`, `10,2` and `NOT NULL`
+func splitColumnType(definition string) (typeName, args, rest string, e error) {
+
+	var end = strings.IndexAny(definition, " (")
+	if end < 0 {
+		return definition, "", "", nil
+	}
+
+	typeName = definition[0:end]
+
+	if definition[end] == ' ' {
+		return typeName, "", definition[end:], nil
+	}
+
+	// Find the closing paren, skipping over quoted enum values
+	var inQuote = false
+	for k := end + 1; k < len(definition); k++ {
+		switch {
+		case definition[k] == '\'':
+			inQuote = !inQuote
+		case definition[k] == ')' && !inQuote:
+			return typeName, definition[end+1 : k], definition[k+1:], nil
+		}
+	}
+
+	return "", "", "", fmt.Errorf("Unclosed `(` in column definition `%s`", definition)
+}
+
+// tokenizeColumnDefinition splits on whitespace, keeping quoted strings (e.g. DEFAULT 'a b') together
+func tokenizeColumnDefinition(str string) []string {
+
+	var tokens = []string{}
+	var current strings.Builder
+	var inQuote = false
+
+	for _, r := range str {
+		switch {
+		case r == '\'':
+			inQuote = !inQuote
+			current.WriteRune(r)
+		case (r == ' ' || r == '\t') && !inQuote:
+			if current.Len() > 0 {
+				tokens = append(tokens, current.String())
+				current.Reset()
+			}
+		default:
+			current.WriteRune(r)
+		}
+	}
+
+	if current.Len() > 0 {
+		tokens = append(tokens, current.String())
+	}
+
+	return tokens
+}
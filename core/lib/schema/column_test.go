@@ -0,0 +1,109 @@
+package schema_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/macinnir/dvc/core/lib/schema"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseColumnDefinition(t *testing.T) {
+
+	var c, e = schema.ParseColumnDefinition("Nickname", "varchar(100) NULL")
+	require.Nil(t, e)
+	assert.Equal(t, "varchar", c.DataType)
+	assert.Equal(t, "varchar(100)", c.Type)
+	assert.Equal(t, 100, c.MaxLength)
+	assert.True(t, c.IsNullable)
+	assert.Equal(t, "null.String", c.GoType)
+	assert.True(t, c.IsString)
+
+	c, e = schema.ParseColumnDefinition("LoginCount", "INT UNSIGNED NOT NULL DEFAULT 0 INDEX")
+	require.Nil(t, e)
+	assert.Equal(t, "int unsigned", c.Type)
+	assert.True(t, c.IsUnsigned)
+	assert.False(t, c.IsNullable)
+	assert.Equal(t, "0", c.Default)
+	assert.True(t, c.HasDefault)
+	assert.Equal(t, "MUL", c.ColumnKey)
+	assert.Equal(t, "int64", c.GoType)
+
+	c, e = schema.ParseColumnDefinition("Title", "varchar(50) NOT NULL DEFAULT ''")
+	require.Nil(t, e)
+	assert.Equal(t, "", c.Default)
+	assert.True(t, c.HasDefault)
+
+	c, e = schema.ParseColumnDefinition("Amount", "decimal(10, 2) NOT NULL")
+	require.Nil(t, e)
+	assert.False(t, c.HasDefault)
+	assert.Equal(t, "decimal(10,2)", c.Type)
+	assert.Equal(t, 10, c.Precision)
+	assert.Equal(t, 2, c.NumericScale)
+
+	c, e = schema.ParseColumnDefinition("Role", "enum('a b','c)') NOT NULL DEFAULT 'a b' UNIQUE")
+	require.Nil(t, e)
+	assert.Equal(t, "enum('a b','c)')", c.Type)
+	assert.Equal(t, []string{"a b", "c)"}, schema.EnumValues(c))
+	assert.Equal(t, "a b", c.Default)
+	assert.Equal(t, "UNI", c.ColumnKey)
+
+	for _, definition := range []string{
+		"varchar NULL",
+		"foo(10)",
+		"varchar(10) NOT NULL FOO",
+		"varchar(10 NULL",
+		"int DEFAULT abc",
+		"varchar(10) unsigned",
+		"enum('a') DEFAULT 'b'",
+	} {
+		_, e = schema.ParseColumnDefinition("Foo", definition)
+		assert.NotNil(t, e, definition)
+	}
+}
+
+func TestValidateValue(t *testing.T) {
+
+	var tinyint = &schema.Column{Name: "Age", DataType: "tinyint", IsUnsigned: true}
+	var name = &schema.Column{Name: "Name", DataType: "varchar", MaxLength: 3, IsNullable: true}
+	var date = &schema.Column{Name: "Birthday", DataType: "date"}
+
+	var v, e = schema.ValidateValue(tinyint, json.Number("200"))
+	assert.Nil(t, e)
+	assert.Equal(t, uint64(200), v)
+
+	v, e = schema.ValidateValue(tinyint, true)
+	assert.Nil(t, e)
+	assert.Equal(t, int64(1), v)
+
+	v, e = schema.ValidateValue(name, nil)
+	assert.Nil(t, e)
+	assert.Nil(t, v)
+
+	_, e = schema.ValidateValue(tinyint, nil)
+	assert.NotNil(t, e)
+	_, e = schema.ValidateValue(tinyint, json.Number("256"))
+	assert.NotNil(t, e)
+	_, e = schema.ValidateValue(tinyint, json.Number("1.5"))
+	assert.NotNil(t, e)
+	_, e = schema.ValidateValue(name, json.Number("1"))
+	assert.NotNil(t, e)
+	_, e = schema.ValidateValue(name, "abcd")
+	assert.NotNil(t, e)
+	_, e = schema.ValidateValue(name, map[string]interface{}{})
+	assert.NotNil(t, e)
+	_, e = schema.ValidateValue(date, "2020-13-01")
+	assert.NotNil(t, e)
+	_, e = schema.ValidateValue(date, "2020-12-01")
+	assert.Nil(t, e)
+}
+
+func TestIsRequired(t *testing.T) {
+	assert.True(t, schema.IsRequired(&schema.Column{DataType: "int"}))
+	assert.True(t, schema.IsRequired(&schema.Column{DataType: "text"}))
+	assert.False(t, schema.IsRequired(&schema.Column{DataType: "varchar"}))
+	assert.False(t, schema.IsRequired(&schema.Column{DataType: "int", IsNullable: true}))
+	assert.False(t, schema.IsRequired(&schema.Column{DataType: "int", Default: "0"}))
+	assert.False(t, schema.IsRequired(&schema.Column{DataType: "int", Extra: "auto_increment"}))
+}
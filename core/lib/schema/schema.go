@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
+	"math"
 	"strings"
 
 	"github.com/macinnir/dvc/core/lib"
@@ -45,6 +46,32 @@ func LoadLocalSchemas() (*SchemaList, error) {
 	return appSchema, nil
 }
 
+// LoadSchemaFile loads a single local schema file (e.g. lib.SchemasFilePath)
+func LoadSchemaFile(filePath string) (*SchemaList, error) {
+	return loadSchema(filePath)
+}
+
+// SaveSchemaFile writes a schema list to a local schema file in the same format as `dvc import`
+func SaveSchemaFile(filePath string, schemaList *SchemaList) error {
+
+	var e error
+	var dbBytes []byte
+
+	if dbBytes, e = json.MarshalIndent(schemaList, " ", "    "); e != nil {
+		return e
+	}
+
+	return ioutil.WriteFile(filePath, dbBytes, 0644)
+}
+
+// SchemaFilePath returns the local schema file that `schemaName` is stored in
+func SchemaFilePath(schemaName string) string {
+	if schemaName == lib.CoreSchemasName || schemaName == lib.CoreSchemasLogName {
+		return lib.CoreSchemasFilePath
+	}
+	return lib.SchemasFilePath
+}
+
 func loadSchema(filePath string) (*SchemaList, error) {
 
 	// fmt.Println("Load schema from ", filePath)
@@ -290,6 +317,11 @@ func GoTypeToTypescriptString(goDataType string) string {
 		return ParseMapTypeToTypescriptString(goDataType[2:]) + "[]"
 	}
 
+	// Pages (see db.Page)
+	if item, ok := PageItemType(goDataType); ok {
+		return "Page<" + GoTypeToTypescriptString(item) + ">"
+	}
+
 	// DTOs
 	if len(goDataType) > 5 && goDataType[0:5] == "dtos." {
 		return goDataType[5:]
@@ -376,6 +408,19 @@ func GoTypeToTypescriptString(goDataType string) string {
 	return GoBaseTypeToBaseTypescriptType(goDataType)
 }
 
+// PageItemType returns the item type of a page of keyset paginated results, e.g. `*models.Foo` for
+// `db.Page[*models.Foo]`
+func PageItemType(goDataType string) (string, bool) {
+
+	goDataType = strings.TrimPrefix(goDataType, "*")
+
+	if len(goDataType) > 9 && goDataType[0:8] == "db.Page[" && goDataType[len(goDataType)-1:] == "]" {
+		return goDataType[8 : len(goDataType)-1], true
+	}
+
+	return "", false
+}
+
 func GoTypeToTypescriptDefault(goDataType string) (fieldType string) {
 
 	if len(goDataType) > 3 && goDataType[0:4] == "map[" {
@@ -459,6 +504,63 @@ func IsString(column *Column) bool {
 
 }
 
+// IsInteger returns true if the column is an integer type
+func IsInteger(column *Column) bool {
+	switch column.DataType {
+	case "tinyint", "smallint", "mediumint", "int", "integer", "bigint":
+		return true
+	default:
+		return false
+	}
+}
+
+// IntegerRange returns the smallest and largest values an integer column can hold
+func IntegerRange(column *Column) (min int64, max uint64) {
+
+	var bits uint
+	switch column.DataType {
+	case "tinyint":
+		bits = 8
+	case "smallint":
+		bits = 16
+	case "mediumint":
+		bits = 24
+	case "int", "integer":
+		bits = 32
+	default:
+		bits = 64
+	}
+
+	if column.IsUnsigned {
+		if bits == 64 {
+			return 0, math.MaxUint64
+		}
+		return 0, 1<<bits - 1
+	}
+
+	return -(1 << (bits - 1)), 1<<(bits-1) - 1
+}
+
+// EnumValues parses the allowed values from an enum or set column type (e.g. `enum('a','b')`)
+func EnumValues(column *Column) []string {
+
+	var start = strings.Index(column.Type, "(")
+	var end = strings.LastIndex(column.Type, ")")
+	if start < 0 || end <= start {
+		return []string{}
+	}
+
+	var values = []string{}
+	for _, v := range strings.Split(column.Type[start+1:end], ",") {
+		v = strings.TrimSpace(v)
+		v = strings.TrimPrefix(v, "'")
+		v = strings.TrimSuffix(v, "'")
+		values = append(values, strings.ReplaceAll(v, "''", "'"))
+	}
+
+	return values
+}
+
 // IsNull returns true if the field uses the `null` package
 func IsNull(fieldType string) bool {
 	return len(fieldType) > 5 && fieldType[0:5] == "null."
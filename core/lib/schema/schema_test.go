@@ -90,6 +90,9 @@ func TestGoTypeToTypescriptString(t *testing.T) {
 		{"[]byte", "any"},
 
 		{"[][]string", "string[][]"},
+
+		{"db.Page[*models.Foo]", "Page<Foo>"},
+		{"*db.Page[*aggregates.Foo]", "Page<Foo>"},
 	}
 
 	for k := range tests {
@@ -219,3 +222,22 @@ func TestIsGoTypeBaseType(t *testing.T) {
 	}
 
 }
+
+func TestEnumValues(t *testing.T) {
+	assert.Equal(t, []string{"a", "b", "it's"}, schema.EnumValues(&schema.Column{Type: "enum('a','b','it''s')"}))
+	assert.Equal(t, []string{}, schema.EnumValues(&schema.Column{Type: "varchar"}))
+}
+
+func TestIntegerRange(t *testing.T) {
+
+	min, max := schema.IntegerRange(&schema.Column{DataType: "int"})
+	assert.Equal(t, int64(-2147483648), min)
+	assert.Equal(t, uint64(2147483647), max)
+
+	min, max = schema.IntegerRange(&schema.Column{DataType: "tinyint", IsUnsigned: true})
+	assert.Equal(t, int64(0), min)
+	assert.Equal(t, uint64(255), max)
+
+	_, max = schema.IntegerRange(&schema.Column{DataType: "bigint", IsUnsigned: true})
+	assert.Equal(t, uint64(18446744073709551615), max)
+}
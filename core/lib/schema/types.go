@@ -96,6 +96,8 @@ type Table struct {
 	RowFormat     string             `json:"rowFormat"`
 	Rows          int64              `json:"-"`
 	DataLength    int64              `json:"-"`
+	IndexLength   int64              `json:"-"`
+	DataFree      int64              `json:"-"`
 	Collation     string             `json:"collation"`
 	CharacterSet  string             `json:"characterSet"`
 	AutoIncrement int64              `json:"-"`
@@ -179,6 +181,8 @@ type Column struct {
 	FmtType      string `json:"fmtType"`
 	GoType       string `json:"goType"`
 	IsString     bool   `json:"isString"`
+	// HasDefault is true if the column has a default other than NULL, which may be an empty string
+	HasDefault bool `json:"hasDefault,omitempty"`
 }
 
 // ColumnWithTable is a column with the table name included
@@ -0,0 +1,108 @@
+package schema
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ParseValue converts a string to the column's type, returning an error if it can't be stored in the column
+func ParseValue(column *Column, str string) (interface{}, error) {
+
+	switch {
+	case IsInteger(column):
+		if column.IsUnsigned {
+			var n, e = strconv.ParseUint(str, 10, 64)
+			if e != nil {
+				return nil, fmt.Errorf("`%s` expects an unsigned integer, got `%s`", column.Name, str)
+			}
+			if _, max := IntegerRange(column); n > max {
+				return nil, fmt.Errorf("`%s` value %d is out of range", column.Name, n)
+			}
+			return n, nil
+		}
+		var n, e = strconv.ParseInt(str, 10, 64)
+		if e != nil {
+			return nil, fmt.Errorf("`%s` expects an integer, got `%s`", column.Name, str)
+		}
+		if min, max := IntegerRange(column); n < min || (n > 0 && uint64(n) > max) {
+			return nil, fmt.Errorf("`%s` value %d is out of range", column.Name, n)
+		}
+		return n, nil
+	case column.DataType == "decimal", column.DataType == "float", column.DataType == "double":
+		if _, e := strconv.ParseFloat(str, 64); e != nil {
+			return nil, fmt.Errorf("`%s` expects a number, got `%s`", column.Name, str)
+		}
+		return str, nil
+	case column.DataType == "enum":
+		for _, v := range EnumValues(column) {
+			if v == str {
+				return str, nil
+			}
+		}
+		return nil, fmt.Errorf("`%s` expects one of %s, got `%s`", column.Name, strings.Join(EnumValues(column), ", "), str)
+	case column.DataType == "date":
+		if _, e := time.Parse("2006-01-02", str); e != nil {
+			return nil, fmt.Errorf("`%s` expects a date (YYYY-MM-DD), got `%s`", column.Name, str)
+		}
+	case column.DataType == "datetime":
+		if _, e := time.Parse("2006-01-02 15:04:05", str); e != nil {
+			return nil, fmt.Errorf("`%s` expects a datetime (YYYY-MM-DD HH:MM:SS), got `%s`", column.Name, str)
+		}
+	case column.MaxLength > 0 && IsString(column) && len([]rune(str)) > column.MaxLength:
+		return nil, fmt.Errorf("`%s` value is longer than %d characters", column.Name, column.MaxLength)
+	}
+
+	return str, nil
+}
+
+// ValidateValue checks a decoded JSON value (decoded with UseNumber) against the column's type and nullability
+// and returns the value to bind for it
+func ValidateValue(column *Column, value interface{}) (interface{}, error) {
+
+	switch v := value.(type) {
+	case nil:
+		if !column.IsNullable {
+			return nil, fmt.Errorf("`%s` cannot be null", column.Name)
+		}
+		return nil, nil
+	case json.Number:
+		if IsString(column) && column.DataType != "enum" {
+			return nil, fmt.Errorf("`%s` expects a string, got %s", column.Name, v)
+		}
+		return ParseValue(column, v.String())
+	case float64:
+		return ValidateValue(column, json.Number(strconv.FormatFloat(v, 'f', -1, 64)))
+	case bool:
+		if !IsInteger(column) {
+			return nil, fmt.Errorf("`%s` does not accept a boolean", column.Name)
+		}
+		if v {
+			return int64(1), nil
+		}
+		return int64(0), nil
+	case string:
+		return ParseValue(column, v)
+	}
+
+	return nil, fmt.Errorf("`%s` does not accept a value of type %T", column.Name, value)
+}
+
+// IsRequired returns true if an insert must provide a value for the column. char, varchar and enum columns
+// are created with an empty string default.
+func IsRequired(column *Column) bool {
+
+	if column.IsNullable || column.HasDefault || len(column.Default) > 0 {
+		return false
+	}
+
+	switch column.DataType {
+	case "char", "varchar", "enum":
+		return false
+	}
+
+	var extra = strings.ToLower(column.Extra)
+	return !strings.Contains(extra, "auto_increment") && !strings.Contains(extra, "generated")
+}
@@ -0,0 +1,85 @@
+package seed
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+
+	"github.com/macinnir/dvc/core/lib"
+)
+
+// Rule overrides how values are generated for a single column. Only one of Value, Values, Format,
+// Generator or Min/Max is expected to be set.
+//
+//	{
+//	   "User": {
+//	      "Email": { "format": "user%d@example.com" },
+//	      "Role": { "values": ["admin", "member"] },
+//	      "Age": { "min": 18, "max": 90 },
+//	      "Bio": { "generator": "sentence", "nullRate": 0.5 }
+//	   }
+//	}
+type Rule struct {
+	// Value is used for every row
+	Value interface{} `json:"value"`
+	// Values are picked from at random
+	Values []interface{} `json:"values"`
+	// Format is passed to fmt.Sprintf with the row number (starting at 1)
+	Format string `json:"format"`
+	// Generator is the name of a built in generator (e.g. email, phone, firstName, sentence)
+	Generator string `json:"generator"`
+	// Min and Max bound numeric values
+	Min *float64 `json:"min"`
+	Max *float64 `json:"max"`
+	// NullRate is the fraction (0-1) of rows that are NULL. Only applies to nullable columns.
+	NullRate *float64 `json:"nullRate"`
+}
+
+// Config is a map of table name => column name => rule
+type Config map[string]map[string]*Rule
+
+// Rule returns the rule for a column, or nil
+func (c Config) Rule(tableName, columnName string) *Rule {
+	if c == nil {
+		return nil
+	}
+	if _, ok := c[tableName]; !ok {
+		return nil
+	}
+	return c[tableName][columnName]
+}
+
+// LoadConfig loads the seed config file at `filePath`. A missing file is an empty config.
+func LoadConfig(filePath string) (Config, error) {
+
+	var config = Config{}
+
+	if !lib.FileExists(filePath) {
+		return config, nil
+	}
+
+	var e error
+	var fileBytes []byte
+	if fileBytes, e = ioutil.ReadFile(filePath); e != nil {
+		return nil, e
+	}
+
+	if e = json.Unmarshal(fileBytes, &config); e != nil {
+		return nil, fmt.Errorf("invalid seed config: %w", e)
+	}
+
+	for tableName := range config {
+		for columnName, rule := range config[tableName] {
+			if rule.Min != nil && rule.Max != nil && *rule.Min > *rule.Max {
+				return nil, fmt.Errorf("invalid seed config: %s.%s: min %v is greater than max %v", tableName, columnName, *rule.Min, *rule.Max)
+			}
+			if len(rule.Generator) > 0 {
+				if _, ok := generators[rule.Generator]; !ok {
+					return nil, fmt.Errorf("invalid seed config: %s.%s: unknown generator `%s`", tableName, columnName, rule.Generator)
+				}
+			}
+		}
+	}
+
+	return config, nil
+}
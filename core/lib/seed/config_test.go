@@ -0,0 +1,29 @@
+package seed
+
+import (
+	"os"
+	"path"
+	"testing"
+
+	"github.com/macinnir/dvc/core/lib"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadConfig(t *testing.T) {
+
+	var filePath = path.Join(t.TempDir(), "seed.json")
+
+	require.Nil(t, os.WriteFile(filePath, []byte(`{"User": {"Age": {"min": 18, "max": 90}}}`), lib.DefaultFileMode))
+	config, e := LoadConfig(filePath)
+	require.Nil(t, e)
+	assert.Equal(t, 90.0, *config.Rule("User", "Age").Max)
+
+	require.Nil(t, os.WriteFile(filePath, []byte(`{"User": {"Age": {"min": 90, "max": 18}}}`), lib.DefaultFileMode))
+	_, e = LoadConfig(filePath)
+	assert.EqualError(t, e, "invalid seed config: User.Age: min 90 is greater than max 18")
+
+	require.Nil(t, os.WriteFile(filePath, []byte(`{"User": {"Bio": {"generator": "haiku"}}}`), lib.DefaultFileMode))
+	_, e = LoadConfig(filePath)
+	assert.EqualError(t, e, "invalid seed config: User.Bio: unknown generator `haiku`")
+}
@@ -0,0 +1,436 @@
+package seed
+
+import (
+	"database/sql"
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/macinnir/dvc/core/lib/schema"
+	"github.com/macinnir/dvc/core/lib/subset"
+	"go.uber.org/zap"
+)
+
+// poolLimit caps the number of parent keys loaded for a relationship
+const poolLimit = 100000
+
+// keyAttempts caps the number of values tried for the columns of a composite key before giving up on a row
+const keyAttempts = 1000
+
+// Seeder inserts generated rows into a database
+type Seeder struct {
+	db            *sql.DB
+	schema        *schema.Schema
+	relationships []*subset.Relationship
+	config        Config
+	generator     *Generator
+	log           *zap.Logger
+
+	// BatchSize is the number of rows per INSERT statement
+	BatchSize int
+	// ParentRatio is the number of child rows per parent row when an empty parent table has to be seeded first
+	ParentRatio int
+
+	seeding map[string]bool
+}
+
+// NewSeeder returns a new Seeder
+func NewSeeder(db *sql.DB, s *schema.Schema, relationships []*subset.Relationship, config Config, generator *Generator, log *zap.Logger) *Seeder {
+	return &Seeder{
+		db:            db,
+		schema:        s,
+		relationships: relationships,
+		config:        config,
+		generator:     generator,
+		log:           log,
+		BatchSize:     500,
+		ParentRatio:   10,
+		seeding:       map[string]bool{},
+	}
+}
+
+// uniqueColumn tracks the values already used in a unique column
+type uniqueColumn struct {
+	used    map[string]struct{}
+	nextInt int64
+}
+
+// compositeKey tracks the tuples of values already used in a unique key of several columns (e.g. the primary key
+// of a join table)
+type compositeKey struct {
+	columns []*schema.Column
+	used    map[string]struct{}
+}
+
+// tuple returns the key of the values of `row` in the key's columns
+func (k *compositeKey) tuple(row map[string]interface{}) string {
+	var values = make([]string, len(k.columns))
+	for i, column := range k.columns {
+		values[i] = fmt.Sprint(row[column.Name])
+	}
+	return strings.Join(values, "\x00")
+}
+
+// names returns the backticked names of the key's columns
+func (k *compositeKey) names() string {
+	var names = make([]string, len(k.columns))
+	for i, column := range k.columns {
+		names[i] = "`" + column.Name + "`"
+	}
+	return strings.Join(names, ", ")
+}
+
+// uniqueKeys returns the sets of insert columns whose values must be unique together: each UNIQUE column on its own,
+// and the primary key columns as one set. A primary key with an auto increment column is left out, since the
+// database keeps it unique.
+func uniqueKeys(table *schema.Table) [][]*schema.Column {
+
+	var keys = [][]*schema.Column{}
+	var primaryKey = []*schema.Column{}
+	var autoIncrement = false
+
+	for _, column := range table.ToSortedColumns() {
+		switch column.ColumnKey {
+		case "PRI":
+			primaryKey = append(primaryKey, column)
+			if strings.Contains(strings.ToLower(column.Extra), "auto_increment") {
+				autoIncrement = true
+			}
+		case "UNI":
+			keys = append(keys, []*schema.Column{column})
+		}
+	}
+
+	if len(primaryKey) > 0 && !autoIncrement {
+		keys = append([][]*schema.Column{primaryKey}, keys...)
+	}
+
+	return keys
+}
+
+// Seed inserts `n` generated rows into `tableName`. Empty parent tables are seeded first.
+func (s *Seeder) Seed(tableName string, n int) error {
+
+	var e error
+	var table, ok = s.schema.Tables[tableName]
+	if !ok {
+		return fmt.Errorf("Table `%s` not found in schema `%s`", tableName, s.schema.Name)
+	}
+
+	s.seeding[tableName] = true
+	defer delete(s.seeding, tableName)
+
+	var columns = InsertColumns(table)
+
+	// Parent keys for every column that references another table
+	var pools = map[string][]interface{}{}
+	for _, column := range columns {
+
+		var r = s.parentOf(tableName, column.Name)
+		if r == nil {
+			continue
+		}
+
+		var pool []interface{}
+		if pool, e = s.distinct(r.ParentTable, r.ParentColumn, poolLimit); e != nil {
+			return e
+		}
+
+		if len(pool) == 0 && !s.seeding[r.ParentTable] {
+
+			var parentCount = n / s.ParentRatio
+			if parentCount < 1 {
+				parentCount = 1
+			}
+
+			s.log.Info("Seeding parent table first", zap.String("table", r.ParentTable), zap.Int("rows", parentCount))
+			if e = s.Seed(r.ParentTable, parentCount); e != nil {
+				return e
+			}
+
+			if pool, e = s.distinct(r.ParentTable, r.ParentColumn, poolLimit); e != nil {
+				return e
+			}
+		}
+
+		if len(pool) == 0 && !column.IsNullable {
+			return fmt.Errorf("Cannot seed `%s`: no rows in parent `%s` for `%s`", tableName, r.ParentTable, column.Name)
+		}
+
+		pools[column.Name] = pool
+	}
+
+	// Existing values for unique keys
+	var uniques = map[string]*uniqueColumn{}
+	var composites = []*compositeKey{}
+	for _, key := range uniqueKeys(table) {
+
+		if len(key) > 1 {
+			var k = &compositeKey{columns: key}
+			if k.used, e = s.distinctTuples(tableName, k); e != nil {
+				return e
+			}
+			composites = append(composites, k)
+			continue
+		}
+
+		var existing []interface{}
+		if existing, e = s.distinct(tableName, key[0].Name, 0); e != nil {
+			return e
+		}
+
+		var u = &uniqueColumn{used: make(map[string]struct{}, len(existing)+n), nextInt: 1}
+		for k := range existing {
+			var str = fmt.Sprint(existing[k])
+			u.used[str] = struct{}{}
+			if i, e := strconv.ParseInt(str, 10, 64); e == nil && i >= u.nextInt {
+				u.nextInt = i + 1
+			}
+		}
+
+		uniques[key[0].Name] = u
+	}
+
+	var insertTable = &schema.Table{Name: table.Name, Columns: map[string]*schema.Column{}}
+	for _, column := range columns {
+		insertTable.Columns[column.Name] = column
+	}
+
+	var batch = make([]map[string]interface{}, 0, s.BatchSize)
+	var inserted = 0
+
+	for seq := 1; seq <= n; seq++ {
+
+		var row = make(map[string]interface{}, len(columns))
+
+		for _, column := range columns {
+
+			var u = uniques[column.Name]
+
+			if pool, ok := pools[column.Name]; ok {
+				if row[column.Name], e = s.pickFromPool(column, pool, u); e != nil {
+					return fmt.Errorf("Cannot seed `%s`: %w", tableName, e)
+				}
+				continue
+			}
+
+			var value = s.generator.Value(column, s.config.Rule(tableName, column.Name), seq)
+
+			if u != nil && value != nil {
+				if value, e = makeUnique(column, value, u, seq); e != nil {
+					return fmt.Errorf("Cannot seed `%s`: %w", tableName, e)
+				}
+			}
+
+			row[column.Name] = value
+		}
+
+		for _, key := range composites {
+			if e = s.makeUniqueKey(tableName, key, row, pools, seq); e != nil {
+				return fmt.Errorf("Cannot seed `%s`: %w", tableName, e)
+			}
+		}
+
+		batch = append(batch, row)
+
+		if len(batch) == s.BatchSize || seq == n {
+
+			var query, args = subset.InsertSQLWithArgs(insertTable, batch)
+			if _, e = s.db.Exec(query, args...); e != nil {
+				return fmt.Errorf("Error seeding `%s`: %w", tableName, e)
+			}
+
+			inserted += len(batch)
+			s.log.Info("Seeded batch", zap.String("table", tableName), zap.Int("rows", inserted), zap.Int("total", n))
+			batch = batch[:0]
+		}
+	}
+
+	return nil
+}
+
+// InsertColumns returns the columns that values are generated for (everything except auto increment and generated columns)
+func InsertColumns(table *schema.Table) []*schema.Column {
+
+	var columns = []*schema.Column{}
+	for _, column := range table.ToSortedColumns() {
+		var extra = strings.ToLower(column.Extra)
+		if strings.Contains(extra, "auto_increment") || strings.Contains(extra, "generated") {
+			continue
+		}
+		columns = append(columns, column)
+	}
+
+	return columns
+}
+
+// parentOf returns the relationship where `tableName`.`columnName` is the child
+func (s *Seeder) parentOf(tableName, columnName string) *subset.Relationship {
+	for _, r := range s.relationships {
+		if r.ChildTable == tableName && r.ChildColumn == columnName {
+			return r
+		}
+	}
+	return nil
+}
+
+// distinct returns the distinct non-null values of a column. A limit of 0 is unlimited.
+func (s *Seeder) distinct(tableName, columnName string, limit int) ([]interface{}, error) {
+
+	var e error
+	var rows *sql.Rows
+
+	var query = fmt.Sprintf("SELECT DISTINCT `%s` FROM `%s` WHERE `%s` IS NOT NULL", columnName, tableName, columnName)
+	if limit > 0 {
+		query += fmt.Sprintf(" LIMIT %d", limit)
+	}
+
+	if rows, e = s.db.Query(query); e != nil {
+		return nil, fmt.Errorf("Error reading `%s`.`%s`: %w", tableName, columnName, e)
+	}
+
+	defer rows.Close()
+
+	var result []map[string]interface{}
+	if result, e = subset.ScanRows(rows); e != nil {
+		return nil, e
+	}
+
+	var values = make([]interface{}, len(result))
+	for k := range result {
+		values[k] = result[k][columnName]
+	}
+
+	return values, nil
+}
+
+// distinctTuples returns the tuples of values (see compositeKey.tuple) already in the columns of a composite key
+func (s *Seeder) distinctTuples(tableName string, key *compositeKey) (map[string]struct{}, error) {
+
+	var e error
+	var rows *sql.Rows
+
+	var query = fmt.Sprintf("SELECT DISTINCT %s FROM `%s`", key.names(), tableName)
+	if rows, e = s.db.Query(query); e != nil {
+		return nil, fmt.Errorf("Error reading `%s` (%s): %w", tableName, key.names(), e)
+	}
+
+	defer rows.Close()
+
+	var result []map[string]interface{}
+	if result, e = subset.ScanRows(rows); e != nil {
+		return nil, e
+	}
+
+	var used = make(map[string]struct{}, len(result))
+	for k := range result {
+		used[key.tuple(result[k])] = struct{}{}
+	}
+
+	return used, nil
+}
+
+// makeUniqueKey picks other values for the columns of a composite key until the tuple in `row` has not been used
+func (s *Seeder) makeUniqueKey(tableName string, key *compositeKey, row map[string]interface{}, pools map[string][]interface{}, seq int) error {
+
+	for attempt := 0; attempt < keyAttempts; attempt++ {
+
+		var tuple = key.tuple(row)
+		if _, ok := key.used[tuple]; !ok {
+			key.used[tuple] = struct{}{}
+			return nil
+		}
+
+		for _, column := range key.columns {
+			if pool, ok := pools[column.Name]; ok {
+				if len(pool) > 0 {
+					row[column.Name] = pool[s.generator.rand.Intn(len(pool))]
+				}
+				continue
+			}
+			row[column.Name] = s.generator.Value(column, s.config.Rule(tableName, column.Name), seq)
+		}
+	}
+
+	return fmt.Errorf("not enough distinct values for the key (%s)", key.names())
+}
+
+// pickFromPool chooses a parent key. Unique columns (e.g. one-to-one relationships) never reuse a key.
+func (s *Seeder) pickFromPool(column *schema.Column, pool []interface{}, u *uniqueColumn) (interface{}, error) {
+
+	if len(pool) == 0 {
+		return nil, nil
+	}
+
+	if u == nil {
+		return pool[s.generator.rand.Intn(len(pool))], nil
+	}
+
+	var start = s.generator.rand.Intn(len(pool))
+	for k := range pool {
+		var value = pool[(start+k)%len(pool)]
+		var str = fmt.Sprint(value)
+		if _, ok := u.used[str]; !ok {
+			u.used[str] = struct{}{}
+			return value, nil
+		}
+	}
+
+	return nil, fmt.Errorf("not enough parent rows for unique column `%s`", column.Name)
+}
+
+// makeUnique alters a generated value until it has not been used in the column. Text values get a suffix, and are
+// cut short to make room for it if the column is too narrow for both.
+func makeUnique(column *schema.Column, value interface{}, u *uniqueColumn, seq int) (interface{}, error) {
+
+	var str = fmt.Sprint(value)
+	if _, ok := u.used[str]; !ok {
+		u.used[str] = struct{}{}
+		return value, nil
+	}
+
+	if schema.IsInteger(column) {
+		for {
+			var candidate = u.nextInt
+			u.nextInt++
+			if _, ok := u.used[strconv.FormatInt(candidate, 10)]; !ok {
+				u.used[strconv.FormatInt(candidate, 10)] = struct{}{}
+				return candidate, nil
+			}
+		}
+	}
+
+	for attempt := 0; ; attempt++ {
+
+		var suffix = "-" + strconv.Itoa(seq)
+		if attempt > 0 {
+			suffix += "-" + strconv.Itoa(attempt)
+		}
+
+		var candidate = str + suffix
+
+		// Email addresses keep their domain
+		if at := strings.LastIndex(str, "@"); at > 0 {
+			candidate = str[0:at] + suffix + str[at:]
+		}
+
+		if column.MaxLength > 0 && utf8.RuneCountInString(candidate) > column.MaxLength {
+
+			if len(suffix) > column.MaxLength {
+				return nil, fmt.Errorf("no unique value for `%s` fits in %d characters", column.Name, column.MaxLength)
+			}
+
+			var base = []rune(str)
+			if keep := column.MaxLength - len(suffix); keep < len(base) {
+				base = base[0:keep]
+			}
+			candidate = string(base) + suffix
+		}
+
+		if _, ok := u.used[candidate]; !ok {
+			u.used[candidate] = struct{}{}
+			return candidate, nil
+		}
+	}
+}
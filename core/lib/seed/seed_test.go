@@ -0,0 +1,62 @@
+package seed
+
+import (
+	"testing"
+
+	"github.com/macinnir/dvc/core/lib/schema"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUniqueKeys(t *testing.T) {
+
+	var table = &schema.Table{
+		Name: "UserGroup",
+		Columns: map[string]*schema.Column{
+			"UserID":  {Name: "UserID", DataType: "bigint", ColumnKey: "PRI"},
+			"GroupID": {Name: "GroupID", DataType: "bigint", ColumnKey: "PRI"},
+			"Code":    {Name: "Code", DataType: "varchar", ColumnKey: "UNI"},
+			"Note":    {Name: "Note", DataType: "varchar", ColumnKey: "MUL"},
+		},
+	}
+
+	var keys = uniqueKeys(table)
+	require.Len(t, keys, 2)
+	require.Len(t, keys[0], 2)
+	assert.Equal(t, "GroupID", keys[0][0].Name)
+	assert.Equal(t, "UserID", keys[0][1].Name)
+	require.Len(t, keys[1], 1)
+	assert.Equal(t, "Code", keys[1][0].Name)
+
+	// An auto increment primary key is kept unique by the database
+	var users = &schema.Table{
+		Name: "User",
+		Columns: map[string]*schema.Column{
+			"UserID": {Name: "UserID", DataType: "bigint", ColumnKey: "PRI", Extra: "auto_increment"},
+		},
+	}
+
+	assert.Empty(t, uniqueKeys(users))
+}
+
+func TestMakeUniqueKey(t *testing.T) {
+
+	var s = &Seeder{generator: NewGenerator(1)}
+	var key = &compositeKey{
+		columns: []*schema.Column{{Name: "UserID"}, {Name: "GroupID"}},
+		used:    map[string]struct{}{},
+	}
+
+	// Each parent is reused, but no pair is
+	var pools = map[string][]interface{}{"UserID": {int64(1), int64(2)}, "GroupID": {int64(1), int64(2)}}
+
+	for seq := 1; seq <= 4; seq++ {
+		var row = map[string]interface{}{"UserID": int64(1), "GroupID": int64(1)}
+		require.Nil(t, s.makeUniqueKey("UserGroup", key, row, pools, seq))
+	}
+
+	assert.Len(t, key.used, 4)
+
+	var row = map[string]interface{}{"UserID": int64(1), "GroupID": int64(1)}
+	assert.EqualError(t, s.makeUniqueKey("UserGroup", key, row, pools, 5), "not enough distinct values for the key (`UserID`, `GroupID`)")
+}
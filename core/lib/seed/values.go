@@ -0,0 +1,331 @@
+package seed
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/macinnir/dvc/core/lib/schema"
+)
+
+var firstNames = []string{"James", "Mary", "Robert", "Patricia", "John", "Jennifer", "Michael", "Linda", "David", "Elizabeth", "William", "Barbara", "Richard", "Susan", "Joseph", "Jessica", "Thomas", "Sarah", "Carlos", "Karen", "Wei", "Aisha", "Mateo", "Yuki", "Priya", "Omar", "Elena", "Kwame"}
+var lastNames = []string{"Smith", "Johnson", "Williams", "Brown", "Jones", "Garcia", "Miller", "Davis", "Rodriguez", "Martinez", "Hernandez", "Lopez", "Wilson", "Anderson", "Thomas", "Taylor", "Moore", "Jackson", "Martin", "Lee", "Nguyen", "Chen", "Patel", "Kim", "Okafor", "Silva"}
+var words = []string{"lorem", "ipsum", "dolor", "sit", "amet", "consectetur", "adipiscing", "elit", "sed", "do", "eiusmod", "tempor", "incididunt", "ut", "labore", "et", "dolore", "magna", "aliqua", "enim", "ad", "minim", "veniam", "quis", "nostrud", "exercitation", "ullamco", "laboris", "nisi", "aliquip", "ex", "ea", "commodo", "consequat"}
+
+// generatorFunc builds a value for a row; seq is the row number starting at 1
+type generatorFunc func(g *Generator, column *schema.Column, seq int) interface{}
+
+// generators are the named generators that can be referenced from a seed rule
+var generators = map[string]generatorFunc{
+	"email": func(g *Generator, column *schema.Column, seq int) interface{} {
+		return strings.ToLower(fmt.Sprintf("%s.%s%d@example.com", g.pick(firstNames), g.pick(lastNames), seq))
+	},
+	"phone": func(g *Generator, column *schema.Column, seq int) interface{} {
+		return fmt.Sprintf("555-%03d-%04d", g.rand.Intn(1000), g.rand.Intn(10000))
+	},
+	"firstName": func(g *Generator, column *schema.Column, seq int) interface{} {
+		return g.pick(firstNames)
+	},
+	"lastName": func(g *Generator, column *schema.Column, seq int) interface{} {
+		return g.pick(lastNames)
+	},
+	"name": func(g *Generator, column *schema.Column, seq int) interface{} {
+		return g.pick(firstNames) + " " + g.pick(lastNames)
+	},
+	"url": func(g *Generator, column *schema.Column, seq int) interface{} {
+		return fmt.Sprintf("https://example.com/%s/%d", g.pick(words), seq)
+	},
+	"word": func(g *Generator, column *schema.Column, seq int) interface{} {
+		return g.pick(words)
+	},
+	"sentence": func(g *Generator, column *schema.Column, seq int) interface{} {
+		return g.sentence(5 + g.rand.Intn(10))
+	},
+	"paragraph": func(g *Generator, column *schema.Column, seq int) interface{} {
+		return g.sentence(30 + g.rand.Intn(40))
+	},
+	"hex": func(g *Generator, column *schema.Column, seq int) interface{} {
+		var b = make([]byte, 16)
+		g.rand.Read(b)
+		return fmt.Sprintf("%x", b)
+	},
+	"bool": func(g *Generator, column *schema.Column, seq int) interface{} {
+		return g.rand.Intn(2)
+	},
+	"zero": func(g *Generator, column *schema.Column, seq int) interface{} {
+		return 0
+	},
+	"timestamp": func(g *Generator, column *schema.Column, seq int) interface{} {
+		return g.recentTime().UnixNano() / 1000000
+	},
+	"date": func(g *Generator, column *schema.Column, seq int) interface{} {
+		return g.recentTime().Format("2006-01-02")
+	},
+	"datetime": func(g *Generator, column *schema.Column, seq int) interface{} {
+		return g.recentTime().Format("2006-01-02 15:04:05")
+	},
+}
+
+// Generator produces fake values for columns
+type Generator struct {
+	rand *rand.Rand
+	now  time.Time
+}
+
+// NewGenerator returns a new Generator. The same seed always produces the same values.
+func NewGenerator(seed int64) *Generator {
+	return &Generator{
+		rand: rand.New(rand.NewSource(seed)),
+		now:  time.Now(),
+	}
+}
+
+func (g *Generator) pick(list []string) string {
+	return list[g.rand.Intn(len(list))]
+}
+
+func (g *Generator) sentence(n int) string {
+	var parts = make([]string, n)
+	for k := range parts {
+		parts[k] = g.pick(words)
+	}
+	var str = strings.Join(parts, " ")
+	return strings.ToUpper(str[0:1]) + str[1:] + "."
+}
+
+// recentTime returns a time within the last year
+func (g *Generator) recentTime() time.Time {
+	return g.now.Add(-time.Duration(g.rand.Int63n(int64(365 * 24 * time.Hour))))
+}
+
+func isText(dataType string) bool {
+	switch dataType {
+	case "char", "varchar", "tinytext", "text", "mediumtext", "longtext":
+		return true
+	}
+	return false
+}
+
+// hasWordPrefix returns true if the camel case name starts with the word `prefix` (e.g. `IsActive` but not `Issue`)
+func hasWordPrefix(name, prefix string) bool {
+	return len(name) > len(prefix) && strings.HasPrefix(name, prefix) && unicode.IsUpper(rune(name[len(prefix)]))
+}
+
+// heuristic returns the name of the generator implied by the column name, if any
+func heuristic(column *schema.Column) string {
+
+	var name = strings.ToLower(column.Name)
+
+	switch {
+	case isText(column.DataType) && strings.Contains(name, "email"):
+		return "email"
+	case isText(column.DataType) && (strings.Contains(name, "phone") || strings.Contains(name, "mobile") || strings.Contains(name, "fax")):
+		return "phone"
+	case isText(column.DataType) && strings.Contains(name, "firstname"):
+		return "firstName"
+	case isText(column.DataType) && (strings.Contains(name, "lastname") || strings.Contains(name, "surname")):
+		return "lastName"
+	case isText(column.DataType) && (name == "name" || strings.HasSuffix(name, "fullname") || strings.HasSuffix(name, "displayname")):
+		return "name"
+	case isText(column.DataType) && (strings.Contains(name, "url") || strings.Contains(name, "website")):
+		return "url"
+	case isText(column.DataType) && (strings.Contains(name, "description") || strings.Contains(name, "content") || strings.Contains(name, "body") || strings.Contains(name, "notes")):
+		return "paragraph"
+	case isText(column.DataType) && (strings.Contains(name, "title") || strings.Contains(name, "subject")):
+		return "sentence"
+	case isText(column.DataType) && (strings.Contains(name, "hash") || strings.Contains(name, "token") || strings.Contains(name, "guid") || strings.Contains(name, "uuid")):
+		return "hex"
+	case schema.IsInteger(column) && name == "isdeleted":
+		return "zero"
+	case schema.IsInteger(column) && (hasWordPrefix(column.Name, "Is") || hasWordPrefix(column.Name, "Has") || column.Type == "tinyint(1)"):
+		return "bool"
+	case schema.IsInteger(column) && column.DataType == "bigint" && (strings.HasPrefix(name, "date") || strings.HasSuffix(name, "date") || name == "lastupdated" || strings.HasSuffix(column.Name, "At")):
+		// DateCreated and LastUpdated are stored as unix milliseconds
+		return "timestamp"
+	}
+
+	return ""
+}
+
+// Value produces a value for the column. A nil return means NULL.
+func (g *Generator) Value(column *schema.Column, rule *Rule, seq int) interface{} {
+
+	var nullRate = 0.0
+	if column.IsNullable {
+		nullRate = 0.1
+	}
+
+	if rule != nil {
+
+		if rule.NullRate != nil {
+			nullRate = *rule.NullRate
+		}
+
+		if column.IsNullable && nullRate > 0 && g.rand.Float64() < nullRate {
+			return nil
+		}
+
+		switch {
+		case rule.Value != nil:
+			return rule.Value
+		case len(rule.Values) > 0:
+			return rule.Values[g.rand.Intn(len(rule.Values))]
+		case len(rule.Format) > 0:
+			return fit(column, fmt.Sprintf(rule.Format, seq))
+		case len(rule.Generator) > 0:
+			return fit(column, generators[rule.Generator](g, column, seq))
+		case rule.Min != nil || rule.Max != nil:
+			return g.number(column, rule.Min, rule.Max)
+		}
+
+		return g.byType(column, seq)
+	}
+
+	var name = heuristic(column)
+
+	// Columns with a heuristic are "real" data, so they are never randomly NULL
+	if len(name) > 0 {
+		return fit(column, generators[name](g, column, seq))
+	}
+
+	if column.IsNullable && g.rand.Float64() < nullRate {
+		return nil
+	}
+
+	return g.byType(column, seq)
+}
+
+// byType generates a value from the column's data type alone
+func (g *Generator) byType(column *schema.Column, seq int) interface{} {
+
+	switch {
+	case column.DataType == "enum", column.DataType == "set":
+		var values = schema.EnumValues(column)
+		if len(values) == 0 {
+			return ""
+		}
+		return values[g.rand.Intn(len(values))]
+	case schema.IsInteger(column), column.DataType == "decimal", column.DataType == "float", column.DataType == "double":
+		return g.number(column, nil, nil)
+	case column.DataType == "date":
+		return generators["date"](g, column, seq)
+	case column.DataType == "datetime", column.DataType == "timestamp":
+		return generators["datetime"](g, column, seq)
+	case column.DataType == "time":
+		return fmt.Sprintf("%02d:%02d:%02d", g.rand.Intn(24), g.rand.Intn(60), g.rand.Intn(60))
+	case column.DataType == "year":
+		return 1970 + g.rand.Intn(60)
+	case column.DataType == "json":
+		return "{}"
+	case strings.Contains(column.DataType, "text"):
+		return fit(column, generators["paragraph"](g, column, seq))
+	}
+
+	// char, varchar, binary, blob...
+	var maxWords = 1 + g.rand.Intn(4)
+	return fit(column, g.sentence(maxWords))
+}
+
+// number generates a number within the column's range, optionally narrowed by min and max.
+// Without bounds values are kept "realistic" (0 - 100000) rather than spanning the whole type.
+func (g *Generator) number(column *schema.Column, min, max *float64) interface{} {
+
+	if schema.IsInteger(column) {
+		return g.integer(column, min, max)
+	}
+
+	var lo, hi = 0.0, 100000.0
+
+	if column.DataType == "decimal" && column.Precision > 0 {
+		var limit = math.Pow(10, float64(column.Precision-column.NumericScale)) - 1
+		hi = math.Min(hi, limit)
+	}
+
+	if min != nil {
+		lo = *min
+	}
+
+	if max != nil {
+		hi = *max
+	}
+
+	if hi < lo {
+		hi = lo
+	}
+
+	var value = lo + g.rand.Float64()*(hi-lo)
+	var scale = column.NumericScale
+	if column.DataType != "decimal" {
+		scale = 2
+	}
+
+	return fmt.Sprintf("%.*f", scale, value)
+}
+
+// integer generates an integer within the column's type, optionally narrowed by min and max. Values are int64, so
+// unsigned bigints stop at math.MaxInt64.
+func (g *Generator) integer(column *schema.Column, min, max *float64) int64 {
+
+	var typeMin, typeMax = schema.IntegerRange(column)
+	var lo, hi = clampInteger(0, typeMin, typeMax), clampInteger(100000, typeMin, typeMax)
+
+	if min != nil {
+		lo = clampInteger(*min, typeMin, typeMax)
+	}
+
+	if max != nil {
+		hi = clampInteger(*max, typeMin, typeMax)
+	}
+
+	if hi < lo {
+		hi = lo
+	}
+
+	// The span of e.g. a whole signed bigint doesn't fit in an int64
+	var span = uint64(hi - lo)
+	if span < math.MaxInt64 {
+		return lo + g.rand.Int63n(int64(span)+1)
+	}
+
+	for {
+		if n := g.rand.Uint64(); n <= span {
+			return lo + int64(n)
+		}
+	}
+}
+
+// clampInteger converts value to an int64 within typeMin and typeMax
+func clampInteger(value float64, typeMin int64, typeMax uint64) int64 {
+
+	if typeMax > math.MaxInt64 {
+		typeMax = math.MaxInt64
+	}
+
+	switch {
+	case value <= float64(typeMin):
+		return typeMin
+	case value >= float64(typeMax):
+		return int64(typeMax)
+	}
+
+	return int64(value)
+}
+
+// fit truncates string values to the column's max length
+func fit(column *schema.Column, value interface{}) interface{} {
+
+	var str, ok = value.(string)
+	if !ok || column.MaxLength <= 0 {
+		return value
+	}
+
+	var runes = []rune(str)
+	if len(runes) > column.MaxLength {
+		return string(runes[0:column.MaxLength])
+	}
+
+	return str
+}
@@ -0,0 +1,160 @@
+package seed
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/macinnir/dvc/core/lib/schema"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHeuristic(t *testing.T) {
+
+	var tests = []struct {
+		column   *schema.Column
+		expected string
+	}{
+		{&schema.Column{Name: "Email", DataType: "varchar"}, "email"},
+		{&schema.Column{Name: "WorkPhone", DataType: "varchar"}, "phone"},
+		{&schema.Column{Name: "DateCreated", DataType: "bigint"}, "timestamp"},
+		{&schema.Column{Name: "LastUpdated", DataType: "bigint"}, "timestamp"},
+		{&schema.Column{Name: "IsDeleted", DataType: "tinyint"}, "zero"},
+		{&schema.Column{Name: "IsActive", DataType: "tinyint"}, "bool"},
+		{&schema.Column{Name: "IssueID", DataType: "bigint"}, ""},
+		{&schema.Column{Name: "Format", DataType: "bigint"}, ""},
+		{&schema.Column{Name: "Email", DataType: "int"}, ""},
+	}
+
+	for _, test := range tests {
+		assert.Equal(t, test.expected, heuristic(test.column), test.column.Name)
+	}
+}
+
+func TestValueRespectsType(t *testing.T) {
+
+	var g = NewGenerator(1)
+
+	for seq := 1; seq < 200; seq++ {
+
+		var v = g.Value(&schema.Column{Name: "Code", DataType: "varchar", MaxLength: 5}, nil, seq)
+		assert.LessOrEqual(t, len(v.(string)), 5)
+
+		var n = g.Value(&schema.Column{Name: "Count", DataType: "tinyint", IsUnsigned: true}, nil, seq)
+		assert.GreaterOrEqual(t, n.(int64), int64(0))
+		assert.LessOrEqual(t, n.(int64), int64(255))
+
+		var d = g.Value(&schema.Column{Name: "Amount", DataType: "decimal", Precision: 4, NumericScale: 2}, nil, seq)
+		assert.Regexp(t, `^\d{1,2}\.\d{2}$`, d)
+
+		var en = g.Value(&schema.Column{Name: "Status", DataType: "enum", Type: "enum('on','off')"}, nil, seq)
+		assert.Contains(t, []string{"on", "off"}, en)
+
+		assert.NotNil(t, g.Value(&schema.Column{Name: "Notes", DataType: "text"}, nil, seq))
+	}
+}
+
+func TestValueNullable(t *testing.T) {
+
+	var g = NewGenerator(1)
+	var nulls = 0
+
+	for seq := 1; seq <= 1000; seq++ {
+		if g.Value(&schema.Column{Name: "Foo", DataType: "int", IsNullable: true}, nil, seq) == nil {
+			nulls++
+		}
+	}
+
+	assert.Greater(t, nulls, 0)
+	assert.Less(t, nulls, 300)
+}
+
+func TestValueRules(t *testing.T) {
+
+	var g = NewGenerator(1)
+	var min, max, never = 5.0, 6.0, 0.0
+	var column = &schema.Column{Name: "Foo", DataType: "int", IsNullable: true}
+
+	assert.Equal(t, "fixed", g.Value(column, &Rule{Value: "fixed"}, 1))
+	assert.Equal(t, "user3@example.com", g.Value(&schema.Column{Name: "Email", DataType: "varchar"}, &Rule{Format: "user%d@example.com"}, 3))
+	assert.Contains(t, []interface{}{"a", "b"}, g.Value(column, &Rule{Values: []interface{}{"a", "b"}}, 1))
+
+	for seq := 1; seq < 100; seq++ {
+		var v = g.Value(column, &Rule{Min: &min, Max: &max, NullRate: &never}, seq)
+		require.NotNil(t, v)
+		assert.Contains(t, []int64{5, 6}, v.(int64))
+	}
+}
+
+func TestValueRuleRange(t *testing.T) {
+
+	var g = NewGenerator(1)
+	var huge, tiny, low, high = 1e20, -1e20, -1000.0, 1000.0
+	var never = 0.0
+
+	for seq := 1; seq < 100; seq++ {
+
+		var v = g.Value(&schema.Column{Name: "Foo", DataType: "bigint"}, &Rule{Min: &tiny, Max: &huge, NullRate: &never}, seq)
+		require.IsType(t, int64(0), v)
+
+		v = g.Value(&schema.Column{Name: "Foo", DataType: "bigint", IsUnsigned: true}, &Rule{Max: &huge}, seq)
+		assert.GreaterOrEqual(t, v.(int64), int64(0))
+
+		v = g.Value(&schema.Column{Name: "Foo", DataType: "tinyint"}, &Rule{Min: &low, Max: &high}, seq)
+		assert.GreaterOrEqual(t, v.(int64), int64(-128))
+		assert.LessOrEqual(t, v.(int64), int64(127))
+	}
+
+	assert.Equal(t, int64(math.MaxInt64), g.Value(&schema.Column{Name: "Foo", DataType: "bigint", IsUnsigned: true}, &Rule{Min: &huge}, 1))
+}
+
+func TestMakeUnique(t *testing.T) {
+
+	var u = &uniqueColumn{used: map[string]struct{}{"a@example.com": {}, "7": {}, "abcdef": {}}, nextInt: 8}
+
+	var email, e = makeUnique(&schema.Column{DataType: "varchar", MaxLength: 100}, "a@example.com", u, 2)
+	require.Nil(t, e)
+	assert.Equal(t, "a-2@example.com", email)
+
+	short, e := makeUnique(&schema.Column{DataType: "varchar", MaxLength: 4}, "a@example.com", u, 12)
+	require.Nil(t, e)
+	assert.Equal(t, 4, len(short.(string)))
+	assert.True(t, strings.HasSuffix(short.(string), "-12"))
+
+	// The base is cut short so that it fits with the suffix
+	truncated, e := makeUnique(&schema.Column{DataType: "varchar", MaxLength: 6}, "abcdef", u, 3)
+	require.Nil(t, e)
+	assert.Equal(t, "abcd-3", truncated)
+
+	// The suffix alone is too long
+	_, e = makeUnique(&schema.Column{Name: "Code", DataType: "varchar", MaxLength: 3}, "abcdef", u, 100)
+	assert.EqualError(t, e, "no unique value for `Code` fits in 3 characters")
+
+	for _, expected := range []int64{8, 9} {
+		n, e := makeUnique(&schema.Column{DataType: "int"}, int64(7), u, 1)
+		require.Nil(t, e)
+		assert.Equal(t, expected, n)
+	}
+
+	n, e := makeUnique(&schema.Column{DataType: "int"}, int64(3), u, 3)
+	require.Nil(t, e)
+	assert.Equal(t, int64(3), n)
+	_, ok := u.used[fmt.Sprint(3)]
+	assert.True(t, ok)
+}
+
+func TestInsertColumns(t *testing.T) {
+	var table = &schema.Table{
+		Columns: map[string]*schema.Column{
+			"FooID": {Name: "FooID", DataType: "int", ColumnKey: "PRI", Extra: "auto_increment"},
+			"Name":  {Name: "Name", DataType: "varchar"},
+			"Total": {Name: "Total", DataType: "int", Extra: "VIRTUAL GENERATED"},
+		},
+	}
+
+	var columns = InsertColumns(table)
+	require.Len(t, columns, 1)
+	assert.Equal(t, "Name", columns[0].Name)
+}
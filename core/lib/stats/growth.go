@@ -0,0 +1,188 @@
+package stats
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"path"
+
+	"github.com/macinnir/dvc/core/lib"
+)
+
+const (
+	// KeyUsageWarning is the fraction of the primary key range used before a warning is shown
+	KeyUsageWarning = 0.8
+	// OverflowWarningDays is the projected number of days until overflow below which a warning is shown
+	OverflowWarningDays = 365
+)
+
+// SnapshotPath returns the path of the snapshot file for a connection
+func SnapshotPath(connection string) string {
+	return path.Join(lib.StatsDirectory, connection+".jsonl")
+}
+
+// SaveSnapshot appends a snapshot to the connection's snapshot file
+func SaveSnapshot(snapshot *Snapshot) error {
+
+	var e error
+
+	if e = lib.EnsureDir(lib.StatsDirectory); e != nil {
+		return e
+	}
+
+	var f *os.File
+	if f, e = os.OpenFile(SnapshotPath(snapshot.Connection), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644); e != nil {
+		return e
+	}
+
+	defer f.Close()
+
+	var line []byte
+	if line, e = json.Marshal(snapshot); e != nil {
+		return e
+	}
+
+	_, e = f.Write(append(line, '\n'))
+
+	return e
+}
+
+// LoadSnapshots loads every snapshot saved for a connection, oldest first
+func LoadSnapshots(connection string) ([]*Snapshot, error) {
+
+	var snapshots = []*Snapshot{}
+	var filePath = SnapshotPath(connection)
+
+	if !lib.FileExists(filePath) {
+		return snapshots, nil
+	}
+
+	var e error
+	var f *os.File
+	if f, e = os.Open(filePath); e != nil {
+		return nil, e
+	}
+
+	defer f.Close()
+
+	var scanner = bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 1024*1024), 64*1024*1024)
+
+	for scanner.Scan() {
+
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+
+		var snapshot = &Snapshot{}
+		if e = json.Unmarshal(scanner.Bytes(), snapshot); e != nil {
+			return nil, fmt.Errorf("Invalid snapshot in %s: %w", filePath, e)
+		}
+
+		snapshots = append(snapshots, snapshot)
+	}
+
+	return snapshots, scanner.Err()
+}
+
+// Growth is the change in a table between two snapshots
+type Growth struct {
+	Name       string
+	Days       float64
+	From       *TableStats
+	To         *TableStats
+	RowsPerDay float64
+	// BytesPerDay is the growth of data plus indexes
+	BytesPerDay float64
+	// IDsPerDay is the growth of the auto increment counter
+	IDsPerDay float64
+}
+
+// RowsDelta is the change in row count
+func (g *Growth) RowsDelta() int64 {
+	return g.To.Rows - g.From.Rows
+}
+
+// BytesDelta is the change in data plus index size
+func (g *Growth) BytesDelta() int64 {
+	return g.To.TotalLength() - g.From.TotalLength()
+}
+
+// DaysUntilOverflow projects the number of days until the primary key overflows. Returns +Inf if the counter is not moving.
+func (g *Growth) DaysUntilOverflow() float64 {
+	if !g.To.HasAutoIncrement() || g.IDsPerDay <= 0 {
+		return math.Inf(1)
+	}
+	return float64(g.To.Headroom()) / g.IDsPerDay
+}
+
+// Warning returns a message if the table's primary key is close to overflowing, otherwise an empty string
+func (g *Growth) Warning() string {
+
+	if !g.To.HasAutoIncrement() {
+		return ""
+	}
+
+	var days = g.DaysUntilOverflow()
+
+	switch {
+	case g.To.KeyUsage() >= KeyUsageWarning:
+		return fmt.Sprintf("%s.%s (%s) has used %.1f%% of its range", g.Name, g.To.PrimaryKey, g.To.PrimaryKeyType, g.To.KeyUsage()*100)
+	case days < OverflowWarningDays:
+		return fmt.Sprintf("%s.%s (%s) is projected to overflow in %.0f days", g.Name, g.To.PrimaryKey, g.To.PrimaryKeyType, days)
+	}
+
+	return ""
+}
+
+// CalculateGrowth compares the first and last snapshots and returns the growth of every table in the last snapshot
+func CalculateGrowth(snapshots []*Snapshot) []*Growth {
+
+	var growth = []*Growth{}
+
+	if len(snapshots) == 0 {
+		return growth
+	}
+
+	var first = snapshots[0]
+	var last = snapshots[len(snapshots)-1]
+	var days = float64(last.Time-first.Time) / 86400
+
+	for _, to := range last.Tables {
+
+		var from = to
+		var tableDays = days
+
+		// Tables created after the first snapshot are measured from the first snapshot they appear in
+		if first.Table(to.Name) == nil {
+			for _, snapshot := range snapshots {
+				if t := snapshot.Table(to.Name); t != nil {
+					from = t
+					tableDays = float64(last.Time-snapshot.Time) / 86400
+					break
+				}
+			}
+		} else {
+			from = first.Table(to.Name)
+		}
+
+		var g = &Growth{
+			Name: to.Name,
+			Days: tableDays,
+			From: from,
+			To:   to,
+		}
+
+		if tableDays > 0 {
+			g.RowsPerDay = float64(to.Rows-from.Rows) / tableDays
+			g.BytesPerDay = float64(to.TotalLength()-from.TotalLength()) / tableDays
+			g.IDsPerDay = float64(to.AutoIncrement-from.AutoIncrement) / tableDays
+		}
+
+		growth = append(growth, g)
+	}
+
+	return growth
+}
@@ -0,0 +1,142 @@
+package stats
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/macinnir/dvc/core/lib/schema"
+)
+
+// TableStats are the size and key usage statistics of a single table at a point in time
+type TableStats struct {
+	Name           string `json:"name"`
+	Rows           int64  `json:"rows"`
+	DataLength     int64  `json:"dataLength"`
+	IndexLength    int64  `json:"indexLength"`
+	DataFree       int64  `json:"dataFree"`
+	AutoIncrement  int64  `json:"autoIncrement"`
+	PrimaryKey     string `json:"primaryKey"`
+	PrimaryKeyType string `json:"primaryKeyType"`
+	PrimaryKeyMax  uint64 `json:"primaryKeyMax"`
+}
+
+// Snapshot is the statistics of every table in a database at a point in time
+type Snapshot struct {
+	Connection string        `json:"connection"`
+	Time       int64         `json:"time"`
+	Tables     []*TableStats `json:"tables"`
+}
+
+// NewTableStats builds the statistics for a table fetched from a remote database
+func NewTableStats(table *schema.Table) *TableStats {
+
+	var s = &TableStats{
+		Name:          table.Name,
+		Rows:          table.Rows,
+		DataLength:    table.DataLength,
+		IndexLength:   table.IndexLength,
+		DataFree:      table.DataFree,
+		AutoIncrement: table.AutoIncrement,
+	}
+
+	for _, column := range table.ToSortedColumns() {
+		if column.ColumnKey == "PRI" && schema.IsInteger(column) {
+			s.PrimaryKey = column.Name
+			s.PrimaryKeyType = column.Type
+			_, s.PrimaryKeyMax = schema.IntegerRange(column)
+			break
+		}
+	}
+
+	return s
+}
+
+// NewSnapshot builds a snapshot of all of the tables in a database
+func NewSnapshot(connection string, database *schema.Database, now time.Time) *Snapshot {
+
+	var snapshot = &Snapshot{
+		Connection: connection,
+		Time:       now.Unix(),
+		Tables:     make([]*TableStats, 0, len(database.Tables)),
+	}
+
+	for _, table := range database.Tables {
+		snapshot.Tables = append(snapshot.Tables, NewTableStats(table))
+	}
+
+	sort.Slice(snapshot.Tables, func(i, j int) bool {
+		return snapshot.Tables[i].Name < snapshot.Tables[j].Name
+	})
+
+	return snapshot
+}
+
+// Table returns the stats for a table in the snapshot, or nil
+func (s *Snapshot) Table(tableName string) *TableStats {
+	for k := range s.Tables {
+		if s.Tables[k].Name == tableName {
+			return s.Tables[k]
+		}
+	}
+	return nil
+}
+
+// TotalLength is the data plus index size
+func (s *TableStats) TotalLength() int64 {
+	return s.DataLength + s.IndexLength
+}
+
+// HasAutoIncrement returns true if the table has an auto increment integer primary key
+func (s *TableStats) HasAutoIncrement() bool {
+	return s.PrimaryKeyMax > 0 && s.AutoIncrement > 0
+}
+
+// KeyUsage is the fraction (0-1) of the primary key's range used by the auto increment counter
+func (s *TableStats) KeyUsage() float64 {
+	if !s.HasAutoIncrement() {
+		return 0
+	}
+	return float64(s.AutoIncrement) / float64(s.PrimaryKeyMax)
+}
+
+// Headroom is the number of ids left before the primary key overflows
+func (s *TableStats) Headroom() uint64 {
+	if !s.HasAutoIncrement() || uint64(s.AutoIncrement) > s.PrimaryKeyMax {
+		return 0
+	}
+	return s.PrimaryKeyMax - uint64(s.AutoIncrement)
+}
+
+// Fragmentation is the fraction (0-1) of allocated space that is free
+func (s *TableStats) Fragmentation() float64 {
+	var total = s.DataLength + s.IndexLength + s.DataFree
+	if total == 0 {
+		return 0
+	}
+	return float64(s.DataFree) / float64(total)
+}
+
+// FormatBytes renders a byte count in human readable units
+func FormatBytes(n int64) string {
+
+	const unit = 1024
+
+	var sign = ""
+	if n < 0 {
+		sign = "-"
+		n = -n
+	}
+
+	if n < unit {
+		return fmt.Sprintf("%s%d B", sign, n)
+	}
+
+	var div, exp = int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+
+	return fmt.Sprintf("%s%.1f %ciB", sign, float64(n)/float64(div), "KMGTPE"[exp])
+}
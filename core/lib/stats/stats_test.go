@@ -0,0 +1,101 @@
+package stats
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/macinnir/dvc/core/lib/schema"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewTableStats(t *testing.T) {
+
+	var s = NewTableStats(&schema.Table{
+		Name:          "User",
+		Rows:          10,
+		DataLength:    300,
+		IndexLength:   100,
+		DataFree:      100,
+		AutoIncrement: 2147483647 / 2,
+		Columns: map[string]*schema.Column{
+			"UserID": {Name: "UserID", DataType: "int", Type: "int(11)", ColumnKey: "PRI"},
+			"Name":   {Name: "Name", DataType: "varchar"},
+		},
+	})
+
+	assert.Equal(t, "UserID", s.PrimaryKey)
+	assert.Equal(t, uint64(2147483647), s.PrimaryKeyMax)
+	assert.InDelta(t, 0.5, s.KeyUsage(), 0.001)
+	assert.Equal(t, uint64(2147483647-2147483647/2), s.Headroom())
+	assert.InDelta(t, 0.2, s.Fragmentation(), 0.001)
+	assert.Equal(t, int64(400), s.TotalLength())
+}
+
+func TestNewTableStatsNoAutoIncrement(t *testing.T) {
+	var s = NewTableStats(&schema.Table{
+		Name: "Setting",
+		Columns: map[string]*schema.Column{
+			"Name": {Name: "Name", DataType: "varchar", ColumnKey: "PRI"},
+		},
+	})
+
+	assert.False(t, s.HasAutoIncrement())
+	assert.Equal(t, float64(0), s.KeyUsage())
+	assert.Equal(t, float64(0), s.Fragmentation())
+}
+
+func TestFormatBytes(t *testing.T) {
+	assert.Equal(t, "512 B", FormatBytes(512))
+	assert.Equal(t, "1.5 KiB", FormatBytes(1536))
+	assert.Equal(t, "2.0 GiB", FormatBytes(2*1024*1024*1024))
+	assert.Equal(t, "-1.0 MiB", FormatBytes(-1024*1024))
+}
+
+func TestCalculateGrowth(t *testing.T) {
+
+	var day = int64(86400)
+	var start = time.Now().Unix()
+	var max = uint64(1000000)
+
+	var snapshots = []*Snapshot{
+		{Time: start, Tables: []*TableStats{
+			{Name: "User", Rows: 100, DataLength: 1000, AutoIncrement: 100, PrimaryKey: "UserID", PrimaryKeyMax: max},
+		}},
+		{Time: start + 10*day, Tables: []*TableStats{
+			{Name: "Log", Rows: 10, AutoIncrement: 10, PrimaryKey: "LogID", PrimaryKeyMax: max},
+			{Name: "User", Rows: 200, DataLength: 2000, AutoIncrement: 200, PrimaryKey: "UserID", PrimaryKeyMax: max},
+		}},
+		{Time: start + 20*day, Tables: []*TableStats{
+			{Name: "Log", Rows: 110010, AutoIncrement: 900010, PrimaryKey: "LogID", PrimaryKeyMax: max},
+			{Name: "User", Rows: 300, DataLength: 3000, AutoIncrement: 300, PrimaryKey: "UserID", PrimaryKeyMax: max},
+		}},
+	}
+
+	var growth = CalculateGrowth(snapshots)
+	require.Len(t, growth, 2)
+
+	var log, user = growth[0], growth[1]
+
+	assert.Equal(t, "User", user.Name)
+	assert.Equal(t, int64(200), user.RowsDelta())
+	assert.InDelta(t, 10, user.RowsPerDay, 0.001)
+	assert.InDelta(t, 100, user.BytesPerDay, 0.001)
+	assert.InDelta(t, float64(max-300)/10, user.DaysUntilOverflow(), 0.001)
+	assert.Equal(t, "", user.Warning())
+
+	// Log only exists from the second snapshot on
+	assert.Equal(t, "Log", log.Name)
+	assert.InDelta(t, 10, log.Days, 0.001)
+	assert.InDelta(t, 90000, log.IDsPerDay, 0.001)
+	assert.Contains(t, log.Warning(), "has used 90.0% of its range")
+
+	log.To.AutoIncrement = 500000
+	log.IDsPerDay = 1000
+	assert.Equal(t, "", log.Warning())
+	log.IDsPerDay = 2000
+	assert.Contains(t, log.Warning(), "projected to overflow in 250 days")
+	log.IDsPerDay = 0
+	assert.True(t, math.IsInf(log.DaysUntilOverflow(), 1))
+}
@@ -0,0 +1,104 @@
+package subset
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/macinnir/dvc/core/lib/schema"
+)
+
+// escapeString escapes a string value for use inside a single-quoted SQL literal
+func escapeString(val string) string {
+	return strings.ReplaceAll(
+		strings.ReplaceAll(
+			val,
+			"\\", "\\\\",
+		),
+		"'", "''",
+	)
+}
+
+// FormatValue renders a single value as a SQL literal for the given column
+func FormatValue(column *schema.Column, value interface{}) string {
+
+	if value == nil {
+		return "NULL"
+	}
+
+	if schema.IsString(column) {
+		return "'" + escapeString(fmt.Sprint(value)) + "'"
+	}
+
+	switch v := value.(type) {
+	case string:
+		// Numbers are scanned as []byte by the mysql driver; anything else (time, timestamp, blob...) is quoted
+		if _, e := strconv.ParseFloat(v, 64); e == nil {
+			return v
+		}
+		return "'" + escapeString(v) + "'"
+	case bool:
+		if v {
+			return "1"
+		}
+		return "0"
+	}
+
+	return fmt.Sprint(value)
+}
+
+// InsertSQL builds a single multi-row INSERT statement for `rows` with the values inlined
+func InsertSQL(table *schema.Table, rows []map[string]interface{}) string {
+
+	var columns = table.ToSortedColumns()
+	var sb strings.Builder
+
+	sb.WriteString(insertHead(table, columns))
+
+	for k := range rows {
+		if k > 0 {
+			sb.WriteString(",\n")
+		}
+		sb.WriteString("(")
+		for l := range columns {
+			if l > 0 {
+				sb.WriteString(",")
+			}
+			sb.WriteString(FormatValue(columns[l], rows[k][columns[l].Name]))
+		}
+		sb.WriteString(")")
+	}
+
+	return sb.String()
+}
+
+// InsertSQLWithArgs builds a single multi-row INSERT statement for `rows` with placeholders
+func InsertSQLWithArgs(table *schema.Table, rows []map[string]interface{}) (string, []interface{}) {
+
+	var columns = table.ToSortedColumns()
+	var args = make([]interface{}, 0, len(columns)*len(rows))
+	var placeholders = "(" + strings.TrimSuffix(strings.Repeat("?,", len(columns)), ",") + ")"
+	var sb strings.Builder
+
+	sb.WriteString(insertHead(table, columns))
+
+	for k := range rows {
+		if k > 0 {
+			sb.WriteString(",\n")
+		}
+		sb.WriteString(placeholders)
+		for l := range columns {
+			args = append(args, rows[k][columns[l].Name])
+		}
+	}
+
+	return sb.String(), args
+}
+
+func insertHead(table *schema.Table, columns schema.SortedColumns) string {
+	var names = make([]string, len(columns))
+	for k := range columns {
+		names[k] = "`" + columns[k].Name + "`"
+	}
+	return fmt.Sprintf("INSERT INTO `%s` (%s) VALUES\n", table.Name, strings.Join(names, ","))
+}
@@ -0,0 +1,213 @@
+package subset
+
+import (
+	"database/sql"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/macinnir/dvc/core/lib"
+)
+
+// Relationship links a column on a child table to the column it references on a parent table
+type Relationship struct {
+	ParentTable  string
+	ParentColumn string
+	ChildTable   string
+	ChildColumn  string
+}
+
+func (r *Relationship) String() string {
+	return fmt.Sprintf("%s.%s => %s.%s", r.ParentTable, r.ParentColumn, r.ChildTable, r.ChildColumn)
+}
+
+// Querier is satisfied by *sql.DB, *sql.Conn and *sql.Tx
+type Querier interface {
+	Query(query string, args ...interface{}) (*sql.Rows, error)
+}
+
+// ParseRelationships builds relationships from the OneToMany, ManyToOne and OneToOne maps on a database config.
+// Both sides of each entry use the `Table.Column` notation and the value can be a comma-separated list.
+//
+//	"onetomany": { "Account.AccountID": "User.AccountID, Invoice.AccountID" }
+//	"manytoone": { "User.RoleID": "Role.RoleID" }
+//	"onetoone":  { "User.UserID": "UserProfile.UserID" }
+//
+// OneToMany and OneToOne entries are keyed by the parent; ManyToOne entries are keyed by the child.
+func ParseRelationships(config *lib.ConfigDatabase) ([]*Relationship, error) {
+
+	var e error
+	var relationships = []*Relationship{}
+
+	var parse = func(m map[string]string, parentIsKey bool) error {
+
+		var keys = make([]string, 0, len(m))
+		for k := range m {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+
+		for _, key := range keys {
+
+			var keyTable, keyColumn string
+			if keyTable, keyColumn, e = splitTableColumn(key); e != nil {
+				return e
+			}
+
+			for _, value := range strings.Split(m[key], ",") {
+
+				var valueTable, valueColumn string
+				if valueTable, valueColumn, e = splitTableColumn(value); e != nil {
+					return e
+				}
+
+				if parentIsKey {
+					relationships = append(relationships, &Relationship{keyTable, keyColumn, valueTable, valueColumn})
+				} else {
+					relationships = append(relationships, &Relationship{valueTable, valueColumn, keyTable, keyColumn})
+				}
+			}
+		}
+
+		return nil
+	}
+
+	if e = parse(config.OneToMany, true); e != nil {
+		return nil, fmt.Errorf("onetomany: %w", e)
+	}
+
+	if e = parse(config.OneToOne, true); e != nil {
+		return nil, fmt.Errorf("onetoone: %w", e)
+	}
+
+	if e = parse(config.ManyToOne, false); e != nil {
+		return nil, fmt.Errorf("manytoone: %w", e)
+	}
+
+	return relationships, nil
+}
+
+func splitTableColumn(str string) (string, string, error) {
+	var parts = strings.Split(strings.TrimSpace(str), ".")
+	if len(parts) != 2 || len(parts[0]) == 0 || len(parts[1]) == 0 {
+		return "", "", fmt.Errorf("Invalid relationship `%s`; expected `Table.Column`", str)
+	}
+	return parts[0], parts[1], nil
+}
+
+// FetchForeignKeys returns the foreign keys defined on the database `databaseName` as relationships
+func FetchForeignKeys(db Querier, databaseName string) ([]*Relationship, error) {
+
+	var e error
+	var rows *sql.Rows
+
+	if rows, e = db.Query(`
+		SELECT
+			REFERENCED_TABLE_NAME,
+			REFERENCED_COLUMN_NAME,
+			TABLE_NAME,
+			COLUMN_NAME
+		FROM information_schema.KEY_COLUMN_USAGE
+		WHERE
+			TABLE_SCHEMA = ?
+			AND REFERENCED_TABLE_NAME IS NOT NULL
+		ORDER BY TABLE_NAME, COLUMN_NAME`, databaseName); e != nil {
+		return nil, fmt.Errorf("Error fetching foreign keys: %w", e)
+	}
+
+	defer rows.Close()
+
+	var relationships = []*Relationship{}
+	for rows.Next() {
+		var r = &Relationship{}
+		if e = rows.Scan(&r.ParentTable, &r.ParentColumn, &r.ChildTable, &r.ChildColumn); e != nil {
+			return nil, e
+		}
+		relationships = append(relationships, r)
+	}
+
+	return relationships, rows.Err()
+}
+
+// MergeRelationships combines sets of relationships, dropping duplicates
+func MergeRelationships(sets ...[]*Relationship) []*Relationship {
+
+	var seen = map[string]struct{}{}
+	var merged = []*Relationship{}
+
+	for k := range sets {
+		for l := range sets[k] {
+			var key = sets[k][l].String()
+			if _, ok := seen[key]; ok {
+				continue
+			}
+			seen[key] = struct{}{}
+			merged = append(merged, sets[k][l])
+		}
+	}
+
+	return merged
+}
+
+// SortTablesByDependency orders table names so that parents come before their children.
+// Tables that are part of a cycle are appended in name order.
+func SortTablesByDependency(tables []string, relationships []*Relationship) []string {
+
+	var included = map[string]bool{}
+	for k := range tables {
+		included[tables[k]] = true
+	}
+
+	var parents = map[string]map[string]struct{}{}
+	for k := range tables {
+		parents[tables[k]] = map[string]struct{}{}
+	}
+
+	for _, r := range relationships {
+		if !included[r.ParentTable] || !included[r.ChildTable] || r.ParentTable == r.ChildTable {
+			continue
+		}
+		parents[r.ChildTable][r.ParentTable] = struct{}{}
+	}
+
+	var sorted = make([]string, 0, len(tables))
+	var done = map[string]bool{}
+
+	for len(sorted) < len(tables) {
+
+		var ready = []string{}
+		for table := range parents {
+			if done[table] {
+				continue
+			}
+			var isReady = true
+			for parent := range parents[table] {
+				if !done[parent] {
+					isReady = false
+					break
+				}
+			}
+			if isReady {
+				ready = append(ready, table)
+			}
+		}
+
+		// Cycle; add whatever is left
+		if len(ready) == 0 {
+			for table := range parents {
+				if !done[table] {
+					ready = append(ready, table)
+				}
+			}
+		}
+
+		sort.Strings(ready)
+
+		for k := range ready {
+			done[ready[k]] = true
+			sorted = append(sorted, ready[k])
+		}
+	}
+
+	return sorted
+}
@@ -0,0 +1,102 @@
+package subset
+
+import (
+	"testing"
+
+	"github.com/macinnir/dvc/core/lib"
+	"github.com/macinnir/dvc/core/lib/schema"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseRelationships(t *testing.T) {
+
+	var config = &lib.ConfigDatabase{
+		OneToMany: map[string]string{
+			"Account.AccountID": "User.AccountID, Invoice.AccountID",
+		},
+		ManyToOne: map[string]string{
+			"User.RoleID": "Role.RoleID",
+		},
+		OneToOne: map[string]string{
+			"User.UserID": "UserProfile.UserID",
+		},
+	}
+
+	relationships, e := ParseRelationships(config)
+	require.Nil(t, e)
+	require.Len(t, relationships, 4)
+
+	assert.Equal(t, "Account.AccountID => User.AccountID", relationships[0].String())
+	assert.Equal(t, "Account.AccountID => Invoice.AccountID", relationships[1].String())
+	assert.Equal(t, "User.UserID => UserProfile.UserID", relationships[2].String())
+	assert.Equal(t, "Role.RoleID => User.RoleID", relationships[3].String())
+}
+
+func TestParseRelationshipsInvalid(t *testing.T) {
+	_, e := ParseRelationships(&lib.ConfigDatabase{
+		OneToMany: map[string]string{"Account": "User.AccountID"},
+	})
+	assert.NotNil(t, e)
+}
+
+func TestMergeRelationships(t *testing.T) {
+	var a = []*Relationship{{"Account", "AccountID", "User", "AccountID"}}
+	var b = []*Relationship{{"Account", "AccountID", "User", "AccountID"}, {"Role", "RoleID", "User", "RoleID"}}
+	assert.Len(t, MergeRelationships(a, b), 2)
+}
+
+func TestSortTablesByDependency(t *testing.T) {
+
+	var relationships = []*Relationship{
+		{"Account", "AccountID", "User", "AccountID"},
+		{"Role", "RoleID", "User", "RoleID"},
+		{"User", "UserID", "Invoice", "UserID"},
+		{"Account", "AccountID", "Invoice", "AccountID"},
+		{"Missing", "MissingID", "Invoice", "MissingID"},
+	}
+
+	sorted := SortTablesByDependency([]string{"Invoice", "User", "Role", "Account"}, relationships)
+	assert.Equal(t, []string{"Account", "Role", "User", "Invoice"}, sorted)
+}
+
+func TestSortTablesByDependencyCycle(t *testing.T) {
+
+	var relationships = []*Relationship{
+		{"A", "AID", "B", "AID"},
+		{"B", "BID", "A", "BID"},
+		{"A", "AID", "C", "AID"},
+	}
+
+	sorted := SortTablesByDependency([]string{"C", "B", "A"}, relationships)
+	assert.Equal(t, []string{"A", "B", "C"}, sorted)
+}
+
+func TestInsertSQL(t *testing.T) {
+
+	var table = &schema.Table{
+		Name: "User",
+		Columns: map[string]*schema.Column{
+			"UserID":      {Name: "UserID", DataType: "bigint", ColumnKey: "PRI"},
+			"Name":        {Name: "Name", DataType: "varchar"},
+			"DateCreated": {Name: "DateCreated", DataType: "timestamp"},
+			"Score":       {Name: "Score", DataType: "decimal"},
+		},
+	}
+
+	var rows = []map[string]interface{}{
+		{"UserID": int64(1), "Name": "O'Brien", "DateCreated": "2020-01-01 00:00:00", "Score": "1.50"},
+		{"UserID": int64(2), "Name": nil, "DateCreated": nil, "Score": nil},
+	}
+
+	assert.Equal(t,
+		"INSERT INTO `User` (`DateCreated`,`Name`,`Score`,`UserID`) VALUES\n"+
+			"('2020-01-01 00:00:00','O''Brien',1.50,1),\n"+
+			"(NULL,NULL,NULL,2)",
+		InsertSQL(table, rows),
+	)
+
+	query, args := InsertSQLWithArgs(table, rows)
+	assert.Equal(t, "INSERT INTO `User` (`DateCreated`,`Name`,`Score`,`UserID`) VALUES\n(?,?,?,?),\n(?,?,?,?)", query)
+	assert.Len(t, args, 8)
+}
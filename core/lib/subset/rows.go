@@ -0,0 +1,42 @@
+package subset
+
+import "database/sql"
+
+// ScanRows reads all of the rows into maps keyed by column name. Byte slices are converted to strings.
+func ScanRows(rows *sql.Rows) ([]map[string]interface{}, error) {
+
+	var e error
+	var columnNames []string
+
+	if columnNames, e = rows.Columns(); e != nil {
+		return nil, e
+	}
+
+	var result = []map[string]interface{}{}
+
+	for rows.Next() {
+
+		var values = make([]interface{}, len(columnNames))
+		var valuePtrs = make([]interface{}, len(columnNames))
+		for i := range columnNames {
+			valuePtrs[i] = &values[i]
+		}
+
+		if e = rows.Scan(valuePtrs...); e != nil {
+			return nil, e
+		}
+
+		var row = make(map[string]interface{}, len(columnNames))
+		for i, col := range columnNames {
+			if b, ok := values[i].([]byte); ok {
+				row[col] = string(b)
+			} else {
+				row[col] = values[i]
+			}
+		}
+
+		result = append(result, row)
+	}
+
+	return result, rows.Err()
+}
@@ -0,0 +1,348 @@
+package subset
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/macinnir/dvc/core/lib/schema"
+)
+
+// inChunkSize is the maximum number of values placed in a single `IN (...)` clause
+const inChunkSize = 500
+
+// TableRows is the set of rows extracted for a single table
+type TableRows struct {
+	Table *schema.Table
+	Keys  []string
+	Rows  map[string]map[string]interface{}
+
+	// expanded tracks rows whose children have already been followed
+	expanded map[string]bool
+}
+
+// Subset extracts a referentially closed set of rows starting from a root table
+type Subset struct {
+	db            Querier
+	schema        *schema.Schema
+	relationships []*Relationship
+	Tables        map[string]*TableRows
+}
+
+// NewSubset returns a new Subset
+func NewSubset(db Querier, s *schema.Schema, relationships []*Relationship) *Subset {
+	return &Subset{
+		db:            db,
+		schema:        s,
+		relationships: relationships,
+		Tables:        map[string]*TableRows{},
+	}
+}
+
+type pending struct {
+	tableName string
+	keys      []string
+	down      bool
+}
+
+// Extract selects the rows in `rootTable` matching `where` and then follows relationships
+// until the set is closed. Children are followed from the root rows and their descendants;
+// parents are followed from every extracted row so that nothing references a missing row.
+func (s *Subset) Extract(rootTable, where string) error {
+
+	var e error
+
+	if _, ok := s.schema.Tables[rootTable]; !ok {
+		return fmt.Errorf("Table `%s` not found in schema `%s`", rootTable, s.schema.Name)
+	}
+
+	var query = fmt.Sprintf("SELECT * FROM `%s`", rootTable)
+	if len(strings.TrimSpace(where)) > 0 {
+		query += " WHERE " + where
+	}
+
+	var keys []string
+	if keys, e = s.fetch(rootTable, query, true); e != nil {
+		return e
+	}
+
+	var queue = []*pending{{rootTable, keys, true}}
+
+	for len(queue) > 0 {
+
+		var p = queue[0]
+		queue = queue[1:]
+
+		if len(p.keys) == 0 {
+			continue
+		}
+
+		for _, r := range s.relationships {
+
+			// Parents
+			if r.ChildTable == p.tableName {
+				if keys, e = s.follow(p.tableName, p.keys, r.ChildColumn, r.ParentTable, r.ParentColumn, false); e != nil {
+					return e
+				}
+				queue = append(queue, &pending{r.ParentTable, keys, false})
+			}
+
+			// Children
+			if p.down && r.ParentTable == p.tableName {
+				if keys, e = s.follow(p.tableName, p.keys, r.ParentColumn, r.ChildTable, r.ChildColumn, true); e != nil {
+					return e
+				}
+				queue = append(queue, &pending{r.ChildTable, keys, true})
+			}
+		}
+	}
+
+	return nil
+}
+
+// follow selects the rows in `toTable` where `toColumn` matches the `fromColumn` values of the given rows
+// and returns the keys of the rows that still need to be expanded
+func (s *Subset) follow(fromTable string, fromKeys []string, fromColumn, toTable, toColumn string, down bool) ([]string, error) {
+
+	if _, ok := s.schema.Tables[toTable]; !ok {
+		return nil, fmt.Errorf("Relationship references unknown table `%s`", toTable)
+	}
+
+	var from = s.Tables[fromTable]
+	var seen = map[string]struct{}{}
+	var values = []interface{}{}
+
+	for _, key := range fromKeys {
+		var value = from.Rows[key][fromColumn]
+		if value == nil {
+			continue
+		}
+		var str = fmt.Sprint(value)
+		if _, ok := seen[str]; ok {
+			continue
+		}
+		seen[str] = struct{}{}
+		values = append(values, value)
+	}
+
+	var keys = []string{}
+
+	for len(values) > 0 {
+
+		var chunk = values
+		if len(chunk) > inChunkSize {
+			chunk = values[0:inChunkSize]
+		}
+		values = values[len(chunk):]
+
+		var query = fmt.Sprintf(
+			"SELECT * FROM `%s` WHERE `%s` IN (%s)",
+			toTable,
+			toColumn,
+			strings.TrimSuffix(strings.Repeat("?,", len(chunk)), ","),
+		)
+
+		var chunkKeys []string
+		var e error
+		if chunkKeys, e = s.fetch(toTable, query, down, chunk...); e != nil {
+			return nil, e
+		}
+
+		keys = append(keys, chunkKeys...)
+	}
+
+	return keys, nil
+}
+
+// fetch runs the query and adds the resulting rows to the table's set. Keys are returned for
+// rows that are new, or for rows that are now being expanded downward for the first time.
+func (s *Subset) fetch(tableName, query string, down bool, args ...interface{}) ([]string, error) {
+
+	var e error
+	var rows *sql.Rows
+
+	if rows, e = s.db.Query(query, args...); e != nil {
+		return nil, fmt.Errorf("Error selecting from `%s`: %w", tableName, e)
+	}
+
+	defer rows.Close()
+
+	var result []map[string]interface{}
+	if result, e = ScanRows(rows); e != nil {
+		return nil, e
+	}
+
+	var t = s.tableRows(tableName)
+	var keys = []string{}
+
+	for _, row := range result {
+
+		var key = RowKey(t.Table, row)
+
+		if _, ok := t.Rows[key]; !ok {
+			t.Rows[key] = row
+			t.Keys = append(t.Keys, key)
+			t.expanded[key] = down
+			keys = append(keys, key)
+			continue
+		}
+
+		if down && !t.expanded[key] {
+			t.expanded[key] = true
+			keys = append(keys, key)
+		}
+	}
+
+	return keys, nil
+}
+
+func (s *Subset) tableRows(tableName string) *TableRows {
+	if _, ok := s.Tables[tableName]; !ok {
+		s.Tables[tableName] = &TableRows{
+			Table:    s.schema.Tables[tableName],
+			Keys:     []string{},
+			Rows:     map[string]map[string]interface{}{},
+			expanded: map[string]bool{},
+		}
+	}
+	return s.Tables[tableName]
+}
+
+// RowKey returns a string that uniquely identifies a row by its primary key columns (or every column if
+// the table has no primary key)
+func RowKey(table *schema.Table, row map[string]interface{}) string {
+
+	var columns = table.ToSortedColumns()
+	var parts = []string{}
+
+	for _, col := range columns {
+		if col.ColumnKey == "PRI" {
+			parts = append(parts, fmt.Sprint(row[col.Name]))
+		}
+	}
+
+	if len(parts) == 0 {
+		for _, col := range columns {
+			parts = append(parts, fmt.Sprint(row[col.Name]))
+		}
+	}
+
+	return strings.Join(parts, "\x00")
+}
+
+// SortedTableNames returns the names of the extracted tables, parents first
+func (s *Subset) SortedTableNames() []string {
+	var names = make([]string, 0, len(s.Tables))
+	for name := range s.Tables {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return SortTablesByDependency(names, s.relationships)
+}
+
+// RowCount returns the total number of extracted rows
+func (s *Subset) RowCount() int {
+	var n = 0
+	for k := range s.Tables {
+		n += len(s.Tables[k].Keys)
+	}
+	return n
+}
+
+// WriteSQL writes the extracted rows as INSERT statements
+func (s *Subset) WriteSQL(w io.Writer, batchSize int) error {
+
+	var e error
+
+	if _, e = fmt.Fprint(w, "SET FOREIGN_KEY_CHECKS = 0;\n\n"); e != nil {
+		return e
+	}
+
+	for _, tableName := range s.SortedTableNames() {
+
+		var t = s.Tables[tableName]
+		if len(t.Keys) == 0 {
+			continue
+		}
+
+		fmt.Fprintf(w, "--\n-- Table: `%s`\n-- Rows: `%d`\n--\n", tableName, len(t.Keys))
+
+		var rows = make([]map[string]interface{}, len(t.Keys))
+		for k := range t.Keys {
+			rows[k] = t.Rows[t.Keys[k]]
+		}
+
+		for len(rows) > 0 {
+
+			var batch = rows
+			if len(batch) > batchSize {
+				batch = rows[0:batchSize]
+			}
+			rows = rows[len(batch):]
+
+			if _, e = fmt.Fprint(w, InsertSQL(t.Table, batch)+";\n"); e != nil {
+				return e
+			}
+		}
+
+		fmt.Fprint(w, "\n")
+	}
+
+	_, e = fmt.Fprint(w, "SET FOREIGN_KEY_CHECKS = 1;\n")
+
+	return e
+}
+
+// Load inserts the extracted rows into `db` in a single transaction
+func (s *Subset) Load(db *sql.DB, batchSize int) error {
+
+	var e error
+	var conn *sql.Conn
+	var ctx = context.Background()
+
+	// FOREIGN_KEY_CHECKS is a session variable, so everything has to run on the same connection
+	if conn, e = db.Conn(ctx); e != nil {
+		return e
+	}
+	defer conn.Close()
+
+	if _, e = conn.ExecContext(ctx, "SET FOREIGN_KEY_CHECKS = 0"); e != nil {
+		return e
+	}
+	defer conn.ExecContext(ctx, "SET FOREIGN_KEY_CHECKS = 1")
+
+	var tx *sql.Tx
+	if tx, e = conn.BeginTx(ctx, nil); e != nil {
+		return e
+	}
+
+	for _, tableName := range s.SortedTableNames() {
+
+		var t = s.Tables[tableName]
+
+		var rows = make([]map[string]interface{}, len(t.Keys))
+		for k := range t.Keys {
+			rows[k] = t.Rows[t.Keys[k]]
+		}
+
+		for len(rows) > 0 {
+
+			var batch = rows
+			if len(batch) > batchSize {
+				batch = rows[0:batchSize]
+			}
+			rows = rows[len(batch):]
+
+			var query, args = InsertSQLWithArgs(t.Table, batch)
+			if _, e = tx.Exec(query, args...); e != nil {
+				tx.Rollback()
+				return fmt.Errorf("Error inserting into `%s`: %w", tableName, e)
+			}
+		}
+	}
+
+	return tx.Commit()
+}
@@ -0,0 +1,111 @@
+package transfer
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+
+	"github.com/macinnir/dvc/core/lib"
+)
+
+// TableCheckpoint records the progress of a single table
+type TableCheckpoint struct {
+	LastKey []interface{} `json:"lastKey"`
+	Rows    int64         `json:"rows"`
+	Done    bool          `json:"done"`
+}
+
+// Checkpoint records the progress of a transfer so that an interrupted run can be resumed
+type Checkpoint struct {
+	From   string                      `json:"from"`
+	To     string                      `json:"to"`
+	Tables map[string]*TableCheckpoint `json:"tables"`
+	path   string
+}
+
+// LoadCheckpoint loads the checkpoint file at `filePath`, or returns an empty checkpoint if it does not exist
+func LoadCheckpoint(filePath, from, to string) (*Checkpoint, error) {
+
+	var e error
+	var c = &Checkpoint{
+		From:   from,
+		To:     to,
+		Tables: map[string]*TableCheckpoint{},
+		path:   filePath,
+	}
+
+	if !lib.FileExists(filePath) {
+		return c, nil
+	}
+
+	var fileBytes []byte
+	if fileBytes, e = ioutil.ReadFile(filePath); e != nil {
+		return nil, e
+	}
+
+	// Keep numeric keys as json.Number so that large integer keys survive the round trip
+	var decoder = json.NewDecoder(bytes.NewReader(fileBytes))
+	decoder.UseNumber()
+
+	if e = decoder.Decode(c); e != nil {
+		return nil, e
+	}
+
+	if c.From != from || c.To != to {
+		return nil, ErrCheckpointMismatch
+	}
+
+	if c.Tables == nil {
+		c.Tables = map[string]*TableCheckpoint{}
+	}
+
+	for k := range c.Tables {
+		for l := range c.Tables[k].LastKey {
+			if n, ok := c.Tables[k].LastKey[l].(json.Number); ok {
+				if i, e := n.Int64(); e == nil {
+					c.Tables[k].LastKey[l] = i
+				} else {
+					c.Tables[k].LastKey[l] = n.String()
+				}
+			}
+		}
+	}
+
+	return c, nil
+}
+
+// Table returns the checkpoint for a table, creating it if it does not exist
+func (c *Checkpoint) Table(tableName string) *TableCheckpoint {
+	if _, ok := c.Tables[tableName]; !ok {
+		c.Tables[tableName] = &TableCheckpoint{}
+	}
+	return c.Tables[tableName]
+}
+
+// Save writes the checkpoint to disk
+func (c *Checkpoint) Save() error {
+
+	var e error
+	var fileBytes []byte
+
+	if fileBytes, e = json.MarshalIndent(c, "", "  "); e != nil {
+		return e
+	}
+
+	// Write to a temp file first so that a crash can't leave a half-written checkpoint
+	var tmpPath = c.path + ".tmp"
+	if e = ioutil.WriteFile(tmpPath, fileBytes, 0644); e != nil {
+		return e
+	}
+
+	return os.Rename(tmpPath, c.path)
+}
+
+// Remove deletes the checkpoint file
+func (c *Checkpoint) Remove() error {
+	if !lib.FileExists(c.path) {
+		return nil
+	}
+	return os.Remove(c.path)
+}
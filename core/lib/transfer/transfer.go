@@ -0,0 +1,219 @@
+package transfer
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/macinnir/dvc/core/lib/schema"
+	"github.com/macinnir/dvc/core/lib/subset"
+	"go.uber.org/zap"
+)
+
+var (
+	ErrNoPrimaryKey       = errors.New("Table has no primary key")
+	ErrCheckpointMismatch = errors.New("Checkpoint file belongs to a different transfer")
+)
+
+// Options configures a transfer
+type Options struct {
+	// BatchSize is the number of rows read and written at a time
+	BatchSize int
+	// RowsPerSecond limits the write rate. Zero is unlimited.
+	RowsPerSecond int
+}
+
+// Transfer copies table data from one connection to another in primary key order
+type Transfer struct {
+	source     *sql.DB
+	dest       *sql.Conn
+	checkpoint *Checkpoint
+	options    Options
+	log        *zap.Logger
+}
+
+// NewTransfer returns a new Transfer. The destination is a single connection so that
+// session settings (e.g. FOREIGN_KEY_CHECKS) apply to every batch.
+func NewTransfer(source *sql.DB, dest *sql.Conn, checkpoint *Checkpoint, options Options, log *zap.Logger) *Transfer {
+
+	if options.BatchSize <= 0 {
+		options.BatchSize = 1000
+	}
+
+	return &Transfer{
+		source:     source,
+		dest:       dest,
+		checkpoint: checkpoint,
+		options:    options,
+		log:        log,
+	}
+}
+
+// PrimaryKeyColumns returns the primary key columns of a table in name order
+func PrimaryKeyColumns(table *schema.Table) []*schema.Column {
+	var columns = []*schema.Column{}
+	for _, column := range table.ToSortedColumns() {
+		if column.ColumnKey == "PRI" {
+			columns = append(columns, column)
+		}
+	}
+	return columns
+}
+
+// BatchSQL builds the query that selects the next batch of rows after `lastKey`
+func BatchSQL(table *schema.Table, lastKey []interface{}, limit int) (string, error) {
+
+	var pk = PrimaryKeyColumns(table)
+	if len(pk) == 0 {
+		return "", fmt.Errorf("%s: %w", table.Name, ErrNoPrimaryKey)
+	}
+
+	var names = make([]string, len(pk))
+	for k := range pk {
+		names[k] = "`" + pk[k].Name + "`"
+	}
+
+	var query = fmt.Sprintf("SELECT * FROM `%s`", table.Name)
+
+	if len(lastKey) > 0 {
+		if len(pk) == 1 {
+			query += fmt.Sprintf(" WHERE %s > ?", names[0])
+		} else {
+			query += fmt.Sprintf(
+				" WHERE (%s) > (%s)",
+				strings.Join(names, ", "),
+				strings.TrimSuffix(strings.Repeat("?,", len(pk)), ","),
+			)
+		}
+	}
+
+	query += fmt.Sprintf(" ORDER BY %s LIMIT %d", strings.Join(names, ", "), limit)
+
+	return query, nil
+}
+
+// UpsertSQL builds a multi-row insert that overwrites existing rows, so that a batch can be safely
+// replayed when a run is resumed
+func UpsertSQL(table *schema.Table, rows []map[string]interface{}) (string, []interface{}) {
+
+	var query, args = subset.InsertSQLWithArgs(table, rows)
+	var updates = []string{}
+
+	for _, column := range table.ToSortedColumns() {
+		updates = append(updates, fmt.Sprintf("`%s` = VALUES(`%s`)", column.Name, column.Name))
+	}
+
+	return query + "\nON DUPLICATE KEY UPDATE " + strings.Join(updates, ", "), args
+}
+
+// keyValue normalizes a primary key value so that integer keys are compared as integers
+func keyValue(column *schema.Column, value interface{}) interface{} {
+
+	var str, ok = value.(string)
+	if !ok || schema.IsString(column) {
+		return value
+	}
+
+	if column.IsUnsigned {
+		if n, e := strconv.ParseUint(str, 10, 64); e == nil {
+			return n
+		}
+	}
+
+	if n, e := strconv.ParseInt(str, 10, 64); e == nil {
+		return n
+	}
+
+	return value
+}
+
+// Table copies the rows of a single table, resuming from its checkpoint
+func (t *Transfer) Table(ctx context.Context, table *schema.Table) error {
+
+	var e error
+	var cp = t.checkpoint.Table(table.Name)
+	var pk = PrimaryKeyColumns(table)
+
+	if cp.Done {
+		t.log.Info("Skipping completed table", zap.String("table", table.Name), zap.Int64("rows", cp.Rows))
+		return nil
+	}
+
+	if len(pk) == 0 {
+		return fmt.Errorf("%s: %w", table.Name, ErrNoPrimaryKey)
+	}
+
+	if cp.Rows > 0 {
+		t.log.Info("Resuming table", zap.String("table", table.Name), zap.Int64("rows", cp.Rows), zap.Any("after", cp.LastKey))
+	}
+
+	for {
+
+		var batchStart = time.Now()
+
+		var query string
+		if query, e = BatchSQL(table, cp.LastKey, t.options.BatchSize); e != nil {
+			return e
+		}
+
+		var rows *sql.Rows
+		if rows, e = t.source.QueryContext(ctx, query, cp.LastKey...); e != nil {
+			return fmt.Errorf("Error reading from `%s`: %w", table.Name, e)
+		}
+
+		var batch []map[string]interface{}
+		batch, e = subset.ScanRows(rows)
+		rows.Close()
+
+		if e != nil {
+			return fmt.Errorf("Error reading from `%s`: %w", table.Name, e)
+		}
+
+		if len(batch) == 0 {
+			cp.Done = true
+			return t.checkpoint.Save()
+		}
+
+		var insertSQL, args = UpsertSQL(table, batch)
+		if _, e = t.dest.ExecContext(ctx, insertSQL, args...); e != nil {
+			return fmt.Errorf("Error writing to `%s`: %w", table.Name, e)
+		}
+
+		var last = batch[len(batch)-1]
+		cp.LastKey = make([]interface{}, len(pk))
+		for k := range pk {
+			cp.LastKey[k] = keyValue(pk[k], last[pk[k].Name])
+		}
+		cp.Rows += int64(len(batch))
+
+		if e = t.checkpoint.Save(); e != nil {
+			return e
+		}
+
+		t.log.Info("Transferred batch", zap.String("table", table.Name), zap.Int("batch", len(batch)), zap.Int64("rows", cp.Rows))
+
+		if len(batch) < t.options.BatchSize {
+			cp.Done = true
+			return t.checkpoint.Save()
+		}
+
+		t.throttle(len(batch), time.Since(batchStart))
+	}
+}
+
+// throttle sleeps long enough to keep the write rate under RowsPerSecond
+func (t *Transfer) throttle(rows int, elapsed time.Duration) {
+
+	if t.options.RowsPerSecond <= 0 {
+		return
+	}
+
+	var target = time.Duration(float64(rows) / float64(t.options.RowsPerSecond) * float64(time.Second))
+	if target > elapsed {
+		time.Sleep(target - elapsed)
+	}
+}
@@ -0,0 +1,97 @@
+package transfer
+
+import (
+	"errors"
+	"path"
+	"testing"
+
+	"github.com/macinnir/dvc/core/lib/schema"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testTable() *schema.Table {
+	return &schema.Table{
+		Name: "User",
+		Columns: map[string]*schema.Column{
+			"UserID": {Name: "UserID", DataType: "bigint", ColumnKey: "PRI", IsUnsigned: true},
+			"Name":   {Name: "Name", DataType: "varchar"},
+		},
+	}
+}
+
+func TestBatchSQL(t *testing.T) {
+
+	query, e := BatchSQL(testTable(), nil, 100)
+	require.Nil(t, e)
+	assert.Equal(t, "SELECT * FROM `User` ORDER BY `UserID` LIMIT 100", query)
+
+	query, e = BatchSQL(testTable(), []interface{}{int64(5)}, 100)
+	require.Nil(t, e)
+	assert.Equal(t, "SELECT * FROM `User` WHERE `UserID` > ? ORDER BY `UserID` LIMIT 100", query)
+}
+
+func TestBatchSQLCompositeKey(t *testing.T) {
+
+	var table = &schema.Table{
+		Name: "UserRole",
+		Columns: map[string]*schema.Column{
+			"UserID": {Name: "UserID", DataType: "bigint", ColumnKey: "PRI"},
+			"RoleID": {Name: "RoleID", DataType: "bigint", ColumnKey: "PRI"},
+		},
+	}
+
+	query, e := BatchSQL(table, []interface{}{int64(1), int64(2)}, 10)
+	require.Nil(t, e)
+	assert.Equal(t, "SELECT * FROM `UserRole` WHERE (`RoleID`, `UserID`) > (?,?) ORDER BY `RoleID`, `UserID` LIMIT 10", query)
+}
+
+func TestBatchSQLNoPrimaryKey(t *testing.T) {
+	_, e := BatchSQL(&schema.Table{Name: "Log", Columns: map[string]*schema.Column{"Msg": {Name: "Msg", DataType: "text"}}}, nil, 10)
+	assert.True(t, errors.Is(e, ErrNoPrimaryKey))
+}
+
+func TestUpsertSQL(t *testing.T) {
+	query, args := UpsertSQL(testTable(), []map[string]interface{}{{"UserID": 1, "Name": "a"}})
+	assert.Equal(t, "INSERT INTO `User` (`Name`,`UserID`) VALUES\n(?,?)\nON DUPLICATE KEY UPDATE `Name` = VALUES(`Name`), `UserID` = VALUES(`UserID`)", query)
+	assert.Equal(t, []interface{}{"a", 1}, args)
+}
+
+func TestChecksumSQL(t *testing.T) {
+	assert.Equal(t,
+		"SELECT COUNT(*), COALESCE(BIT_XOR(CRC32(CONCAT_WS('#', `Name`, ISNULL(`Name`), `UserID`, ISNULL(`UserID`)))), 0) FROM `User`",
+		ChecksumSQL(testTable()),
+	)
+}
+
+func TestKeyValue(t *testing.T) {
+	var table = testTable()
+	assert.Equal(t, uint64(18446744073709551615), keyValue(table.Columns["UserID"], "18446744073709551615"))
+	assert.Equal(t, "abc", keyValue(table.Columns["Name"], "abc"))
+	assert.Equal(t, int64(3), keyValue(table.Columns["UserID"], int64(3)))
+}
+
+func TestCheckpointRoundTrip(t *testing.T) {
+
+	var filePath = path.Join(t.TempDir(), "checkpoint.json")
+
+	c, e := LoadCheckpoint(filePath, "a", "b")
+	require.Nil(t, e)
+
+	c.Table("User").LastKey = []interface{}{int64(9007199254740993), "x"}
+	c.Table("User").Rows = 10
+	require.Nil(t, c.Save())
+
+	c, e = LoadCheckpoint(filePath, "a", "b")
+	require.Nil(t, e)
+	assert.Equal(t, []interface{}{int64(9007199254740993), "x"}, c.Table("User").LastKey)
+	assert.Equal(t, int64(10), c.Table("User").Rows)
+
+	_, e = LoadCheckpoint(filePath, "a", "c")
+	assert.True(t, errors.Is(e, ErrCheckpointMismatch))
+
+	require.Nil(t, c.Remove())
+	c, e = LoadCheckpoint(filePath, "a", "b")
+	require.Nil(t, e)
+	assert.Len(t, c.Tables, 0)
+}
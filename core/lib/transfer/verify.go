@@ -0,0 +1,65 @@
+package transfer
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/macinnir/dvc/core/lib/schema"
+)
+
+// Verification is the result of comparing a table on both sides of a transfer
+type Verification struct {
+	Table          string
+	SourceRows     int64
+	DestRows       int64
+	SourceChecksum string
+	DestChecksum   string
+}
+
+// OK returns true if the row counts and checksums match
+func (v *Verification) OK() bool {
+	return v.SourceRows == v.DestRows && v.SourceChecksum == v.DestChecksum
+}
+
+func (v *Verification) String() string {
+	var status = "OK"
+	if !v.OK() {
+		status = "MISMATCH"
+	}
+	return fmt.Sprintf("%s: %s (rows %d => %d, checksum %s => %s)", v.Table, status, v.SourceRows, v.DestRows, v.SourceChecksum, v.DestChecksum)
+}
+
+// ChecksumSQL builds a query that returns the row count and an order-independent checksum of a table.
+// NULLs are marked explicitly because CONCAT_WS skips them.
+func ChecksumSQL(table *schema.Table) string {
+
+	var parts = []string{}
+	for _, column := range table.ToSortedColumns() {
+		parts = append(parts, fmt.Sprintf("`%s`", column.Name), fmt.Sprintf("ISNULL(`%s`)", column.Name))
+	}
+
+	return fmt.Sprintf(
+		"SELECT COUNT(*), COALESCE(BIT_XOR(CRC32(CONCAT_WS('#', %s))), 0) FROM `%s`",
+		strings.Join(parts, ", "),
+		table.Name,
+	)
+}
+
+// Verify compares the row count and checksum of a table on the source and destination
+func (t *Transfer) Verify(ctx context.Context, table *schema.Table) (*Verification, error) {
+
+	var e error
+	var v = &Verification{Table: table.Name}
+	var query = ChecksumSQL(table)
+
+	if e = t.source.QueryRowContext(ctx, query).Scan(&v.SourceRows, &v.SourceChecksum); e != nil {
+		return nil, fmt.Errorf("Error verifying source `%s`: %w", table.Name, e)
+	}
+
+	if e = t.dest.QueryRowContext(ctx, query).Scan(&v.DestRows, &v.DestChecksum); e != nil {
+		return nil, fmt.Errorf("Error verifying destination `%s`: %w", table.Name, e)
+	}
+
+	return v, nil
+}
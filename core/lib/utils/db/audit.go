@@ -0,0 +1,224 @@
+package db
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	query "github.com/macinnir/goquery"
+)
+
+// AuditTableSQL creates the table in the core_log schema that AuditLog writes change records to
+const AuditTableSQL = "CREATE TABLE IF NOT EXISTS `core_log`.`Audit` (" +
+	"`AuditID` BIGINT UNSIGNED NOT NULL AUTO_INCREMENT, " +
+	"`TableName` VARCHAR(64) NOT NULL, " +
+	"`RecordID` VARCHAR(64) NOT NULL, " +
+	"`Action` VARCHAR(16) NOT NULL, " +
+	"`ActorID` BIGINT NOT NULL DEFAULT 0, " +
+	"`Changes` MEDIUMTEXT NOT NULL, " +
+	"`DateCreated` BIGINT NOT NULL, " +
+	"PRIMARY KEY (`AuditID`), " +
+	"KEY `TableName_RecordID` (`TableName`, `RecordID`)" +
+	")"
+
+const (
+	auditInsertSQL  = "INSERT INTO `core_log`.`Audit` (`TableName`, `RecordID`, `Action`, `ActorID`, `Changes`, `DateCreated`) VALUES (?, ?, ?, ?, ?, ?)"
+	auditHistorySQL = "SELECT `AuditID`, `TableName`, `RecordID`, `Action`, `ActorID`, `Changes`, `DateCreated` FROM `core_log`.`Audit` WHERE `TableName` = ? AND `RecordID` = ? ORDER BY `AuditID`"
+)
+
+// The actions of change records. Upserts record the values written, without the values they replaced.
+const (
+	AuditCreate = "create"
+	AuditUpdate = "update"
+	AuditDelete = "delete"
+	AuditUpsert = "upsert"
+)
+
+// Change is the value of a column before and after a write. Before is nil for creates and After for deletes.
+type Change struct {
+	Before interface{} `json:"before,omitempty"`
+	After  interface{} `json:"after,omitempty"`
+}
+
+// Diff is the changes a write made, by column
+type Diff map[string]*Change
+
+// Only returns the changes of `cols`
+func (d Diff) Only(cols ...query.Column) Diff {
+	var only = Diff{}
+	for _, col := range cols {
+		if change, ok := d[string(col)]; ok {
+			only[string(col)] = change
+		}
+	}
+	return only
+}
+
+// Without returns the changes of the columns other than `cols`
+func (d Diff) Without(cols ...query.Column) Diff {
+	var without = make(Diff, len(d))
+	for col, change := range d {
+		without[col] = change
+	}
+	for _, col := range cols {
+		delete(without, string(col))
+	}
+	return without
+}
+
+// columnValuer is implemented by generated models
+type columnValuer interface {
+	Table_Columns() []query.Column
+	Table_Column_Value(col query.Column) interface{}
+}
+
+// CreatedDiff returns the Diff of creating `model`: the value of each of its columns
+func CreatedDiff(model columnValuer) Diff {
+	var diff = Diff{}
+	for _, col := range model.Table_Columns() {
+		diff[string(col)] = &Change{After: model.Table_Column_Value(col)}
+	}
+	return diff
+}
+
+// DeletedDiff returns the Diff of deleting `model`: the value of each of its columns
+func DeletedDiff(model columnValuer) Diff {
+	var diff = Diff{}
+	for _, col := range model.Table_Columns() {
+		diff[string(col)] = &Change{Before: model.Table_Column_Value(col)}
+	}
+	return diff
+}
+
+// AuditRecord is a change record of a write to an audited table
+type AuditRecord struct {
+	AuditID     int64  `db:"AuditID" json:"AuditID"`
+	TableName   string `db:"TableName" json:"TableName"`
+	RecordID    string `db:"RecordID" json:"RecordID"`
+	Action      string `db:"Action" json:"Action"`
+	ActorID     int64  `db:"ActorID" json:"ActorID"`
+	Changes     Diff   `db:"Changes" json:"Changes"`
+	DateCreated int64  `db:"DateCreated" json:"DateCreated"`
+}
+
+// NewAuditRecord returns the change record of `action` on the record of `table` with primary key `recordID`, by
+// the actor set on `ctx` (see WithActor)
+func NewAuditRecord(ctx context.Context, table string, recordID interface{}, action string, changes Diff) *AuditRecord {
+	actorID, _ := ActorFromContext(ctx)
+	return &AuditRecord{
+		TableName:   table,
+		RecordID:    fmt.Sprint(recordID),
+		Action:      action,
+		ActorID:     actorID,
+		Changes:     changes,
+		DateCreated: time.Now().UnixNano() / 1000000,
+	}
+}
+
+type actorContextKey struct{}
+
+// WithActor returns a context whose writes to audited tables are recorded as made by `actorID` (e.g. a user ID)
+func WithActor(ctx context.Context, actorID int64) context.Context {
+	return context.WithValue(ctx, actorContextKey{}, actorID)
+}
+
+// ActorFromContext returns the actor set with WithActor
+func ActorFromContext(ctx context.Context) (int64, bool) {
+	actorID, ok := ctx.Value(actorContextKey{}).(int64)
+	return actorID, ok
+}
+
+// Auditor records the writes of the DALs of audited tables and returns the history of their records
+type Auditor interface {
+	Audit(ctx context.Context, records ...*AuditRecord) error
+	History(ctx context.Context, table string, recordID interface{}) ([]*AuditRecord, error)
+}
+
+// ErrNoAuditConnection is returned by an AuditLog without a connection to the core_log schema
+var ErrNoAuditConnection = errors.New("no core_log connection to audit to")
+
+// AuditLog is an Auditor on the `Audit` table of the core_log schema (see AuditTableSQL)
+type AuditLog struct {
+	conn query.DBInterface
+}
+
+// NewAuditLog returns an AuditLog on the (first) connection to the core_log schema
+func NewAuditLog(conns []query.DBInterface) *AuditLog {
+	var a = &AuditLog{}
+	if len(conns) > 0 {
+		a.conn = conns[0]
+	}
+	return a
+}
+
+// Audit writes change records
+func (a *AuditLog) Audit(ctx context.Context, records ...*AuditRecord) error {
+
+	if len(records) == 0 {
+		return nil
+	}
+
+	if a.conn == nil {
+		return ErrNoAuditConnection
+	}
+
+	var rows = make([][]interface{}, len(records))
+	for k, record := range records {
+		changes, e := json.Marshal(record.Changes)
+		if e != nil {
+			return fmt.Errorf("AuditLog.Audit(%s %s): %w", record.TableName, record.RecordID, e)
+		}
+		rows[k] = []interface{}{record.TableName, record.RecordID, record.Action, record.ActorID, string(changes), record.DateCreated}
+	}
+
+	ids, e := InsertMany(ctx, a.conn, auditInsertSQL, rows)
+	if e != nil {
+		return fmt.Errorf("AuditLog.Audit: %w", e)
+	}
+
+	for k := range ids {
+		records[k].AuditID = ids[k]
+	}
+
+	return nil
+}
+
+// History returns the change records of the record of `table` with primary key `recordID`, oldest first
+func (a *AuditLog) History(ctx context.Context, table string, recordID interface{}) ([]*AuditRecord, error) {
+
+	if a.conn == nil {
+		return nil, ErrNoAuditConnection
+	}
+
+	rows, e := QueryContext(ctx, a.conn, auditHistorySQL, table, fmt.Sprint(recordID))
+	if e != nil {
+		return nil, fmt.Errorf("AuditLog.History(%s %v): %w", table, recordID, e)
+	}
+	defer rows.Close()
+
+	var records = []*AuditRecord{}
+
+	for rows.Next() {
+
+		var record = &AuditRecord{}
+		var changes []byte
+
+		if e = rows.Scan(&record.AuditID, &record.TableName, &record.RecordID, &record.Action, &record.ActorID, &changes, &record.DateCreated); e != nil {
+			return nil, fmt.Errorf("AuditLog.History(%s %v): %w", table, recordID, e)
+		}
+
+		if e = json.Unmarshal(changes, &record.Changes); e != nil {
+			return nil, fmt.Errorf("AuditLog.History(%s %v): %w", table, recordID, e)
+		}
+
+		records = append(records, record)
+	}
+
+	if e = rows.Err(); e != nil {
+		return nil, fmt.Errorf("AuditLog.History(%s %v): %w", table, recordID, e)
+	}
+
+	return records, nil
+}
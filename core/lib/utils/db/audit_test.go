@@ -0,0 +1,82 @@
+package db
+
+import (
+	"context"
+	"testing"
+
+	query "github.com/macinnir/goquery"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// auditedModel is a model with the column methods of generated models
+type auditedModel struct {
+	ID   int64
+	Name string
+}
+
+func (m *auditedModel) Table_Columns() []query.Column { return []query.Column{"ID", "Name"} }
+func (m *auditedModel) Table_Column_Value(col query.Column) interface{} {
+	if col == "ID" {
+		return m.ID
+	}
+	return m.Name
+}
+
+func TestDiff(t *testing.T) {
+
+	model := &auditedModel{ID: 1, Name: "foo"}
+
+	created := CreatedDiff(model)
+	assert.Equal(t, Diff{"ID": {After: int64(1)}, "Name": {After: "foo"}}, created)
+	assert.Equal(t, Diff{"ID": {Before: int64(1)}, "Name": {Before: "foo"}}, DeletedDiff(model))
+
+	assert.Equal(t, Diff{"Name": {After: "foo"}}, created.Only("Name", "Missing"))
+	assert.Equal(t, Diff{"ID": {After: int64(1)}}, created.Without("Name"))
+	assert.Len(t, created, 2)
+}
+
+func TestNewAuditRecord(t *testing.T) {
+
+	ctx := WithActor(context.Background(), 7)
+
+	actorID, ok := ActorFromContext(ctx)
+	assert.True(t, ok)
+	assert.Equal(t, int64(7), actorID)
+
+	record := NewAuditRecord(ctx, "Foo", int64(3), AuditUpdate, Diff{})
+	assert.Equal(t, "Foo", record.TableName)
+	assert.Equal(t, "3", record.RecordID)
+	assert.Equal(t, AuditUpdate, record.Action)
+	assert.Equal(t, int64(7), record.ActorID)
+	assert.NotZero(t, record.DateCreated)
+}
+
+func TestAuditLog_Audit(t *testing.T) {
+
+	conn, log := newRecordingDB(t)
+	ctx := context.Background()
+
+	records := []*AuditRecord{
+		NewAuditRecord(ctx, "Foo", 1, AuditCreate, Diff{"Name": {After: "a"}}),
+		NewAuditRecord(ctx, "Foo", 2, AuditCreate, Diff{"Name": {After: "b"}}),
+	}
+
+	require.Nil(t, NewAuditLog([]query.DBInterface{conn}).Audit(ctx, records...))
+	assert.Equal(t, []string{
+		"BEGIN",
+		"INSERT INTO `core_log`.`Audit` (`TableName`, `RecordID`, `Action`, `ActorID`, `Changes`, `DateCreated`) VALUES (?, ?, ?, ?, ?, ?), (?, ?, ?, ?, ?, ?)",
+		"COMMIT",
+	}, *log)
+	assert.NotZero(t, records[0].AuditID)
+	assert.Equal(t, records[0].AuditID+1, records[1].AuditID)
+}
+
+func TestAuditLog_NoConnection(t *testing.T) {
+
+	auditLog := NewAuditLog(nil)
+
+	assert.Equal(t, ErrNoAuditConnection, auditLog.Audit(context.Background(), &AuditRecord{}))
+	_, e := auditLog.History(context.Background(), "Foo", 1)
+	assert.Equal(t, ErrNoAuditConnection, e)
+}
@@ -0,0 +1,54 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+
+	query "github.com/macinnir/goquery"
+)
+
+// ContextDB is implemented by connections that can pass a context through to the driver
+// (e.g. *sql.DB, *sql.Tx and *MySQL)
+type ContextDB interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
+
+// ExecContext executes a query on `db` with `ctx` if the connection supports contexts
+func ExecContext(ctx context.Context, db query.DBInterface, q string, args ...interface{}) (sql.Result, error) {
+
+	if cdb, ok := db.(ContextDB); ok {
+		return cdb.ExecContext(ctx, q, args...)
+	}
+
+	if e := ctx.Err(); e != nil {
+		return nil, e
+	}
+
+	return db.Exec(q, args...)
+}
+
+// QueryContext runs a query on `db` with `ctx` if the connection supports contexts
+func QueryContext(ctx context.Context, db query.DBInterface, q string, args ...interface{}) (*sql.Rows, error) {
+
+	if cdb, ok := db.(ContextDB); ok {
+		return cdb.QueryContext(ctx, q, args...)
+	}
+
+	if e := ctx.Err(); e != nil {
+		return nil, e
+	}
+
+	return db.Query(q, args...)
+}
+
+// QueryRowContext runs a query expected to return at most one row on `db` with `ctx` if the connection supports contexts
+func QueryRowContext(ctx context.Context, db query.DBInterface, q string, args ...interface{}) *sql.Row {
+
+	if cdb, ok := db.(ContextDB); ok {
+		return cdb.QueryRowContext(ctx, q, args...)
+	}
+
+	return db.QueryRow(q, args...)
+}
@@ -0,0 +1,198 @@
+package db
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"strings"
+
+	"github.com/macinnir/dvc/core/lib/utils/errors"
+	query "github.com/macinnir/goquery"
+)
+
+// Page is a page of rows selected with keyset pagination. Next and Prev are the cursors of the pages after and
+// before it, and are empty when there is nothing more to select in that direction.
+type Page[T any] struct {
+	Items []T    `json:"Items"`
+	Next  string `json:"Next"`
+	Prev  string `json:"Prev"`
+}
+
+// keyset is the page a statement selects (see SelectStatement.Page)
+type keyset struct {
+	cols      []orderColumn
+	limit     int64
+	before    bool
+	hasCursor bool
+}
+
+// EncodeCursor returns an opaque cursor holding the values of the keyset columns of a row
+func EncodeCursor(values []interface{}) (string, error) {
+	b, e := json.Marshal(values)
+	if e != nil {
+		return "", e
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// DecodeCursor returns the values held by a cursor. An invalid cursor is an errors.ArgumentError.
+func DecodeCursor(cursor string) ([]interface{}, error) {
+
+	var values []interface{}
+
+	b, e := base64.RawURLEncoding.DecodeString(cursor)
+	if e != nil {
+		return nil, errors.NewArgumentError("invalid cursor")
+	}
+
+	var decoder = json.NewDecoder(bytes.NewReader(b))
+	decoder.UseNumber()
+	if e = decoder.Decode(&values); e != nil {
+		return nil, errors.NewArgumentError("invalid cursor")
+	}
+
+	// Keep integers (e.g. IDs) exact
+	for k := range values {
+		if n, ok := values[k].(json.Number); ok {
+			if i, e := n.Int64(); e == nil {
+				values[k] = i
+			} else if f, e := n.Float64(); e == nil {
+				values[k] = f
+			}
+		}
+	}
+
+	return values, nil
+}
+
+// Page sets the statement up to select `limit` rows after (or before) `cursor` in the order of its ORDER BY
+// columns, which must not be nullable. `pk` is added to them to break ties. An empty cursor selects the first
+// (or last) page. One more row than the limit is selected to tell whether there is a next page, so the rows have
+// to be cut with NewPage.
+func (s *SelectStatement) Page(pk query.Column, cursor string, before bool, limit int64) error {
+
+	var cols = append([]orderColumn{}, s.orderBy...)
+
+	var hasPK = false
+	for k := range cols {
+		if cols[k].col == pk {
+			hasPK = true
+		}
+	}
+
+	if !hasPK {
+		var desc = len(cols) > 0 && cols[len(cols)-1].desc
+		cols = append(cols, orderColumn{pk, desc})
+	}
+
+	s.page = &keyset{cols: cols, limit: limit, before: before, hasCursor: len(cursor) > 0}
+
+	// Rows before the cursor are selected in reverse and put back in order by NewPage
+	s.orderBy = make([]orderColumn, len(cols))
+	for k := range cols {
+		s.orderBy[k] = orderColumn{cols[k].col, cols[k].desc != before}
+	}
+
+	s.Limit(limit+1, 0)
+
+	if len(cursor) == 0 {
+		return nil
+	}
+
+	values, e := DecodeCursor(cursor)
+	if e != nil {
+		return e
+	}
+
+	if len(values) != len(cols) {
+		return errors.NewArgumentError("invalid cursor")
+	}
+
+	s.Where(keysetCondition(s.orderBy, values))
+	return nil
+}
+
+// keysetCondition matches the rows that come after `values` when sorted by `cols`, e.g.
+// (`A` > ?) OR (`A` = ? AND `ID` > ?)
+func keysetCondition(cols []orderColumn, values []interface{}) Condition {
+
+	var ors = make([]string, len(cols))
+	var args = []interface{}{}
+
+	for k := range cols {
+
+		var ands = make([]string, 0, k+1)
+		for l := 0; l < k; l++ {
+			ands = append(ands, quote(cols[l].col)+" = ?")
+			args = append(args, values[l])
+		}
+
+		var operator = " > ?"
+		if cols[k].desc {
+			operator = " < ?"
+		}
+
+		ands = append(ands, quote(cols[k].col)+operator)
+		args = append(args, values[k])
+
+		ors[k] = "(" + strings.Join(ands, " AND ") + ")"
+	}
+
+	return Condition{SQL: strings.Join(ors, " OR "), Args: args}
+}
+
+// NewPage cuts the rows selected by a statement set up with Page into a page. `value` returns the value of a
+// column of a row.
+func NewPage[T any](s *SelectStatement, rows []T, value func(row T, col query.Column) interface{}) (*Page[T], error) {
+
+	var page = &Page[T]{Items: rows}
+
+	if s.page == nil {
+		return page, nil
+	}
+
+	var hasMore = int64(len(rows)) > s.page.limit
+	if hasMore {
+		page.Items = rows[:s.page.limit]
+	}
+
+	if s.page.before {
+		for i, j := 0, len(page.Items)-1; i < j; i, j = i+1, j-1 {
+			page.Items[i], page.Items[j] = page.Items[j], page.Items[i]
+		}
+	}
+
+	if len(page.Items) == 0 {
+		return page, nil
+	}
+
+	var cursor = func(row T) (string, error) {
+		var values = make([]interface{}, len(s.page.cols))
+		for k := range s.page.cols {
+			values[k] = value(row, s.page.cols[k].col)
+		}
+		return EncodeCursor(values)
+	}
+
+	var e error
+
+	// Going forward there is a previous page if we came from one, and a next page if there were more rows
+	var hasNext, hasPrev = hasMore, s.page.hasCursor
+	if s.page.before {
+		hasNext, hasPrev = s.page.hasCursor, hasMore
+	}
+
+	if hasNext {
+		if page.Next, e = cursor(page.Items[len(page.Items)-1]); e != nil {
+			return nil, e
+		}
+	}
+
+	if hasPrev {
+		if page.Prev, e = cursor(page.Items[0]); e != nil {
+			return nil, e
+		}
+	}
+
+	return page, nil
+}
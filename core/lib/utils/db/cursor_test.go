@@ -0,0 +1,89 @@
+package db
+
+import (
+	"testing"
+
+	"github.com/macinnir/dvc/core/lib/utils/errors"
+	query "github.com/macinnir/goquery"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type cursorRow struct {
+	ID   int64
+	Name string
+}
+
+func cursorRowValue(row *cursorRow, col query.Column) interface{} {
+	if col == "ID" {
+		return row.ID
+	}
+	return row.Name
+}
+
+func TestCursor_RoundTrip(t *testing.T) {
+
+	cursor, e := EncodeCursor([]interface{}{"b", int64(9007199254740993), 1.5})
+	require.Nil(t, e)
+
+	values, e := DecodeCursor(cursor)
+	require.Nil(t, e)
+	assert.Equal(t, []interface{}{"b", int64(9007199254740993), 1.5}, values)
+
+	_, e = DecodeCursor("not a cursor!")
+	assert.IsType(t, errors.ArgumentError{}, e)
+}
+
+func TestSelectStatement_Page(t *testing.T) {
+
+	s := NewSelect("Foo", "`ID`", "`Name`").OrderBy("Name", query.OrderDirFromString("DESC"))
+	require.Nil(t, s.Page("ID", "", false, 2))
+
+	q, args := s.SQL()
+	assert.Equal(t, "SELECT `ID`, `Name` FROM `Foo` ORDER BY `Name` DESC, `ID` DESC LIMIT 0, 3", q)
+	assert.Len(t, args, 0)
+
+	// First page
+	page, e := NewPage(s, []*cursorRow{{3, "c"}, {2, "b"}, {1, "b"}}, cursorRowValue)
+	require.Nil(t, e)
+	assert.Equal(t, []*cursorRow{{3, "c"}, {2, "b"}}, page.Items)
+	assert.Equal(t, "", page.Prev)
+	require.NotEqual(t, "", page.Next)
+
+	// Next page
+	s = NewSelect("Foo", "`ID`", "`Name`").OrderBy("Name", query.OrderDirFromString("DESC"))
+	require.Nil(t, s.Page("ID", page.Next, false, 2))
+
+	q, args = s.SQL()
+	assert.Equal(t, "SELECT `ID`, `Name` FROM `Foo` WHERE (`Name` < ?) OR (`Name` = ? AND `ID` < ?) ORDER BY `Name` DESC, `ID` DESC LIMIT 0, 3", q)
+	assert.Equal(t, []interface{}{"b", "b", int64(2)}, args)
+
+	page, e = NewPage(s, []*cursorRow{{1, "b"}}, cursorRowValue)
+	require.Nil(t, e)
+	assert.Equal(t, []*cursorRow{{1, "b"}}, page.Items)
+	assert.Equal(t, "", page.Next)
+	require.NotEqual(t, "", page.Prev)
+
+	// Back to the first page
+	s = NewSelect("Foo", "`ID`", "`Name`").OrderBy("Name", query.OrderDirFromString("DESC"))
+	require.Nil(t, s.Page("ID", page.Prev, true, 2))
+
+	q, args = s.SQL()
+	assert.Equal(t, "SELECT `ID`, `Name` FROM `Foo` WHERE (`Name` > ?) OR (`Name` = ? AND `ID` > ?) ORDER BY `Name` ASC, `ID` ASC LIMIT 0, 3", q)
+	assert.Equal(t, []interface{}{"b", "b", int64(1)}, args)
+
+	page, e = NewPage(s, []*cursorRow{{2, "b"}, {3, "c"}}, cursorRowValue)
+	require.Nil(t, e)
+	assert.Equal(t, []*cursorRow{{3, "c"}, {2, "b"}}, page.Items)
+	assert.Equal(t, "", page.Prev)
+	assert.NotEqual(t, "", page.Next)
+}
+
+func TestSelectStatement_PageInvalidCursor(t *testing.T) {
+
+	cursor, e := EncodeCursor([]interface{}{1})
+	require.Nil(t, e)
+
+	s := NewSelect("Foo", "`ID`").OrderBy("Name", query.OrderDirFromString("ASC"))
+	assert.IsType(t, errors.ArgumentError{}, s.Page("ID", cursor, false, 10))
+}
@@ -0,0 +1,26 @@
+package db
+
+import query "github.com/macinnir/goquery"
+
+// Deleted is which rows a selector on a table with an `IsDeleted` column returns
+type Deleted int
+
+const (
+	// ExcludeDeleted selects rows that aren't deleted (the default)
+	ExcludeDeleted Deleted = iota
+	// WithDeleted selects rows whether or not they are deleted
+	WithDeleted
+	// OnlyDeleted selects deleted rows
+	OnlyDeleted
+)
+
+// Condition returns the condition on the `IsDeleted` column `col`
+func (d Deleted) Condition(col query.Column) Condition {
+	switch d {
+	case WithDeleted:
+		return Condition{}
+	case OnlyDeleted:
+		return NE(col, 0)
+	}
+	return EQ(col, 0)
+}
@@ -0,0 +1,16 @@
+package db
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDeleted_Condition(t *testing.T) {
+	assert.Equal(t, EQ("IsDeleted", 0), ExcludeDeleted.Condition("IsDeleted"))
+	assert.Equal(t, NE("IsDeleted", 0), OnlyDeleted.Condition("IsDeleted"))
+	assert.Equal(t, Condition{}, WithDeleted.Condition("IsDeleted"))
+
+	q, _ := NewSelect("User", "`UserID`").SQL(Condition{}, WithDeleted.Condition("IsDeleted"))
+	assert.Equal(t, "SELECT `UserID` FROM `User`", q)
+}
@@ -0,0 +1,96 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"iter"
+
+	query "github.com/macinnir/goquery"
+)
+
+// errStopped is returned to the function passed to an each function when the consumer of EachSeq stops early
+var errStopped = errors.New("db: iteration stopped")
+
+// EachSeq returns an iterator over the rows `each` (e.g. a generated selector's Each) calls its function with.
+// An error ends the iteration and is yielded with the zero value. Breaking out of the loop stops `each`.
+func EachSeq[T any](ctx context.Context, each func(ctx context.Context, fn func(T) error) error) iter.Seq2[T, error] {
+	return func(yield func(T, error) bool) {
+
+		var e = each(ctx, func(row T) error {
+			if !yield(row, nil) {
+				return errStopped
+			}
+			return nil
+		})
+
+		if e != nil && !errors.Is(e, errStopped) {
+			var zero T
+			yield(zero, e)
+		}
+	}
+}
+
+// EachChan runs `each` (e.g. a generated selector's Each) in a goroutine that sends the rows it calls its function
+// with to the returned channel, which has `buffer` slots and is closed once `each` returns. The error channel then
+// receives its error, or nil. Cancel `ctx` to stop early without reading the rest of the rows.
+func EachChan[T any](ctx context.Context, buffer int, each func(ctx context.Context, fn func(T) error) error) (<-chan T, <-chan error) {
+
+	var rows = make(chan T, buffer)
+	var errs = make(chan error, 1)
+
+	go func() {
+
+		defer close(errs)
+		defer close(rows)
+
+		errs <- each(ctx, func(row T) error {
+			select {
+			case rows <- row:
+				return nil
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		})
+	}()
+
+	return rows, errs
+}
+
+// EachBatch calls `fn` with each row of `s`, selected in batches of `size` rows by keyset in the order of its
+// ORDER BY columns and then of `pk` (see SelectStatement.Page). `run` runs the statement of a batch. Each batch is
+// its own query, so a long scan doesn't hold a connection (or a snapshot) between batches. `value` returns the
+// value of a column of a row. It stops at the first error.
+func EachBatch[T any](ctx context.Context, s *SelectStatement, pk query.Column, size int64, run func(ctx context.Context, batch *SelectStatement) ([]T, error), value func(row T, col query.Column) interface{}, fn func(T) error) error {
+
+	var cursor string
+
+	for {
+
+		var batch = s.Clone()
+		if e := batch.Page(pk, cursor, false, size); e != nil {
+			return e
+		}
+
+		rows, e := run(ctx, batch)
+		if e != nil {
+			return e
+		}
+
+		page, e := NewPage(batch, rows, value)
+		if e != nil {
+			return e
+		}
+
+		for _, row := range page.Items {
+			if e = fn(row); e != nil {
+				return e
+			}
+		}
+
+		if len(page.Next) == 0 {
+			return nil
+		}
+
+		cursor = page.Next
+	}
+}
@@ -0,0 +1,134 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// eachRows calls fn with each of rows
+func eachRows(rows []*cursorRow) func(ctx context.Context, fn func(*cursorRow) error) error {
+	return func(ctx context.Context, fn func(*cursorRow) error) error {
+		for _, row := range rows {
+			if e := fn(row); e != nil {
+				return e
+			}
+		}
+		return nil
+	}
+}
+
+func TestEachSeq(t *testing.T) {
+
+	rows := []*cursorRow{{1, "a"}, {2, "b"}, {3, "c"}}
+
+	var ids = []int64{}
+	for row, e := range EachSeq(context.Background(), eachRows(rows)) {
+		require.Nil(t, e)
+		ids = append(ids, row.ID)
+		if row.ID == 2 {
+			break
+		}
+	}
+	assert.Equal(t, []int64{1, 2}, ids)
+
+	var failed = errors.New("failed")
+	var errs = []error{}
+	for row, e := range EachSeq(context.Background(), func(ctx context.Context, fn func(*cursorRow) error) error {
+		fn(rows[0])
+		return failed
+	}) {
+		errs = append(errs, e)
+		if e != nil {
+			assert.Nil(t, row)
+		}
+	}
+	assert.Equal(t, []error{nil, failed}, errs)
+}
+
+func TestEachChan(t *testing.T) {
+
+	rows, errs := EachChan(context.Background(), 1, eachRows([]*cursorRow{{1, "a"}, {2, "b"}}))
+
+	var ids = []int64{}
+	for row := range rows {
+		ids = append(ids, row.ID)
+	}
+
+	assert.Equal(t, []int64{1, 2}, ids)
+	assert.Nil(t, <-errs)
+}
+
+func TestEachChan_Cancel(t *testing.T) {
+
+	ctx, cancel := context.WithCancel(context.Background())
+	rows, errs := EachChan(ctx, 0, eachRows([]*cursorRow{{1, "a"}, {2, "b"}, {3, "c"}}))
+
+	assert.Equal(t, int64(1), (<-rows).ID)
+	cancel()
+
+	// The sender stops without the rest of the rows being read
+	assert.Equal(t, context.Canceled, <-errs)
+}
+
+func TestEachBatch(t *testing.T) {
+
+	table := []*cursorRow{{1, "a"}, {2, "b"}, {3, "c"}, {4, "d"}, {5, "e"}}
+	s := NewSelect("Foo", "`ID`", "`Name`").Where(Condition{SQL: "`Name` <> ?", Args: []interface{}{"z"}})
+
+	var queries = []string{}
+	var ids = []int64{}
+
+	e := EachBatch(context.Background(), s, "ID", 2, func(ctx context.Context, batch *SelectStatement) ([]*cursorRow, error) {
+
+		q, args := batch.SQL()
+		queries = append(queries, q)
+
+		var after = int64(0)
+		if len(args) > 1 {
+			after = args[1].(int64)
+		}
+
+		var rows = []*cursorRow{}
+		for _, row := range table {
+			if row.ID > after && len(rows) < 3 {
+				rows = append(rows, row)
+			}
+		}
+		return rows, nil
+	}, cursorRowValue, func(row *cursorRow) error {
+		ids = append(ids, row.ID)
+		return nil
+	})
+
+	require.Nil(t, e)
+	assert.Equal(t, []int64{1, 2, 3, 4, 5}, ids)
+	assert.Equal(t, []string{
+		"SELECT `ID`, `Name` FROM `Foo` WHERE `Name` <> ? ORDER BY `ID` ASC LIMIT 0, 3",
+		"SELECT `ID`, `Name` FROM `Foo` WHERE (`Name` <> ?) AND ((`ID` > ?)) ORDER BY `ID` ASC LIMIT 0, 3",
+		"SELECT `ID`, `Name` FROM `Foo` WHERE (`Name` <> ?) AND ((`ID` > ?)) ORDER BY `ID` ASC LIMIT 0, 3",
+	}, queries)
+
+	// The statement itself is left as it was
+	q, _ := s.SQL()
+	assert.Equal(t, "SELECT `ID`, `Name` FROM `Foo` WHERE `Name` <> ?", q)
+}
+
+func TestEachBatch_Error(t *testing.T) {
+
+	var failed = errors.New("failed")
+	var calls = 0
+
+	e := EachBatch(context.Background(), NewSelect("Foo", "`ID`"), "ID", 1, func(ctx context.Context, batch *SelectStatement) ([]*cursorRow, error) {
+		calls++
+		return []*cursorRow{{1, "a"}, {2, "b"}}, nil
+	}, cursorRowValue, func(row *cursorRow) error {
+		return failed
+	})
+
+	assert.Equal(t, failed, e)
+	assert.Equal(t, 1, calls)
+}
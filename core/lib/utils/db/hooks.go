@@ -0,0 +1,110 @@
+package db
+
+import "context"
+
+// BeforeCreateHook is implemented by models that run code before generated DALs create them. An error aborts
+// the create.
+type BeforeCreateHook interface {
+	BeforeCreate(ctx context.Context) error
+}
+
+// AfterCreateHook is implemented by models that run code after generated DALs create them
+type AfterCreateHook interface {
+	AfterCreate(ctx context.Context) error
+}
+
+// BeforeUpdateHook is implemented by models that run code before generated DALs update them. An error aborts
+// the update.
+type BeforeUpdateHook interface {
+	BeforeUpdate(ctx context.Context) error
+}
+
+// AfterUpdateHook is implemented by models that run code after generated DALs update them
+type AfterUpdateHook interface {
+	AfterUpdate(ctx context.Context) error
+}
+
+// BeforeDeleteHook is implemented by models that run code before generated DALs delete them. An error aborts
+// the delete.
+type BeforeDeleteHook interface {
+	BeforeDelete(ctx context.Context) error
+}
+
+// AfterDeleteHook is implemented by models that run code after generated DALs delete them
+type AfterDeleteHook interface {
+	AfterDelete(ctx context.Context) error
+}
+
+// BeforeCreate runs the BeforeCreate hook of `model`, if it has one
+func BeforeCreate(ctx context.Context, model interface{}) error {
+	if hook, ok := model.(BeforeCreateHook); ok {
+		return hook.BeforeCreate(ctx)
+	}
+	return nil
+}
+
+// AfterCreate runs the AfterCreate hook of `model`, if it has one
+func AfterCreate(ctx context.Context, model interface{}) error {
+	if hook, ok := model.(AfterCreateHook); ok {
+		return hook.AfterCreate(ctx)
+	}
+	return nil
+}
+
+// BeforeUpdate runs the BeforeUpdate hook of `model`, if it has one
+func BeforeUpdate(ctx context.Context, model interface{}) error {
+	if hook, ok := model.(BeforeUpdateHook); ok {
+		return hook.BeforeUpdate(ctx)
+	}
+	return nil
+}
+
+// AfterUpdate runs the AfterUpdate hook of `model`, if it has one
+func AfterUpdate(ctx context.Context, model interface{}) error {
+	if hook, ok := model.(AfterUpdateHook); ok {
+		return hook.AfterUpdate(ctx)
+	}
+	return nil
+}
+
+// BeforeDelete runs the BeforeDelete hook of `model`, if it has one
+func BeforeDelete(ctx context.Context, model interface{}) error {
+	if hook, ok := model.(BeforeDeleteHook); ok {
+		return hook.BeforeDelete(ctx)
+	}
+	return nil
+}
+
+// AfterDelete runs the AfterDelete hook of `model`, if it has one
+func AfterDelete(ctx context.Context, model interface{}) error {
+	if hook, ok := model.(AfterDeleteHook); ok {
+		return hook.AfterDelete(ctx)
+	}
+	return nil
+}
+
+// HasDeleteHooks returns true if `model` has a BeforeDelete or AfterDelete hook, which DALs that delete by primary
+// key read the record for
+func HasDeleteHooks(model interface{}) bool {
+	_, before := model.(BeforeDeleteHook)
+	_, after := model.(AfterDeleteHook)
+	return before || after
+}
+
+// HasUpdateHooks returns true if `model` has a BeforeUpdate or AfterUpdate hook, which DALs that update a column by
+// primary key (e.g. Set<Col>) read the record for
+func HasUpdateHooks(model interface{}) bool {
+	_, before := model.(BeforeUpdateHook)
+	_, after := model.(AfterUpdateHook)
+	return before || after
+}
+
+// EachModel runs `hook` on each model of `modelSlice`, stopping at the first error
+func EachModel[T any](ctx context.Context, modelSlice []*T, hook func(ctx context.Context, model interface{}) error) error {
+	for _, model := range modelSlice {
+		if e := hook(ctx, model); e != nil {
+			return e
+		}
+	}
+	return nil
+}
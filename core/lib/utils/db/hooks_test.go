@@ -0,0 +1,62 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type hookedModel struct {
+	Name  string
+	calls []string
+}
+
+func (m *hookedModel) BeforeCreate(ctx context.Context) error {
+	m.calls = append(m.calls, "BeforeCreate")
+	if len(m.Name) == 0 {
+		return errors.New("no name")
+	}
+	return nil
+}
+
+func (m *hookedModel) AfterDelete(ctx context.Context) error {
+	m.calls = append(m.calls, "AfterDelete")
+	return nil
+}
+
+type updateHookedModel struct{}
+
+func (m *updateHookedModel) AfterUpdate(ctx context.Context) error {
+	return nil
+}
+
+func TestHooks(t *testing.T) {
+
+	ctx := context.Background()
+	model := &hookedModel{Name: "foo"}
+
+	assert.Nil(t, BeforeCreate(ctx, model))
+	assert.Nil(t, AfterCreate(ctx, model))
+	assert.Nil(t, BeforeUpdate(ctx, model))
+	assert.Nil(t, AfterUpdate(ctx, model))
+	assert.Nil(t, BeforeDelete(ctx, model))
+	assert.Nil(t, AfterDelete(ctx, model))
+	assert.Equal(t, []string{"BeforeCreate", "AfterDelete"}, model.calls)
+
+	assert.True(t, HasDeleteHooks(model))
+	assert.False(t, HasDeleteHooks(&struct{}{}))
+	assert.False(t, HasUpdateHooks(model))
+	assert.True(t, HasUpdateHooks(&updateHookedModel{}))
+}
+
+func TestEachModel(t *testing.T) {
+
+	modelSlice := []*hookedModel{{Name: "a"}, {}, {Name: "c"}}
+
+	assert.EqualError(t, EachModel(context.Background(), modelSlice, BeforeCreate), "no name")
+	assert.Len(t, modelSlice[0].calls, 1)
+	assert.Len(t, modelSlice[1].calls, 1)
+	assert.Len(t, modelSlice[2].calls, 0)
+}
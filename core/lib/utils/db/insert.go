@@ -0,0 +1,376 @@
+package db
+
+import (
+	"context"
+	"database/sql/driver"
+	"fmt"
+	"io"
+	"iter"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/macinnir/dvc/core/lib/utils/errors"
+	query "github.com/macinnir/goquery"
+)
+
+// DefaultMaxAllowedPacket is the size multi-row inserts are kept under when the server's max_allowed_packet can't
+// be read (the MySQL 5.7 default)
+var DefaultMaxAllowedPacket = 4 << 20
+
+// maxPlaceholders is the most placeholders MySQL allows in a prepared statement
+const maxPlaceholders = 65535
+
+// packetOverhead is room left in a packet for its header and the driver's encoding of the arguments
+const packetOverhead = 1024
+
+// InsertBatch is a multi-row INSERT statement and its arguments
+type InsertBatch struct {
+	SQL  string
+	Args []interface{}
+	// Rows is the number of rows the statement inserts
+	Rows int
+}
+
+// InsertBatches turns a single-row INSERT ... VALUES (?, ...) statement and the arguments of each row into multi-row
+// INSERT statements, each kept under `maxPacket` bytes and the placeholder limit of a prepared statement. A row too
+// large for a packet on its own gets a statement of its own.
+func InsertBatches(insert string, rows [][]interface{}, maxPacket int) ([]*InsertBatch, error) {
+
+	var idx = strings.LastIndex(insert, " VALUES ")
+	if idx < 0 {
+		return nil, fmt.Errorf("db: %s is not an INSERT ... VALUES statement", insert)
+	}
+
+	var prefix = insert[:idx+len(" VALUES ")]
+	var row = strings.TrimSpace(insert[idx+len(" VALUES "):])
+	var placeholders = strings.Count(row, "?")
+
+	var batches = []*InsertBatch{}
+	var batch *InsertBatch
+	var sqlSize, argSize int
+	var values []string
+
+	var flush = func() {
+		if batch != nil {
+			batch.SQL = prefix + strings.Join(values, ", ")
+			batches = append(batches, batch)
+			batch = nil
+		}
+	}
+
+	for k := range rows {
+
+		if len(rows[k]) != placeholders {
+			return nil, fmt.Errorf("db: row %d has %d values for %d placeholders", k, len(rows[k]), placeholders)
+		}
+
+		var size = 0
+		for _, arg := range rows[k] {
+			size += insertArgSize(arg)
+		}
+
+		if batch != nil && (sqlSize+len(row)+2 > maxPacket-packetOverhead ||
+			argSize+size > maxPacket-packetOverhead ||
+			len(batch.Args)+placeholders > maxPlaceholders) {
+			flush()
+		}
+
+		if batch == nil {
+			batch = &InsertBatch{}
+			sqlSize, argSize = len(prefix), 0
+			values = []string{}
+		}
+
+		batch.Args = append(batch.Args, rows[k]...)
+		batch.Rows++
+		values = append(values, row)
+		sqlSize += len(row) + 2
+		argSize += size
+	}
+
+	flush()
+
+	return batches, nil
+}
+
+// insertArgSize estimates the bytes an argument takes in a statement
+func insertArgSize(arg interface{}) int {
+
+	if valuer, ok := arg.(driver.Valuer); ok {
+		if value, e := valuer.Value(); e == nil {
+			arg = value
+		}
+	}
+
+	// Each argument is preceded by its type
+	switch value := arg.(type) {
+	case nil:
+		return 2
+	case string:
+		return len(value) + 11
+	case []byte:
+		return len(value) + 11
+	case time.Time:
+		return 14
+	default:
+		return 10
+	}
+}
+
+// insertLimits are the server settings multi-row inserts depend on
+type insertLimits struct {
+	maxPacket int
+	increment int64
+}
+
+// insertLimitsCache holds the insertLimits of each connection
+var insertLimitsCache sync.Map
+
+// insertLimitsFor returns the insertLimits of the server `conn` is connected to, read once per connection. If they
+// can't be read, the defaults are used.
+func insertLimitsFor(ctx context.Context, conn query.DBInterface) insertLimits {
+
+	var cacheable = !InTx(conn) && reflect.TypeOf(conn).Kind() == reflect.Ptr
+
+	if cacheable {
+		if limits, ok := insertLimitsCache.Load(conn); ok {
+			return limits.(insertLimits)
+		}
+	}
+
+	var limits = insertLimits{DefaultMaxAllowedPacket, 1}
+	var maxPacket, increment int64
+
+	if e := QueryRowContext(ctx, conn, "SELECT @@max_allowed_packet, @@auto_increment_increment").Scan(&maxPacket, &increment); e != nil {
+		return limits
+	}
+
+	if maxPacket > 0 {
+		limits.maxPacket = int(maxPacket)
+	}
+
+	if increment > 0 {
+		limits.increment = increment
+	}
+
+	if cacheable {
+		insertLimitsCache.Store(conn, limits)
+	}
+
+	return limits
+}
+
+// InsertMany inserts rows with multi-row INSERT statements built from the single-row `insert` (see InsertBatches),
+// kept under the server's max_allowed_packet, in a transaction on `conn`. It returns the auto-increment IDs of the
+// rows in order: a multi-row INSERT gets consecutive IDs (spaced by auto_increment_increment) from the one
+// LAST_INSERT_ID() reports.
+func InsertMany(ctx context.Context, conn query.DBInterface, insert string, rows [][]interface{}) ([]int64, error) {
+
+	var ids = make([]int64, len(rows))
+
+	if len(rows) == 0 {
+		return ids, nil
+	}
+
+	var limits = insertLimitsFor(ctx, conn)
+
+	batches, e := InsertBatches(insert, rows, limits.maxPacket)
+	if e != nil {
+		return nil, e
+	}
+
+	e = Transaction(ctx, conn, func(tx query.DBInterface) error {
+
+		var offset = 0
+
+		for k, batch := range batches {
+
+			result, e := ExecContext(ctx, tx, batch.SQL, batch.Args...)
+			if e != nil {
+				return fmt.Errorf("batch %d: %w", k, e)
+			}
+
+			firstID, e := result.LastInsertId()
+			if e != nil {
+				return fmt.Errorf("batch %d: %w", k, e)
+			}
+
+			for l := 0; l < batch.Rows; l++ {
+				ids[offset+l] = firstID + int64(l)*limits.increment
+			}
+
+			offset += batch.Rows
+		}
+
+		return nil
+	})
+
+	if e != nil {
+		return nil, e
+	}
+
+	return ids, nil
+}
+
+// loadDataReaderID names the readers registered for LoadData
+var loadDataReaderID int64
+
+// LoadData inserts rows into the columns `cols` of `table` with LOAD DATA LOCAL INFILE, streaming them to the server
+// as tab-separated values, and returns the number of rows inserted. It is much faster than INSERT for very large
+// batches, but the server must allow local_infile and the IDs of the rows aren't reported.
+func LoadData(ctx context.Context, conn query.DBInterface, table string, cols []string, rows [][]interface{}) (int64, error) {
+
+	if len(rows) == 0 {
+		return 0, nil
+	}
+
+	// LOAD DATA can't be prepared
+	if cache, ok := conn.(*StatementCache); ok {
+		conn = cache.DBInterface
+	}
+
+	var name = "dvc-load-data-" + strconv.FormatInt(atomic.AddInt64(&loadDataReaderID, 1), 10)
+	var reader, writer = io.Pipe()
+
+	mysql.RegisterReaderHandler(name, func() io.Reader { return reader })
+	defer mysql.DeregisterReaderHandler(name)
+
+	go func() {
+		writer.CloseWithError(writeLoadData(writer, rows))
+	}()
+
+	result, e := ExecContext(ctx, conn, "LOAD DATA LOCAL INFILE 'Reader::"+name+"' INTO TABLE `"+table+"` "+
+		"CHARACTER SET utf8mb4 FIELDS TERMINATED BY '\\t' ESCAPED BY '\\\\' LINES TERMINATED BY '\\n' "+
+		"(`"+strings.Join(cols, "`, `")+"`)")
+
+	// Stops the writer if the server didn't read everything
+	reader.CloseWithError(io.ErrClosedPipe)
+
+	if e != nil {
+		return 0, e
+	}
+
+	return result.RowsAffected()
+}
+
+// writeLoadData writes rows as the tab-separated lines LoadData sends
+func writeLoadData(w io.Writer, rows [][]interface{}) error {
+
+	var line strings.Builder
+
+	for k := range rows {
+
+		line.Reset()
+
+		for l, arg := range rows[k] {
+
+			if l > 0 {
+				line.WriteByte('\t')
+			}
+
+			field, e := loadDataField(arg)
+			if e != nil {
+				return fmt.Errorf("row %d: %w", k, e)
+			}
+
+			line.WriteString(field)
+		}
+
+		line.WriteByte('\n')
+
+		if _, e := io.WriteString(w, line.String()); e != nil {
+			return e
+		}
+	}
+
+	return nil
+}
+
+// loadDataEscaper escapes the characters that delimit LOAD DATA fields and lines
+var loadDataEscaper = strings.NewReplacer("\\", "\\\\", "\t", "\\t", "\n", "\\n", "\r", "\\r", "\x00", "\\0")
+
+// loadDataField returns a value as a LOAD DATA field, where \N is NULL
+func loadDataField(arg interface{}) (string, error) {
+
+	if valuer, ok := arg.(driver.Valuer); ok {
+		value, e := valuer.Value()
+		if e != nil {
+			return "", e
+		}
+		arg = value
+	}
+
+	switch value := arg.(type) {
+	case nil:
+		return "\\N", nil
+	case string:
+		return loadDataEscaper.Replace(value), nil
+	case []byte:
+		return loadDataEscaper.Replace(string(value)), nil
+	case bool:
+		if value {
+			return "1", nil
+		}
+		return "0", nil
+	case time.Time:
+		return value.Format("2006-01-02 15:04:05.999999"), nil
+	default:
+		return loadDataEscaper.Replace(fmt.Sprint(value)), nil
+	}
+}
+
+// Chunks calls fn with the values of seq in chunks of up to size values, stopping at the first error or when ctx is
+// done. A size less than 1 is an errors.ArgumentError.
+func Chunks[T any](ctx context.Context, seq iter.Seq[T], size int, fn func(chunk []T) error) error {
+
+	if size < 1 {
+		return errors.NewArgumentError(fmt.Sprintf("chunk size %d is less than 1", size))
+	}
+
+	var chunk = make([]T, 0, size)
+
+	for value := range seq {
+
+		chunk = append(chunk, value)
+
+		if len(chunk) < size {
+			continue
+		}
+
+		if e := ctx.Err(); e != nil {
+			return e
+		}
+
+		if e := fn(chunk); e != nil {
+			return e
+		}
+
+		chunk = make([]T, 0, size)
+	}
+
+	if len(chunk) == 0 {
+		return nil
+	}
+
+	if e := ctx.Err(); e != nil {
+		return e
+	}
+
+	return fn(chunk)
+}
+
+// ChanSeq returns an iterator over the values received on ch until it is closed
+func ChanSeq[T any](ch <-chan T) iter.Seq[T] {
+	return func(yield func(T) bool) {
+		for value := range ch {
+			if !yield(value) {
+				return
+			}
+		}
+	}
+}
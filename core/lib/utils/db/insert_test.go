@@ -0,0 +1,114 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+
+	dvcerrors "github.com/macinnir/dvc/core/lib/utils/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const insertFoo = "INSERT INTO `Foo` (`A`, `B`) VALUES (?, ?)"
+
+func TestInsertBatches(t *testing.T) {
+
+	rows := [][]interface{}{{1, "a"}, {2, "b"}, {3, "c"}}
+
+	batches, e := InsertBatches(insertFoo, rows, DefaultMaxAllowedPacket)
+	require.Nil(t, e)
+	require.Len(t, batches, 1)
+	assert.Equal(t, "INSERT INTO `Foo` (`A`, `B`) VALUES (?, ?), (?, ?), (?, ?)", batches[0].SQL)
+	assert.Equal(t, []interface{}{1, "a", 2, "b", 3, "c"}, batches[0].Args)
+	assert.Equal(t, 3, batches[0].Rows)
+
+	// Rows are split to stay under the packet size
+	rows = [][]interface{}{{1, strings.Repeat("a", 600)}, {2, strings.Repeat("b", 600)}, {3, sql.NullString{}}}
+
+	batches, e = InsertBatches(insertFoo, rows, packetOverhead+1000)
+	require.Nil(t, e)
+	require.Len(t, batches, 2)
+	assert.Equal(t, 1, batches[0].Rows)
+	assert.Equal(t, 2, batches[1].Rows)
+	assert.Equal(t, "INSERT INTO `Foo` (`A`, `B`) VALUES (?, ?), (?, ?)", batches[1].SQL)
+
+	// ... and under the placeholder limit
+	rows = make([][]interface{}, maxPlaceholders)
+	for k := range rows {
+		rows[k] = []interface{}{k, "a"}
+	}
+
+	batches, e = InsertBatches(insertFoo, rows, 1<<30)
+	require.Nil(t, e)
+	require.Len(t, batches, 3)
+	assert.Equal(t, maxPlaceholders/2, batches[0].Rows)
+	assert.Equal(t, 1, batches[2].Rows)
+
+	_, e = InsertBatches(insertFoo, [][]interface{}{{1}}, DefaultMaxAllowedPacket)
+	assert.NotNil(t, e)
+
+	_, e = InsertBatches("DELETE FROM `Foo`", rows, DefaultMaxAllowedPacket)
+	assert.NotNil(t, e)
+}
+
+func TestInsertMany(t *testing.T) {
+
+	conn, log := newRecordingDB(t)
+
+	ids, e := InsertMany(context.Background(), conn, insertFoo, [][]interface{}{{1, "a"}, {2, "b"}})
+	require.Nil(t, e)
+
+	assert.Equal(t, []string{"BEGIN", "INSERT INTO `Foo` (`A`, `B`) VALUES (?, ?), (?, ?)", "COMMIT"}, *log)
+
+	// The recorded statement was the second, so its rows got IDs 2 and 3
+	assert.Equal(t, []int64{2, 3}, ids)
+}
+
+func TestWriteLoadData(t *testing.T) {
+
+	var b strings.Builder
+
+	e := writeLoadData(&b, [][]interface{}{
+		{int64(1), "a\tb\\c\nd", sql.NullString{}, true},
+		{int64(2), sql.NullString{String: "e", Valid: true}, []byte("f"), false},
+	})
+	require.Nil(t, e)
+
+	assert.Equal(t, "1\ta\\tb\\\\c\\nd\t\\N\t1\n2\te\tf\t0\n", b.String())
+}
+
+func TestChunks(t *testing.T) {
+
+	var ch = make(chan int, 5)
+	for k := 1; k <= 5; k++ {
+		ch <- k
+	}
+	close(ch)
+
+	var chunks = [][]int{}
+	e := Chunks(context.Background(), ChanSeq(ch), 2, func(chunk []int) error {
+		chunks = append(chunks, chunk)
+		return nil
+	})
+	require.Nil(t, e)
+	assert.Equal(t, [][]int{{1, 2}, {3, 4}, {5}}, chunks)
+
+	var stop = errors.New("stop")
+	var calls = 0
+	e = Chunks(context.Background(), slices.Values([]int{1, 2, 3}), 1, func(chunk []int) error {
+		calls++
+		return stop
+	})
+	assert.Equal(t, stop, e)
+	assert.Equal(t, 1, calls)
+
+	// A chunk size of 0 or less is refused rather than panicking
+	for _, size := range []int{0, -1} {
+		e = Chunks(context.Background(), slices.Values([]int{1}), size, func(chunk []int) error { return nil })
+		assert.IsType(t, dvcerrors.ArgumentError{}, e, size)
+	}
+}
@@ -0,0 +1,352 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	query "github.com/macinnir/goquery"
+)
+
+// QueryEvent is a query run on an instrumented connection (see Instrumented)
+type QueryEvent struct {
+	// Table is the (first) table the query reads or writes, without backticks, or empty if it has none
+	Table string
+	// Operation is the first keyword of the query, upper-cased (e.g. SELECT, INSERT, UPDATE or DELETE)
+	Operation string
+	// Fingerprint is the query with its literals replaced by ? and its lists of values collapsed to ?+, so that
+	// it is the same for every run of a query (see Fingerprint)
+	Fingerprint string
+	// Duration is the time the query took to run. For reads it does not include reading the rows.
+	Duration time.Duration
+	// RowsAffected is the number of rows a write changed, or zero for reads
+	RowsAffected int64
+	// Err is the error the query returned, if any
+	Err error
+}
+
+// Instrument observes the queries run on an instrumented connection. BeforeQuery returns the context the query
+// runs with (e.g. one holding a span), which is then passed to AfterQuery along with the completed event.
+type Instrument interface {
+	BeforeQuery(ctx context.Context, event *QueryEvent) context.Context
+	AfterQuery(ctx context.Context, event *QueryEvent)
+}
+
+// instruments is an Instrument that reports to each of a list of instruments
+type instruments []Instrument
+
+// BeforeQuery runs BeforeQuery on each instrument in order
+func (l instruments) BeforeQuery(ctx context.Context, event *QueryEvent) context.Context {
+	for k := range l {
+		ctx = l[k].BeforeQuery(ctx, event)
+	}
+	return ctx
+}
+
+// AfterQuery runs AfterQuery on each instrument in reverse order
+func (l instruments) AfterQuery(ctx context.Context, event *QueryEvent) {
+	for k := len(l) - 1; k >= 0; k-- {
+		l[k].AfterQuery(ctx, event)
+	}
+}
+
+// Instrumented wraps a connection and reports every query run through it to an Instrument. Transactions begun on
+// it (see RunInTx) and StatementCaches wrapping it report their queries too.
+type Instrumented struct {
+	query.DBInterface
+	instrument Instrument
+	mu         sync.Mutex
+	replicas   map[query.DBInterface]*Instrumented
+}
+
+// NewInstrumented returns an Instrumented for `conn` that reports to each of `list`
+func NewInstrumented(conn query.DBInterface, list ...Instrument) *Instrumented {
+
+	var instrument Instrument = instruments(list)
+	if len(list) == 1 {
+		instrument = list[0]
+	}
+
+	return &Instrumented{
+		DBInterface: conn,
+		instrument:  instrument,
+		replicas:    map[query.DBInterface]*Instrumented{},
+	}
+}
+
+// InstrumentConns returns `conns` (e.g. those passed to a generated BootstrapDAL) with each connection wrapped in
+// an Instrumented that reports to `list`
+func InstrumentConns(conns map[string][]query.DBInterface, list ...Instrument) map[string][]query.DBInterface {
+
+	var instrumented = make(map[string][]query.DBInterface, len(conns))
+
+	for schemaName := range conns {
+		instrumented[schemaName] = make([]query.DBInterface, len(conns[schemaName]))
+		for k := range conns[schemaName] {
+			instrumented[schemaName][k] = NewInstrumented(conns[schemaName][k], list...)
+		}
+	}
+
+	return instrumented
+}
+
+// instrumentOf returns the Instrument of `conn` if it is (or a StatementCache wraps) an Instrumented
+func instrumentOf(conn query.DBInterface) Instrument {
+	switch c := conn.(type) {
+	case *Instrumented:
+		return c.instrument
+	case *StatementCache:
+		return instrumentOf(c.DBInterface)
+	}
+	return nil
+}
+
+// ReadConn returns an Instrumented for the replica a read should run on if the connection has replicas (see
+// ReplicaSet)
+func (i *Instrumented) ReadConn(ctx context.Context) query.DBInterface {
+
+	var conn = ReadConn(ctx, i.DBInterface)
+	if conn == i.DBInterface {
+		return i
+	}
+
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	if _, ok := i.replicas[conn]; !ok {
+		i.replicas[conn] = NewInstrumented(conn, i.instrument)
+	}
+
+	return i.replicas[conn]
+}
+
+// ExecContext executes a query and reports it
+func (i *Instrumented) ExecContext(ctx context.Context, q string, args ...interface{}) (sql.Result, error) {
+	return instrumentExec(ctx, i.instrument, q, func(ctx context.Context) (sql.Result, error) {
+		return ExecContext(ctx, i.DBInterface, q, args...)
+	})
+}
+
+// QueryContext runs a query and reports it
+func (i *Instrumented) QueryContext(ctx context.Context, q string, args ...interface{}) (*sql.Rows, error) {
+	return instrumentQuery(ctx, i.instrument, q, func(ctx context.Context) (*sql.Rows, error) {
+		return QueryContext(ctx, i.DBInterface, q, args...)
+	})
+}
+
+// QueryRowContext runs a query expected to return at most one row and reports it
+func (i *Instrumented) QueryRowContext(ctx context.Context, q string, args ...interface{}) *sql.Row {
+	return instrumentQueryRow(ctx, i.instrument, q, func(ctx context.Context) *sql.Row {
+		return QueryRowContext(ctx, i.DBInterface, q, args...)
+	})
+}
+
+// Exec executes a query and reports it
+func (i *Instrumented) Exec(q string, args ...interface{}) (sql.Result, error) {
+	return i.ExecContext(context.Background(), q, args...)
+}
+
+// Query runs a query and reports it
+func (i *Instrumented) Query(q string, args ...interface{}) (*sql.Rows, error) {
+	return i.QueryContext(context.Background(), q, args...)
+}
+
+// QueryRow runs a query expected to return at most one row and reports it
+func (i *Instrumented) QueryRow(q string, args ...interface{}) *sql.Row {
+	return i.QueryRowContext(context.Background(), q, args...)
+}
+
+// startQuery returns the event of `q` and the context to run it with
+func startQuery(ctx context.Context, instrument Instrument, q string) (context.Context, *QueryEvent) {
+	var event = NewQueryEvent(q)
+	return instrument.BeforeQuery(ctx, event), event
+}
+
+// instrumentExec runs `exec` and reports it to `instrument`, if there is one
+func instrumentExec(ctx context.Context, instrument Instrument, q string, exec func(ctx context.Context) (sql.Result, error)) (sql.Result, error) {
+
+	if instrument == nil {
+		return exec(ctx)
+	}
+
+	ctx, event := startQuery(ctx, instrument, q)
+	var start = time.Now()
+
+	result, e := exec(ctx)
+
+	event.Duration = time.Since(start)
+	event.Err = e
+	if e == nil {
+		event.RowsAffected, _ = result.RowsAffected()
+	}
+
+	instrument.AfterQuery(ctx, event)
+
+	return result, e
+}
+
+// instrumentQuery runs `run` and reports it to `instrument`, if there is one
+func instrumentQuery(ctx context.Context, instrument Instrument, q string, run func(ctx context.Context) (*sql.Rows, error)) (*sql.Rows, error) {
+
+	if instrument == nil {
+		return run(ctx)
+	}
+
+	ctx, event := startQuery(ctx, instrument, q)
+	var start = time.Now()
+
+	rows, e := run(ctx)
+
+	event.Duration = time.Since(start)
+	event.Err = e
+
+	instrument.AfterQuery(ctx, event)
+
+	return rows, e
+}
+
+// instrumentQueryRow runs `run` and reports it to `instrument`, if there is one
+func instrumentQueryRow(ctx context.Context, instrument Instrument, q string, run func(ctx context.Context) *sql.Row) *sql.Row {
+
+	if instrument == nil {
+		return run(ctx)
+	}
+
+	ctx, event := startQuery(ctx, instrument, q)
+	var start = time.Now()
+
+	row := run(ctx)
+
+	event.Duration = time.Since(start)
+	if row != nil {
+		event.Err = row.Err()
+	}
+
+	instrument.AfterQuery(ctx, event)
+
+	return row
+}
+
+// maxFingerprints is the number of parsed queries NewQueryEvent remembers
+const maxFingerprints = 10000
+
+var fingerprints = struct {
+	sync.RWMutex
+	events map[string]QueryEvent
+}{events: map[string]QueryEvent{}}
+
+// NewQueryEvent returns the event of running `q`, with its table, operation and fingerprint
+func NewQueryEvent(q string) *QueryEvent {
+
+	fingerprints.RLock()
+	event, ok := fingerprints.events[q]
+	fingerprints.RUnlock()
+
+	if !ok {
+
+		event.Fingerprint = Fingerprint(q)
+		event.Operation, event.Table = parseQuery(event.Fingerprint)
+
+		fingerprints.Lock()
+		if len(fingerprints.events) < maxFingerprints {
+			fingerprints.events[q] = event
+		}
+		fingerprints.Unlock()
+	}
+
+	return &event
+}
+
+var (
+	fingerprintValueLists = regexp.MustCompile(`\?(?:\s*,\s*\?)+`)
+	fingerprintTuples     = regexp.MustCompile(`\(\s*\?\+?\s*\)(?:\s*,\s*\(\s*\?\+?\s*\))+|\(\s*\?\+\s*\)`)
+	queryTable            = regexp.MustCompile("(?i)\\b(?:FROM|INTO(?:\\s+TABLE)?|UPDATE(?:\\s+IGNORE)?)\\s+((?:`[^`]+`|[\\w$]+)(?:\\.(?:`[^`]+`|[\\w$]+))?)")
+)
+
+// Fingerprint returns `q` with its string and number literals replaced by ?, its lists of values (e.g. of IN or of
+// a multi-row INSERT) collapsed to ?+ and its whitespace collapsed
+func Fingerprint(q string) string {
+
+	var b strings.Builder
+	b.Grow(len(q))
+
+	var space = false
+
+	for k := 0; k < len(q); k++ {
+
+		var c = q[k]
+
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			space = true
+			continue
+		}
+
+		if space && b.Len() > 0 {
+			b.WriteByte(' ')
+		}
+		space = false
+
+		switch {
+		case c == '`':
+			// Identifiers are kept as they are
+			var end = strings.IndexByte(q[k+1:], '`')
+			if end < 0 {
+				b.WriteString(q[k:])
+				k = len(q)
+				continue
+			}
+			b.WriteString(q[k : k+end+2])
+			k += end + 1
+		case c == '\'' || c == '"':
+			// Strings, with backslash and doubled-quote escapes
+			for k++; k < len(q); k++ {
+				if q[k] == '\\' {
+					k++
+				} else if q[k] == c {
+					if k+1 < len(q) && q[k+1] == c {
+						k++
+						continue
+					}
+					break
+				}
+			}
+			b.WriteByte('?')
+		case c >= '0' && c <= '9' && (k == 0 || !isWordByte(q[k-1])):
+			// Numbers, including decimals and hex literals
+			for k+1 < len(q) && (isWordByte(q[k+1]) || q[k+1] == '.') {
+				k++
+			}
+			b.WriteByte('?')
+		default:
+			b.WriteByte(c)
+		}
+	}
+
+	var fingerprint = fingerprintValueLists.ReplaceAllString(b.String(), "?+")
+	return fingerprintTuples.ReplaceAllString(fingerprint, "(?+)")
+}
+
+func isWordByte(c byte) bool {
+	return c == '_' || c == '$' || (c >= '0' && c <= '9') || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+// parseQuery returns the operation of `q` and the first table it reads or writes
+func parseQuery(q string) (operation, table string) {
+
+	q = strings.TrimLeft(q, "( ")
+	if end := strings.IndexAny(q, " (;"); end > -1 {
+		operation = strings.ToUpper(q[0:end])
+	} else {
+		operation = strings.ToUpper(q)
+	}
+
+	if match := queryTable.FindStringSubmatch(q); match != nil {
+		table = strings.ReplaceAll(match[1], "`", "")
+	}
+
+	return operation, table
+}
@@ -0,0 +1,268 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	query "github.com/macinnir/goquery"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// recordingInstrument records the events reported to it
+type recordingInstrument struct {
+	name   string
+	calls  *[]string
+	events []*QueryEvent
+}
+
+func (r *recordingInstrument) BeforeQuery(ctx context.Context, event *QueryEvent) context.Context {
+	if r.calls != nil {
+		*r.calls = append(*r.calls, "before "+r.name)
+	}
+	return ctx
+}
+
+func (r *recordingInstrument) AfterQuery(ctx context.Context, event *QueryEvent) {
+	if r.calls != nil {
+		*r.calls = append(*r.calls, "after "+r.name)
+	}
+	r.events = append(r.events, event)
+}
+
+func TestFingerprint(t *testing.T) {
+
+	tests := map[string]string{
+		"SELECT `FooID` FROM `Foo` WHERE `FooID` = ?":                      "SELECT `FooID` FROM `Foo` WHERE `FooID` = ?",
+		"SELECT *\n\tFROM `Foo`  WHERE `Name` = 'O''Brien' AND `A` = 12.5": "SELECT * FROM `Foo` WHERE `Name` = ? AND `A` = ?",
+		"SELECT * FROM `Foo` WHERE `Name` = \"a\\\"b\" LIMIT 10, 20":       "SELECT * FROM `Foo` WHERE `Name` = ? LIMIT ?+",
+		"SELECT * FROM `Foo2` WHERE `FooID` IN (?, ?, ?)":                  "SELECT * FROM `Foo2` WHERE `FooID` IN (?+)",
+		"SELECT * FROM `Foo` WHERE `FooID` IN (1,2)":                       "SELECT * FROM `Foo` WHERE `FooID` IN (?+)",
+		"INSERT INTO `Foo` (`A`, `B`) VALUES (?, ?)":                       "INSERT INTO `Foo` (`A`, `B`) VALUES (?+)",
+		"INSERT INTO `Foo` (`A`, `B`) VALUES (?, ?), (?, ?), (?, ?)":       "INSERT INTO `Foo` (`A`, `B`) VALUES (?+)",
+		"INSERT INTO `Foo` (`A`) VALUES (?), (?)":                          "INSERT INTO `Foo` (`A`) VALUES (?+)",
+		"SAVEPOINT sp_1": "SAVEPOINT sp_1",
+	}
+
+	for q, fingerprint := range tests {
+		assert.Equal(t, fingerprint, Fingerprint(q), q)
+	}
+}
+
+func TestNewQueryEvent(t *testing.T) {
+
+	tests := []struct {
+		q         string
+		operation string
+		table     string
+	}{
+		{"SELECT `FooID` FROM `Foo` WHERE `FooID` = ?", "SELECT", "Foo"},
+		{"select count(*) from Foo", "SELECT", "Foo"},
+		{"INSERT INTO `core_log`.`Audit` (`A`) VALUES (?)", "INSERT", "core_log.Audit"},
+		{"UPDATE `Foo` SET `A` = ? WHERE `FooID` = ?", "UPDATE", "Foo"},
+		{"UPDATE IGNORE `Foo` SET `A` = ?", "UPDATE", "Foo"},
+		{"DELETE FROM `Foo` WHERE `FooID` = ?", "DELETE", "Foo"},
+		{"INSERT INTO `Foo` (`A`) VALUES (?) ON DUPLICATE KEY UPDATE `A` = VALUES(`A`)", "INSERT", "Foo"},
+		{"LOAD DATA LOCAL INFILE 'Reader::1' INTO TABLE `Foo` (`A`)", "LOAD", "Foo"},
+		{"(SELECT `A` FROM `Foo`) UNION (SELECT `A` FROM `Bar`)", "SELECT", "Foo"},
+		{"SAVEPOINT sp_1", "SAVEPOINT", ""},
+	}
+
+	for _, test := range tests {
+		event := NewQueryEvent(test.q)
+		assert.Equal(t, test.operation, event.Operation, test.q)
+		assert.Equal(t, test.table, event.Table, test.q)
+	}
+
+	// Events are copies of the parsed query
+	NewQueryEvent(tests[0].q).Duration = time.Second
+	assert.Zero(t, NewQueryEvent(tests[0].q).Duration)
+}
+
+func TestInstrumented_Exec(t *testing.T) {
+
+	conn, log := newRecordingDB(t)
+	instrument := &recordingInstrument{}
+	i := NewInstrumented(conn, instrument)
+
+	_, e := ExecContext(context.Background(), i, "UPDATE `Foo` SET `A` = ? WHERE `FooID` = ?", 1, 2)
+
+	require.Nil(t, e)
+	assert.Equal(t, []string{"UPDATE `Foo` SET `A` = ? WHERE `FooID` = ?"}, *log)
+	require.Len(t, instrument.events, 1)
+	assert.Equal(t, "Foo", instrument.events[0].Table)
+	assert.Equal(t, "UPDATE", instrument.events[0].Operation)
+	assert.Equal(t, int64(1), instrument.events[0].RowsAffected)
+	assert.Nil(t, instrument.events[0].Err)
+}
+
+func TestInstrumented_QueryRowError(t *testing.T) {
+
+	conn, _ := newRecordingDB(t)
+	instrument := &recordingInstrument{}
+	i := NewInstrumented(conn, instrument)
+
+	var id int64
+	e := QueryRowContext(context.Background(), i, "SELECT `FooID` FROM `Foo`").Scan(&id)
+
+	assert.NotNil(t, e)
+	require.Len(t, instrument.events, 1)
+	assert.Equal(t, "SELECT", instrument.events[0].Operation)
+	assert.Equal(t, e, instrument.events[0].Err)
+}
+
+func TestInstrumented_Order(t *testing.T) {
+
+	conn, _ := newRecordingDB(t)
+	calls := []string{}
+	i := NewInstrumented(conn, &recordingInstrument{name: "a", calls: &calls}, &recordingInstrument{name: "b", calls: &calls})
+
+	i.Exec("DELETE FROM `Foo`")
+
+	assert.Equal(t, []string{"before a", "before b", "after b", "after a"}, calls)
+}
+
+func TestInstrumented_Tx(t *testing.T) {
+
+	conn, log := newRecordingDB(t)
+	instrument := &recordingInstrument{}
+
+	e := Transaction(context.Background(), NewInstrumented(conn, instrument), func(tx query.DBInterface) error {
+		assert.True(t, InTx(tx))
+		_, e := ExecContext(context.Background(), tx, "UPDATE `Foo` SET `A` = ?", 1)
+		return e
+	})
+
+	require.Nil(t, e)
+	assert.Equal(t, []string{"BEGIN", "UPDATE `Foo` SET `A` = ?", "COMMIT"}, *log)
+	require.Len(t, instrument.events, 1)
+	assert.Equal(t, "Foo", instrument.events[0].Table)
+}
+
+func TestInstrumented_StatementCache(t *testing.T) {
+
+	var log = []string{}
+	var conn = sql.OpenDB(connector{recordingDriver{&log}})
+	t.Cleanup(func() { conn.Close() })
+
+	instrument := &recordingInstrument{}
+	c := NewStatementCache(NewInstrumented(conn, instrument))
+
+	for k := 0; k < 2; k++ {
+		_, e := ExecContext(context.Background(), c, "DELETE FROM `Foo` WHERE `FooID` = ?", k)
+		require.Nil(t, e)
+	}
+
+	assert.Equal(t, 1, c.Len())
+	assert.Len(t, instrument.events, 2)
+}
+
+func TestInstrumented_ReadConn(t *testing.T) {
+
+	primary, replica := &recordingDB{}, &recordingDB{}
+	i := NewInstrumented(NewReplicaSet(primary, 0, replica), &recordingInstrument{})
+
+	read := ReadConn(context.Background(), i)
+	require.IsType(t, &Instrumented{}, read)
+	assert.Same(t, replica, read.(*Instrumented).DBInterface)
+	assert.Same(t, read, ReadConn(context.Background(), i))
+	assert.Same(t, i, ReadConn(WithPrimary(context.Background()), i))
+
+	single := NewInstrumented(primary, &recordingInstrument{})
+	assert.Same(t, single, ReadConn(context.Background(), single))
+}
+
+func TestInstrumentConns(t *testing.T) {
+
+	a, b := &recordingDB{}, &recordingDB{}
+	conns := InstrumentConns(map[string][]query.DBInterface{"app": {a, b}}, &recordingInstrument{})
+
+	require.Len(t, conns["app"], 2)
+	assert.Same(t, a, conns["app"][0].(*Instrumented).DBInterface)
+	assert.Same(t, b, conns["app"][1].(*Instrumented).DBInterface)
+}
+
+// warnLog is a log.ILog that records warnings
+type warnLog struct {
+	warnings []string
+}
+
+func (l *warnLog) Debug(args ...interface{})                 {}
+func (l *warnLog) Debugf(format string, args ...interface{}) {}
+func (l *warnLog) Info(args ...interface{})                  {}
+func (l *warnLog) Infof(format string, args ...interface{})  {}
+func (l *warnLog) Error(args ...interface{})                 {}
+func (l *warnLog) Errorf(format string, args ...interface{}) {}
+func (l *warnLog) Println(args ...interface{})               {}
+func (l *warnLog) Printf(format string, args ...interface{}) {}
+func (l *warnLog) Fatalf(format string, args ...interface{}) {}
+func (l *warnLog) Fatal(args ...interface{})                 {}
+func (l *warnLog) Warn(args ...interface{})                  { l.warnings = append(l.warnings, fmt.Sprint(args...)) }
+
+func TestSlowQueryLog(t *testing.T) {
+
+	log := &warnLog{}
+	s := NewSlowQueryLog(log, 100*time.Millisecond)
+
+	event := NewQueryEvent("SELECT * FROM `Foo` WHERE `FooID` = 1")
+	event.Duration = 10 * time.Millisecond
+	s.AfterQuery(context.Background(), event)
+	assert.Empty(t, log.warnings)
+
+	event.Duration = 150 * time.Millisecond
+	event.Err = errors.New("timeout")
+	s.AfterQuery(context.Background(), event)
+	assert.Equal(t, []string{"Slow query (150ms) on Foo: SELECT * FROM `Foo` WHERE `FooID` = ?; rows: 0; timeout"}, log.warnings)
+}
+
+// recordingSpan records what is set on it
+type recordingSpan struct {
+	name       string
+	attributes map[string]interface{}
+	err        error
+	ended      bool
+}
+
+func (s *recordingSpan) SetAttribute(key string, value interface{}) { s.attributes[key] = value }
+func (s *recordingSpan) RecordError(e error)                        { s.err = e }
+func (s *recordingSpan) End()                                       { s.ended = true }
+
+type recordingTracer struct {
+	spans []*recordingSpan
+}
+
+func (r *recordingTracer) Start(ctx context.Context, name string) (context.Context, Span) {
+	span := &recordingSpan{name: name, attributes: map[string]interface{}{}}
+	r.spans = append(r.spans, span)
+	return ctx, span
+}
+
+func TestTracing(t *testing.T) {
+
+	conn, _ := newRecordingDB(t)
+	tracer := &recordingTracer{}
+	i := NewInstrumented(conn, NewTracing(tracer))
+
+	_, e := i.Exec("DELETE FROM `Foo` WHERE `FooID` IN (?, ?)", 1, 2)
+	require.Nil(t, e)
+
+	require.Len(t, tracer.spans, 1)
+	span := tracer.spans[0]
+	assert.Equal(t, "DELETE Foo", span.name)
+	assert.True(t, span.ended)
+	assert.Nil(t, span.err)
+	assert.Equal(t, "mysql", span.attributes["db.system"])
+	assert.Equal(t, "DELETE", span.attributes["db.operation"])
+	assert.Equal(t, "Foo", span.attributes["db.sql.table"])
+	assert.Equal(t, "DELETE FROM `Foo` WHERE `FooID` IN (?+)", span.attributes["db.statement"])
+	assert.Equal(t, int64(1), span.attributes["db.rows_affected"])
+
+	var id int64
+	e = i.QueryRow("SELECT `FooID` FROM `Foo`").Scan(&id)
+	require.Len(t, tracer.spans, 2)
+	assert.Equal(t, e, tracer.spans[1].err)
+}
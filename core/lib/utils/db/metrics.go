@@ -0,0 +1,159 @@
+package db
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// DefaultQueryBuckets are the upper bounds, in seconds, of the query duration histogram of NewQueryMetrics
+var DefaultQueryBuckets = []float64{.001, .005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+
+// QueryMetrics is an Instrument that keeps Prometheus-style metrics of queries, by table and operation:
+//
+//	dvc_queries_total{table,operation,status}     counter of queries, with a status of ok or error
+//	dvc_query_rows_affected_total{table,operation} counter of the rows written
+//	dvc_query_duration_seconds{table,operation}   histogram of query durations
+//
+// It serves them in the Prometheus text format (e.g. on /metrics).
+type QueryMetrics struct {
+	mu      sync.Mutex
+	buckets []float64
+	series  map[queryMetricsKey]*queryMetrics
+}
+
+type queryMetricsKey struct {
+	table     string
+	operation string
+}
+
+type queryMetrics struct {
+	ok           uint64
+	errors       uint64
+	rowsAffected int64
+	buckets      []uint64
+	sum          float64
+}
+
+// NewQueryMetrics returns a QueryMetrics whose histogram has `buckets` (in seconds), or DefaultQueryBuckets if
+// none are given
+func NewQueryMetrics(buckets ...float64) *QueryMetrics {
+
+	if len(buckets) == 0 {
+		buckets = DefaultQueryBuckets
+	}
+
+	buckets = append([]float64{}, buckets...)
+	sort.Float64s(buckets)
+
+	return &QueryMetrics{
+		buckets: buckets,
+		series:  map[queryMetricsKey]*queryMetrics{},
+	}
+}
+
+// BeforeQuery does nothing
+func (m *QueryMetrics) BeforeQuery(ctx context.Context, event *QueryEvent) context.Context {
+	return ctx
+}
+
+// AfterQuery counts `event` and observes its duration
+func (m *QueryMetrics) AfterQuery(ctx context.Context, event *QueryEvent) {
+
+	var key = queryMetricsKey{event.Table, event.Operation}
+	var seconds = event.Duration.Seconds()
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var series, ok = m.series[key]
+	if !ok {
+		series = &queryMetrics{buckets: make([]uint64, len(m.buckets))}
+		m.series[key] = series
+	}
+
+	if event.Err != nil {
+		series.errors++
+	} else {
+		series.ok++
+	}
+
+	series.rowsAffected += event.RowsAffected
+	series.sum += seconds
+
+	for k := range m.buckets {
+		if seconds <= m.buckets[k] {
+			series.buckets[k]++
+		}
+	}
+}
+
+// WriteTo writes the metrics to `w` in the Prometheus text format
+func (m *QueryMetrics) WriteTo(w io.Writer) (int64, error) {
+
+	m.mu.Lock()
+
+	var keys = make([]queryMetricsKey, 0, len(m.series))
+	for key := range m.series {
+		keys = append(keys, key)
+	}
+
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].table != keys[j].table {
+			return keys[i].table < keys[j].table
+		}
+		return keys[i].operation < keys[j].operation
+	})
+
+	var b bytes.Buffer
+
+	b.WriteString("# HELP dvc_queries_total Queries run by generated DALs.\n# TYPE dvc_queries_total counter\n")
+	for _, key := range keys {
+		var series = m.series[key]
+		if series.ok > 0 {
+			fmt.Fprintf(&b, "dvc_queries_total{%s,status=\"ok\"} %d\n", key.labels(), series.ok)
+		}
+		if series.errors > 0 {
+			fmt.Fprintf(&b, "dvc_queries_total{%s,status=\"error\"} %d\n", key.labels(), series.errors)
+		}
+	}
+
+	b.WriteString("# HELP dvc_query_rows_affected_total Rows written by generated DALs.\n# TYPE dvc_query_rows_affected_total counter\n")
+	for _, key := range keys {
+		fmt.Fprintf(&b, "dvc_query_rows_affected_total{%s} %d\n", key.labels(), m.series[key].rowsAffected)
+	}
+
+	b.WriteString("# HELP dvc_query_duration_seconds Duration of the queries run by generated DALs.\n# TYPE dvc_query_duration_seconds histogram\n")
+	for _, key := range keys {
+		var series = m.series[key]
+		for k := range m.buckets {
+			fmt.Fprintf(&b, "dvc_query_duration_seconds_bucket{%s,le=\"%s\"} %d\n", key.labels(), strconv.FormatFloat(m.buckets[k], 'g', -1, 64), series.buckets[k])
+		}
+		fmt.Fprintf(&b, "dvc_query_duration_seconds_bucket{%s,le=\"+Inf\"} %d\n", key.labels(), series.ok+series.errors)
+		fmt.Fprintf(&b, "dvc_query_duration_seconds_sum{%s} %s\n", key.labels(), strconv.FormatFloat(series.sum, 'g', -1, 64))
+		fmt.Fprintf(&b, "dvc_query_duration_seconds_count{%s} %d\n", key.labels(), series.ok+series.errors)
+	}
+
+	m.mu.Unlock()
+
+	return b.WriteTo(w)
+}
+
+// ServeHTTP serves the metrics in the Prometheus text format
+func (m *QueryMetrics) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	m.WriteTo(w)
+}
+
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+// labels returns the Prometheus labels of the key
+func (k queryMetricsKey) labels() string {
+	return `table="` + labelEscaper.Replace(k.table) + `",operation="` + labelEscaper.Replace(k.operation) + `"`
+}
@@ -0,0 +1,61 @@
+package db
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestQueryMetrics(t *testing.T) {
+
+	m := NewQueryMetrics(0.1, 0.01)
+
+	for _, d := range []time.Duration{5 * time.Millisecond, 50 * time.Millisecond} {
+		event := NewQueryEvent("UPDATE `Foo` SET `A` = ?")
+		event.Duration = d
+		event.RowsAffected = 2
+		m.AfterQuery(context.Background(), event)
+	}
+
+	event := NewQueryEvent("SELECT * FROM `Bar`")
+	event.Duration = time.Second
+	event.Err = errors.New("failed")
+	m.AfterQuery(context.Background(), event)
+
+	var b bytes.Buffer
+	_, e := m.WriteTo(&b)
+	require.Nil(t, e)
+
+	assert.Equal(t, `# HELP dvc_queries_total Queries run by generated DALs.
+# TYPE dvc_queries_total counter
+dvc_queries_total{table="Bar",operation="SELECT",status="error"} 1
+dvc_queries_total{table="Foo",operation="UPDATE",status="ok"} 2
+# HELP dvc_query_rows_affected_total Rows written by generated DALs.
+# TYPE dvc_query_rows_affected_total counter
+dvc_query_rows_affected_total{table="Bar",operation="SELECT"} 0
+dvc_query_rows_affected_total{table="Foo",operation="UPDATE"} 4
+# HELP dvc_query_duration_seconds Duration of the queries run by generated DALs.
+# TYPE dvc_query_duration_seconds histogram
+dvc_query_duration_seconds_bucket{table="Bar",operation="SELECT",le="0.01"} 0
+dvc_query_duration_seconds_bucket{table="Bar",operation="SELECT",le="0.1"} 0
+dvc_query_duration_seconds_bucket{table="Bar",operation="SELECT",le="+Inf"} 1
+dvc_query_duration_seconds_sum{table="Bar",operation="SELECT"} 1
+dvc_query_duration_seconds_count{table="Bar",operation="SELECT"} 1
+dvc_query_duration_seconds_bucket{table="Foo",operation="UPDATE",le="0.01"} 1
+dvc_query_duration_seconds_bucket{table="Foo",operation="UPDATE",le="0.1"} 2
+dvc_query_duration_seconds_bucket{table="Foo",operation="UPDATE",le="+Inf"} 2
+dvc_query_duration_seconds_sum{table="Foo",operation="UPDATE"} 0.055
+dvc_query_duration_seconds_count{table="Foo",operation="UPDATE"} 2
+`, b.String())
+
+	w := httptest.NewRecorder()
+	m.ServeHTTP(w, httptest.NewRequest("GET", "/metrics", nil))
+	assert.Equal(t, b.String(), w.Body.String())
+	assert.Contains(t, w.Header().Get("Content-Type"), "text/plain")
+}
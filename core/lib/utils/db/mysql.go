@@ -3,7 +3,9 @@ package db
 import (
 	"context"
 	"fmt"
+	"time"
 
+	"github.com/macinnir/dvc/core/lib"
 	"github.com/macinnir/dvc/core/lib/utils/log"
 	query "github.com/macinnir/goquery"
 
@@ -19,6 +21,35 @@ type Config struct {
 	Name string
 	User string
 	Pass string
+	// Replicas are read replicas of the database. Empty fields are taken from the primary's config.
+	Replicas []*Config
+	// MaxReplicaLag is how far behind a replica can be and still be read from (zero for no limit)
+	MaxReplicaLag time.Duration
+	// LagInterval is how often the lag of the replicas is checked (DefaultLagInterval if zero)
+	LagInterval time.Duration
+}
+
+// DefaultLagInterval is how often the lag of replicas is checked if the config doesn't say
+const DefaultLagInterval = 5 * time.Second
+
+// NewConfig returns the config of a database in .dvc/config.json, with its replicas
+func NewConfig(database *lib.ConfigDatabase) *Config {
+
+	var c = &Config{
+		Host:          database.Host,
+		Name:          database.Name,
+		User:          database.User,
+		Pass:          database.Pass,
+		MaxReplicaLag: time.Duration(database.MaxReplicaLag) * time.Second,
+		LagInterval:   time.Duration(database.ReplicaLagInterval) * time.Second,
+	}
+
+	for k := range database.Replicas {
+		var r = database.Replica(k)
+		c.Replicas = append(c.Replicas, &Config{Host: r.Host, Name: r.Name, User: r.User, Pass: r.Pass})
+	}
+
+	return c
 }
 
 // MySQL is mysql
@@ -28,7 +59,9 @@ type MySQL struct {
 	log    log.ILog
 }
 
-// New returns a new MySQL object
+// New returns a new MySQL object. If the config has replicas, a ReplicaSet of the primary and its replicas is
+// returned. Their lag is checked before it returns and then every LagInterval for the life of the process, so
+// that replicas that fall behind or stop replicating aren't read from.
 func NewMySQL(config *Config, log log.ILog) query.DBInterface {
 	m := &MySQL{
 		config: config,
@@ -36,7 +69,55 @@ func NewMySQL(config *Config, log log.ILog) query.DBInterface {
 	}
 
 	m.connect()
-	return m
+
+	if len(config.Replicas) == 0 {
+		return m
+	}
+
+	var replicas = make([]query.DBInterface, len(config.Replicas))
+	for k := range config.Replicas {
+		replicas[k] = NewMySQL(config.replica(k), log)
+	}
+
+	var set = NewReplicaSet(m, config.MaxReplicaLag, replicas...)
+
+	var interval = config.LagInterval
+	if interval <= 0 {
+		interval = DefaultLagInterval
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), interval)
+	set.CheckLag(ctx)
+	cancel()
+
+	go set.MonitorLag(context.Background(), interval)
+
+	return set
+}
+
+// replica returns the config of replica `k`, filled in from the primary's
+func (c *Config) replica(k int) *Config {
+
+	var r = *c.Replicas[k]
+	r.Replicas = nil
+
+	if len(r.Host) == 0 {
+		r.Host = c.Host
+	}
+
+	if len(r.Name) == 0 {
+		r.Name = c.Name
+	}
+
+	if len(r.User) == 0 {
+		r.User = c.User
+	}
+
+	if len(r.Pass) == 0 {
+		r.Pass = c.Pass
+	}
+
+	return &r
 }
 
 func (m *MySQL) Host() string {
@@ -84,6 +165,26 @@ func (m *MySQL) QueryRow(query string, args ...interface{}) *sql.Row {
 	return m.db.QueryRow(query, args...)
 }
 
+// ExecContext executes a query without returning any rows, cancelled when `ctx` is done
+func (m *MySQL) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	return m.db.ExecContext(ctx, query, args...)
+}
+
+// QueryContext executes a query that returns rows, cancelled when `ctx` is done
+func (m *MySQL) QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	return m.db.QueryContext(ctx, query, args...)
+}
+
+// PrepareContext creates a prepared statement for later queries or executions
+func (m *MySQL) PrepareContext(ctx context.Context, query string) (*sql.Stmt, error) {
+	return m.db.PrepareContext(ctx, query)
+}
+
+// QueryRowContext executes a query that is expected to return at most one row, cancelled when `ctx` is done
+func (m *MySQL) QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row {
+	return m.db.QueryRowContext(ctx, query, args...)
+}
+
 // Close closes the database and prevents new queries from starting.
 // Close then waits for all queries that have started processing on the server
 // to finish.
@@ -2,7 +2,9 @@ package db
 
 import (
 	"testing"
+	"time"
 
+	"github.com/macinnir/dvc/core/lib"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -55,3 +57,25 @@ func TestBuildExecManyChunks(t *testing.T) {
 	}
 
 }
+
+func TestNewConfig(t *testing.T) {
+
+	c := NewConfig(&lib.ConfigDatabase{
+		Host:          "db",
+		Name:          "app",
+		User:          "user",
+		Pass:          "pass",
+		MaxReplicaLag: 5,
+		Replicas: []*lib.ConfigDatabaseReplica{
+			{Host: "db-replica-1"},
+			{Host: "db-replica-2", User: "reader"},
+		},
+	})
+
+	assert.Equal(t, 5*time.Second, c.MaxReplicaLag)
+	assert.Equal(t, time.Duration(0), c.LagInterval)
+	assert.Equal(t, []*Config{
+		{Host: "db-replica-1", Name: "app", User: "user", Pass: "pass"},
+		{Host: "db-replica-2", Name: "app", User: "reader", Pass: "pass"},
+	}, c.Replicas)
+}
@@ -0,0 +1,225 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	query "github.com/macinnir/goquery"
+)
+
+// ErrReplicationStopped is returned by ReplicationLag when a replica isn't replicating
+var ErrReplicationStopped = errors.New("db: replication is not running")
+
+// ReadRouter is implemented by connections that send reads somewhere other than where they send writes
+type ReadRouter interface {
+	ReadConn(ctx context.Context) query.DBInterface
+}
+
+type primaryContextKey struct{}
+
+// WithPrimary returns a context whose reads go to the primary, e.g. to read a record that was just written
+func WithPrimary(ctx context.Context) context.Context {
+	return context.WithValue(ctx, primaryContextKey{}, true)
+}
+
+// IsPrimary returns true if reads on `ctx` must go to the primary
+func IsPrimary(ctx context.Context) bool {
+	primary, _ := ctx.Value(primaryContextKey{}).(bool)
+	return primary
+}
+
+// ReadConn returns the connection a read on `conn` should run on: a replica if `conn` has them, unless `ctx` was
+// made with WithPrimary. Reads in a transaction stay in the transaction.
+func ReadConn(ctx context.Context, conn query.DBInterface) query.DBInterface {
+	if router, ok := conn.(ReadRouter); ok && !IsPrimary(ctx) {
+		return router.ReadConn(ctx)
+	}
+	return conn
+}
+
+// ReplicaSet is a primary connection and its read replicas. Everything run on it goes to the primary; reads routed
+// with ReadConn go to a replica that is less than the max lag behind.
+type ReplicaSet struct {
+	query.DBInterface
+	replicas []*replica
+	maxLag   time.Duration
+	next     uint32
+}
+
+type replica struct {
+	conn query.DBInterface
+	// lag in nanoseconds, or -1 if the replica is unavailable
+	lag int64
+}
+
+// NewReplicaSet returns a ReplicaSet. A `maxLag` of zero accepts any lag, but replicas that stopped replicating
+// are still skipped once CheckLag has run.
+func NewReplicaSet(primary query.DBInterface, maxLag time.Duration, replicas ...query.DBInterface) *ReplicaSet {
+
+	r := &ReplicaSet{
+		DBInterface: primary,
+		replicas:    make([]*replica, len(replicas)),
+		maxLag:      maxLag,
+	}
+
+	for k := range replicas {
+		r.replicas[k] = &replica{conn: replicas[k]}
+	}
+
+	return r
+}
+
+// Primary returns the primary connection
+func (r *ReplicaSet) Primary() query.DBInterface {
+	return r.DBInterface
+}
+
+// ReadConn returns the next replica (round robin) whose lag is acceptable, or the primary if there is none
+func (r *ReplicaSet) ReadConn(ctx context.Context) query.DBInterface {
+
+	var n = uint32(len(r.replicas))
+	if n == 0 {
+		return r.DBInterface
+	}
+
+	var start = atomic.AddUint32(&r.next, 1)
+
+	for k := uint32(0); k < n; k++ {
+		rep := r.replicas[(start+k)%n]
+		lag := atomic.LoadInt64(&rep.lag)
+		if lag >= 0 && (r.maxLag == 0 || time.Duration(lag) <= r.maxLag) {
+			return rep.conn
+		}
+	}
+
+	return r.DBInterface
+}
+
+// SetLag records the lag of replica `k`. A negative lag marks the replica as unavailable.
+func (r *ReplicaSet) SetLag(k int, lag time.Duration) {
+	if lag < 0 {
+		lag = -1
+	}
+	atomic.StoreInt64(&r.replicas[k].lag, int64(lag))
+}
+
+// CheckLag measures the lag of each replica. Replicas whose lag can't be read are marked as unavailable until the
+// next check.
+func (r *ReplicaSet) CheckLag(ctx context.Context) {
+
+	var wg sync.WaitGroup
+
+	for k := range r.replicas {
+		wg.Add(1)
+		go func(k int) {
+			defer wg.Done()
+			lag, e := ReplicationLag(ctx, r.replicas[k].conn)
+			if e != nil {
+				lag = -1
+			}
+			r.SetLag(k, lag)
+		}(k)
+	}
+
+	wg.Wait()
+}
+
+// MonitorLag runs CheckLag every `interval` until `ctx` is done
+func (r *ReplicaSet) MonitorLag(ctx context.Context, interval time.Duration) {
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		r.CheckLag(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// ExecContext executes a query on the primary
+func (r *ReplicaSet) ExecContext(ctx context.Context, q string, args ...interface{}) (sql.Result, error) {
+	return ExecContext(ctx, r.DBInterface, q, args...)
+}
+
+// QueryContext runs a query on the primary
+func (r *ReplicaSet) QueryContext(ctx context.Context, q string, args ...interface{}) (*sql.Rows, error) {
+	return QueryContext(ctx, r.DBInterface, q, args...)
+}
+
+// QueryRowContext runs a query expected to return at most one row on the primary
+func (r *ReplicaSet) QueryRowContext(ctx context.Context, q string, args ...interface{}) *sql.Row {
+	return QueryRowContext(ctx, r.DBInterface, q, args...)
+}
+
+// PrepareContext prepares a statement on the primary
+func (r *ReplicaSet) PrepareContext(ctx context.Context, q string) (*sql.Stmt, error) {
+	if p, ok := r.DBInterface.(preparer); ok {
+		return p.PrepareContext(ctx, q)
+	}
+	return nil, errors.New("db: the primary can't prepare statements")
+}
+
+// ReplicationLag returns how far behind its source a replica is (Seconds_Behind_Source)
+func ReplicationLag(ctx context.Context, conn query.DBInterface) (time.Duration, error) {
+
+	rows, e := QueryContext(ctx, conn, "SHOW REPLICA STATUS")
+	if e != nil {
+		// MySQL < 8.0.22 and MariaDB < 10.5
+		if rows, e = QueryContext(ctx, conn, "SHOW SLAVE STATUS"); e != nil {
+			return 0, e
+		}
+	}
+
+	defer rows.Close()
+
+	cols, e := rows.Columns()
+	if e != nil {
+		return 0, e
+	}
+
+	if !rows.Next() {
+		if e = rows.Err(); e != nil {
+			return 0, e
+		}
+		return 0, ErrReplicationStopped
+	}
+
+	var values = make([]sql.RawBytes, len(cols))
+	var dest = make([]interface{}, len(cols))
+	for k := range values {
+		dest[k] = &values[k]
+	}
+
+	if e = rows.Scan(dest...); e != nil {
+		return 0, e
+	}
+
+	for k := range cols {
+		if cols[k] != "Seconds_Behind_Source" && cols[k] != "Seconds_Behind_Master" {
+			continue
+		}
+
+		// NULL when the replication threads aren't running
+		if values[k] == nil {
+			return 0, ErrReplicationStopped
+		}
+
+		seconds, e := strconv.ParseInt(string(values[k]), 10, 64)
+		if e != nil {
+			return 0, e
+		}
+
+		return time.Duration(seconds) * time.Second, nil
+	}
+
+	return 0, ErrReplicationStopped
+}
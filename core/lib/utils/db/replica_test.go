@@ -0,0 +1,87 @@
+package db
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	query "github.com/macinnir/goquery"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReplicaSet_ReadConn(t *testing.T) {
+
+	primary, a, b := &recordingDB{}, &recordingDB{}, &recordingDB{}
+	r := NewReplicaSet(primary, time.Second, a, b)
+
+	first := r.ReadConn(context.Background())
+	second := r.ReadConn(context.Background())
+
+	assert.NotSame(t, first, second)
+	assert.ElementsMatch(t, []query.DBInterface{a, b}, []query.DBInterface{first, second})
+	assert.Same(t, primary, r.Primary())
+}
+
+func TestReplicaSet_ReadConn_Lag(t *testing.T) {
+
+	primary, a, b := &recordingDB{}, &recordingDB{}, &recordingDB{}
+	r := NewReplicaSet(primary, time.Second, a, b)
+
+	r.SetLag(0, 5*time.Second)
+	assert.Same(t, b, r.ReadConn(context.Background()))
+	assert.Same(t, b, r.ReadConn(context.Background()))
+
+	r.SetLag(1, -1)
+	assert.Same(t, primary, r.ReadConn(context.Background()))
+
+	r.SetLag(0, 0)
+	assert.Same(t, a, r.ReadConn(context.Background()))
+}
+
+func TestReplicaSet_NoReplicas(t *testing.T) {
+	primary := &recordingDB{}
+	assert.Same(t, primary, NewReplicaSet(primary, 0).ReadConn(context.Background()))
+}
+
+func TestReadConn(t *testing.T) {
+
+	primary, replica := &recordingDB{}, &recordingDB{}
+	r := NewReplicaSet(primary, 0, replica)
+
+	assert.Same(t, replica, ReadConn(context.Background(), r))
+	assert.Same(t, r, ReadConn(WithPrimary(context.Background()), r))
+	assert.True(t, IsPrimary(WithPrimary(context.Background())))
+
+	// Not a replica set
+	assert.Same(t, primary, ReadConn(context.Background(), primary))
+}
+
+func TestReplicaSet_ExecOnPrimary(t *testing.T) {
+
+	primary, log := newRecordingDB(t)
+	replica, replicaLog := newRecordingDB(t)
+	r := NewReplicaSet(primary, 0, replica)
+
+	_, e := ExecContext(context.Background(), r, "DELETE FROM `Foo` WHERE `FooID` = ?", 1)
+
+	assert.Nil(t, e)
+	assert.Equal(t, []string{"DELETE FROM `Foo` WHERE `FooID` = ?"}, *log)
+	assert.Empty(t, *replicaLog)
+}
+
+func TestStatementCache_ReadConn(t *testing.T) {
+
+	primary, replica := &recordingDB{}, &recordingDB{}
+	c := NewStatementCache(NewReplicaSet(primary, 0, replica))
+
+	read := c.ReadConn(context.Background())
+	assert.IsType(t, &StatementCache{}, read)
+	assert.Same(t, replica, read.(*StatementCache).DBInterface)
+	assert.Same(t, read, c.ReadConn(context.Background()))
+
+	assert.Same(t, c, ReadConn(WithPrimary(context.Background()), c))
+
+	// No replicas
+	single := NewStatementCache(primary)
+	assert.Same(t, single, single.ReadConn(context.Background()))
+}
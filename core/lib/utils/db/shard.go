@@ -0,0 +1,217 @@
+package db
+
+import (
+	"context"
+	"database/sql/driver"
+	"fmt"
+	"hash/fnv"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/macinnir/dvc/core/lib/utils/shard"
+	query "github.com/macinnir/goquery"
+)
+
+type shardKeyContextKey struct{}
+
+// WithShardKey returns a context that routes queries on sharded tables to the shard holding `key`, instead of the
+// shard chosen from the record or every shard
+func WithShardKey(ctx context.Context, key interface{}) context.Context {
+	return context.WithValue(ctx, shardKeyContextKey{}, key)
+}
+
+// ShardKeyFromContext returns the shard key set with WithShardKey
+func ShardKeyFromContext(ctx context.Context) (interface{}, bool) {
+	key := ctx.Value(shardKeyContextKey{})
+	return key, key != nil
+}
+
+// ShardFromID returns the index of the shard holding `id` out of `n` shards, using the shard bits of the
+// utils/shard ID layout
+func ShardFromID(id int64, n int) int {
+	if n < 2 {
+		return 0
+	}
+	return int(shard.NewShardIDFromID(id).Shard() % int64(n))
+}
+
+// ShardFromKey returns the index of the shard for a shard key value out of `n` shards. Integers are taken modulo
+// `n` and anything else is hashed.
+func ShardFromKey(key interface{}, n int) int {
+
+	if n < 2 {
+		return 0
+	}
+
+	if i, ok := toInt64(key); ok {
+		if i < 0 {
+			i = -i
+		}
+		return int(i % int64(n))
+	}
+
+	h := fnv.New32a()
+	h.Write([]byte(fmt.Sprint(key)))
+	return int(h.Sum32() % uint32(n))
+}
+
+// ShardIndex returns the index of the shard for `key`. If `byID` is true the key is a primary key built with
+// utils/shard.
+func ShardIndex(key interface{}, n int, byID bool) int {
+	if id, ok := toInt64(key); ok && byID {
+		return ShardFromID(id, n)
+	}
+	return ShardFromKey(key, n)
+}
+
+func toInt64(v interface{}) (int64, bool) {
+	switch t := v.(type) {
+	case int64:
+		return t, true
+	case int:
+		return int64(t), true
+	case int32:
+		return int64(t), true
+	case int16:
+		return int64(t), true
+	case int8:
+		return int64(t), true
+	case uint64:
+		return int64(t), true
+	case uint32:
+		return int64(t), true
+	case uint:
+		return int64(t), true
+	case driver.Valuer:
+		if value, e := t.Value(); e == nil {
+			return toInt64(value)
+		}
+	}
+	return 0, false
+}
+
+// FanOut runs `fn` on every connection concurrently and returns the first error
+func FanOut(ctx context.Context, conns []query.DBInterface, fn func(shardID int, conn query.DBInterface) error) error {
+
+	if len(conns) == 1 {
+		return fn(0, conns[0])
+	}
+
+	var wg sync.WaitGroup
+	var errs = make([]error, len(conns))
+
+	for k := range conns {
+		wg.Add(1)
+		go func(k int) {
+			defer wg.Done()
+			errs[k] = fn(k, conns[k])
+		}(k)
+	}
+
+	wg.Wait()
+
+	for k := range errs {
+		if errs[k] != nil {
+			return fmt.Errorf("shard %d: %w", k, errs[k])
+		}
+	}
+
+	return nil
+}
+
+// ExecEach executes a query on each connection, e.g. an update by primary key on every shard that might hold the
+// record
+func ExecEach(ctx context.Context, conns []query.DBInterface, q string, args ...interface{}) error {
+	for k := range conns {
+		if _, e := ExecContext(ctx, conns[k], q, args...); e != nil {
+			return e
+		}
+	}
+	return nil
+}
+
+// MergeShardRows merges the rows selected from each shard with the statement's ShardSQL, re-applying its ORDER BY
+// and LIMIT. `value` returns the value of a column of a row.
+func MergeShardRows[T any](s *SelectStatement, shards [][]T, value func(row T, col query.Column) interface{}) []T {
+
+	var rows = []T{}
+	for k := range shards {
+		rows = append(rows, shards[k]...)
+	}
+
+	if len(s.orderBy) > 0 {
+		sort.SliceStable(rows, func(i, j int) bool {
+			for _, o := range s.orderBy {
+				c := compareValues(value(rows[i], o.col), value(rows[j], o.col))
+				if c == 0 {
+					continue
+				}
+				return (c < 0) != o.desc
+			}
+			return false
+		})
+	}
+
+	if !s.hasLimit {
+		return rows
+	}
+
+	if s.offset >= int64(len(rows)) {
+		return []T{}
+	}
+
+	var end = s.offset + s.limit
+	if end > int64(len(rows)) {
+		end = int64(len(rows))
+	}
+
+	return rows[s.offset:end]
+}
+
+// compareValues orders two column values. NULL sorts first, as in MySQL.
+func compareValues(a, b interface{}) int {
+
+	if v, ok := a.(driver.Valuer); ok {
+		a, _ = v.Value()
+	}
+
+	if v, ok := b.(driver.Valuer); ok {
+		b, _ = v.Value()
+	}
+
+	switch {
+	case a == nil && b == nil:
+		return 0
+	case a == nil:
+		return -1
+	case b == nil:
+		return 1
+	}
+
+	if x, ok := toInt64(a); ok {
+		if y, ok := toInt64(b); ok {
+			switch {
+			case x < y:
+				return -1
+			case x > y:
+				return 1
+			}
+			return 0
+		}
+	}
+
+	if x, ok := a.(float64); ok {
+		if y, ok := b.(float64); ok {
+			switch {
+			case x < y:
+				return -1
+			case x > y:
+				return 1
+			}
+			return 0
+		}
+	}
+
+	return strings.Compare(fmt.Sprint(a), fmt.Sprint(b))
+}
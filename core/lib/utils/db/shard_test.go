@@ -0,0 +1,106 @@
+package db
+
+import (
+	"context"
+	"testing"
+
+	"github.com/macinnir/dvc/core/lib/utils/shard"
+	query "github.com/macinnir/goquery"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestShardFromID(t *testing.T) {
+	id := shard.NewShardID(5, 1).ID()
+	assert.Equal(t, 5, ShardFromID(id, 8))
+	assert.Equal(t, 1, ShardFromID(id, 4))
+	assert.Equal(t, 0, ShardFromID(id, 1))
+}
+
+func TestShardFromKey(t *testing.T) {
+	assert.Equal(t, 3, ShardFromKey(int64(11), 4))
+	assert.Equal(t, 3, ShardFromKey(-11, 4))
+	assert.Equal(t, ShardFromKey("acme", 4), ShardFromKey("acme", 4))
+	assert.True(t, ShardFromKey("acme", 4) < 4)
+	assert.Equal(t, 0, ShardFromKey("acme", 1))
+}
+
+func TestShardIndex(t *testing.T) {
+	id := shard.NewShardID(2, 1).ID()
+	assert.Equal(t, 2, ShardIndex(id, 4, true))
+	assert.Equal(t, int(id%4), ShardIndex(id, 4, false))
+}
+
+func TestWithShardKey(t *testing.T) {
+
+	_, ok := ShardKeyFromContext(context.Background())
+	assert.False(t, ok)
+
+	key, ok := ShardKeyFromContext(WithShardKey(context.Background(), int64(3)))
+	assert.True(t, ok)
+	assert.Equal(t, int64(3), key)
+}
+
+type shardRow struct {
+	Name  string
+	Score int64
+}
+
+func shardRowValue(row *shardRow, col query.Column) interface{} {
+	switch col {
+	case "Name":
+		return row.Name
+	case "Score":
+		return row.Score
+	}
+	return nil
+}
+
+func TestSelectStatement_ShardSQL(t *testing.T) {
+
+	q, _ := NewSelect("User", "`UserID`").
+		OrderBy("Email", query.OrderDirFromString("ASC")).
+		Limit(10, 20).
+		ShardSQL()
+
+	assert.Equal(t, "SELECT `UserID` FROM `User` ORDER BY `Email` ASC LIMIT 0, 30", q)
+}
+
+func TestMergeShardRows(t *testing.T) {
+
+	s := NewSelect("Foo", "`Name`", "`Score`").
+		OrderBy("Score", query.OrderDirFromString("DESC")).
+		OrderBy("Name", query.OrderDirFromString("ASC")).
+		Limit(3, 1)
+
+	rows := MergeShardRows(s, [][]*shardRow{
+		{{"a", 5}, {"d", 1}},
+		{{"b", 7}, {"c", 5}},
+		{},
+	}, shardRowValue)
+
+	assert.Equal(t, []*shardRow{{"a", 5}, {"c", 5}, {"d", 1}}, rows)
+}
+
+func TestMergeShardRows_PastTheEnd(t *testing.T) {
+
+	s := NewSelect("Foo", "`Name`").Limit(10, 5)
+
+	rows := MergeShardRows(s, [][]*shardRow{{{"a", 1}}, {{"b", 2}}}, shardRowValue)
+
+	assert.Equal(t, []*shardRow{}, rows)
+}
+
+func TestFanOut(t *testing.T) {
+
+	conns := []query.DBInterface{&recordingDB{}, &recordingDB{}, &recordingDB{}}
+	seen := make([]bool, len(conns))
+
+	e := FanOut(context.Background(), conns, func(shardID int, conn query.DBInterface) error {
+		assert.Equal(t, conns[shardID], conn)
+		seen[shardID] = true
+		return nil
+	})
+
+	assert.Nil(t, e)
+	assert.Equal(t, []bool{true, true, true}, seen)
+}
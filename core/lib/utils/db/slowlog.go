@@ -0,0 +1,40 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/macinnir/dvc/core/lib/utils/log"
+)
+
+// SlowQueryLog is an Instrument that logs a warning for each query that takes at least its threshold to run
+type SlowQueryLog struct {
+	log       log.ILog
+	threshold time.Duration
+}
+
+// NewSlowQueryLog returns a SlowQueryLog that logs queries slower than `threshold` to `log`
+func NewSlowQueryLog(log log.ILog, threshold time.Duration) *SlowQueryLog {
+	return &SlowQueryLog{log: log, threshold: threshold}
+}
+
+// BeforeQuery does nothing
+func (s *SlowQueryLog) BeforeQuery(ctx context.Context, event *QueryEvent) context.Context {
+	return ctx
+}
+
+// AfterQuery logs `event` if it took at least the threshold
+func (s *SlowQueryLog) AfterQuery(ctx context.Context, event *QueryEvent) {
+
+	if event.Duration < s.threshold {
+		return
+	}
+
+	var status = "ok"
+	if event.Err != nil {
+		status = event.Err.Error()
+	}
+
+	s.log.Warn(fmt.Sprintf("Slow query (%s) on %s: %s; rows: %d; %s", event.Duration, event.Table, event.Fingerprint, event.RowsAffected, status))
+}
@@ -0,0 +1,388 @@
+package db
+
+import (
+	"strconv"
+	"strings"
+
+	query "github.com/macinnir/goquery"
+)
+
+// Condition is a WHERE clause fragment with `?` placeholders and the values bound to them
+type Condition struct {
+	SQL  string
+	Args []interface{}
+}
+
+// quote backtick-quotes a column name
+func quote(col query.Column) string {
+	return "`" + string(col) + "`"
+}
+
+func compare(col query.Column, operator string, value interface{}) Condition {
+	return Condition{quote(col) + " " + operator + " ?", []interface{}{value}}
+}
+
+// EQ is `col` = value
+func EQ(col query.Column, value interface{}) Condition { return compare(col, "=", value) }
+
+// NE is `col` <> value
+func NE(col query.Column, value interface{}) Condition { return compare(col, "<>", value) }
+
+// GT is `col` > value
+func GT(col query.Column, value interface{}) Condition { return compare(col, ">", value) }
+
+// GTE is `col` >= value
+func GTE(col query.Column, value interface{}) Condition { return compare(col, ">=", value) }
+
+// LT is `col` < value
+func LT(col query.Column, value interface{}) Condition { return compare(col, "<", value) }
+
+// LTE is `col` <= value
+func LTE(col query.Column, value interface{}) Condition { return compare(col, "<=", value) }
+
+// Like is `col` LIKE value
+func Like(col query.Column, value string) Condition { return compare(col, "LIKE", value) }
+
+// IsNull is `col` IS NULL
+func IsNull(col query.Column) Condition { return Condition{SQL: quote(col) + " IS NULL"} }
+
+// IsNotNull is `col` IS NOT NULL
+func IsNotNull(col query.Column) Condition { return Condition{SQL: quote(col) + " IS NOT NULL"} }
+
+// IN is `col` IN (values...). An empty list matches nothing.
+func IN(col query.Column, values ...interface{}) Condition {
+
+	if len(values) == 0 {
+		return Condition{SQL: "1 = 0"}
+	}
+
+	return Condition{
+		SQL:  quote(col) + " IN (" + Placeholders(len(values)) + ")",
+		Args: values,
+	}
+}
+
+// INInt64 is IN for a slice of int64 values
+func INInt64(col query.Column, values ...int64) Condition {
+	var args = make([]interface{}, len(values))
+	for k := range values {
+		args[k] = values[k]
+	}
+	return IN(col, args...)
+}
+
+// INInt is IN for a slice of int values
+func INInt(col query.Column, values ...int) Condition {
+	var args = make([]interface{}, len(values))
+	for k := range values {
+		args[k] = values[k]
+	}
+	return IN(col, args...)
+}
+
+// INString is IN for a slice of string values
+func INString(col query.Column, values ...string) Condition {
+	var args = make([]interface{}, len(values))
+	for k := range values {
+		args[k] = values[k]
+	}
+	return IN(col, args...)
+}
+
+// Or joins conditions with OR
+func Or(conditions ...Condition) Condition {
+
+	var parts = make([]string, len(conditions))
+	var args = []interface{}{}
+
+	for k := range conditions {
+		parts[k] = conditions[k].SQL
+		args = append(args, conditions[k].Args...)
+	}
+
+	return Condition{"(" + strings.Join(parts, " OR ") + ")", args}
+}
+
+// Expr is a raw condition, e.g. Expr("`A` > `B` + ?", 1)
+func Expr(sql string, args ...interface{}) Condition {
+	return Condition{sql, args}
+}
+
+// Placeholders returns `n` comma separated placeholders
+func Placeholders(n int) string {
+	if n < 1 {
+		return ""
+	}
+	return strings.Repeat("?,", n-1) + "?"
+}
+
+// OrderDirSQL returns the SQL keyword for a sort direction
+func OrderDirSQL(dir query.OrderDir) string {
+	if dir == query.OrderDirFromString("DESC") {
+		return "DESC"
+	}
+	return "ASC"
+}
+
+// WhereFromQuery returns the WHERE clause (without the keyword) of a query string built by goquery, so that
+// where parts built with goquery can be combined with bound conditions
+func WhereFromQuery(ql string) string {
+	var k = strings.Index(ql, " WHERE ")
+	if k < 0 {
+		return ""
+	}
+	return strings.TrimSpace(ql[k+len(" WHERE "):])
+}
+
+// orderColumn is a column in an ORDER BY clause
+type orderColumn struct {
+	col  query.Column
+	desc bool
+}
+
+// SelectStatement builds a SELECT statement whose values are bound as parameters
+type SelectStatement struct {
+	table    query.TableName
+	alias    string
+	fields   []string
+	where    []Condition
+	groupBy  []query.Column
+	orderBy  []orderColumn
+	limit    int64
+	offset   int64
+	hasLimit bool
+	page     *keyset
+}
+
+// NewSelect returns a SELECT statement on `table` for `fields`, which are SQL expressions (e.g. "`Name`")
+func NewSelect(table query.TableName, fields ...string) *SelectStatement {
+	return &SelectStatement{
+		table:  table,
+		fields: fields,
+	}
+}
+
+// Clone returns a copy of the statement that can be changed without changing it
+func (s *SelectStatement) Clone() *SelectStatement {
+	var clone = *s
+	clone.fields = append([]string{}, s.fields...)
+	clone.where = append([]Condition{}, s.where...)
+	clone.groupBy = append([]query.Column{}, s.groupBy...)
+	clone.orderBy = append([]orderColumn{}, s.orderBy...)
+	return &clone
+}
+
+// Alias sets the table alias
+func (s *SelectStatement) Alias(alias string) *SelectStatement {
+	s.alias = alias
+	return s
+}
+
+// Fields replaces the selected fields
+func (s *SelectStatement) Fields(fields ...string) *SelectStatement {
+	s.fields = fields
+	return s
+}
+
+// Where adds conditions, joined with AND
+func (s *SelectStatement) Where(conditions ...Condition) *SelectStatement {
+	s.where = append(s.where, conditions...)
+	return s
+}
+
+// GroupBy adds GROUP BY columns
+func (s *SelectStatement) GroupBy(cols ...query.Column) *SelectStatement {
+	s.groupBy = append(s.groupBy, cols...)
+	return s
+}
+
+// OrderBy adds a sort column
+func (s *SelectStatement) OrderBy(col query.Column, dir query.OrderDir) *SelectStatement {
+	s.orderBy = append(s.orderBy, orderColumn{col, OrderDirSQL(dir) == "DESC"})
+	return s
+}
+
+// Limit sets the LIMIT and OFFSET
+func (s *SelectStatement) Limit(limit, offset int64) *SelectStatement {
+	s.limit = limit
+	s.offset = offset
+	s.hasLimit = true
+	return s
+}
+
+// SQL returns the statement and its arguments. `extraWhere` is ANDed with the statement's conditions.
+func (s *SelectStatement) SQL(extraWhere ...Condition) (string, []interface{}) {
+	return s.sql(s.offset, s.limit, extraWhere)
+}
+
+// ShardSQL is SQL for a query run on each shard: rows up to the end of the page are selected so that the page can be
+// cut from the merged rows (see MergeShardRows)
+func (s *SelectStatement) ShardSQL(extraWhere ...Condition) (string, []interface{}) {
+	return s.sql(0, s.offset+s.limit, extraWhere)
+}
+
+func (s *SelectStatement) sql(offset, limit int64, extraWhere []Condition) (string, []interface{}) {
+
+	var sb strings.Builder
+	var args = []interface{}{}
+
+	sb.WriteString("SELECT " + strings.Join(s.fields, ", ") + " FROM `" + string(s.table) + "`")
+
+	if len(s.alias) > 0 {
+		sb.WriteString(" `" + s.alias + "`")
+	}
+
+	var where = []Condition{}
+	for _, c := range append(append([]Condition{}, s.where...), extraWhere...) {
+		if len(c.SQL) > 0 {
+			where = append(where, c)
+		}
+	}
+
+	if len(where) > 0 {
+		var parts = make([]string, len(where))
+		for k := range where {
+			parts[k] = where[k].SQL
+			if len(where) > 1 {
+				parts[k] = "(" + parts[k] + ")"
+			}
+			args = append(args, where[k].Args...)
+		}
+		sb.WriteString(" WHERE " + strings.Join(parts, " AND "))
+	}
+
+	if len(s.groupBy) > 0 {
+		var cols = make([]string, len(s.groupBy))
+		for k := range s.groupBy {
+			cols[k] = quote(s.groupBy[k])
+		}
+		sb.WriteString(" GROUP BY " + strings.Join(cols, ", "))
+	}
+
+	if len(s.orderBy) > 0 {
+		var cols = make([]string, len(s.orderBy))
+		for k := range s.orderBy {
+			cols[k] = quote(s.orderBy[k].col) + " ASC"
+			if s.orderBy[k].desc {
+				cols[k] = quote(s.orderBy[k].col) + " DESC"
+			}
+		}
+		sb.WriteString(" ORDER BY " + strings.Join(cols, ", "))
+	}
+
+	if s.hasLimit {
+		sb.WriteString(" LIMIT " + strconv.FormatInt(offset, 10) + ", " + strconv.FormatInt(limit, 10))
+	}
+
+	return sb.String(), args
+}
+
+// Aggregate returns an aggregate field, e.g. Aggregate("SUM", "Total") is SUM(`Total`) AS `c`
+func Aggregate(fn string, col query.Column) string {
+	return fn + "(" + quote(col) + ") AS `c`"
+}
+
+// QueryWhere returns the where parts added to a goquery select as a condition, with the values goquery wrote into
+// the query bound as parameters (see BindLiterals) so that queries that differ only in their values share a prepared
+// statement. `pk` is selected so that the query renders.
+func QueryWhere(q *query.Q, pk query.Column) (Condition, error) {
+
+	q.Fields(query.NewField(query.FieldTypeBasic, pk))
+
+	var ql, e = q.String()
+	if e != nil {
+		return Condition{}, e
+	}
+
+	return BindLiterals(WhereFromQuery(ql)), nil
+}
+
+// BindLiterals returns a condition of `sql` with its string and number literals replaced by placeholders and bound
+// as its arguments. Identifiers (in backticks) and other literals (e.g. NULL) are kept as they are.
+func BindLiterals(sql string) Condition {
+
+	var b strings.Builder
+	b.Grow(len(sql))
+
+	var args = []interface{}{}
+
+	for k := 0; k < len(sql); k++ {
+
+		var c = sql[k]
+
+		switch {
+		case c == '`':
+			var end = strings.IndexByte(sql[k+1:], '`')
+			if end < 0 {
+				b.WriteString(sql[k:])
+				k = len(sql)
+				continue
+			}
+			b.WriteString(sql[k : k+end+2])
+			k += end + 1
+		case c == '\'' || c == '"':
+			var value strings.Builder
+			for k++; k < len(sql); k++ {
+				if sql[k] == '\\' && k+1 < len(sql) {
+					k++
+					value.WriteString(unescapeLiteral(sql[k]))
+					continue
+				}
+				if sql[k] == c {
+					if k+1 < len(sql) && sql[k+1] == c {
+						k++
+						value.WriteByte(c)
+						continue
+					}
+					break
+				}
+				value.WriteByte(sql[k])
+			}
+			b.WriteByte('?')
+			args = append(args, value.String())
+		case c >= '0' && c <= '9' && (k == 0 || !isWordByte(sql[k-1])):
+			var start = k
+			for k+1 < len(sql) && (isWordByte(sql[k+1]) || sql[k+1] == '.' ||
+				((sql[k+1] == '-' || sql[k+1] == '+') && (sql[k] == 'e' || sql[k] == 'E'))) {
+				k++
+			}
+			var literal = sql[start : k+1]
+			if i, e := strconv.ParseInt(literal, 10, 64); e == nil {
+				b.WriteByte('?')
+				args = append(args, i)
+			} else if f, e := strconv.ParseFloat(literal, 64); e == nil {
+				b.WriteByte('?')
+				args = append(args, f)
+			} else {
+				// e.g. a hex literal
+				b.WriteString(literal)
+			}
+		default:
+			b.WriteByte(c)
+		}
+	}
+
+	return Condition{b.String(), args}
+}
+
+// unescapeLiteral returns the character escaped by a backslash in a MySQL string literal. \% and \_ keep their
+// backslash, as MySQL does for LIKE patterns.
+func unescapeLiteral(c byte) string {
+	switch c {
+	case '0':
+		return "\x00"
+	case 'b':
+		return "\b"
+	case 'n':
+		return "\n"
+	case 'r':
+		return "\r"
+	case 't':
+		return "\t"
+	case 'Z':
+		return "\x1a"
+	case '%', '_':
+		return "\\" + string(c)
+	}
+	return string(c)
+}
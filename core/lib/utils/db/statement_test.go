@@ -0,0 +1,54 @@
+package db
+
+import (
+	"testing"
+
+	query "github.com/macinnir/goquery"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSelectStatement_SQL(t *testing.T) {
+
+	q, args := NewSelect("User", "`UserID`", "`Email`").
+		Where(EQ("AccountID", int64(3)), INInt64("UserID", 1, 2)).
+		OrderBy("Email", query.OrderDirFromString("ASC")).
+		Limit(10, 20).
+		SQL()
+
+	assert.Equal(t, "SELECT `UserID`, `Email` FROM `User` WHERE (`AccountID` = ?) AND (`UserID` IN (?,?)) ORDER BY `Email` ASC LIMIT 20, 10", q)
+	assert.Equal(t, []interface{}{int64(3), int64(1), int64(2)}, args)
+}
+
+func TestSelectStatement_SQLExtraWhere(t *testing.T) {
+
+	q, args := NewSelect("User", Aggregate("COUNT", "UserID")).
+		Alias("u").
+		Where(Like("Email", "a%")).
+		SQL(Condition{SQL: "`IsDeleted` = 0"}, Condition{})
+
+	assert.Equal(t, "SELECT COUNT(`UserID`) AS `c` FROM `User` `u` WHERE (`Email` LIKE ?) AND (`IsDeleted` = 0)", q)
+	assert.Equal(t, []interface{}{"a%"}, args)
+}
+
+func TestIN_Empty(t *testing.T) {
+	assert.Equal(t, Condition{SQL: "1 = 0"}, IN("UserID"))
+}
+
+func TestOr(t *testing.T) {
+	c := Or(EQ("A", 1), IsNull("B"), GT("C", 2))
+	assert.Equal(t, "(`A` = ? OR `B` IS NULL OR `C` > ?)", c.SQL)
+	assert.Equal(t, []interface{}{1, 2}, c.Args)
+}
+
+func TestWhereFromQuery(t *testing.T) {
+	assert.Equal(t, "`A` = 1 AND `B` = 'x'", WhereFromQuery("SELECT `A` FROM `T` WHERE `A` = 1 AND `B` = 'x'"))
+	assert.Equal(t, "", WhereFromQuery("SELECT `A` FROM `T`"))
+}
+
+func TestBindLiterals(t *testing.T) {
+
+	c := BindLiterals("`A1` = 1 AND `B` IN ('x', 'it''s', 'a\\'b') AND `C` > -2.5 AND `D` LIKE 'a\\%' AND `E` IS NULL AND `F` = 0x1F AND `G` = 1e-3")
+
+	assert.Equal(t, "`A1` = ? AND `B` IN (?, ?, ?) AND `C` > -? AND `D` LIKE ? AND `E` IS NULL AND `F` = 0x1F AND `G` = ?", c.SQL)
+	assert.Equal(t, []interface{}{int64(1), "x", "it's", "a'b", 2.5, "a\\%", 0.001}, c.Args)
+}
@@ -0,0 +1,241 @@
+package db
+
+import (
+	"container/list"
+	"context"
+	"database/sql"
+	"sync"
+
+	query "github.com/macinnir/goquery"
+)
+
+// preparer is implemented by connections that can prepare statements (e.g. *sql.DB and *MySQL)
+type preparer interface {
+	PrepareContext(ctx context.Context, query string) (*sql.Stmt, error)
+}
+
+// DefaultStatementCacheSize is the number of statements a StatementCache from NewStatementCache keeps prepared
+var DefaultStatementCacheSize = 256
+
+// StatementCache wraps a connection and keeps a prepared statement for each of the distinct queries run through it
+// most recently, up to its size. The least recently used statement is closed to make room for a new one, so that
+// a cache never holds more than its size of the server's max_prepared_stmt_count. Connections that can't prepare
+// statements are used directly.
+type StatementCache struct {
+	query.DBInterface
+	mu       sync.Mutex
+	size     int
+	stmts    map[string]*list.Element
+	lru      *list.List
+	replicas map[query.DBInterface]*StatementCache
+}
+
+// cachedStmt is a statement in a StatementCache. A statement evicted while in use is closed once the last use ends.
+type cachedStmt struct {
+	q       string
+	stmt    *sql.Stmt
+	uses    int
+	evicted bool
+}
+
+// NewStatementCache returns a StatementCache for `conn` of DefaultStatementCacheSize
+func NewStatementCache(conn query.DBInterface) *StatementCache {
+	return NewStatementCacheSize(conn, DefaultStatementCacheSize)
+}
+
+// NewStatementCacheSize returns a StatementCache for `conn` that keeps up to `size` statements prepared
+func NewStatementCacheSize(conn query.DBInterface, size int) *StatementCache {
+
+	if size < 1 {
+		size = 1
+	}
+
+	return &StatementCache{
+		DBInterface: conn,
+		size:        size,
+		stmts:       map[string]*list.Element{},
+		lru:         list.New(),
+		replicas:    map[query.DBInterface]*StatementCache{},
+	}
+}
+
+// ReadConn returns a StatementCache for the replica a read should run on if the connection has replicas (see
+// ReplicaSet)
+func (c *StatementCache) ReadConn(ctx context.Context) query.DBInterface {
+
+	var router, ok = c.DBInterface.(ReadRouter)
+	if !ok {
+		return c
+	}
+
+	var conn = router.ReadConn(ctx)
+	if conn == c.DBInterface {
+		return c
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if _, ok = c.replicas[conn]; !ok {
+		c.replicas[conn] = NewStatementCacheSize(conn, c.size)
+	}
+
+	return c.replicas[conn]
+}
+
+// prepare returns the cached statement for `q`, preparing it on first use, or nil if the connection can't prepare
+// statements. The statement is in use until it is passed to release.
+func (c *StatementCache) prepare(ctx context.Context, q string) (*cachedStmt, error) {
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if elem, ok := c.stmts[q]; ok {
+		c.lru.MoveToFront(elem)
+		var cached = elem.Value.(*cachedStmt)
+		cached.uses++
+		return cached, nil
+	}
+
+	// Statements are prepared under an Instrumented, whose instrument is then reported to by the cache
+	var conn = c.DBInterface
+	if i, ok := conn.(*Instrumented); ok {
+		conn = i.DBInterface
+	}
+
+	var p, ok = conn.(preparer)
+	if !ok {
+		return nil, nil
+	}
+
+	var stmt, e = p.PrepareContext(ctx, q)
+	if e != nil {
+		return nil, e
+	}
+
+	var cached = &cachedStmt{q: q, stmt: stmt, uses: 1}
+	c.stmts[q] = c.lru.PushFront(cached)
+
+	for c.lru.Len() > c.size {
+		c.evict(c.lru.Back())
+	}
+
+	return cached, nil
+}
+
+// release ends a use of a statement returned by prepare
+func (c *StatementCache) release(cached *cachedStmt) {
+
+	if cached == nil {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	cached.uses--
+	if cached.evicted && cached.uses == 0 {
+		cached.stmt.Close()
+	}
+}
+
+// evict removes a statement from the cache and closes it, unless it is in use. The caller holds c.mu.
+func (c *StatementCache) evict(elem *list.Element) {
+
+	var cached = c.lru.Remove(elem).(*cachedStmt)
+	delete(c.stmts, cached.q)
+
+	cached.evicted = true
+	if cached.uses == 0 {
+		cached.stmt.Close()
+	}
+}
+
+// Len returns the number of cached statements, not counting those prepared on replicas
+func (c *StatementCache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.lru.Len()
+}
+
+// CloseStatements closes and forgets every cached statement. Statements in use are closed once their use ends.
+func (c *StatementCache) CloseStatements() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for c.lru.Len() > 0 {
+		c.evict(c.lru.Back())
+	}
+	for conn := range c.replicas {
+		c.replicas[conn].CloseStatements()
+	}
+}
+
+// ExecContext executes a cached statement
+func (c *StatementCache) ExecContext(ctx context.Context, q string, args ...interface{}) (sql.Result, error) {
+
+	var cached, e = c.prepare(ctx, q)
+	if e != nil {
+		return nil, e
+	}
+
+	if cached == nil {
+		return ExecContext(ctx, c.DBInterface, q, args...)
+	}
+
+	defer c.release(cached)
+
+	return instrumentExec(ctx, instrumentOf(c.DBInterface), q, func(ctx context.Context) (sql.Result, error) {
+		return cached.stmt.ExecContext(ctx, args...)
+	})
+}
+
+// QueryContext runs a cached statement
+func (c *StatementCache) QueryContext(ctx context.Context, q string, args ...interface{}) (*sql.Rows, error) {
+
+	var cached, e = c.prepare(ctx, q)
+	if e != nil {
+		return nil, e
+	}
+
+	if cached == nil {
+		return QueryContext(ctx, c.DBInterface, q, args...)
+	}
+
+	// Rows keep their statement open until they are closed
+	defer c.release(cached)
+
+	return instrumentQuery(ctx, instrumentOf(c.DBInterface), q, func(ctx context.Context) (*sql.Rows, error) {
+		return cached.stmt.QueryContext(ctx, args...)
+	})
+}
+
+// QueryRowContext runs a cached statement expected to return at most one row. If the statement can't be prepared
+// the query is run directly so that the error is returned from Scan.
+func (c *StatementCache) QueryRowContext(ctx context.Context, q string, args ...interface{}) *sql.Row {
+
+	var cached, e = c.prepare(ctx, q)
+	if e != nil || cached == nil {
+		return QueryRowContext(ctx, c.DBInterface, q, args...)
+	}
+
+	defer c.release(cached)
+
+	return instrumentQueryRow(ctx, instrumentOf(c.DBInterface), q, func(ctx context.Context) *sql.Row {
+		return cached.stmt.QueryRowContext(ctx, args...)
+	})
+}
+
+// Exec executes a cached statement
+func (c *StatementCache) Exec(q string, args ...interface{}) (sql.Result, error) {
+	return c.ExecContext(context.Background(), q, args...)
+}
+
+// Query runs a cached statement
+func (c *StatementCache) Query(q string, args ...interface{}) (*sql.Rows, error) {
+	return c.QueryContext(context.Background(), q, args...)
+}
+
+// QueryRow runs a cached statement expected to return at most one row
+func (c *StatementCache) QueryRow(q string, args ...interface{}) *sql.Row {
+	return c.QueryRowContext(context.Background(), q, args...)
+}
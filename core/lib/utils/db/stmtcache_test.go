@@ -0,0 +1,59 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStatementCache_Evicts(t *testing.T) {
+
+	var log = []string{}
+	var conn = sql.OpenDB(connector{recordingDriver{&log}})
+	t.Cleanup(func() { conn.Close() })
+
+	ctx := context.Background()
+	c := NewStatementCacheSize(conn, 2)
+
+	for _, q := range []string{"DELETE FROM `A`", "DELETE FROM `B`", "DELETE FROM `A`", "DELETE FROM `C`"} {
+		_, e := ExecContext(ctx, c, q)
+		require.Nil(t, e)
+	}
+
+	// B was the least recently used
+	assert.Equal(t, 2, c.Len())
+	assert.Contains(t, c.stmts, "DELETE FROM `A`")
+	assert.Contains(t, c.stmts, "DELETE FROM `C`")
+	assert.Equal(t, []string{"DELETE FROM `A`", "DELETE FROM `B`", "DELETE FROM `A`", "DELETE FROM `C`"}, log)
+
+	c.CloseStatements()
+	assert.Equal(t, 0, c.Len())
+}
+
+func TestStatementCache_EvictedInUse(t *testing.T) {
+
+	var log = []string{}
+	var conn = sql.OpenDB(connector{recordingDriver{&log}})
+	t.Cleanup(func() { conn.Close() })
+
+	ctx := context.Background()
+	c := NewStatementCacheSize(conn, 1)
+
+	cached, e := c.prepare(ctx, "DELETE FROM `A`")
+	require.Nil(t, e)
+
+	_, e = ExecContext(ctx, c, "DELETE FROM `B`")
+	require.Nil(t, e)
+
+	// Evicted, but still open for the use that started before
+	_, e = cached.stmt.ExecContext(ctx)
+	assert.Nil(t, e)
+
+	c.release(cached)
+
+	_, e = cached.stmt.ExecContext(ctx)
+	assert.NotNil(t, e)
+}
@@ -0,0 +1,68 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+)
+
+// Span is the part of a tracing span (e.g. an OpenTelemetry trace.Span) that Tracing uses
+type Span interface {
+	SetAttribute(key string, value interface{})
+	RecordError(e error)
+	End()
+}
+
+// Tracer starts spans, e.g. an adapter of an OpenTelemetry trace.Tracer
+type Tracer interface {
+	Start(ctx context.Context, name string) (context.Context, Span)
+}
+
+// Tracing is an Instrument that runs each query in a span named after its operation and table (e.g. "SELECT Foo"),
+// with the OpenTelemetry database attributes
+type Tracing struct {
+	tracer Tracer
+}
+
+// NewTracing returns a Tracing that starts its spans on `tracer`
+func NewTracing(tracer Tracer) *Tracing {
+	return &Tracing{tracer: tracer}
+}
+
+type spanContextKey struct {
+	tracing *Tracing
+}
+
+// BeforeQuery starts the span of `event`. The query runs with the span's context, so that the driver can propagate it.
+func (t *Tracing) BeforeQuery(ctx context.Context, event *QueryEvent) context.Context {
+
+	var name = event.Operation
+	if len(event.Table) > 0 {
+		name += " " + event.Table
+	}
+
+	ctx, span := t.tracer.Start(ctx, name)
+
+	return context.WithValue(ctx, spanContextKey{t}, span)
+}
+
+// AfterQuery sets the attributes of `event` on its span and ends it
+func (t *Tracing) AfterQuery(ctx context.Context, event *QueryEvent) {
+
+	var span, ok = ctx.Value(spanContextKey{t}).(Span)
+	if !ok {
+		return
+	}
+
+	span.SetAttribute("db.system", "mysql")
+	span.SetAttribute("db.operation", event.Operation)
+	span.SetAttribute("db.sql.table", event.Table)
+	span.SetAttribute("db.statement", event.Fingerprint)
+	span.SetAttribute("db.rows_affected", event.RowsAffected)
+
+	if event.Err != nil && !errors.Is(event.Err, sql.ErrNoRows) {
+		span.RecordError(event.Err)
+	}
+
+	span.End()
+}
@@ -0,0 +1,264 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+	query "github.com/macinnir/goquery"
+)
+
+// MaxTxAttempts is the number of times a transaction is run before a deadlock is returned to the caller
+var MaxTxAttempts = 3
+
+// ErrNestedBeginTx is returned by Tx.BeginTx. Use RunInTx, which nests with savepoints.
+var ErrNestedBeginTx = errors.New("db: BeginTx called on a transaction")
+
+// mysqlDeadlock is ER_LOCK_DEADLOCK
+const mysqlDeadlock = 1213
+
+// txState is shared by every Tx in a RunInTx call and its savepoints
+type txState struct {
+	afterCommit []func()
+	onRollback  []func()
+	savepoints  int
+}
+
+// rollback runs the OnRollback functions registered after the first `from`, last first, and forgets them
+func (s *txState) rollback(from int) {
+	for k := len(s.onRollback) - 1; k >= from; k-- {
+		s.onRollback[k]()
+	}
+	s.onRollback = s.onRollback[0:from]
+}
+
+// Tx is a connection bound to a transaction. It satisfies query.DBInterface so that generated DALs can run on it.
+type Tx struct {
+	query.DBInterface
+	tx         *sql.Tx
+	state      *txState
+	instrument Instrument
+}
+
+// Exec executes a query in the transaction
+func (t *Tx) Exec(q string, args ...interface{}) (sql.Result, error) {
+	return t.ExecContext(context.Background(), q, args...)
+}
+
+// Query runs a query in the transaction
+func (t *Tx) Query(q string, args ...interface{}) (*sql.Rows, error) {
+	return t.QueryContext(context.Background(), q, args...)
+}
+
+// QueryRow runs a query expected to return at most one row in the transaction
+func (t *Tx) QueryRow(q string, args ...interface{}) *sql.Row {
+	return t.QueryRowContext(context.Background(), q, args...)
+}
+
+// ExecContext executes a query in the transaction
+func (t *Tx) ExecContext(ctx context.Context, q string, args ...interface{}) (sql.Result, error) {
+	return instrumentExec(ctx, t.instrument, q, func(ctx context.Context) (sql.Result, error) {
+		return t.tx.ExecContext(ctx, q, args...)
+	})
+}
+
+// QueryContext runs a query in the transaction
+func (t *Tx) QueryContext(ctx context.Context, q string, args ...interface{}) (*sql.Rows, error) {
+	return instrumentQuery(ctx, t.instrument, q, func(ctx context.Context) (*sql.Rows, error) {
+		return t.tx.QueryContext(ctx, q, args...)
+	})
+}
+
+// QueryRowContext runs a query expected to return at most one row in the transaction
+func (t *Tx) QueryRowContext(ctx context.Context, q string, args ...interface{}) *sql.Row {
+	return instrumentQueryRow(ctx, t.instrument, q, func(ctx context.Context) *sql.Row {
+		return t.tx.QueryRowContext(ctx, q, args...)
+	})
+}
+
+// PrepareContext prepares a statement in the transaction
+func (t *Tx) PrepareContext(ctx context.Context, q string) (*sql.Stmt, error) {
+	return t.tx.PrepareContext(ctx, q)
+}
+
+// BeginTx always fails; transactions nest through RunInTx
+func (t *Tx) BeginTx(ctx context.Context, opts *sql.TxOptions) (*sql.Tx, error) {
+	return nil, ErrNestedBeginTx
+}
+
+// AfterCommit registers `fn` to run once the outermost transaction commits. It is discarded if the transaction
+// (or the savepoint it was registered in) rolls back.
+func (t *Tx) AfterCommit(fn func()) {
+	t.state.afterCommit = append(t.state.afterCommit, fn)
+}
+
+// OnRollback registers `fn` to run if the transaction (or the savepoint it was registered in) rolls back, e.g. to
+// undo changes made in memory before the transaction is retried. It is discarded once the transaction commits.
+func (t *Tx) OnRollback(fn func()) {
+	t.state.onRollback = append(t.state.onRollback, fn)
+}
+
+// OnRollback runs `fn` if `conn`'s transaction rolls back. It does nothing if `conn` isn't in a transaction.
+func OnRollback(conn query.DBInterface, fn func()) {
+	if tx, ok := conn.(*Tx); ok {
+		tx.OnRollback(fn)
+	}
+}
+
+// AfterCommit runs `fn` after `conn`'s transaction commits, or immediately if `conn` isn't in a transaction
+func AfterCommit(conn query.DBInterface, fn func()) {
+	if tx, ok := conn.(*Tx); ok {
+		tx.AfterCommit(fn)
+		return
+	}
+	fn()
+}
+
+// InTx returns true if `conn` is bound to a transaction
+func InTx(conn query.DBInterface) bool {
+	_, ok := conn.(*Tx)
+	return ok
+}
+
+// IsDeadlock returns true if `e` is (or wraps) a MySQL deadlock error
+func IsDeadlock(e error) bool {
+	var mysqlErr *mysql.MySQLError
+	return errors.As(e, &mysqlErr) && mysqlErr.Number == mysqlDeadlock
+}
+
+// Transaction runs `fn` in a transaction on `conn`. See RunInTx.
+func Transaction(ctx context.Context, conn query.DBInterface, fn func(tx query.DBInterface) error) error {
+	return RunInTx(ctx, map[string]query.DBInterface{"": conn}, func(txs map[string]query.DBInterface) error {
+		return fn(txs[""])
+	})
+}
+
+// RunInTx runs `fn` with a transaction on each of `conns` (keyed by schema name). The transactions are committed
+// in key order if `fn` returns nil and rolled back otherwise. If the connections are already transactions, `fn`
+// runs in a savepoint instead. Deadlocks retry the whole transaction up to MaxTxAttempts times.
+func RunInTx(ctx context.Context, conns map[string]query.DBInterface, fn func(txs map[string]query.DBInterface) error) error {
+
+	var keys = make([]string, 0, len(conns))
+	var nested = len(conns) > 0
+	for key := range conns {
+		keys = append(keys, key)
+		if !InTx(conns[key]) {
+			nested = false
+		}
+	}
+
+	sort.Strings(keys)
+
+	if nested {
+		return runInSavepoint(ctx, keys, conns, fn)
+	}
+
+	var e error
+
+	for attempt := 1; attempt <= MaxTxAttempts; attempt++ {
+
+		if e = runInTx(ctx, keys, conns, fn); e == nil || !IsDeadlock(e) {
+			return e
+		}
+
+		select {
+		case <-ctx.Done():
+			return e
+		case <-time.After(time.Duration(attempt*attempt) * 10 * time.Millisecond):
+		}
+	}
+
+	return e
+}
+
+func runInTx(ctx context.Context, keys []string, conns map[string]query.DBInterface, fn func(txs map[string]query.DBInterface) error) (e error) {
+
+	var state = &txState{}
+	var txs = make(map[string]query.DBInterface, len(conns))
+	var begun = []*Tx{}
+
+	var rollback = func() {
+		for k := range begun {
+			begun[k].tx.Rollback()
+		}
+		state.rollback(0)
+	}
+
+	for _, key := range keys {
+
+		var tx *sql.Tx
+		if tx, e = conns[key].BeginTx(ctx, nil); e != nil {
+			rollback()
+			return fmt.Errorf("db.RunInTx(): begin: %w", e)
+		}
+
+		var t = &Tx{DBInterface: conns[key], tx: tx, state: state, instrument: instrumentOf(conns[key])}
+		begun = append(begun, t)
+		txs[key] = t
+	}
+
+	defer func() {
+		if p := recover(); p != nil {
+			rollback()
+			panic(p)
+		}
+	}()
+
+	if e = fn(txs); e != nil {
+		rollback()
+		return e
+	}
+
+	for k := range begun {
+		if e = begun[k].tx.Commit(); e != nil {
+			// Transactions already committed can't be undone
+			for l := k + 1; l < len(begun); l++ {
+				begun[l].tx.Rollback()
+			}
+			state.rollback(0)
+			return fmt.Errorf("db.RunInTx(): commit: %w", e)
+		}
+	}
+
+	for k := range state.afterCommit {
+		state.afterCommit[k]()
+	}
+
+	return nil
+}
+
+func runInSavepoint(ctx context.Context, keys []string, txs map[string]query.DBInterface, fn func(txs map[string]query.DBInterface) error) (e error) {
+
+	var state = txs[keys[0]].(*Tx).state
+	state.savepoints++
+	var name = fmt.Sprintf("sp_%d", state.savepoints)
+	var hooks = len(state.afterCommit)
+	var undo = len(state.onRollback)
+
+	for _, key := range keys {
+		if _, e = txs[key].(*Tx).tx.ExecContext(ctx, "SAVEPOINT "+name); e != nil {
+			return fmt.Errorf("db.RunInTx(): savepoint: %w", e)
+		}
+	}
+
+	if e = fn(txs); e != nil {
+		for _, key := range keys {
+			txs[key].(*Tx).tx.ExecContext(ctx, "ROLLBACK TO SAVEPOINT "+name)
+		}
+		state.afterCommit = state.afterCommit[0:hooks]
+		state.rollback(undo)
+		return e
+	}
+
+	for _, key := range keys {
+		if _, e = txs[key].(*Tx).tx.ExecContext(ctx, "RELEASE SAVEPOINT "+name); e != nil {
+			return fmt.Errorf("db.RunInTx(): release savepoint: %w", e)
+		}
+	}
+
+	return nil
+}
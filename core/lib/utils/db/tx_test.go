@@ -0,0 +1,259 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"testing"
+
+	"github.com/go-sql-driver/mysql"
+	query "github.com/macinnir/goquery"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// recordingDriver is a database/sql driver that records the statements run on it
+type recordingDriver struct {
+	log *[]string
+}
+
+func (d recordingDriver) Open(name string) (driver.Conn, error) { return recordingConn(d), nil }
+
+type recordingConn recordingDriver
+
+func (c recordingConn) Prepare(q string) (driver.Stmt, error) { return recordingStmt{c.log, q}, nil }
+func (c recordingConn) Close() error                          { return nil }
+func (c recordingConn) Begin() (driver.Tx, error) {
+	*c.log = append(*c.log, "BEGIN")
+	return recordingTx(c), nil
+}
+
+type recordingTx recordingConn
+
+func (t recordingTx) Commit() error   { *t.log = append(*t.log, "COMMIT"); return nil }
+func (t recordingTx) Rollback() error { *t.log = append(*t.log, "ROLLBACK"); return nil }
+
+type recordingStmt struct {
+	log *[]string
+	q   string
+}
+
+func (s recordingStmt) Close() error  { return nil }
+func (s recordingStmt) NumInput() int { return -1 }
+func (s recordingStmt) Exec(args []driver.Value) (driver.Result, error) {
+	*s.log = append(*s.log, s.q)
+	return recordingResult(len(*s.log)), nil
+}
+func (s recordingStmt) Query(args []driver.Value) (driver.Rows, error) {
+	return nil, errors.New("not supported")
+}
+
+// recordingResult reports the number of statements recorded so far as the last insert ID
+type recordingResult int64
+
+func (r recordingResult) LastInsertId() (int64, error) { return int64(r), nil }
+func (r recordingResult) RowsAffected() (int64, error) { return 1, nil }
+
+// recordingDB is a connection on a recordingDriver
+type recordingDB struct {
+	query.DBInterface
+	db *sql.DB
+}
+
+func (r *recordingDB) BeginTx(ctx context.Context, opts *sql.TxOptions) (*sql.Tx, error) {
+	return r.db.BeginTx(ctx, opts)
+}
+
+func (r *recordingDB) Exec(q string, args ...interface{}) (sql.Result, error) {
+	return r.db.Exec(q, args...)
+}
+
+func (r *recordingDB) QueryRow(q string, args ...interface{}) *sql.Row {
+	return r.db.QueryRow(q, args...)
+}
+
+func newRecordingDB(t *testing.T) (*recordingDB, *[]string) {
+	var log = []string{}
+	var db = sql.OpenDB(connector{recordingDriver{&log}})
+	t.Cleanup(func() { db.Close() })
+	return &recordingDB{db: db}, &log
+}
+
+type connector struct{ d recordingDriver }
+
+func (c connector) Connect(context.Context) (driver.Conn, error) { return c.d.Open("") }
+func (c connector) Driver() driver.Driver                        { return c.d }
+
+func TestTransaction_Commit(t *testing.T) {
+
+	conn, log := newRecordingDB(t)
+	var committed = false
+
+	e := Transaction(context.Background(), conn, func(tx query.DBInterface) error {
+		assert.True(t, InTx(tx))
+		AfterCommit(tx, func() { committed = true })
+		_, e := ExecContext(context.Background(), tx, "UPDATE `Foo` SET `A` = ?", 1)
+		assert.False(t, committed)
+		return e
+	})
+
+	require.Nil(t, e)
+	assert.True(t, committed)
+	assert.Equal(t, []string{"BEGIN", "UPDATE `Foo` SET `A` = ?", "COMMIT"}, *log)
+}
+
+func TestTransaction_Rollback(t *testing.T) {
+
+	conn, log := newRecordingDB(t)
+	var committed = false
+	var failed = errors.New("failed")
+
+	e := Transaction(context.Background(), conn, func(tx query.DBInterface) error {
+		AfterCommit(tx, func() { committed = true })
+		return failed
+	})
+
+	assert.Equal(t, failed, e)
+	assert.False(t, committed)
+	assert.Equal(t, []string{"BEGIN", "ROLLBACK"}, *log)
+}
+
+func TestTransaction_NestedSavepoint(t *testing.T) {
+
+	conn, log := newRecordingDB(t)
+	var hooks = []string{}
+
+	e := Transaction(context.Background(), conn, func(tx query.DBInterface) error {
+
+		AfterCommit(tx, func() { hooks = append(hooks, "outer") })
+
+		inner := Transaction(context.Background(), tx, func(tx query.DBInterface) error {
+			AfterCommit(tx, func() { hooks = append(hooks, "inner") })
+			return errors.New("inner failed")
+		})
+		assert.NotNil(t, inner)
+
+		return Transaction(context.Background(), tx, func(tx query.DBInterface) error {
+			return nil
+		})
+	})
+
+	require.Nil(t, e)
+	assert.Equal(t, []string{"outer"}, hooks)
+	assert.Equal(t, []string{
+		"BEGIN",
+		"SAVEPOINT sp_1",
+		"ROLLBACK TO SAVEPOINT sp_1",
+		"SAVEPOINT sp_2",
+		"RELEASE SAVEPOINT sp_2",
+		"COMMIT",
+	}, *log)
+}
+
+func TestTransaction_RetryOnDeadlock(t *testing.T) {
+
+	conn, log := newRecordingDB(t)
+	var attempts = 0
+
+	e := Transaction(context.Background(), conn, func(tx query.DBInterface) error {
+		attempts++
+		if attempts == 1 {
+			return &mysql.MySQLError{Number: mysqlDeadlock}
+		}
+		return nil
+	})
+
+	require.Nil(t, e)
+	assert.Equal(t, 2, attempts)
+	assert.Equal(t, []string{"BEGIN", "ROLLBACK", "BEGIN", "COMMIT"}, *log)
+}
+
+func TestAfterCommit_NoTransaction(t *testing.T) {
+
+	conn, _ := newRecordingDB(t)
+	var ran = false
+
+	AfterCommit(conn, func() { ran = true })
+
+	assert.True(t, ran)
+	assert.False(t, InTx(conn))
+}
+
+// versioned is a model with a version column, updated the way generated DALs update them
+type versioned struct {
+	ID      int64
+	Version int64
+}
+
+func (v *versioned) update(ctx context.Context, conn query.DBInterface) error {
+
+	if e := UpdateVersioned(ctx, conn, "Foo", v.ID, "UPDATE `Foo` SET `Version` = `Version` + 1 WHERE `ID` = ? AND `Version` = ?", v.ID, v.Version); e != nil {
+		return e
+	}
+
+	var version = v.Version
+	OnRollback(conn, func() { v.Version = version })
+	v.Version++
+
+	return nil
+}
+
+func TestTransaction_RetryVersionedUpdate(t *testing.T) {
+
+	conn, log := newRecordingDB(t)
+	model := &versioned{ID: 1, Version: 1}
+	var versions = []int64{}
+
+	e := Transaction(context.Background(), conn, func(tx query.DBInterface) error {
+
+		// Each attempt updates the version the model was read with
+		versions = append(versions, model.Version)
+		if e := model.update(context.Background(), tx); e != nil {
+			return e
+		}
+
+		if len(versions) == 1 {
+			return &mysql.MySQLError{Number: mysqlDeadlock}
+		}
+		return nil
+	})
+
+	require.Nil(t, e)
+	assert.Equal(t, []int64{1, 1}, versions)
+	assert.Equal(t, int64(2), model.Version)
+	assert.Equal(t, []string{
+		"BEGIN",
+		"UPDATE `Foo` SET `Version` = `Version` + 1 WHERE `ID` = ? AND `Version` = ?",
+		"ROLLBACK",
+		"BEGIN",
+		"UPDATE `Foo` SET `Version` = `Version` + 1 WHERE `ID` = ? AND `Version` = ?",
+		"COMMIT",
+	}, *log)
+}
+
+func TestTransaction_OnRollbackSavepoint(t *testing.T) {
+
+	conn, _ := newRecordingDB(t)
+	var undone = []string{}
+
+	e := Transaction(context.Background(), conn, func(tx query.DBInterface) error {
+
+		OnRollback(tx, func() { undone = append(undone, "outer") })
+
+		Transaction(context.Background(), tx, func(tx query.DBInterface) error {
+			OnRollback(tx, func() { undone = append(undone, "inner") })
+			return errors.New("inner failed")
+		})
+
+		assert.Equal(t, []string{"inner"}, undone)
+		return nil
+	})
+
+	require.Nil(t, e)
+	assert.Equal(t, []string{"inner"}, undone)
+
+	// Outside of a transaction there is nothing to roll back
+	OnRollback(conn, func() { undone = append(undone, "none") })
+	assert.Equal(t, []string{"inner"}, undone)
+}
@@ -0,0 +1,56 @@
+package db
+
+import (
+	"fmt"
+	"strings"
+
+	query "github.com/macinnir/goquery"
+)
+
+// UpdateColumnsSQL returns an UPDATE of `cols` on a record by its primary key and the columns it sets, in the order
+// of their `?`, which are followed by one for the primary key. Columns that aren't in `updateColumns` are an error.
+// If `version` isn't empty, the version column is incremented rather than set and a `?` for the version the record
+// was read with is added.
+func UpdateColumnsSQL(table query.TableName, pk query.Column, updateColumns, cols []query.Column, version query.Column) (string, []query.Column, error) {
+
+	var set = []string{}
+	var setCols = []query.Column{}
+	var seen = map[query.Column]bool{}
+
+	for _, col := range cols {
+
+		if col == version || seen[col] {
+			continue
+		}
+
+		if !hasColumn(updateColumns, col) {
+			return "", nil, fmt.Errorf("%s is not an update column of %s", col, table)
+		}
+
+		seen[col] = true
+		set = append(set, quote(col)+" = ?")
+		setCols = append(setCols, col)
+	}
+
+	if len(set) == 0 && len(version) == 0 {
+		return "", nil, fmt.Errorf("no columns of %s to update", table)
+	}
+
+	var where = quote(pk) + " = ?"
+
+	if len(version) > 0 {
+		set = append(set, quote(version)+" = "+quote(version)+" + 1")
+		where += " AND " + quote(version) + " = ?"
+	}
+
+	return "UPDATE `" + string(table) + "` SET " + strings.Join(set, ", ") + " WHERE " + where, setCols, nil
+}
+
+func hasColumn(cols []query.Column, col query.Column) bool {
+	for k := range cols {
+		if cols[k] == col {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,38 @@
+package db
+
+import (
+	"testing"
+
+	query "github.com/macinnir/goquery"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var postUpdateColumns = []query.Column{"Body", "Title", "Version"}
+
+func TestUpdateColumnsSQL(t *testing.T) {
+
+	q, cols, e := UpdateColumnsSQL("Post", "PostID", postUpdateColumns, []query.Column{"Title", "Body", "Title"}, "")
+
+	require.Nil(t, e)
+	assert.Equal(t, "UPDATE `Post` SET `Title` = ?, `Body` = ? WHERE `PostID` = ?", q)
+	assert.Equal(t, []query.Column{"Title", "Body"}, cols)
+}
+
+func TestUpdateColumnsSQL_Version(t *testing.T) {
+
+	q, cols, e := UpdateColumnsSQL("Post", "PostID", postUpdateColumns, []query.Column{"Title", "Version"}, "Version")
+
+	require.Nil(t, e)
+	assert.Equal(t, "UPDATE `Post` SET `Title` = ?, `Version` = `Version` + 1 WHERE `PostID` = ? AND `Version` = ?", q)
+	assert.Equal(t, []query.Column{"Title"}, cols)
+}
+
+func TestUpdateColumnsSQL_NotAnUpdateColumn(t *testing.T) {
+
+	_, _, e := UpdateColumnsSQL("Post", "PostID", postUpdateColumns, []query.Column{"PostID"}, "")
+	assert.NotNil(t, e)
+
+	_, _, e = UpdateColumnsSQL("Post", "PostID", postUpdateColumns, nil, "")
+	assert.NotNil(t, e)
+}
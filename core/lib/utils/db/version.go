@@ -0,0 +1,30 @@
+package db
+
+import (
+	"context"
+
+	"github.com/macinnir/dvc/core/lib/utils/errors"
+	query "github.com/macinnir/goquery"
+)
+
+// UpdateVersioned executes an update whose WHERE clause checks the record's version column (optimistic locking).
+// If no row matched, the record was changed or deleted since it was read and an errors.ConcurrentModificationError
+// is returned.
+func UpdateVersioned(ctx context.Context, conn query.DBInterface, table string, id interface{}, q string, args ...interface{}) error {
+
+	result, e := ExecContext(ctx, conn, q, args...)
+	if e != nil {
+		return e
+	}
+
+	n, e := result.RowsAffected()
+	if e != nil {
+		return e
+	}
+
+	if n == 0 {
+		return errors.NewConcurrentModificationError(table, id)
+	}
+
+	return nil
+}
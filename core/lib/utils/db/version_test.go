@@ -0,0 +1,33 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"testing"
+
+	"github.com/macinnir/dvc/core/lib/utils/errors"
+	query "github.com/macinnir/goquery"
+	"github.com/stretchr/testify/assert"
+)
+
+// rowsAffectedDB is a connection whose updates affect `n` rows
+type rowsAffectedDB struct {
+	query.DBInterface
+	n int64
+}
+
+func (d rowsAffectedDB) Exec(q string, args ...interface{}) (sql.Result, error) {
+	return driver.RowsAffected(d.n), nil
+}
+
+func TestUpdateVersioned(t *testing.T) {
+	q := "UPDATE `Post` SET `Title` = ?, `Version` = `Version` + 1 WHERE `PostID` = ? AND `Version` = ?"
+	assert.Nil(t, UpdateVersioned(context.Background(), rowsAffectedDB{n: 1}, "Post", int64(1), q, "a", int64(1), int64(3)))
+}
+
+func TestUpdateVersioned_Conflict(t *testing.T) {
+	q := "UPDATE `Post` SET `Title` = ?, `Version` = `Version` + 1 WHERE `PostID` = ? AND `Version` = ?"
+	e := UpdateVersioned(context.Background(), rowsAffectedDB{n: 0}, "Post", int64(1), q, "a", int64(1), int64(3))
+	assert.Equal(t, errors.NewConcurrentModificationError("Post", int64(1)), e)
+}
@@ -80,6 +80,24 @@ func NewRecordNotFoundError() RecordNotFoundError {
 	return RecordNotFoundError{}
 }
 
+// ConcurrentModificationError is an error thrown when a record being updated was changed by someone else
+// since it was read
+// Conflict - 409
+type ConcurrentModificationError struct {
+	Table string
+	ID    interface{}
+}
+
+// Error matches the error interface for ConcurrentModificationError
+func (e ConcurrentModificationError) Error() string {
+	return fmt.Sprintf("%s %v was modified by someone else", e.Table, e.ID)
+}
+
+// NewConcurrentModificationError returns a ConcurrentModificationError
+func NewConcurrentModificationError(table string, id interface{}) ConcurrentModificationError {
+	return ConcurrentModificationError{Table: table, ID: id}
+}
+
 // NewError returns a generic new error
 func NewError(text string) error {
 	return errors.New(text)
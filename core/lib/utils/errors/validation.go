@@ -0,0 +1,115 @@
+package errors
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"unicode/utf8"
+)
+
+// FieldError is a validation failure of a single field
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// ValidationError is an error thrown when one or more fields of a model are not valid
+// BadRequest - 400
+type ValidationError struct {
+	Fields []FieldError
+}
+
+// NewValidationError returns a ValidationError for the given field errors
+func NewValidationError(fields ...FieldError) *ValidationError {
+	return &ValidationError{Fields: fields}
+}
+
+// Error matches the error interface for ValidationError
+func (e *ValidationError) Error() string {
+
+	var messages = make([]string, len(e.Fields))
+	for k := range e.Fields {
+		messages[k] = e.Fields[k].Field + ": " + e.Fields[k].Message
+	}
+
+	return strings.Join(messages, "; ")
+}
+
+// Add adds a failure of field
+func (e *ValidationError) Add(field, message string) {
+	e.Fields = append(e.Fields, FieldError{Field: field, Message: message})
+}
+
+// Err returns the ValidationError if any field failed, or nil
+func (e *ValidationError) Err() error {
+	if len(e.Fields) == 0 {
+		return nil
+	}
+	return e
+}
+
+// Required adds a failure of field if value is empty
+func (e *ValidationError) Required(field, value string) {
+	if len(value) == 0 {
+		e.Add(field, "is required")
+	}
+}
+
+// MaxLength adds a failure of field if value is longer than max characters
+func (e *ValidationError) MaxLength(field, value string, max int) {
+	if utf8.RuneCountInString(value) > max {
+		e.Add(field, fmt.Sprintf("must be at most %d characters long", max))
+	}
+}
+
+// Range adds a failure of field if value is not between min and max
+func (e *ValidationError) Range(field string, value int64, min int64, max uint64) {
+	if value < min || (value > 0 && uint64(value) > max) {
+		e.Add(field, fmt.Sprintf("must be between %d and %d", min, max))
+	}
+}
+
+// OneOf adds a failure of field if value is not one of values (e.g. of an enum column)
+func (e *ValidationError) OneOf(field, value string, values ...string) {
+	for _, v := range values {
+		if v == value {
+			return
+		}
+	}
+	e.Add(field, "must be one of "+strings.Join(values, ", "))
+}
+
+// SetOf adds a failure of field if value, a comma-separated list (e.g. of a set column), has a member that is
+// not one of values
+func (e *ValidationError) SetOf(field, value string, values ...string) {
+
+	if len(value) == 0 {
+		return
+	}
+
+	for _, member := range strings.Split(value, ",") {
+		var found = false
+		for _, v := range values {
+			if v == member {
+				found = true
+				break
+			}
+		}
+		if !found {
+			e.Add(field, "must be a combination of "+strings.Join(values, ", "))
+			return
+		}
+	}
+}
+
+// Decimal adds a failure of field if value, rounded to scale decimal places, does not fit a decimal(precision,scale)
+// column
+func (e *ValidationError) Decimal(field string, value float64, precision, scale int, unsigned bool) {
+
+	var factor = math.Pow10(scale)
+	var rounded = math.Round(math.Abs(value)*factor) / factor
+
+	if math.IsNaN(value) || rounded >= math.Pow10(precision-scale) || (unsigned && value < 0) {
+		e.Add(field, fmt.Sprintf("must fit decimal(%d,%d)", precision, scale))
+	}
+}
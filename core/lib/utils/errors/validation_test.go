@@ -0,0 +1,38 @@
+package errors
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidationError(t *testing.T) {
+
+	v := NewValidationError()
+	assert.Nil(t, v.Err())
+
+	v.Required("Name", "x")
+	v.MaxLength("Name", "héllo", 5)
+	v.Range("Age", 255, 0, 255)
+	v.OneOf("Status", "a", "a", "b")
+	v.SetOf("Tags", "x,y", "x", "y", "z")
+	v.SetOf("Tags", "", "x")
+	v.Decimal("Amount", 999.994, 5, 2, false)
+	assert.Nil(t, v.Err())
+
+	v.Required("Name", "")
+	v.MaxLength("Title", "héllo!", 5)
+	v.Range("Age", -1, 0, 255)
+	v.Range("Age", 256, 0, 255)
+	v.OneOf("Status", "c", "a", "b")
+	v.SetOf("Tags", "x,w", "x", "y")
+	v.Decimal("Amount", 999.995, 5, 2, false)
+	v.Decimal("Amount", -1, 5, 2, true)
+
+	e := v.Err()
+	assert.NotNil(t, e)
+	assert.Len(t, v.Fields, 8)
+	assert.Equal(t, FieldError{Field: "Title", Message: "must be at most 5 characters long"}, v.Fields[1])
+	assert.Equal(t, FieldError{Field: "Amount", Message: "must fit decimal(5,2)"}, v.Fields[6])
+	assert.Contains(t, e.Error(), "Name: is required; Title: must be at most 5 characters long")
+}
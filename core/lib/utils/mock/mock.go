@@ -0,0 +1,265 @@
+package mock
+
+import (
+	"cmp"
+	"database/sql/driver"
+	"fmt"
+	"reflect"
+	"slices"
+	"sync"
+	"time"
+)
+
+// Call is a call made on a mock
+type Call struct {
+	Method string
+	Args   []interface{}
+}
+
+// Recorder records the calls made on a mock. Generated mocks embed it.
+type Recorder struct {
+	mu    sync.Mutex
+	calls []Call
+}
+
+// Record records a call of `method` with `args`
+func (r *Recorder) Record(method string, args ...interface{}) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.calls = append(r.calls, Call{Method: method, Args: args})
+}
+
+// Calls returns the calls made so far, in order
+func (r *Recorder) Calls() []Call {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]Call{}, r.calls...)
+}
+
+// CallsTo returns the calls made to `method` so far, in order
+func (r *Recorder) CallsTo(method string) []Call {
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var calls = []Call{}
+	for k := range r.calls {
+		if r.calls[k].Method == method {
+			calls = append(calls, r.calls[k])
+		}
+	}
+
+	return calls
+}
+
+// Called returns the number of times `method` was called
+func (r *Recorder) Called(method string) int {
+	return len(r.CallsTo(method))
+}
+
+// Reset forgets the calls made so far
+func (r *Recorder) Reset() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.calls = nil
+}
+
+// Store is an in-memory table of records keyed by primary key. It keeps copies of the records put in it and returns
+// copies, so callers can't change stored records without putting them back. Generated fakes keep their records in it.
+type Store[K cmp.Ordered, T any] struct {
+	mu      sync.Mutex
+	records map[K]T
+	lastID  int64
+}
+
+// NewStore returns an empty Store
+func NewStore[K cmp.Ordered, T any]() *Store[K, T] {
+	return &Store[K, T]{records: map[K]T{}}
+}
+
+// NextID returns the next auto-increment ID
+func (s *Store[K, T]) NextID() int64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.lastID++
+	return s.lastID
+}
+
+// Put stores a copy of `record` under `key`
+func (s *Store[K, T]) Put(key K, record *T) {
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.records[key] = *record
+
+	// IDs set by callers aren't handed out again
+	if id, ok := any(key).(int64); ok && id > s.lastID {
+		s.lastID = id
+	}
+}
+
+// Get returns a copy of the record stored under `key`
+func (s *Store[K, T]) Get(key K) (*T, bool) {
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	record, ok := s.records[key]
+	if !ok {
+		return nil, false
+	}
+
+	return &record, true
+}
+
+// Delete removes the record stored under `key` and returns true if there was one
+func (s *Store[K, T]) Delete(key K) bool {
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	_, ok := s.records[key]
+	delete(s.records, key)
+	return ok
+}
+
+// Len returns the number of records stored
+func (s *Store[K, T]) Len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.records)
+}
+
+// Filter returns copies of the records `match` returns true for, in key order. A nil `match` returns every record.
+func (s *Store[K, T]) Filter(match func(record *T) bool) []*T {
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var keys = make([]K, 0, len(s.records))
+	for key := range s.records {
+		keys = append(keys, key)
+	}
+
+	slices.Sort(keys)
+
+	var records = []*T{}
+	for _, key := range keys {
+		var record = s.records[key]
+		if match == nil || match(&record) {
+			records = append(records, &record)
+		}
+	}
+
+	return records
+}
+
+// Page returns `limit` records starting at `offset`. A limit of zero (or less) returns every record from `offset` on.
+func Page[T any](records []T, limit, offset int64) []T {
+
+	if offset >= int64(len(records)) {
+		return records[:0]
+	}
+
+	if offset > 0 {
+		records = records[offset:]
+	}
+
+	if limit > 0 && limit < int64(len(records)) {
+		records = records[:limit]
+	}
+
+	return records
+}
+
+// Equal returns true if two column values are equal
+func Equal(a, b interface{}) bool {
+	return reflect.DeepEqual(a, b)
+}
+
+// Compare compares two column values the way the database orders them: NULL first, then numbers, strings, booleans
+// and times by value. Nullable values (e.g. null.String) are compared by their driver value. It returns -1, 0 or 1.
+func Compare(a, b interface{}) int {
+
+	a, b = driverValue(a), driverValue(b)
+
+	switch {
+	case a == nil && b == nil:
+		return 0
+	case a == nil:
+		return -1
+	case b == nil:
+		return 1
+	}
+
+	var va, vb = reflect.ValueOf(a), reflect.ValueOf(b)
+
+	switch {
+	case va.CanInt() && vb.CanInt():
+		return cmp.Compare(va.Int(), vb.Int())
+	case va.CanUint() && vb.CanUint():
+		return cmp.Compare(va.Uint(), vb.Uint())
+	case (va.CanInt() || va.CanUint() || va.CanFloat()) && (vb.CanInt() || vb.CanUint() || vb.CanFloat()):
+		return cmp.Compare(toFloat(va), toFloat(vb))
+	}
+
+	switch x := a.(type) {
+	case string:
+		if y, ok := b.(string); ok {
+			return cmp.Compare(x, y)
+		}
+	case bool:
+		if y, ok := b.(bool); ok {
+			switch {
+			case x == y:
+				return 0
+			case y:
+				return -1
+			default:
+				return 1
+			}
+		}
+	case time.Time:
+		if y, ok := b.(time.Time); ok {
+			return x.Compare(y)
+		}
+	}
+
+	return cmp.Compare(fmt.Sprint(a), fmt.Sprint(b))
+}
+
+// driverValue returns the driver value of a driver.Valuer, or the value itself
+func driverValue(value interface{}) interface{} {
+
+	if valuer, ok := value.(driver.Valuer); ok {
+		if v, e := valuer.Value(); e == nil {
+			return v
+		}
+	}
+
+	return value
+}
+
+// toFloat returns a numeric value as a float64
+func toFloat(v reflect.Value) float64 {
+	switch {
+	case v.CanInt():
+		return float64(v.Int())
+	case v.CanUint():
+		return float64(v.Uint())
+	default:
+		return v.Float()
+	}
+}
+
+// Sort sorts records by the column value `value` returns for each (see Compare), descending if `desc`. Records with
+// equal values keep their order.
+func Sort[T any](records []*T, value func(record *T) interface{}, desc bool) {
+	slices.SortStableFunc(records, func(a, b *T) int {
+		if desc {
+			return Compare(value(b), value(a))
+		}
+		return Compare(value(a), value(b))
+	})
+}
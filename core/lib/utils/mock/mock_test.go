@@ -0,0 +1,84 @@
+package mock
+
+import (
+	"database/sql"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type record struct {
+	ID   int64
+	Name string
+}
+
+func TestRecorder(t *testing.T) {
+
+	var r Recorder
+	r.Record("Create", 1)
+	r.Record("Delete", 2)
+	r.Record("Create", 3)
+
+	assert.Equal(t, 2, r.Called("Create"))
+	assert.Equal(t, []Call{{"Create", []interface{}{1}}, {"Create", []interface{}{3}}}, r.CallsTo("Create"))
+	assert.Len(t, r.Calls(), 3)
+
+	r.Reset()
+	assert.Len(t, r.Calls(), 0)
+}
+
+func TestStore(t *testing.T) {
+
+	s := NewStore[int64, record]()
+
+	var a = &record{ID: s.NextID(), Name: "a"}
+	s.Put(a.ID, a)
+	s.Put(5, &record{ID: 5, Name: "b"})
+	assert.Equal(t, int64(6), s.NextID())
+
+	// Stored records are copies
+	a.Name = "changed"
+	got, ok := s.Get(1)
+	require.True(t, ok)
+	assert.Equal(t, "a", got.Name)
+
+	got.Name = "changed"
+	got, _ = s.Get(1)
+	assert.Equal(t, "a", got.Name)
+
+	assert.Equal(t, []*record{{5, "b"}}, s.Filter(func(r *record) bool { return r.Name == "b" }))
+	assert.Equal(t, []*record{{1, "a"}, {5, "b"}}, s.Filter(nil))
+
+	assert.True(t, s.Delete(1))
+	assert.False(t, s.Delete(1))
+	_, ok = s.Get(1)
+	assert.False(t, ok)
+	assert.Equal(t, 1, s.Len())
+}
+
+func TestPage(t *testing.T) {
+	assert.Equal(t, []int{2, 3}, Page([]int{1, 2, 3, 4}, 2, 1))
+	assert.Equal(t, []int{3, 4}, Page([]int{1, 2, 3, 4}, 0, 2))
+	assert.Equal(t, []int{}, Page([]int{1, 2}, 2, 5))
+}
+
+func TestCompare(t *testing.T) {
+	assert.Equal(t, -1, Compare(int64(1), int64(2)))
+	assert.Equal(t, 1, Compare(2.5, int64(2)))
+	assert.Equal(t, 0, Compare("a", "a"))
+	assert.Equal(t, -1, Compare(sql.NullString{}, "a"))
+	assert.Equal(t, 1, Compare(sql.NullString{String: "b", Valid: true}, "a"))
+	assert.Equal(t, -1, Compare(false, true))
+}
+
+func TestSort(t *testing.T) {
+
+	records := []*record{{1, "b"}, {2, "a"}, {3, "b"}}
+
+	Sort(records, func(r *record) interface{} { return r.Name }, false)
+	assert.Equal(t, []*record{{2, "a"}, {1, "b"}, {3, "b"}}, records)
+
+	Sort(records, func(r *record) interface{} { return r.ID }, true)
+	assert.Equal(t, []*record{{3, "b"}, {2, "a"}, {1, "b"}}, records)
+}
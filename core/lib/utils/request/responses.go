@@ -2,6 +2,7 @@ package request
 
 import (
 	"encoding/json"
+	goerrors "errors"
 	"log"
 	"net/http"
 
@@ -12,6 +13,26 @@ import (
 // HandleError handles errors returned from the service layer and
 // calls a api error handler to return the corresponding HTTP response
 func HandleError(r *Request, w http.ResponseWriter, e error) {
+
+	// Generated DALs wrap the error
+	var conflict errors.ConcurrentModificationError
+	if goerrors.As(e, &conflict) {
+		Conflict(r, w, conflict)
+		return
+	}
+
+	var validation *errors.ValidationError
+	if goerrors.As(e, &validation) {
+		ValidationFailed(r, w, validation)
+		return
+	}
+
+	var argument errors.ArgumentError
+	if goerrors.As(e, &argument) {
+		BadRequest(r, w, argument)
+		return
+	}
+
 	// t := reflect.TypeOf(e)
 	switch e.(type) {
 	case errors.ArgumentError:
@@ -76,6 +97,38 @@ func BadRequest(r *Request, w http.ResponseWriter, e error) {
 	JSON(r, w, errorResponse)
 }
 
+// ValidationErrorResponse is the structure of a response to a request that failed validation
+type ValidationErrorResponse struct {
+	Status string              `json:"status"`
+	Detail string              `json:"detail"`
+	Fields []errors.FieldError `json:"fields"`
+}
+
+// ValidationFailed returns a bad request status (400) with the failure of each field
+func ValidationFailed(r *Request, w http.ResponseWriter, e *errors.ValidationError) {
+	log.Printf("WAR HTTP %s %s 400 BAD REQUEST: %s", r.Method, r.Path, e.Error())
+	w.WriteHeader(http.StatusBadRequest)
+	r.ResponseCode = 400
+	r.Error = e.Error()
+	errorResponse := ValidationErrorResponse{}
+	errorResponse.Status = "400"
+	errorResponse.Detail = e.Error()
+	errorResponse.Fields = e.Fields
+	JSON(r, w, errorResponse)
+}
+
+// Conflict returns a conflict status (409)
+func Conflict(r *Request, w http.ResponseWriter, e error) {
+	log.Printf("WAR HTTP %s %s 409 CONFLICT: %s", r.Method, r.Path, e.Error())
+	w.WriteHeader(http.StatusConflict)
+	r.ResponseCode = 409
+	r.Error = e.Error()
+	errorResponse := ErrorResponse{}
+	errorResponse.Status = "409"
+	errorResponse.Detail = e.Error()
+	JSON(r, w, errorResponse)
+}
+
 // Unauthorized returns an unauthorized status (401)
 func Unauthorized(r *Request, w http.ResponseWriter) {
 	r.ResponseCode = 401
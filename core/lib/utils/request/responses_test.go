@@ -2,8 +2,13 @@ package request
 
 import (
 	"errors"
+	"fmt"
+	"net/http"
 	"net/http/httptest"
 	"testing"
+
+	dvcerrors "github.com/macinnir/dvc/core/lib/utils/errors"
+	"github.com/stretchr/testify/assert"
 )
 
 func TestInternalServerError(t *testing.T) {
@@ -11,3 +16,32 @@ func TestInternalServerError(t *testing.T) {
 	w := httptest.NewRecorder()
 	InternalServerError(r, w, errors.New("Test error"))
 }
+
+func TestHandleError_ConcurrentModification(t *testing.T) {
+	r := &Request{}
+	w := httptest.NewRecorder()
+	e := fmt.Errorf("PostDAL.Update(1): %w", dvcerrors.NewConcurrentModificationError("Post", int64(1)))
+	HandleError(r, w, e)
+	assert.Equal(t, http.StatusConflict, w.Code)
+	assert.Contains(t, w.Body.String(), "Post 1 was modified by someone else")
+}
+
+func TestHandleError_WrappedArgumentError(t *testing.T) {
+	r := &Request{}
+	w := httptest.NewRecorder()
+	e := fmt.Errorf("PostDALSelector.Page: %w", dvcerrors.NewArgumentError("invalid cursor"))
+	HandleError(r, w, e)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestHandleError_ValidationError(t *testing.T) {
+	r := &Request{}
+	w := httptest.NewRecorder()
+	v := dvcerrors.NewValidationError()
+	v.MaxLength("Title", "too long", 3)
+	v.Required("Body", "")
+	HandleError(r, w, fmt.Errorf("PostService.Create: %w", v.Err()))
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), `{"field":"Title","message":"must be at most 3 characters long"}`)
+	assert.Contains(t, w.Body.String(), `{"field":"Body","message":"is required"}`)
+}
@@ -0,0 +1,36 @@
+package lib
+
+import (
+	"fmt"
+	"io/ioutil"
+	"path"
+	"regexp"
+	"time"
+)
+
+var migrationNameRegexp = regexp.MustCompile(`[^A-Za-z0-9_]+`)
+
+// WriteMigration writes `sql` to a new timestamped file in the migrations directory and returns its path
+func WriteMigration(name string, sql string, now time.Time) (string, error) {
+
+	var e error
+
+	if e = EnsureDir(MigrationsDirectory); e != nil {
+		return "", e
+	}
+
+	var filePath = path.Join(
+		MigrationsDirectory,
+		fmt.Sprintf("%s_%s.sql", now.UTC().Format("20060102150405"), migrationNameRegexp.ReplaceAllString(name, "_")),
+	)
+
+	if FileExists(filePath) {
+		return "", fmt.Errorf("Migration `%s` already exists", filePath)
+	}
+
+	if e = ioutil.WriteFile(filePath, []byte(sql+"\n"), 0644); e != nil {
+		return "", e
+	}
+
+	return filePath, nil
+}